@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// bannerWidth is the width of the startup banner's horizontal rules
+const bannerWidth = 56
+
+// printBanner prints the startup banner with build and configuration details
+func printBanner(cfg *config.Config) {
+	metricsAddress := "disabled"
+	if cfg.Metrics.Enabled {
+		metricsAddress = fmt.Sprintf(":%d%s", cfg.Metrics.Port, cfg.Metrics.Path)
+	}
+
+	lines := []string{
+		fmt.Sprintf("🎬 Flixsrota %s", Version),
+		fmt.Sprintf("🕒 Built: %s", BuildTime),
+		fmt.Sprintf("🐹 Go: %s (%s/%s)", runtime.Version(), runtime.GOOS, runtime.GOARCH),
+		fmt.Sprintf("📋 Queue adapter: %s", cfg.Queue.Adapter),
+		fmt.Sprintf("💾 Storage adapter: %s", cfg.Storage.Adapter),
+		fmt.Sprintf("📡 gRPC: %s:%d", cfg.GRPC.Address, cfg.GRPC.Port),
+		fmt.Sprintf("📊 Metrics: %s", metricsAddress),
+		fmt.Sprintf("👷 Workers: %d-%d", cfg.Worker.MinWorkers, cfg.Worker.MaxWorkers),
+		fmt.Sprintf("🎥 FFmpeg: %s", ffmpegVersion(cfg.FFmpeg.ExecutablePath)),
+	}
+
+	fmt.Println("╭" + strings.Repeat("─", bannerWidth))
+	for _, line := range lines {
+		fmt.Println("│ " + line)
+	}
+	fmt.Println("╰" + strings.Repeat("─", bannerWidth))
+}
+
+// ffmpegVersion returns the version reported by `ffmpeg -version`, or
+// "unavailable" if it cannot be run
+func ffmpegVersion(executablePath string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, executablePath, "-version").Output()
+	if err != nil {
+		return "unavailable"
+	}
+
+	// The first line reads "ffmpeg version <version> Copyright ..."
+	firstLine, _, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) >= 3 && fields[1] == "version" {
+		return fields[2]
+	}
+	return strings.TrimSpace(firstLine)
+}
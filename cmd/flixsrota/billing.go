@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func billingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "billing",
+		Short: "Report tenant job costs",
+		Long:  "Report the job costs recorded in the cost ledger of a running Flixsrota server",
+	}
+
+	cmd.PersistentFlags().StringVar(&serverAddress, "server", "", "gRPC server address (default from config)")
+
+	cmd.AddCommand(billingReportCmd())
+
+	return cmd
+}
+
+func billingReportCmd() *cobra.Command {
+	var tenantID string
+	var month string
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Show the job costs of a tenant for one month",
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := time.Parse("2006-01", month)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid month %q, use YYYY-MM\n", month)
+				os.Exit(1)
+			}
+			to := from.AddDate(0, 1, 0)
+
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			resp, err := pb.NewVideoProcessorClient(conn).GetBillingSummary(ctx, &pb.GetBillingSummaryRequest{
+				TenantId: tenantID,
+				From:     timestamppb.New(from),
+				To:       timestamppb.New(to),
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get billing summary: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("💰 Billing report for %s, %s\n", resp.TenantId, from.Format("January 2006"))
+			fmt.Printf("   Jobs:            %d\n", resp.JobCount)
+			fmt.Printf("   Compute minutes: %.2f\n", resp.ComputeMinutes)
+			fmt.Printf("   Output storage:  %.2f GB\n", float64(resp.StorageBytes)/(1<<30))
+			fmt.Printf("   Total cost:      %.2f\n", resp.TotalCost)
+		},
+	}
+
+	cmd.Flags().StringVar(&tenantID, "tenant", "", "tenant ID to report on")
+	cmd.Flags().StringVar(&month, "month", time.Now().UTC().Format("2006-01"), "month to report on, as YYYY-MM (UTC)")
+	cmd.MarkFlagRequired("tenant")
+
+	return cmd
+}
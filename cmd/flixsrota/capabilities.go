@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// capabilities is the YAML layout printed by the capabilities command
+type capabilities struct {
+	Version        string          `yaml:"version"`
+	QueueAdapter   string          `yaml:"queue_adapter"`
+	StorageAdapter string          `yaml:"storage_adapter"`
+	Features       map[string]bool `yaml:"features"`
+	VideoCodecs    []string        `yaml:"video_codecs"`
+	Muxers         []string        `yaml:"muxers"`
+}
+
+func capabilitiesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capabilities",
+		Short: "Show the capabilities of a running server",
+		Long:  "Print the adapters, video codecs, muxers and features available on a running Flixsrota server",
+		Run: func(cmd *cobra.Command, args []string) {
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			resp, err := pb.NewVideoProcessorClient(conn).GetCapabilities(ctx, &pb.GetCapabilitiesRequest{})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get capabilities: %v\n", err)
+				os.Exit(1)
+			}
+
+			out, err := yaml.Marshal(capabilities{
+				Version:        resp.Version,
+				QueueAdapter:   resp.QueueAdapter,
+				StorageAdapter: resp.StorageAdapter,
+				Features:       resp.Features,
+				VideoCodecs:    resp.VideoCodecs,
+				Muxers:         resp.Muxers,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to format capabilities: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(out))
+		},
+	}
+
+	cmd.Flags().StringVar(&serverAddress, "server", "", "gRPC server address (default from config)")
+
+	return cmd
+}
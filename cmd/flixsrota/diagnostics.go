@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/core"
+	"github.com/nikhil0verma/flixsrota/internal/diagnostics"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func diagnosticsCmd() *cobra.Command {
+	var format string
+	var zipReport bool
+
+	cmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Collect a troubleshooting report",
+		Long: `Collect the Go and FFmpeg versions, platform, redacted configuration, queue and
+storage checks, a metrics snapshot of the local server and the end of the log
+file into a report for support`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "text" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Unknown format %q (supported: text, json)\n", format)
+				os.Exit(1)
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			ctx := context.Background()
+
+			q, queueErr := core.NewQueue(ctx, cfg.Queue, cfg.MultiQueue)
+			if queueErr == nil {
+				defer q.Close()
+			}
+
+			st, storageErr := core.NewStorage(cfg.Storage, zap.NewNop())
+
+			report := diagnostics.Collect(ctx, cfg, Version, q, queueErr, st, storageErr)
+
+			if zipReport {
+				name := diagnostics.ZipName(report.GeneratedAt)
+				file, err := os.Create(name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", name, err)
+					os.Exit(1)
+				}
+				if err := report.WriteZip(file); err != nil {
+					file.Close()
+					fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", name, err)
+					os.Exit(1)
+				}
+				if err := file.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", name, err)
+					os.Exit(1)
+				}
+				fmt.Fprintf(os.Stderr, "📦 Diagnostics written to %s\n", name)
+			}
+
+			if format == "json" {
+				out, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to format report: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(out))
+			} else {
+				fmt.Print(report.Summary())
+			}
+
+			if !report.Passed() {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+	cmd.Flags().BoolVar(&zipReport, "zip", false, "also package the report into flixsrota-diagnostics-<timestamp>.zip in the current directory")
+
+	return cmd
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serverAddress overrides the gRPC address taken from the configuration
+var serverAddress string
+
+func jobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Manage jobs on a running server",
+		Long:  "Inspect and retrieve jobs through the gRPC API of a running Flixsrota server",
+	}
+
+	cmd.PersistentFlags().StringVar(&serverAddress, "server", "", "gRPC server address (default from config)")
+
+	cmd.AddCommand(jobsDownloadCmd())
+	cmd.AddCommand(jobsReplayCmd())
+	cmd.AddCommand(jobsGraphCmd())
+
+	return cmd
+}
+
+func jobsDownloadCmd() *cobra.Command {
+	var outputPath string
+	var remotePath string
+
+	cmd := &cobra.Command{
+		Use:   "download <job-id>",
+		Short: "Download a job output file",
+		Long:  "Stream a transcoded output file from the server and save it locally",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if outputPath == "" {
+				outputPath = args[0]
+				if remotePath != "" {
+					outputPath = filepath.Base(remotePath)
+				}
+			}
+
+			client := pb.NewVideoProcessorClient(conn)
+			if err := downloadFile(ctx, client, args[0], remotePath, outputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "\nDownload failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("\n✅ Saved to %s\n", outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "local file to write (default is the job ID or file name)")
+	cmd.Flags().StringVar(&remotePath, "path", "", "file relative to the job output directory")
+
+	return cmd
+}
+
+// downloadFile streams a job output file into outputPath. Data is written to
+// a temporary file that only replaces outputPath once the download completes.
+func downloadFile(ctx context.Context, client pb.VideoProcessorClient, jobID, remotePath, outputPath string) error {
+	stream, err := client.DownloadFile(ctx, &pb.DownloadFileRequest{
+		JobId: jobID,
+		Path:  remotePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	partPath := outputPath + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	var received int64
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(partPath)
+			return err
+		}
+
+		if _, err := file.WriteAt(resp.Chunk, resp.Offset); err != nil {
+			file.Close()
+			os.Remove(partPath)
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+
+		received += int64(len(resp.Chunk))
+		printProgress(received, resp.TotalSize)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return os.Rename(partPath, outputPath)
+}
+
+// printProgress renders a single-line download progress bar
+func printProgress(received, total int64) {
+	const width = 40
+
+	if total <= 0 {
+		fmt.Printf("\r⬇️  %d bytes", received)
+		return
+	}
+
+	filled := int(received * width / total)
+	if filled > width {
+		filled = width
+	}
+
+	fmt.Printf("\r⬇️  [%s%s] %3d%% (%d/%d bytes)",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", width-filled),
+		received*100/total,
+		received,
+		total)
+}
+
+// dialServer connects to the gRPC server from the --server flag or the configuration
+func dialServer() (*grpc.ClientConn, error) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	address := serverAddress
+	if address == "" {
+		host := cfg.GRPC.Address
+		if host == "" || host == "0.0.0.0" {
+			host = "localhost"
+		}
+		address = fmt.Sprintf("%s:%d", host, cfg.GRPC.Port)
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.GRPC.TLSCertFile != "" {
+		creds, err = credentials.NewClientTLSFromFile(cfg.GRPC.TLSCertFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+	}
+
+	return grpc.Dial(address, grpc.WithTransportCredentials(creds))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/spf13/cobra"
+)
+
+func jobsGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph <job-id>",
+		Short: "Print the dependency graph of a job in DOT format",
+		Long: `Print the jobs a job depends on, directly or indirectly, as a Graphviz
+DOT graph with edges in execution order. Render it with Graphviz:
+
+  flixsrota jobs graph <job-id> | dot -Tsvg > graph.svg
+
+Dependency cycles are drawn in red and reported on stderr.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			resp, err := pb.NewVideoProcessorClient(conn).GetJobGraph(ctx, &pb.GetJobGraphRequest{JobId: args[0]})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get job graph: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Print(resp.Dot)
+
+			if len(resp.Cycle) > 0 {
+				fmt.Fprintf(os.Stderr, "⚠️  Dependency cycle: %s\n", strings.Join(resp.Cycle, " -> "))
+			}
+			if resp.Truncated {
+				fmt.Fprintln(os.Stderr, "⚠️  Dependencies more than 10 levels deep were left out")
+			}
+		},
+	}
+
+	return cmd
+}
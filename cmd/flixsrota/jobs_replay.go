@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/core"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/spf13/cobra"
+)
+
+func jobsReplayCmd() *cobra.Command {
+	var dryRun bool
+	var overrideOutput string
+
+	cmd := &cobra.Command{
+		Use:   "replay <job-id>",
+		Short: "Re-run the FFmpeg command of a job locally",
+		Long: `Fetch the FFmpeg log of a job from the server, reconstruct the FFmpeg
+command it ran and print it (--dry-run) or run it again with the local FFmpeg.
+Output is written to the --override-output directory so the original output
+is left untouched.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !dryRun && overrideOutput == "" {
+				fmt.Fprintln(os.Stderr, "Replaying would overwrite the original output, use --override-output or --dry-run")
+				os.Exit(1)
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			content, err := fetchJobLog(ctx, pb.NewVideoProcessorClient(conn), args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get job log: %v\n", err)
+				os.Exit(1)
+			}
+
+			ffmpegArgs, err := core.ParseLogCommand(bytes.NewReader(content))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to reconstruct FFmpeg command: %v\n", err)
+				os.Exit(1)
+			}
+
+			if input := replayInput(ffmpegArgs); input != "" {
+				if _, err := os.Stat(input); errors.Is(err, os.ErrNotExist) {
+					fmt.Fprintf(os.Stderr, "⚠️  Input file no longer exists: %s\n", input)
+				}
+			}
+
+			if overrideOutput != "" {
+				ffmpegArgs = overrideReplayOutput(ffmpegArgs, overrideOutput)
+			}
+
+			if dryRun {
+				fmt.Println(shellJoin(append([]string{cfg.FFmpeg.ExecutablePath}, ffmpegArgs...)))
+				return
+			}
+
+			if err := os.MkdirAll(overrideOutput, 0755); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create output directory: %v\n", err)
+				os.Exit(1)
+			}
+
+			runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// Relative segment file names are resolved against the working directory
+			ffmpeg := exec.CommandContext(runCtx, cfg.FFmpeg.ExecutablePath, ffmpegArgs...)
+			ffmpeg.Dir = overrideOutput
+			ffmpeg.Stdout = os.Stdout
+			ffmpeg.Stderr = os.Stderr
+			if err := ffmpeg.Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "\nFFmpeg failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("\n✅ Replay output written to %s\n", overrideOutput)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the FFmpeg command instead of running it")
+	cmd.Flags().StringVar(&overrideOutput, "override-output", "", "directory to write the replayed output to")
+
+	return cmd
+}
+
+// fetchJobLog collects the chunks of a job's FFmpeg log streamed by the server
+func fetchJobLog(ctx context.Context, client pb.VideoProcessorClient, jobID string) ([]byte, error) {
+	stream, err := client.GetJobLog(ctx, &pb.GetJobLogRequest{JobId: jobID})
+	if err != nil {
+		return nil, err
+	}
+
+	var content bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return content.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content.Write(resp.Chunk)
+	}
+}
+
+// replayInput returns the input file of an FFmpeg command, or "" for inputs
+// that are not local files
+func replayInput(args []string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-i" {
+			if strings.Contains(args[i+1], "://") {
+				return ""
+			}
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// overrideReplayOutput moves the output file, the last argument, into dir
+func overrideReplayOutput(args []string, dir string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	replayed := append([]string(nil), args...)
+	replayed[len(replayed)-1] = filepath.Join(dir, filepath.Base(replayed[len(replayed)-1]))
+	return replayed
+}
+
+// shellJoin quotes arguments so the command can be pasted into a shell
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()*?[]{}#~%!") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
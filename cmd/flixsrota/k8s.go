@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/k8s"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func generateCRDCmd() *cobra.Command {
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "generate-crd",
+		Short: "Print the FlixsrotaJob Kubernetes CRD",
+		Long:  "Generate the CustomResourceDefinition of the FlixsrotaJob resource, which submits jobs from Kubernetes through the k8s-controller command",
+		Run: func(cmd *cobra.Command, args []string) {
+			crd, err := k8s.GenerateCRD()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to generate CRD: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputPath == "" {
+				fmt.Print(string(crd))
+				return
+			}
+			if err := os.WriteFile(outputPath, crd, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write CRD: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ CRD written to %s\n", outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "file to write the CRD to (default stdout)")
+
+	return cmd
+}
+
+func k8sControllerCmd() *cobra.Command {
+	var namespace string
+	var allNamespaces bool
+	var pollInterval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "k8s-controller",
+		Short: "Run the FlixsrotaJob controller",
+		Long: `Watch FlixsrotaJob resources in the cluster this command runs in, submit
+them to a Flixsrota server with ProcessVideo and keep their status in sync
+with the job progress`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := k8s.NewInClusterClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create Kubernetes client: %v\n", err)
+				os.Exit(1)
+			}
+
+			if allNamespaces {
+				namespace = ""
+			} else if namespace == "" {
+				namespace = k8s.InClusterNamespace()
+			}
+
+			conn, err := dialServer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to server: %v\n", err)
+				os.Exit(1)
+			}
+			defer conn.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			logger, _ := zap.NewProduction()
+			defer logger.Sync()
+
+			logger.Info("Starting FlixsrotaJob controller",
+				zap.String("namespace", namespace),
+				zap.Duration("poll_interval", pollInterval))
+
+			controller := k8s.NewController(client, pb.NewVideoProcessorClient(conn), namespace, pollInterval, logger)
+			if err := controller.Run(ctx); err != nil && err != context.Canceled {
+				fmt.Fprintf(os.Stderr, "Controller failed: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serverAddress, "server", "", "gRPC server address (default from config)")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace to watch (default is the controller's own namespace)")
+	cmd.Flags().BoolVar(&allNamespaces, "all-namespaces", false, "watch FlixsrotaJob resources in every namespace")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", k8s.DefaultPollInterval, "how often the status of running jobs is refreshed")
+
+	return cmd
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/nikhil0verma/flixsrota/internal/config"
 	"github.com/nikhil0verma/flixsrota/internal/core"
+	flixgrpc "github.com/nikhil0verma/flixsrota/internal/grpc"
+	"github.com/nikhil0verma/flixsrota/internal/preflight"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -54,6 +58,16 @@ storage systems and is designed to run as a backend service/daemon.`,
 	// Add commands
 	rootCmd.AddCommand(configCmd())
 	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(preflightCmd())
+	rootCmd.AddCommand(diagnosticsCmd())
+	rootCmd.AddCommand(jobsCmd())
+	rootCmd.AddCommand(capabilitiesCmd())
+	rootCmd.AddCommand(billingCmd())
+	rootCmd.AddCommand(queueCmd())
+	rootCmd.AddCommand(storageCmd())
+	rootCmd.AddCommand(profileCmd())
+	rootCmd.AddCommand(generateCRDCmd())
+	rootCmd.AddCommand(k8sControllerCmd())
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
@@ -69,18 +83,21 @@ func configCmd() *cobra.Command {
 		Long:  "Interactive CLI wizard for config file generation and management",
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	var force bool
+	initCmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize configuration file",
 		Long:  "Run interactive wizard to create a new configuration file",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := config.RunWizard(configFile); err != nil {
+			if err := config.RunWizard(configFile, force); err != nil {
 				fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
 				os.Exit(1)
 			}
 			fmt.Println("Configuration file created successfully!")
 		},
-	})
+	}
+	initCmd.Flags().BoolVar(&force, "force", false, "save the configuration even if the linter reports errors")
+	cmd.AddCommand(initCmd)
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "validate",
@@ -101,10 +118,302 @@ func configCmd() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "Print the configuration JSON Schema",
+		Long:  "Output a JSON Schema for the configuration file for use with YAML language servers",
+		Run: func(cmd *cobra.Command, args []string) {
+			schema, err := config.GenerateJSONSchema()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(schema))
+		},
+	})
+
+	cmd.AddCommand(configEncryptSecretsCmd())
+	cmd.AddCommand(configExportEnvCmd())
+	cmd.AddCommand(configSnapshotCmd())
+	cmd.AddCommand(configDriftCmd())
+	cmd.AddCommand(configGenerateDeployCmd("generate-compose", "Generate a docker-compose.yml",
+		`Generate a docker-compose.yml running Flixsrota with the queue, storage volumes
+and monitoring services its configuration needs. Defaults are read from the
+current config file, which is embedded without its secrets; every secret is
+injected from its FLIXSROTA_ environment variable, e.g. from a .env file
+written by config export-env.`,
+		"docker-compose.yml", config.GenerateCompose))
+	cmd.AddCommand(configGenerateDeployCmd("generate-k8s-manifests", "Generate Kubernetes manifests",
+		`Generate a ConfigMap, Deployment and Service running Flixsrota, plus Redis and a
+storage volume claim when the configuration needs them. Defaults are read from
+the current config file, which is embedded without its secrets; every secret is
+read from the Secret written by config export-env --format k8s-secret.`,
+		"", config.GenerateK8sManifests))
+	cmd.AddCommand(&cobra.Command{
+		Use:   "detect-hw",
+		Short: "Detect the hardware encoder",
+		Long:  "Probe the system for a hardware encoder and print a config snippet that enables it",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			backend, err := core.NewFFmpegExecutor(cfg.FFmpeg, "", nil, zap.NewNop()).DetectHardwareAccel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("✅ Detected hardware encoder: %s\n", backend)
+			fmt.Println()
+			fmt.Println("ffmpeg:")
+			fmt.Printf("  video_codec: %q\n", cfg.FFmpeg.VideoCodec)
+			fmt.Printf("  hardware_accel: %q\n", backend)
+		},
+	})
+
+	return cmd
+}
+
+func configEncryptSecretsCmd() *cobra.Command {
+	var vaultAddress string
+	var transitKey string
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "encrypt-secrets",
+		Short: "Encrypt configuration secrets with Vault",
+		Long: `Replace the Redis passwords and S3 secret keys in the configuration with
+ciphertext from the Vault transit engine. The token is read from VAULT_TOKEN
+and is not written to the configuration.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			vault := cfg.Vault
+			if vaultAddress != "" {
+				vault.Address = vaultAddress
+			}
+			if transitKey != "" {
+				vault.TransitKeyName = transitKey
+			}
+
+			client, err := config.NewVaultClient(vault)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create Vault client: %v\n", err)
+				os.Exit(1)
+			}
+
+			encrypted, err := config.EncryptWithVault(cfg, client)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to encrypt secrets: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Loading the encrypted config needs Vault, but never a stored token
+			vault.Enabled = true
+			vault.Token = ""
+			encrypted.Vault = vault
+
+			if outputPath == "" {
+				out, err := yaml.Marshal(encrypted)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to format configuration: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Print(string(out))
+				return
+			}
+
+			if err := config.Save(encrypted, outputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to save configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("🔐 Encrypted configuration written to %s\n", outputPath)
+		},
+	}
+
+	cmd.Flags().StringVar(&vaultAddress, "vault-addr", "", "Vault server address (default from config or VAULT_ADDR)")
+	cmd.Flags().StringVar(&transitKey, "transit-key", "", "transit key name (default from config)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "file to write the encrypted configuration to (default stdout)")
+
+	return cmd
+}
+
+func configExportEnvCmd() *cobra.Command {
+	var format string
+	var secretName string
+
+	cmd := &cobra.Command{
+		Use:   "export-env",
+		Short: "Print the configuration as environment variables",
+		Long: `Print the loaded configuration as FLIXSROTA_ environment variables for
+container deployments, as a .env file, shell export statements or a
+Kubernetes Secret manifest. Secrets such as Redis passwords are included.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			out, err := config.FormatEnv(cfg.ToEnv(), format, secretName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to export configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(out))
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", config.EnvFormatDotenv, "output format: dotenv, shell or k8s-secret")
+	cmd.Flags().StringVar(&secretName, "secret-name", "flixsrota-config", "name of the Kubernetes Secret for --format k8s-secret")
+
+	return cmd
+}
+
+func configSnapshotCmd() *cobra.Command {
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Write the effective configuration as a drift baseline",
+		Long: `Write the loaded configuration, with defaults, environment variables and the
+environment profile applied, as YAML for use as the baseline of config drift.
+Secrets are redacted.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			out, err := config.RedactedYAML(cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to snapshot configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputPath == "" || outputPath == "-" {
+				fmt.Print(string(out))
+				return
+			}
+			if err := os.WriteFile(outputPath, out, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", outputPath, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "✅ Wrote %s\n", outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "file to write to, - for stdout")
+
+	return cmd
+}
+
+func configDriftCmd() *cobra.Command {
+	var baselinePath string
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report configuration changes since a baseline",
+		Long: `Compare the current configuration with a baseline written by config snapshot
+and list the fields that changed. Exits with status 0 when there is no drift
+and 2 when there is. Redacted secrets in the baseline only count as drift when
+the current value is unset.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			baseline, err := config.Load(baselinePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load baseline: %v\n", err)
+				os.Exit(1)
+			}
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			var drift []config.FieldDiff
+			for _, diff := range config.Diff(baseline, cfg) {
+				if diff.Sensitive && diff.Old == config.RedactedValue && diff.New != "" {
+					continue
+				}
+				drift = append(drift, diff)
+			}
+
+			if len(drift) == 0 {
+				fmt.Println("✅ No drift from the baseline")
+				return
+			}
+
+			fmt.Printf("⚠️  %d fields drifted from the baseline:\n", len(drift))
+			for _, diff := range drift {
+				fmt.Printf("  %s: %v -> %v\n", diff.Path, diff.DisplayValue(diff.Old), diff.DisplayValue(diff.New))
+			}
+			os.Exit(2)
+		},
+	}
+
+	cmd.Flags().StringVar(&baselinePath, "baseline", "", "baseline written by config snapshot")
+	cmd.MarkFlagRequired("baseline")
+
+	return cmd
+}
+
+// configGenerateDeployCmd returns a command writing a deployment generated
+// from the loaded configuration, to stdout unless defaultOutput is set
+func configGenerateDeployCmd(use, short, long, defaultOutput string, generate func(*config.Config, config.DeployOptions) ([]byte, error)) *cobra.Command {
+	var opts config.DeployOptions
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  long,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			out, err := generate(cfg, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to generate deployment: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputPath == "" || outputPath == "-" {
+				fmt.Print(string(out))
+				return
+			}
+			if err := os.WriteFile(outputPath, out, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", outputPath, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "✅ Wrote %s\n", outputPath)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Queue, "queue", "", "queue adapter (default from config)")
+	cmd.Flags().StringVar(&opts.Storage, "storage", "", "storage adapter (default from config)")
+	cmd.Flags().StringVar(&opts.Image, "image", config.DefaultDeployImage, "Flixsrota container image")
+	cmd.Flags().BoolVar(&opts.WithPrometheus, "with-prometheus", false, "add Prometheus scraping the metrics endpoint")
+	cmd.Flags().BoolVar(&opts.WithGrafana, "with-grafana", false, "add Grafana with Prometheus as its datasource (compose only)")
+	cmd.Flags().StringVar(&opts.SecretName, "secret-name", "flixsrota-config", "Secret holding the secret environment variables (Kubernetes only)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", defaultOutput, "file to write to, - for stdout")
+
 	return cmd
 }
 
 func serveCmd() *cobra.Command {
+	var noBanner bool
+
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start the Flixsrota server",
@@ -117,7 +426,12 @@ func serveCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if !noBanner {
+				printBanner(cfg)
+			}
+
 			// Create and start the server
+			flixgrpc.Version = Version
 			server := core.NewServer(cfg)
 			if err := server.Start(); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to start server: %v\n", err)
@@ -126,5 +440,66 @@ func serveCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&noBanner, "no-banner", false, "do not print the startup banner")
+
+	return cmd
+}
+
+func preflightCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check that all configured dependencies are reachable",
+		Long:  "Verify queue, storage, FFmpeg and disk space before starting the server",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			ctx := context.Background()
+			var checks []preflight.Check
+
+			if q, err := core.NewQueue(ctx, cfg.Queue, cfg.MultiQueue); err != nil {
+				checks = append(checks, preflight.FailedCheck("queue", err))
+			} else {
+				defer q.Close()
+				checks = append(checks, preflight.QueueCheck(q))
+			}
+
+			if st, err := core.NewStorage(cfg.Storage, zap.NewNop()); err != nil {
+				checks = append(checks, preflight.FailedCheck("storage", err))
+			} else {
+				checks = append(checks, preflight.StorageCheck(st))
+			}
+
+			checks = append(checks,
+				preflight.FFmpegCheck(cfg.FFmpeg.ExecutablePath),
+				preflight.DiskSpaceCheck(cfg.Storage.Local.TempPath, preflight.MinFreeDiskBytes),
+			)
+
+			results := preflight.Run(ctx, checks)
+
+			fmt.Println("🛫 Flixsrota Preflight")
+			fmt.Println("=====================")
+			for _, result := range results {
+				marker := "✅"
+				if !result.Passed {
+					marker = "❌"
+				}
+				fmt.Printf("%s %-12s %s (%dms)\n", marker, result.Name, result.Message, result.Duration.Milliseconds())
+			}
+
+			if !preflight.Passed(results) {
+				fmt.Println()
+				fmt.Println("Some preflight checks failed")
+				os.Exit(1)
+			}
+
+			fmt.Println()
+			fmt.Println("All preflight checks passed!")
+		},
+	}
+
 	return cmd
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/admin"
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// timedProfiles are recorded over --duration; the others are snapshots
+var timedProfiles = map[string]string{
+	"cpu":   "profile",
+	"trace": "trace",
+}
+
+// snapshotProfiles are the runtime profiles served by name
+var snapshotProfiles = []string{"heap", "allocs", "goroutine", "block", "mutex", "threadcreate"}
+
+func profileCmd() *cobra.Command {
+	var profileType string
+	var duration time.Duration
+	var output string
+	var address string
+	var token string
+
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Download a profile from a running server",
+		Long: `Download a CPU, heap or other runtime profile from the admin pprof endpoint
+of a running server and save it for go tool pprof. CPU profiles and traces are
+recorded for --duration. The server must run with admin.enable_pprof.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			endpoint, timed := timedProfiles[profileType]
+			ok := timed
+			if !ok {
+				for _, name := range snapshotProfiles {
+					if name == profileType {
+						endpoint, ok = name, true
+					}
+				}
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown profile type %q (supported: cpu, trace, %s)\n",
+					profileType, strings.Join(snapshotProfiles, ", "))
+				os.Exit(1)
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+			if address == "" {
+				address = fmt.Sprintf("http://localhost:%d", cfg.Admin.PProfPort)
+			}
+			if token == "" {
+				token = cfg.Admin.PprofToken
+			}
+
+			target := strings.TrimRight(address, "/") + admin.PprofPath + endpoint
+			if timed {
+				target += fmt.Sprintf("?seconds=%d", int(duration.Seconds()))
+			}
+
+			req, err := http.NewRequest(http.MethodGet, target, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid address: %v\n", err)
+				os.Exit(1)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			if timed {
+				fmt.Fprintf(os.Stderr, "⏱️  Recording %s profile for %s...\n", profileType, duration)
+			}
+			client := &http.Client{Timeout: duration + time.Minute}
+			resp, err := client.Do(req)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch profile: %v\n", err)
+				os.Exit(1)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+				fmt.Fprintf(os.Stderr, "Failed to fetch profile: %s: %s\n", resp.Status, strings.TrimSpace(string(msg)))
+				os.Exit(1)
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			if _, err := io.Copy(file, resp.Body); err != nil {
+				file.Close()
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			if err := file.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "📦 Profile written to %s\n", output)
+		},
+	}
+
+	cmd.Flags().StringVar(&profileType, "type", "cpu", "profile type: cpu, trace, "+strings.Join(snapshotProfiles, ", "))
+	cmd.Flags().DurationVar(&duration, "duration", 30*time.Second, "recording time of cpu profiles and traces")
+	cmd.Flags().StringVar(&output, "output", "profile.pb.gz", "file the profile is written to")
+	cmd.Flags().StringVar(&address, "address", "", "admin server URL (default http://localhost:<admin.pprof_port>)")
+	cmd.Flags().StringVar(&token, "token", "", "pprof token (default admin.pprof_token)")
+
+	return cmd
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/core"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/spf13/cobra"
+)
+
+func queueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Queue maintenance",
+	}
+
+	cmd.AddCommand(queueMigrateCmd())
+
+	return cmd
+}
+
+func queueMigrateCmd() *cobra.Command {
+	var from, to string
+	var batchSize int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move queued jobs from one queue backend to another",
+		Long: `Move the queued jobs of one queue backend to another, e.g. when switching from
+Redis to Kafka. --from and --to name a queue adapter, configured by the queue
+section of the configuration, or a queue listed in multi_queue. Jobs are
+enqueued in the destination in batches and then cancelled in the source;
+jobs that are processing or finished stay where they are.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if from == to {
+				fmt.Fprintf(os.Stderr, "--from and --to must name different queues\n")
+				os.Exit(1)
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			ctx := context.Background()
+
+			src, err := core.NewQueue(ctx, migrationQueueConfig(cfg, from), nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open source queue %s: %v\n", from, err)
+				os.Exit(1)
+			}
+			defer src.Close()
+
+			var result *queue.MigrateResult
+			if dryRun {
+				result, err = queue.MigrateDryRun(ctx, src, batchSize)
+			} else {
+				dst, openErr := core.NewQueue(ctx, migrationQueueConfig(cfg, to), nil)
+				if openErr != nil {
+					fmt.Fprintf(os.Stderr, "Failed to open destination queue %s: %v\n", to, openErr)
+					os.Exit(1)
+				}
+				defer dst.Close()
+
+				result, err = queue.Migrate(ctx, src, dst, batchSize)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			if dryRun {
+				fmt.Printf("🔍 Dry run: %d queued jobs would move from %s to %s (%d skipped)\n",
+					result.Migrated, from, to, result.Skipped)
+			} else {
+				fmt.Printf("🚚 Moved %d queued jobs from %s to %s\n", result.Migrated, from, to)
+				fmt.Printf("   Skipped: %d (no longer queued)\n", result.Skipped)
+				fmt.Printf("   Failed:  %d\n", result.Failed)
+			}
+			for _, err := range result.Errors {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			}
+
+			if result.Failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "source queue adapter or multi_queue name")
+	cmd.Flags().StringVar(&to, "to", "", "destination queue adapter or multi_queue name")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "jobs listed and enqueued per batch")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only count the jobs that would be moved")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// migrationQueueConfig returns the settings of the queue listed in
+// multi_queue under name, or the queue section with its adapter set to name
+func migrationQueueConfig(cfg *config.Config, name string) config.QueueConfig {
+	for _, queueCfg := range cfg.MultiQueue {
+		if queueCfg.Name == name {
+			return queueCfg
+		}
+	}
+
+	queueCfg := cfg.Queue
+	queueCfg.Adapter = name
+	return queueCfg
+}
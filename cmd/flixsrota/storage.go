@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/core"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func storageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Storage maintenance",
+	}
+
+	cmd.AddCommand(storageCleanupOrphansCmd())
+	cmd.AddCommand(storageSyncCmd())
+
+	return cmd
+}
+
+func storageCleanupOrphansCmd() *cobra.Command {
+	var prefix string
+	var age time.Duration
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "cleanup-orphans",
+		Short: "Delete stored files that belong to no job",
+		Long: `Delete the files below --prefix that are not the output of any job in the
+queue, such as partial outputs left behind when the server crashed. Only
+files last modified more than --age ago are deleted, so outputs of jobs that
+are being written are kept.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+
+			ctx := context.Background()
+
+			q, err := core.NewQueue(ctx, cfg.Queue, cfg.MultiQueue)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open queue: %v\n", err)
+				os.Exit(1)
+			}
+			defer q.Close()
+
+			st, err := core.NewStorage(cfg.Storage, zap.NewNop())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+				os.Exit(1)
+			}
+
+			result, err := storage.CleanupOrphanedFiles(ctx, st, q, prefix, age, dryRun)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cleanup failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			for _, file := range result.Deleted {
+				fmt.Printf("🗑️  %s %s\n", verb, file)
+			}
+			fmt.Printf("%s %d orphaned files (%d kept as newer than %s or of unknown age)\n",
+				verb, len(result.Deleted), len(result.Skipped), age)
+			for _, err := range result.Errors {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			}
+
+			if len(result.Errors) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&prefix, "prefix", "", "only consider files below this storage path")
+	cmd.Flags().DurationVar(&age, "age", time.Hour, "minimum time since an orphan was last modified")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the orphans without deleting them")
+
+	return cmd
+}
+
+func storageSyncCmd() *cobra.Command {
+	var from string
+	var to string
+	var prefix string
+	var mode string
+	var concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync files between two storage backends",
+		Long: `Copy the files below --prefix between the configured settings of two storage
+backends. push copies from --from to --to, pull the other way, and mirror
+both ways with the newer copy winning. Copies of the same size are left
+alone; copies of different sizes modified at the same time are reported as
+conflicts and not copied.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			syncMode, err := storage.ParseSyncMode(mode)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			if from == to {
+				fmt.Fprintf(os.Stderr, "--from and --to must be different backends\n")
+				os.Exit(1)
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+				os.Exit(1)
+			}
+			if concurrency == 0 {
+				concurrency = cfg.Storage.CopyConcurrency
+			}
+
+			src, err := openStorageBackend(cfg.Storage, from)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open %s storage: %v\n", from, err)
+				os.Exit(1)
+			}
+			dst, err := openStorageBackend(cfg.Storage, to)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open %s storage: %v\n", to, err)
+				os.Exit(1)
+			}
+
+			result, err := storage.Sync(context.Background(), src, dst, prefix, syncMode, concurrency)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, file := range result.Pushed {
+				fmt.Printf("⬆️  %s -> %s %s\n", from, to, file)
+			}
+			for _, file := range result.Pulled {
+				fmt.Printf("⬇️  %s -> %s %s\n", to, from, file)
+			}
+			for _, conflict := range result.Conflicts {
+				fmt.Printf("⚠️  Conflict %s (%s: %d bytes, %s: %d bytes)\n",
+					conflict.Path, from, conflict.SourceSize, to, conflict.DestinationSize)
+			}
+			fmt.Printf("Copied %d files to %s and %d to %s, %d unchanged, %d conflicts\n",
+				len(result.Pushed), to, len(result.Pulled), from, result.Unchanged, len(result.Conflicts))
+			for _, err := range result.Errors {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			}
+
+			if len(result.Errors) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "local", "source backend: local, s3 or gcs")
+	cmd.Flags().StringVar(&to, "to", "", "destination backend: local, s3 or gcs")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "only sync files below this storage path")
+	cmd.Flags().StringVar(&mode, "mode", string(storage.SyncPush), "sync mode: push, pull or mirror")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "files copied at a time (default storage.copy_concurrency)")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// openStorageBackend opens the configured settings of one storage adapter,
+// without the fallbacks of the primary backend
+func openStorageBackend(base config.StorageConfig, adapter string) (storage.Storage, error) {
+	cfg := base
+	cfg.Adapter = adapter
+	cfg.Fallback = nil
+	return core.NewStorage(cfg, zap.NewNop())
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/recovery"
+	"go.uber.org/zap"
+)
+
+// PprofPath is the path prefix of the profiling endpoints
+const PprofPath = "/debug/pprof/"
+
+// shutdownTimeout bounds the wait for running requests, such as a CPU
+// profile being recorded, when the admin server stops
+const shutdownTimeout = 5 * time.Second
+
+// PprofHandler serves the net/http/pprof endpoints to requests carrying
+// token as a bearer token
+func PprofHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PprofPath, pprof.Index)
+	mux.HandleFunc(PprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(PprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(PprofPath+"trace", pprof.Trace)
+
+	return RequireToken(token, mux)
+}
+
+// RequireToken rejects requests whose Authorization header does not carry
+// the bearer token, compared in constant time
+func RequireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="flixsrota-admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServeAdmin serves the profiling endpoints on the configured pprof
+// port until ctx is done. It returns immediately when pprof is disabled.
+func ListenAndServeAdmin(ctx context.Context, cfg config.AdminConfig, logger *zap.Logger) error {
+	if !cfg.EnablePprof {
+		return nil
+	}
+
+	// No write timeout: CPU profiles and traces stream for as long as the
+	// client asks
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.PProfPort),
+		Handler:           PprofHandler(cfg.PprofToken),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	recovery.SafeGo(logger, func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
+
+	logger.Info("Admin server starting", zap.Int("port", cfg.PProfPort))
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve admin endpoints: %w", err)
+	}
+	return nil
+}
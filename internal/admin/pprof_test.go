@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestPprofHandler(t *testing.T) {
+	handler := PprofHandler("secret")
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "no token", path: PprofPath, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", path: PprofPath, authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", path: PprofPath, authorization: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "index", path: PprofPath, authorization: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "heap profile", path: PprofPath + "heap", authorization: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "cmdline", path: PprofPath + "cmdline", authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("unauthorized response has no WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestListenAndServeAdmin(t *testing.T) {
+	// Disabled pprof returns without listening
+	if err := ListenAndServeAdmin(context.Background(), config.AdminConfig{}, zap.NewNop()); err != nil {
+		t.Fatalf("ListenAndServeAdmin() of disabled pprof error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenAndServeAdmin(ctx, config.AdminConfig{EnablePprof: true, PProfPort: port, PprofToken: "secret"}, zap.NewNop())
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%sgoroutine", port, PprofPath)
+	var resp *http.Response
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("admin server did not start: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET goroutine profile status = %d, want 200", resp.StatusCode)
+	}
+
+	// The server stops with its context
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServeAdmin() error = %v after shutdown", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("admin server did not stop")
+	}
+}
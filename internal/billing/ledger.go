@@ -0,0 +1,116 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// ledgerKeyPrefix prefixes the Redis sorted set holding a tenant's entries,
+// scored by completion time in milliseconds
+const ledgerKeyPrefix = "flixsrota:billing:"
+
+// bytesPerGB converts output bytes to the GB priced by StorageGBCost
+const bytesPerGB = 1 << 30
+
+// LedgerEntry is the cost of one completed job
+type LedgerEntry struct {
+	TenantID        string    `json:"tenant_id"`
+	JobID           string    `json:"job_id"`
+	CompletedAt     time.Time `json:"completed_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	QualityCount    int       `json:"quality_count"`
+	StorageBytes    int64     `json:"storage_bytes"`
+	ComputeMinutes  float64   `json:"compute_minutes"`
+	Cost            float64   `json:"cost"`
+}
+
+// Summary totals the ledger entries of a tenant
+type Summary struct {
+	TotalCost      float64
+	JobCount       int
+	ComputeMinutes float64
+	StorageBytes   int64
+}
+
+// CostLedger records job costs in Redis so the ledger is shared between servers
+type CostLedger struct {
+	client *redis.Client
+	config config.BillingConfig
+}
+
+// NewCostLedger creates a Redis backed cost ledger pricing jobs with the rates in cfg
+func NewCostLedger(cfg config.BillingConfig, address, password string, db int) *CostLedger {
+	return &CostLedger{
+		client: redis.NewClient(&redis.Options{
+			Addr:     address,
+			Password: password,
+			DB:       db,
+		}),
+		config: cfg,
+	}
+}
+
+// Cost prices FFmpeg compute minutes and output bytes with the configured rates
+func (l *CostLedger) Cost(computeMinutes float64, storageBytes int64) float64 {
+	return computeMinutes*l.config.ComputeMinuteCost + float64(storageBytes)/bytesPerGB*l.config.StorageGBCost
+}
+
+// Record prices an entry and adds it to the tenant's ledger
+func (l *CostLedger) Record(ctx context.Context, entry LedgerEntry) error {
+	entry.Cost = l.Cost(entry.ComputeMinutes, entry.StorageBytes)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	return l.client.ZAdd(ctx, ledgerKeyPrefix+entry.TenantID, &redis.Z{
+		Score:  float64(entry.CompletedAt.UnixMilli()),
+		Member: data,
+	}).Err()
+}
+
+// GetTenantLedger returns the entries of jobs the tenant completed from
+// (inclusive) to (exclusive), oldest first
+func (l *CostLedger) GetTenantLedger(ctx context.Context, tenantID string, from, to time.Time) ([]LedgerEntry, error) {
+	members, err := l.client.ZRangeByScore(ctx, ledgerKeyPrefix+tenantID, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.UnixMilli(), 10),
+		Max: "(" + strconv.FormatInt(to.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LedgerEntry, 0, len(members))
+	for _, member := range members {
+		var entry LedgerEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			return nil, fmt.Errorf("invalid ledger entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Close closes the Redis connection
+func (l *CostLedger) Close() error {
+	return l.client.Close()
+}
+
+// Summarize totals ledger entries
+func Summarize(entries []LedgerEntry) Summary {
+	summary := Summary{JobCount: len(entries)}
+	for _, entry := range entries {
+		summary.TotalCost += entry.Cost
+		summary.ComputeMinutes += entry.ComputeMinutes
+		summary.StorageBytes += entry.StorageBytes
+	}
+	return summary
+}
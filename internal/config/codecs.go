@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// VideoCodec describes how an output video codec is encoded
+type VideoCodec struct {
+	// Encoder is the FFmpeg software encoder
+	Encoder string
+	// DefaultCRF is the constant rate factor used when none is configured
+	DefaultCRF int
+	// MaxCRF is the highest CRF accepted by the encoder
+	MaxCRF int
+	// AudioCodec is the audio encoder compatible with the codec's containers
+	AudioCodec string
+}
+
+// VideoCodecs lists the supported output video codecs
+var VideoCodecs = map[string]VideoCodec{
+	"h264": {Encoder: "libx264", DefaultCRF: 23, MaxCRF: 51, AudioCodec: "aac"},
+	"h265": {Encoder: "libx265", DefaultCRF: 28, MaxCRF: 51, AudioCodec: "aac"},
+	"vp9":  {Encoder: "libvpx-vp9", DefaultCRF: 31, MaxCRF: 63, AudioCodec: "libopus"},
+	"av1":  {Encoder: "libaom-av1", DefaultCRF: 30, MaxCRF: 63, AudioCodec: "libopus"},
+}
+
+// HardwareEncoders maps each hardware acceleration method to the FFmpeg
+// encoder it provides for each codec. Codecs missing from a method are not
+// supported by that hardware.
+var HardwareEncoders = map[string]map[string]string{
+	"nvenc": {
+		"h264": "h264_nvenc",
+		"h265": "hevc_nvenc",
+		"av1":  "av1_nvenc",
+	},
+	"qsv": {
+		"h264": "h264_qsv",
+		"h265": "hevc_qsv",
+		"vp9":  "vp9_qsv",
+		"av1":  "av1_qsv",
+	},
+	"vaapi": {
+		"h264": "h264_vaapi",
+		"h265": "hevc_vaapi",
+		"vp9":  "vp9_vaapi",
+		"av1":  "av1_vaapi",
+	},
+	"videotoolbox": {
+		"h264": "h264_videotoolbox",
+		"h265": "hevc_videotoolbox",
+	},
+}
+
+// AudioEncoders maps the audio codecs accepted for job audio tracks to their
+// FFmpeg encoders
+var AudioEncoders = map[string]string{
+	"aac":  "aac",
+	"ac3":  "ac3",
+	"eac3": "eac3",
+	"mp3":  "libmp3lame",
+	"opus": "libopus",
+}
+
+// MaxAudioChannels is the most channels an audio track can have (7.1)
+const MaxAudioChannels = 8
+
+// AudioEncoder returns the FFmpeg encoder of an audio codec. Names that are
+// already FFmpeg encoders, such as VideoCodec.AudioCodec, are returned as is.
+func AudioEncoder(codec string) string {
+	if encoder, ok := AudioEncoders[codec]; ok {
+		return encoder
+	}
+	return codec
+}
+
+// ValidateAudioTrack checks that an audio track codec is supported and its
+// channel count is in range. A channel count of 0 keeps the input layout.
+func ValidateAudioTrack(codec string, channels int) error {
+	if _, ok := AudioEncoders[codec]; !ok {
+		return fmt.Errorf("unsupported audio codec %q (supported: %v)", codec, sortedKeys(AudioEncoders))
+	}
+	if channels < 0 || channels > MaxAudioChannels {
+		return fmt.Errorf("audio channels must be between 0 and %d", MaxAudioChannels)
+	}
+	return nil
+}
+
+// ValidateCodec checks that a video codec is supported, optionally with the
+// given hardware acceleration method
+func ValidateCodec(codec, hardwareAccel string) error {
+	if _, ok := VideoCodecs[codec]; !ok {
+		return fmt.Errorf("unsupported video codec %q (supported: %v)", codec, sortedKeys(VideoCodecs))
+	}
+
+	if hardwareAccel == "" {
+		return nil
+	}
+
+	encoders, ok := HardwareEncoders[hardwareAccel]
+	if !ok {
+		return fmt.Errorf("unsupported hardware acceleration %q (supported: %v)", hardwareAccel, sortedKeys(HardwareEncoders))
+	}
+	if _, ok := encoders[codec]; !ok {
+		return fmt.Errorf("hardware acceleration %q does not support video codec %q", hardwareAccel, codec)
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
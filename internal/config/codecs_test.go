@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestValidateAudioTrack(t *testing.T) {
+	tests := []struct {
+		codec    string
+		channels int
+		wantErr  bool
+	}{
+		{codec: "aac", channels: 2},
+		{codec: "opus", channels: 0},
+		{codec: "eac3", channels: MaxAudioChannels},
+		{codec: "eac3", channels: MaxAudioChannels + 1, wantErr: true},
+		{codec: "aac", channels: -1, wantErr: true},
+		{codec: "flac", channels: 2, wantErr: true},
+		{codec: "libopus", channels: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateAudioTrack(tt.codec, tt.channels)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAudioTrack(%q, %d) error = %v, want error %v", tt.codec, tt.channels, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAudioEncoder(t *testing.T) {
+	for codec, want := range map[string]string{"mp3": "libmp3lame", "opus": "libopus", "aac": "aac", "libfdk_aac": "libfdk_aac"} {
+		if got := AudioEncoder(codec); got != want {
+			t.Errorf("AudioEncoder(%q) = %q, want %q", codec, got, want)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,111 +18,462 @@ import (
 
 // Config represents the main configuration structure
 type Config struct {
-	GRPC    GRPCConfig    `mapstructure:"grpc" yaml:"grpc"`
-	Queue   QueueConfig   `mapstructure:"queue" yaml:"queue"`
-	Storage StorageConfig `mapstructure:"storage" yaml:"storage"`
-	FFmpeg  FFmpegConfig  `mapstructure:"ffmpeg" yaml:"ffmpeg"`
-	Worker  WorkerConfig  `mapstructure:"worker" yaml:"worker"`
-	Metrics MetricsConfig `mapstructure:"metrics" yaml:"metrics"`
-	Logging LoggingConfig `mapstructure:"logging" yaml:"logging"`
+	GRPC       GRPCConfig    `mapstructure:"grpc" yaml:"grpc" doc:"gRPC server settings"`
+	Queue      QueueConfig   `mapstructure:"queue" yaml:"queue" doc:"Queue adapter settings"`
+	MultiQueue []QueueConfig `mapstructure:"multi_queue" yaml:"multi_queue,omitempty" doc:"Queues combined by the multi queue adapter, in priority order"`
+	Storage    StorageConfig `mapstructure:"storage" yaml:"storage" doc:"Storage adapter settings"`
+	FFmpeg     FFmpegConfig  `mapstructure:"ffmpeg" yaml:"ffmpeg" doc:"FFmpeg execution settings"`
+	Worker     WorkerConfig  `mapstructure:"worker" yaml:"worker" doc:"Worker pool settings"`
+	Metrics    MetricsConfig `mapstructure:"metrics" yaml:"metrics" doc:"Metrics collection settings"`
+	Logging    LoggingConfig `mapstructure:"logging" yaml:"logging" doc:"Logging settings"`
+	Vault      VaultConfig   `mapstructure:"vault" yaml:"vault" doc:"HashiCorp Vault settings for encrypted secrets"`
+	Billing    BillingConfig `mapstructure:"billing" yaml:"billing" doc:"Per-tenant job cost ledger settings"`
+
+	CrashReporter CrashReporterConfig `mapstructure:"crash_reporter" yaml:"crash_reporter" doc:"Where panics in background goroutines are reported"`
+	Admin         AdminConfig         `mapstructure:"admin" yaml:"admin" doc:"Admin HTTP server for profiling"`
+
+	// FilePath is the config file that was loaded, empty when only defaults were used
+	FilePath string `mapstructure:"-" yaml:"-"`
 }
 
 // GRPCConfig contains gRPC server settings
 type GRPCConfig struct {
-	Address          string `mapstructure:"address" yaml:"address"`
-	Port             int    `mapstructure:"port" yaml:"port"`
-	MaxConcurrent    int    `mapstructure:"max_concurrent" yaml:"max_concurrent"`
-	EnableReflection bool   `mapstructure:"enable_reflection" yaml:"enable_reflection"`
+	Address          string `mapstructure:"address" yaml:"address" doc:"Address the gRPC server listens on"`
+	Port             int    `mapstructure:"port" yaml:"port" doc:"Port the gRPC server listens on" schema:"minimum=1,maximum=65535"`
+	MaxConcurrent    int    `mapstructure:"max_concurrent" yaml:"max_concurrent" doc:"Maximum concurrent gRPC streams" schema:"minimum=1"`
+	EnableReflection bool   `mapstructure:"enable_reflection" yaml:"enable_reflection" doc:"Register the gRPC reflection service"`
+	TLSCertFile      string `mapstructure:"tls_cert_file" yaml:"tls_cert_file,omitempty" doc:"TLS certificate file, enables TLS when set"`
+	TLSKeyFile       string `mapstructure:"tls_key_file" yaml:"tls_key_file,omitempty" doc:"TLS private key file"`
+	MaxSubscribers   int    `mapstructure:"max_subscribers" yaml:"max_subscribers" doc:"Maximum concurrent job event subscriptions, 0 for unlimited" schema:"minimum=0"`
+	AdminPort        int    `mapstructure:"admin_port" yaml:"admin_port" doc:"Port the admin gRPC service listens on" schema:"minimum=1,maximum=65535"`
+	AdminAPIKey      string `mapstructure:"admin_api_key" yaml:"admin_api_key,omitempty" doc:"API key required by the admin service, which only starts when set"`
 }
 
 // QueueConfig contains queue adapter settings
 type QueueConfig struct {
-	Adapter string           `mapstructure:"adapter" yaml:"adapter"`
-	Redis   RedisQueueConfig `mapstructure:"redis" yaml:"redis"`
-	Kafka   KafkaQueueConfig `mapstructure:"kafka" yaml:"kafka"`
-	SQS     SQSQueueConfig   `mapstructure:"sqs" yaml:"sqs"`
+	Name    string           `mapstructure:"name" yaml:"name,omitempty" doc:"Queue name used for multi queue routing"`
+	Adapter string           `mapstructure:"adapter" yaml:"adapter" doc:"Queue backend" schema:"enum=redis|memory|kafka|sqs|multi"`
+	Redis   RedisQueueConfig `mapstructure:"redis" yaml:"redis" doc:"Redis queue settings"`
+	Kafka   KafkaQueueConfig `mapstructure:"kafka" yaml:"kafka" doc:"Kafka queue settings"`
+	SQS     SQSQueueConfig   `mapstructure:"sqs" yaml:"sqs" doc:"AWS SQS queue settings"`
+
+	CompressionEnabled   bool   `mapstructure:"compression_enabled" yaml:"compression_enabled" doc:"Compress job payloads stored in the queue"`
+	CompressionAlgorithm string `mapstructure:"compression_algorithm" yaml:"compression_algorithm" doc:"Job payload compression algorithm" schema:"enum=gzip|zstd"`
+
+	PriorityLanes map[string]PriorityLaneConfig `mapstructure:"priority_lanes" yaml:"priority_lanes,omitempty" doc:"Priority ranges queued separately and dequeued by their share, keyed by lane name"`
+}
+
+// PriorityLaneConfig is a range of job priorities with its own queue and a
+// share of the dequeues
+type PriorityLaneConfig struct {
+	Name             string `mapstructure:"name" yaml:"name,omitempty" doc:"Lane name, defaults to the map key"`
+	MinPriority      int    `mapstructure:"min_priority" yaml:"min_priority" doc:"Lowest job priority of the lane"`
+	MaxPriority      int    `mapstructure:"max_priority" yaml:"max_priority" doc:"Highest job priority of the lane"`
+	WorkerAllocation int    `mapstructure:"worker_allocation" yaml:"worker_allocation" doc:"Percentage of dequeues, and so of workers, given to the lane; lanes must add up to 100" schema:"minimum=1,maximum=100"`
+}
+
+// validatePriorityLanes checks that lanes have non-overlapping priority
+// ranges and allocations adding up to 100 percent
+func validatePriorityLanes(lanes map[string]PriorityLaneConfig) error {
+	names := make([]string, 0, len(lanes))
+	for key := range lanes {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	total := 0
+	for i, key := range names {
+		lane := lanes[key]
+		if lane.Name != "" && lane.Name != key {
+			return fmt.Errorf("priority lane %s has a different name: %s", key, lane.Name)
+		}
+		if lane.MinPriority > lane.MaxPriority {
+			return fmt.Errorf("priority lane %s has min priority %d above max priority %d", key, lane.MinPriority, lane.MaxPriority)
+		}
+		if lane.WorkerAllocation < 1 || lane.WorkerAllocation > 100 {
+			return fmt.Errorf("priority lane %s worker allocation must be between 1 and 100", key)
+		}
+		total += lane.WorkerAllocation
+
+		for _, otherKey := range names[:i] {
+			other := lanes[otherKey]
+			if lane.MinPriority <= other.MaxPriority && other.MinPriority <= lane.MaxPriority {
+				return fmt.Errorf("priority lanes %s and %s overlap", otherKey, key)
+			}
+		}
+	}
+	if total != 100 {
+		return fmt.Errorf("priority lane worker allocations add up to %d, not 100", total)
+	}
+	return nil
 }
 
 // RedisQueueConfig contains Redis-specific settings
 type RedisQueueConfig struct {
-	Address  string `mapstructure:"address" yaml:"address"`
-	Password string `mapstructure:"password" yaml:"password"`
-	DB       int    `mapstructure:"db" yaml:"db"`
-	PoolSize int    `mapstructure:"pool_size" yaml:"pool_size"`
+	Address  string `mapstructure:"address" yaml:"address" doc:"Redis host:port"`
+	Password string `mapstructure:"password" yaml:"password" doc:"Redis password"`
+	DB       int    `mapstructure:"db" yaml:"db" doc:"Redis database number" schema:"minimum=0"`
+	PoolSize int    `mapstructure:"pool_size" yaml:"pool_size" doc:"Redis connection pool size" schema:"minimum=1"`
 }
 
 // KafkaQueueConfig contains Kafka-specific settings
 type KafkaQueueConfig struct {
-	Brokers []string `mapstructure:"brokers" yaml:"brokers"`
-	Topic   string   `mapstructure:"topic" yaml:"topic"`
-	GroupID string   `mapstructure:"group_id" yaml:"group_id"`
+	Brokers []string `mapstructure:"brokers" yaml:"brokers" doc:"Kafka broker addresses"`
+	Topic   string   `mapstructure:"topic" yaml:"topic" doc:"Kafka topic for jobs"`
+	GroupID string   `mapstructure:"group_id" yaml:"group_id" doc:"Kafka consumer group ID"`
 }
 
 // SQSQueueConfig contains AWS SQS-specific settings
 type SQSQueueConfig struct {
-	Region          string `mapstructure:"region" yaml:"region"`
-	QueueURL        string `mapstructure:"queue_url" yaml:"queue_url"`
-	MaxMessages     int    `mapstructure:"max_messages" yaml:"max_messages"`
-	WaitTimeSeconds int    `mapstructure:"wait_time_seconds" yaml:"wait_time_seconds"`
+	Region          string `mapstructure:"region" yaml:"region" doc:"AWS region"`
+	QueueURL        string `mapstructure:"queue_url" yaml:"queue_url" doc:"SQS queue URL"`
+	MaxMessages     int    `mapstructure:"max_messages" yaml:"max_messages" doc:"Maximum messages per receive call" schema:"minimum=1,maximum=10"`
+	WaitTimeSeconds int    `mapstructure:"wait_time_seconds" yaml:"wait_time_seconds" doc:"Long polling wait time in seconds" schema:"minimum=0,maximum=20"`
+	AccessKeyID     string `mapstructure:"access_key_id" yaml:"access_key_id" doc:"AWS access key ID"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key" doc:"AWS secret access key"`
+	UseInstanceRole bool   `mapstructure:"use_instance_role" yaml:"use_instance_role" doc:"Authenticate with the AWS default credential chain (instance profile, ECS task role, environment) instead of access keys"`
+	DLQUrl          string `mapstructure:"dlq_url" yaml:"dlq_url,omitempty" doc:"Dead-letter queue URL, set as the redrive policy target of the main queue"`
+	MaxReceiveCount int    `mapstructure:"max_receive_count" yaml:"max_receive_count" doc:"Receives of a message before SQS moves it to the dead-letter queue" schema:"minimum=1,maximum=1000"`
+}
+
+// validate checks the dead-letter settings of the SQS queue configured at field
+func (c SQSQueueConfig) validate(field string) error {
+	if c.DLQUrl == "" {
+		return nil
+	}
+	if c.DLQUrl == c.QueueURL {
+		return fmt.Errorf("%s.dlq_url must differ from the queue URL", field)
+	}
+	// SQS accepts a maxReceiveCount of 1 to 1000 in redrive policies
+	if c.MaxReceiveCount < 1 || c.MaxReceiveCount > 1000 {
+		return fmt.Errorf("%s.max_receive_count must be between 1 and 1000, got %d", field, c.MaxReceiveCount)
+	}
+	return nil
 }
 
 // StorageConfig contains storage adapter settings
 type StorageConfig struct {
-	Adapter string             `mapstructure:"adapter" yaml:"adapter"`
-	Local   LocalStorageConfig `mapstructure:"local" yaml:"local"`
-	S3      S3StorageConfig    `mapstructure:"s3" yaml:"s3"`
-	GCS     GCSStorageConfig   `mapstructure:"gcs" yaml:"gcs"`
+	Adapter           string             `mapstructure:"adapter" yaml:"adapter" doc:"Storage backend" schema:"enum=local|s3|gcs"`
+	Local             LocalStorageConfig `mapstructure:"local" yaml:"local" doc:"Local file storage settings"`
+	S3                S3StorageConfig    `mapstructure:"s3" yaml:"s3" doc:"AWS S3 settings"`
+	GCS               GCSStorageConfig   `mapstructure:"gcs" yaml:"gcs" doc:"Google Cloud Storage settings"`
+	Fallback          []StorageConfig    `mapstructure:"fallback" yaml:"fallback,omitempty" doc:"Fallback storage backends tried in order when the primary fails"`
+	WritableFallbacks bool               `mapstructure:"writable_fallbacks" yaml:"writable_fallbacks" doc:"Allow uploads and deletes on fallback backends"`
+	UseStreamingInput bool               `mapstructure:"use_streaming_input" yaml:"use_streaming_input" doc:"Stream S3 inputs to FFmpeg through a named pipe instead of downloading them first"`
+	Quota             StorageQuota       `mapstructure:"quota" yaml:"quota" doc:"Per-tenant output storage limits"`
+	Cache             StorageCache       `mapstructure:"cache" yaml:"cache" doc:"Local disk cache for downloaded files"`
+	UploadRetry       UploadRetryConfig  `mapstructure:"upload_retry" yaml:"upload_retry" doc:"Retries of job output uploads that fail transiently"`
+	CopyConcurrency   int                `mapstructure:"copy_concurrency" yaml:"copy_concurrency" doc:"Files copied at a time when job outputs are copied to another bucket or backends are synced" schema:"minimum=1"`
+}
+
+// UploadRetryConfig retries output uploads that fail with network timeouts
+// or 5xx responses, so a finished job does not have to run FFmpeg again
+type UploadRetryConfig struct {
+	MaxRetries     int     `mapstructure:"max_retries" yaml:"max_retries" doc:"Retries after the first failed upload, 0 disables retrying" schema:"minimum=0"`
+	InitialDelayMs int     `mapstructure:"initial_delay_ms" yaml:"initial_delay_ms" doc:"Delay before the first retry in milliseconds" schema:"minimum=1"`
+	MaxDelayMs     int     `mapstructure:"max_delay_ms" yaml:"max_delay_ms" doc:"Upper bound of the delay between retries in milliseconds" schema:"minimum=1"`
+	Multiplier     float64 `mapstructure:"multiplier" yaml:"multiplier" doc:"Factor the delay grows by after each retry" schema:"minimum=1"`
+}
+
+// StorageCache keeps recently downloaded files on local disk so frequently
+// read outputs, such as HLS master playlists, are not fetched every time
+type StorageCache struct {
+	Enabled        bool    `mapstructure:"enabled" yaml:"enabled" doc:"Cache downloaded files on local disk"`
+	CachePath      string  `mapstructure:"cache_path" yaml:"cache_path" doc:"Directory for cached files"`
+	MaxCacheSizeGB float64 `mapstructure:"max_cache_size_gb" yaml:"max_cache_size_gb" doc:"Maximum cache size in GB, least recently used files are evicted first" schema:"minimum=0"`
+	WarmJobs       int     `mapstructure:"warm_jobs" yaml:"warm_jobs" doc:"Cache the outputs of this many recently completed jobs at startup, 0 disables warming" schema:"minimum=0"`
+}
+
+// StorageQuota limits the storage used by each tenant, identified by the
+// first segment of the remote path
+type StorageQuota struct {
+	Enabled           bool  `mapstructure:"enabled" yaml:"enabled" doc:"Enforce per-tenant storage quotas"`
+	MaxBytesPerTenant int64 `mapstructure:"max_bytes_per_tenant" yaml:"max_bytes_per_tenant" doc:"Maximum bytes stored per tenant, 0 for unlimited" schema:"minimum=0"`
+	MaxFilesPerTenant int64 `mapstructure:"max_files_per_tenant" yaml:"max_files_per_tenant" doc:"Maximum files stored per tenant, 0 for unlimited" schema:"minimum=0"`
 }
 
 // LocalStorageConfig contains local file storage settings
 type LocalStorageConfig struct {
-	BasePath string `mapstructure:"base_path" yaml:"base_path"`
-	TempPath string `mapstructure:"temp_path" yaml:"temp_path"`
+	BasePath string        `mapstructure:"base_path" yaml:"base_path" doc:"Directory for stored files"`
+	TempPath string        `mapstructure:"temp_path" yaml:"temp_path" doc:"Directory for temporary files"`
+	Cleanup  CleanupConfig `mapstructure:"cleanup" yaml:"cleanup" doc:"Periodic deletion of old temporary files"`
+}
+
+// CleanupConfig contains settings for purging old temporary files
+type CleanupConfig struct {
+	Enabled         bool `mapstructure:"enabled" yaml:"enabled" doc:"Periodically delete old temporary files"`
+	IntervalMinutes int  `mapstructure:"interval_minutes" yaml:"interval_minutes" doc:"Minutes between purges" schema:"minimum=1"`
+	MaxAgeHours     int  `mapstructure:"max_age_hours" yaml:"max_age_hours" doc:"Delete temporary files older than this many hours" schema:"minimum=1"`
 }
 
 // S3StorageConfig contains AWS S3 settings
 type S3StorageConfig struct {
-	Region          string `mapstructure:"region" yaml:"region"`
-	Bucket          string `mapstructure:"bucket" yaml:"bucket"`
-	AccessKeyID     string `mapstructure:"access_key_id" yaml:"access_key_id"`
-	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key"`
+	Region          string    `mapstructure:"region" yaml:"region" doc:"AWS region"`
+	Bucket          string    `mapstructure:"bucket" yaml:"bucket" doc:"S3 bucket name"`
+	AccessKeyID     string    `mapstructure:"access_key_id" yaml:"access_key_id" doc:"AWS access key ID"`
+	SecretAccessKey string    `mapstructure:"secret_access_key" yaml:"secret_access_key" doc:"AWS secret access key"`
+	UseInstanceRole bool      `mapstructure:"use_instance_role" yaml:"use_instance_role" doc:"Authenticate with the AWS default credential chain (instance profile, ECS task role, environment) instead of access keys"`
+	SSE             SSEConfig `mapstructure:"sse" yaml:"sse" doc:"Encryption at rest of uploaded outputs"`
+	DirectS3Mode    bool      `mapstructure:"direct_s3_mode" yaml:"direct_s3_mode" doc:"Let FFmpeg read uploaded inputs through pre-signed URLs instead of downloading them first; requires ffmpeg.allow_remote_input"`
+}
+
+// S3 encryption algorithms: server-side with S3 managed keys, server-side
+// with a KMS key, and client-side with a KMS key through the S3 Encryption
+// Client
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+	SSEAlgorithmCSE    = "S3-CSE"
+)
+
+// SSEConfig encrypts the objects uploaded to S3
+type SSEConfig struct {
+	Enabled   bool   `mapstructure:"enabled" yaml:"enabled" doc:"Encrypt uploaded objects"`
+	Algorithm string `mapstructure:"algorithm" yaml:"algorithm" doc:"AES256 (S3 managed keys), aws:kms (server-side with kms_key_id) or S3-CSE (client-side with the kms_key_id CMK)" schema:"enum=AES256|aws:kms|S3-CSE"`
+	KMSKeyID  string `mapstructure:"kms_key_id" yaml:"kms_key_id" doc:"KMS key ID or ARN, required by aws:kms and S3-CSE"`
+}
+
+// validate checks the algorithm and that a KMS key is given exactly when the
+// algorithm uses one
+func (c SSEConfig) validate(field string) error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Algorithm {
+	case SSEAlgorithmAES256:
+		if c.KMSKeyID != "" {
+			return fmt.Errorf("%s.kms_key_id cannot be used with algorithm %s", field, c.Algorithm)
+		}
+	case SSEAlgorithmKMS, SSEAlgorithmCSE:
+		if c.KMSKeyID == "" {
+			return fmt.Errorf("%s.kms_key_id is required for algorithm %s", field, c.Algorithm)
+		}
+	default:
+		return fmt.Errorf("%s.algorithm must be %s, %s or %s, got %q", field, SSEAlgorithmAES256, SSEAlgorithmKMS, SSEAlgorithmCSE, c.Algorithm)
+	}
+	return nil
 }
 
 // GCSStorageConfig contains Google Cloud Storage settings
 type GCSStorageConfig struct {
-	ProjectID       string `mapstructure:"project_id" yaml:"project_id"`
-	Bucket          string `mapstructure:"bucket" yaml:"bucket"`
-	CredentialsFile string `mapstructure:"credentials_file" yaml:"credentials_file"`
+	ProjectID                 string            `mapstructure:"project_id" yaml:"project_id" doc:"Google Cloud project ID"`
+	Bucket                    string            `mapstructure:"bucket" yaml:"bucket" doc:"GCS bucket name"`
+	CredentialsFile           string            `mapstructure:"credentials_file" yaml:"credentials_file" doc:"Path to the service account credentials file"`
+	ImpersonateServiceAccount string            `mapstructure:"impersonate_service_account" yaml:"impersonate_service_account" doc:"Service account email to impersonate for storage operations"`
+	ImpersonateByTenant       bool              `mapstructure:"impersonate_by_tenant" yaml:"impersonate_by_tenant" doc:"Impersonate the service account mapped to each job's tenant_id"`
+	TenantImpersonationMap    map[string]string `mapstructure:"tenant_impersonation_map" yaml:"tenant_impersonation_map,omitempty" doc:"Service account email to impersonate for each tenant ID"`
+}
+
+// ServiceAccountForTenant returns the service account impersonated for a
+// tenant's jobs. With per-tenant impersonation, tenants without a mapped
+// account are rejected rather than falling back to a shared account.
+func (c GCSStorageConfig) ServiceAccountForTenant(tenantID string) (string, error) {
+	if !c.ImpersonateByTenant {
+		return c.ImpersonateServiceAccount, nil
+	}
+	if tenantID == "" {
+		return "", fmt.Errorf("tenant_id is required for per-tenant GCS impersonation")
+	}
+	account, ok := c.TenantImpersonationMap[tenantID]
+	if !ok {
+		return "", fmt.Errorf("no GCS service account configured for tenant %s", tenantID)
+	}
+	return account, nil
+}
+
+// validateImpersonation checks the service accounts to impersonate
+func (c GCSStorageConfig) validateImpersonation(field string) error {
+	if c.ImpersonateServiceAccount != "" && !isServiceAccountEmail(c.ImpersonateServiceAccount) {
+		return fmt.Errorf("%s.impersonate_service_account: %q is not a service account email", field, c.ImpersonateServiceAccount)
+	}
+	if !c.ImpersonateByTenant {
+		return nil
+	}
+	if len(c.TenantImpersonationMap) == 0 {
+		return fmt.Errorf("%s.impersonate_by_tenant requires tenant_impersonation_map", field)
+	}
+	for _, tenantID := range sortedKeys(c.TenantImpersonationMap) {
+		if account := c.TenantImpersonationMap[tenantID]; !isServiceAccountEmail(account) {
+			return fmt.Errorf("%s.tenant_impersonation_map: %q for tenant %s is not a service account email", field, account, tenantID)
+		}
+	}
+	return nil
+}
+
+// isServiceAccountEmail reports whether account looks like a service account
+// email, e.g. tenant-a@project.iam.gserviceaccount.com
+func isServiceAccountEmail(account string) bool {
+	name, domain, ok := strings.Cut(account, "@")
+	return ok && name != "" && strings.Contains(domain, ".") && !strings.ContainsAny(account, " \t\n")
 }
 
 // FFmpegConfig contains FFmpeg execution settings
 type FFmpegConfig struct {
-	ExecutablePath string          `mapstructure:"executable_path" yaml:"executable_path"`
-	Timeout        int             `mapstructure:"timeout" yaml:"timeout"`
-	Qualities      map[string]bool `mapstructure:"qualities" yaml:"qualities"`
+	ExecutablePath    string          `mapstructure:"executable_path" yaml:"executable_path" doc:"Path to the FFmpeg binary"`
+	Timeout           int             `mapstructure:"timeout" yaml:"timeout" doc:"Maximum job run time in seconds" schema:"minimum=1"`
+	Qualities         map[string]bool `mapstructure:"qualities" yaml:"qualities" doc:"Output qualities to produce"`
+	CaptureLog        bool            `mapstructure:"capture_log" yaml:"capture_log" doc:"Write FFmpeg output to a per-job log file"`
+	LogRetentionHours int             `mapstructure:"log_retention_hours" yaml:"log_retention_hours" doc:"Hours to keep FFmpeg job logs, 0 keeps them forever" schema:"minimum=0"`
+	ThreadsPerJob     int             `mapstructure:"threads_per_job" yaml:"threads_per_job" doc:"FFmpeg threads per job, 0 lets FFmpeg decide" schema:"minimum=0"`
+	NicePriority      int             `mapstructure:"nice_priority" yaml:"nice_priority" doc:"Run FFmpeg with this nice value on Linux, 0 disables" schema:"minimum=0,maximum=19"`
+	UseIonice         bool            `mapstructure:"use_ionice" yaml:"use_ionice" doc:"Run FFmpeg in the idle I/O scheduling class on Linux"`
+	VideoCodec        string          `mapstructure:"video_codec" yaml:"video_codec" doc:"Default output video codec" schema:"enum=h264|h265|vp9|av1"`
+	CRF               int             `mapstructure:"crf" yaml:"crf" doc:"Constant rate factor, 0 uses the codec default" schema:"minimum=0,maximum=63"`
+	HardwareAccel     string          `mapstructure:"hardware_accel" yaml:"hardware_accel,omitempty" doc:"Hardware encoder to use, empty for software encoding" schema:"enum=nvenc|qsv|vaapi|videotoolbox"`
+	AllowRemoteInput  bool            `mapstructure:"allow_remote_input" yaml:"allow_remote_input" doc:"Pass HTTP(S) inputs to FFmpeg directly instead of downloading them to the temp directory first"`
+	AutoDetectHWAccel bool            `mapstructure:"auto_detect_hardware" yaml:"auto_detect_hardware" doc:"Detect the hardware encoder at startup when hardware_accel is empty"`
+	ValidateInput     bool            `mapstructure:"validate_input" yaml:"validate_input" doc:"Reject inputs that do not start with the magic bytes of a known video container (MP4, MKV, MPEG-TS or AVI)"`
+
+	Normalization NormalizationConfig `mapstructure:"normalization" yaml:"normalization" doc:"Input normalization applied before scaling"`
+	HLS           HLSConfig           `mapstructure:"hls" yaml:"hls" doc:"HLS output settings"`
+	FragmentedMP4 FragmentedMP4Config `mapstructure:"fragmented_mp4" yaml:"fragmented_mp4" doc:"Fragmented MP4 output with a DASH manifest"`
+
+	Presets map[string]TranscodePreset `mapstructure:"presets" yaml:"presets,omitempty" doc:"Named transcode presets selected per job, in addition to the built-in ones"`
+
+	AudioNormalization AudioNormalization `mapstructure:"audio_normalization" yaml:"audio_normalization" doc:"Two-pass EBU R128 loudness normalization of the output audio"`
+
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker" yaml:"circuit_breaker" doc:"Stop running jobs while the FFmpeg binary is broken"`
+
+	OutputDirectoryTemplate string `mapstructure:"output_directory_template" yaml:"output_directory_template,omitempty" doc:"Go template for the per-job output subdirectory, e.g. {{.TenantID}}/{{.Year}}/{{.Month}}/{{.JobID}}"`
+	EnableQualityMetrics    bool   `mapstructure:"enable_quality_metrics" yaml:"enable_quality_metrics" doc:"Compute SSIM and PSNR when comparing jobs"`
+
+	AlignKeyframesToChapters bool `mapstructure:"align_keyframes_to_chapters" yaml:"align_keyframes_to_chapters" doc:"Force a keyframe at the start of every input chapter so players can seek to chapters exactly"`
+
+	ConvertVFRtoCFR bool `mapstructure:"convert_vfr_to_cfr" yaml:"convert_vfr_to_cfr" doc:"Probe the input and convert variable frame rate video to target_fps, avoiding A/V drift in HLS"`
+	ForceCFR        bool `mapstructure:"force_cfr" yaml:"force_cfr" doc:"Convert every input to target_fps, also when it is not detected as variable frame rate"`
+	TargetFPS       int  `mapstructure:"target_fps" yaml:"target_fps" doc:"Constant output frame rate of converted inputs" schema:"minimum=1"`
+
+	MultiLanguageAudio bool `mapstructure:"multi_language_audio" yaml:"multi_language_audio" doc:"Probe the input and encode each of its audio streams as a separate HLS audio rendition, labelled with its language"`
+}
+
+// CircuitBreakerConfig contains settings for the FFmpeg circuit breaker
+type CircuitBreakerConfig struct {
+	Enabled           bool `mapstructure:"enabled" yaml:"enabled" doc:"Enable the FFmpeg circuit breaker"`
+	ThresholdFailures int  `mapstructure:"threshold_failures" yaml:"threshold_failures" doc:"Consecutive FFmpeg execution failures before the breaker opens" schema:"minimum=1"`
+	RecoveryInterval  int  `mapstructure:"recovery_interval" yaml:"recovery_interval" doc:"Seconds between ffmpeg -version probes while the breaker is open" schema:"minimum=1"`
+}
+
+// HLSKeyframeIntervalSeconds is the keyframe interval of the encoder
+// settings (a GOP of 48 frames at 24 fps). Segment durations must be a
+// multiple of it so every segment starts on a keyframe.
+const HLSKeyframeIntervalSeconds = 2
+
+// HLSConfig contains HLS packaging settings
+type HLSConfig struct {
+	SegmentDuration          int            `mapstructure:"segment_duration" yaml:"segment_duration" doc:"Default HLS segment duration in seconds" schema:"minimum=1"`
+	SegmentDurationByQuality map[string]int `mapstructure:"segment_duration_by_quality" yaml:"segment_duration_by_quality,omitempty" doc:"Segment duration in seconds per quality, e.g. longer segments for low renditions"`
+	SegmentPattern           string         `mapstructure:"segment_pattern" yaml:"segment_pattern" doc:"Segment file name pattern relative to the output directory, with %v for the variant index and a %d verb for the segment number"`
+	MasterPlaylistName       string         `mapstructure:"master_playlist_name" yaml:"master_playlist_name" doc:"File name of the master playlist"`
+	VariantPlaylistName      string         `mapstructure:"variant_playlist_name" yaml:"variant_playlist_name" doc:"File name pattern of the variant playlists, with %v for the variant index"`
+	AutoKeyframeInterval     bool           `mapstructure:"auto_keyframe_interval" yaml:"auto_keyframe_interval" doc:"Probe the input frame rate and set the keyframe interval of each quality to its segment duration, so segment durations need not be a multiple of the 2s keyframe interval"`
+}
+
+// FragmentedMP4Config contains settings for fragmented MP4 output, which
+// writes one progressive MP4 per quality and a DASH on-demand manifest
+type FragmentedMP4Config struct {
+	Enabled               bool `mapstructure:"enabled" yaml:"enabled" doc:"Produce fragmented MP4 renditions and a DASH manifest instead of HLS for jobs without an output format"`
+	FragmentDuration      int  `mapstructure:"fragment_duration" yaml:"fragment_duration" doc:"Minimum fragment duration in seconds, a multiple of the keyframe interval" schema:"minimum=1"`
+	DefaultSampleDuration int  `mapstructure:"default_sample_duration" yaml:"default_sample_duration" doc:"Video sample duration in 1/90000 s units, forcing a constant frame rate; 0 keeps the input frame rate" schema:"minimum=0"`
+}
+
+// SegmentDurationFor returns the segment duration for a quality
+func (c HLSConfig) SegmentDurationFor(quality string) int {
+	if d := c.SegmentDurationByQuality[quality]; d > 0 {
+		return d
+	}
+	return c.SegmentDuration
+}
+
+// FFprobePath returns the ffprobe binary installed alongside the configured FFmpeg
+func (c FFmpegConfig) FFprobePath() string {
+	dir, name := filepath.Split(c.ExecutablePath)
+	return dir + strings.Replace(name, "ffmpeg", "ffprobe", 1)
+}
+
+// NormalizationConfig contains input pre-processing filters applied before
+// the input is split into output qualities
+type NormalizationConfig struct {
+	AutoRotate      bool    `mapstructure:"auto_rotate" yaml:"auto_rotate" doc:"Correct rotation using the input rotate metadata"`
+	Deinterlace     bool    `mapstructure:"deinterlace" yaml:"deinterlace" doc:"Deinterlace the input with yadif"`
+	DenoiseStrength float64 `mapstructure:"denoise_strength" yaml:"denoise_strength" doc:"hqdn3d denoise strength, 0 disables" schema:"minimum=0"`
+}
+
+// AudioNormalization contains EBU R128 loudness targets for the loudnorm filter
+type AudioNormalization struct {
+	Enabled bool    `mapstructure:"enabled" yaml:"enabled" doc:"Measure the input loudness and normalize the output audio"`
+	Target  float64 `mapstructure:"target" yaml:"target" doc:"Integrated loudness target in LUFS" schema:"minimum=-70,maximum=-5"`
+	TP      float64 `mapstructure:"tp" yaml:"tp" doc:"Maximum true peak in dBTP" schema:"minimum=-9,maximum=0"`
+	LRA     float64 `mapstructure:"lra" yaml:"lra" doc:"Loudness range target in LU" schema:"minimum=1,maximum=50"`
 }
 
 // WorkerConfig contains worker pool settings
 type WorkerConfig struct {
-	MinWorkers  int `mapstructure:"min_workers" yaml:"min_workers"`
-	MaxWorkers  int `mapstructure:"max_workers" yaml:"max_workers"`
-	QueueSize   int `mapstructure:"queue_size" yaml:"queue_size"`
-	IdleTimeout int `mapstructure:"idle_timeout" yaml:"idle_timeout"`
+	MinWorkers        int    `mapstructure:"min_workers" yaml:"min_workers" doc:"Minimum number of workers" schema:"minimum=1"`
+	MaxWorkers        int    `mapstructure:"max_workers" yaml:"max_workers" doc:"Maximum number of workers" schema:"minimum=1"`
+	QueueSize         int    `mapstructure:"queue_size" yaml:"queue_size" doc:"Internal job buffer size" schema:"minimum=0"`
+	IdleTimeout       int    `mapstructure:"idle_timeout" yaml:"idle_timeout" doc:"Seconds before an idle worker is stopped" schema:"minimum=0"`
+	DispatchAlgorithm string `mapstructure:"dispatch_algorithm" yaml:"dispatch_algorithm" doc:"Order in which queued jobs are handed to workers" schema:"enum=priority|fifo|round-robin"`
+	StuckJobTimeout   int    `mapstructure:"stuck_job_timeout" yaml:"stuck_job_timeout" doc:"Seconds a busy worker may go without job progress before its job is failed and the worker replaced, 0 disables the watchdog" schema:"minimum=0"`
+
+	StaleJobCheckInterval int `mapstructure:"stale_job_check_interval" yaml:"stale_job_check_interval" doc:"Seconds between scans for processing jobs abandoned by a crashed worker, 0 disables the reaper" schema:"minimum=0"`
+	MaxJobProcessingTime  int `mapstructure:"max_job_processing_time" yaml:"max_job_processing_time" doc:"Seconds a job may stay in the processing state before the reaper puts it back in the queue" schema:"minimum=1"`
+
+	WorkerLabels map[string]string `mapstructure:"worker_labels" yaml:"worker_labels,omitempty" doc:"Labels of this instance's workers, e.g. gpu: nvidia, matched against the labels a job requires"`
 }
 
 // MetricsConfig contains metrics collection settings
 type MetricsConfig struct {
-	Enabled         bool   `mapstructure:"enabled" yaml:"enabled"`
-	Port            int    `mapstructure:"port" yaml:"port"`
-	Path            string `mapstructure:"path" yaml:"path"`
-	CollectInterval int    `mapstructure:"collect_interval" yaml:"collect_interval"`
+	Enabled           bool   `mapstructure:"enabled" yaml:"enabled" doc:"Expose Prometheus metrics"`
+	Port              int    `mapstructure:"port" yaml:"port" doc:"Metrics HTTP port" schema:"minimum=1,maximum=65535"`
+	Path              string `mapstructure:"path" yaml:"path" doc:"Metrics HTTP path"`
+	CollectInterval   int    `mapstructure:"collect_interval" yaml:"collect_interval" doc:"Metrics collection interval in seconds" schema:"minimum=1"`
+	MaxWebSocketConns int    `mapstructure:"max_websocket_conns" yaml:"max_websocket_conns" doc:"Maximum concurrent job progress WebSocket connections, 0 for unlimited" schema:"minimum=0"`
+
+	InfluxDB InfluxDBConfig `mapstructure:"influxdb" yaml:"influxdb" doc:"Long-term metrics storage in InfluxDB"`
+}
+
+// InfluxDBConfig writes the collected metrics to an InfluxDB 2.x bucket
+type InfluxDBConfig struct {
+	Enabled       bool   `mapstructure:"enabled" yaml:"enabled" doc:"Write system, job and worker metrics to InfluxDB every collect interval"`
+	URL           string `mapstructure:"url" yaml:"url" doc:"InfluxDB server URL, e.g. http://influxdb:8086"`
+	Token         string `mapstructure:"token" yaml:"token,omitempty" doc:"InfluxDB API token with write access to the bucket"`
+	Org           string `mapstructure:"org" yaml:"org" doc:"InfluxDB organization"`
+	Bucket        string `mapstructure:"bucket" yaml:"bucket" doc:"InfluxDB bucket"`
+	FlushInterval int    `mapstructure:"flush_interval" yaml:"flush_interval" doc:"Seconds between writes of the buffered points" schema:"minimum=1"`
+	BatchSize     int    `mapstructure:"batch_size" yaml:"batch_size" doc:"Maximum points per write request" schema:"minimum=1"`
+	RetryBuffer   int    `mapstructure:"retry_buffer" yaml:"retry_buffer" doc:"Maximum points kept for retrying failed writes; the oldest are dropped first" schema:"minimum=0"`
 }
 
 // LoggingConfig contains logging settings
 type LoggingConfig struct {
-	Level      string `mapstructure:"level" yaml:"level"`
-	Format     string `mapstructure:"format" yaml:"format"`
-	OutputPath string `mapstructure:"output_path" yaml:"output_path"`
+	Level      string `mapstructure:"level" yaml:"level" doc:"Log level" schema:"enum=debug|info|warn|error"`
+	Format     string `mapstructure:"format" yaml:"format" doc:"Log format" schema:"enum=json|console"`
+	OutputPath string `mapstructure:"output_path" yaml:"output_path" doc:"Log file path, empty for stdout"`
+}
+
+// BillingConfig contains settings for the job cost ledger, which is kept in
+// the Redis server of the queue
+type BillingConfig struct {
+	Enabled           bool    `mapstructure:"enabled" yaml:"enabled" doc:"Record the cost of every completed job that has a tenant ID"`
+	ComputeMinuteCost float64 `mapstructure:"compute_minute_cost" yaml:"compute_minute_cost" doc:"Cost of one minute of FFmpeg CPU time" schema:"minimum=0"`
+	StorageGBCost     float64 `mapstructure:"storage_gb_cost" yaml:"storage_gb_cost" doc:"Cost of one GB of job output" schema:"minimum=0"`
+}
+
+// CrashReporterConfig selects where recovered goroutine panics are sent, in
+// addition to the log
+type CrashReporterConfig struct {
+	Type            string `mapstructure:"type" yaml:"type" doc:"Crash reporter: log only, a Slack incoming webhook or Sentry" schema:"enum=log|slack|sentry"`
+	SlackWebhookURL string `mapstructure:"slack_webhook_url" yaml:"slack_webhook_url,omitempty" doc:"Slack incoming webhook URL, required by the slack reporter"`
+	SentryDSN       string `mapstructure:"sentry_dsn" yaml:"sentry_dsn,omitempty" doc:"Sentry project DSN, required by the sentry reporter"`
+	RethrowPanic    bool   `mapstructure:"rethrow_panic" yaml:"rethrow_panic" doc:"Re-panic after reporting, crashing the process instead of ending only the goroutine"`
+}
+
+// AdminConfig contains the settings of the admin HTTP server, which serves
+// the net/http/pprof profiles
+type AdminConfig struct {
+	EnablePprof bool   `mapstructure:"enable_pprof" yaml:"enable_pprof" doc:"Serve CPU, heap and other runtime profiles at /debug/pprof/"`
+	PProfPort   int    `mapstructure:"pprof_port" yaml:"pprof_port" doc:"Port the admin HTTP server listens on" schema:"minimum=1,maximum=65535"`
+	PprofToken  string `mapstructure:"pprof_token" yaml:"pprof_token,omitempty" doc:"Bearer token required by the profiling endpoints"`
 }
 
 // DefaultConfig returns a default configuration
@@ -128,26 +484,52 @@ func DefaultConfig() *Config {
 			Port:             50051,
 			MaxConcurrent:    100,
 			EnableReflection: true,
+			MaxSubscribers:   100,
+			AdminPort:        50052,
 		},
 		Queue: QueueConfig{
-			Adapter: "redis",
+			Adapter:              "redis",
+			CompressionAlgorithm: "gzip",
 			Redis: RedisQueueConfig{
 				Address:  "localhost:6379",
 				Password: "",
 				DB:       0,
 				PoolSize: 10,
 			},
+			SQS: SQSQueueConfig{
+				MaxReceiveCount: 5,
+			},
 		},
 		Storage: StorageConfig{
 			Adapter: "local",
 			Local: LocalStorageConfig{
 				BasePath: "/tmp/flixsrota",
 				TempPath: "/tmp/flixsrota/temp",
+				Cleanup: CleanupConfig{
+					IntervalMinutes: 60,
+					MaxAgeHours:     24,
+				},
+			},
+			Cache: StorageCache{
+				CachePath:      "/tmp/flixsrota/cache",
+				MaxCacheSizeGB: 1,
+				WarmJobs:       50,
+			},
+			S3: S3StorageConfig{
+				SSE: SSEConfig{Algorithm: SSEAlgorithmAES256},
+			},
+			UploadRetry: UploadRetryConfig{
+				MaxRetries:     3,
+				InitialDelayMs: 500,
+				MaxDelayMs:     10000,
+				Multiplier:     2,
 			},
+			CopyConcurrency: 4,
 		},
 		FFmpeg: FFmpegConfig{
 			ExecutablePath: "ffmpeg",
 			Timeout:        3600,
+			ValidateInput:  true,
 			Qualities: map[string]bool{
 				"360p":  true,
 				"480p":  true,
@@ -155,30 +537,86 @@ func DefaultConfig() *Config {
 				"1080p": false,
 				"2160p": false,
 				"4320p": false,
-				"8640p": false,
 			},
+			CaptureLog:        true,
+			LogRetentionHours: 72,
+			HLS: HLSConfig{
+				SegmentDuration:     2,
+				SegmentPattern:      "stream_%v/data%02d.ts",
+				MasterPlaylistName:  "srota.m3u8",
+				VariantPlaylistName: "stream_%v.m3u8",
+			},
+			FragmentedMP4: FragmentedMP4Config{
+				FragmentDuration: 4,
+			},
+			AudioNormalization: AudioNormalization{
+				Target: -23,
+				TP:     -1,
+				LRA:    7,
+			},
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:           true,
+				ThresholdFailures: 5,
+				RecoveryInterval:  30,
+			},
+			VideoCodec: "h264",
+			TargetFPS:  30,
 		},
 		Worker: WorkerConfig{
-			MinWorkers:  2,
-			MaxWorkers:  10,
-			QueueSize:   100,
-			IdleTimeout: 300,
+			MinWorkers:        2,
+			MaxWorkers:        10,
+			QueueSize:         100,
+			IdleTimeout:       300,
+			DispatchAlgorithm: "priority",
+			StuckJobTimeout:   900,
+
+			StaleJobCheckInterval: 60,
+			MaxJobProcessingTime:  7200,
 		},
 		Metrics: MetricsConfig{
-			Enabled:         true,
-			Port:            9090,
-			Path:            "/metrics",
-			CollectInterval: 30,
+			Enabled:           true,
+			Port:              9090,
+			Path:              "/metrics",
+			CollectInterval:   30,
+			MaxWebSocketConns: 100,
+			InfluxDB: InfluxDBConfig{
+				FlushInterval: 10,
+				BatchSize:     5000,
+				RetryBuffer:   50000,
+			},
 		},
 		Logging: LoggingConfig{
 			Level:      "info",
 			Format:     "json",
 			OutputPath: "",
 		},
+		Vault: VaultConfig{
+			TransitKeyName: "flixsrota",
+		},
+		CrashReporter: CrashReporterConfig{
+			Type: "log",
+		},
+		Admin: AdminConfig{
+			PProfPort: 6060,
+		},
 	}
 }
 
-// Load loads configuration from file and environment variables
+// Environment profile settings
+const (
+	// EnvVar selects the environment profile merged on top of the base config
+	EnvVar = "FLIXSROTA_ENV"
+
+	// EnvProduction is the environment profile with stricter validation
+	EnvProduction = "production"
+)
+
+// Environments lists the environment profiles offered by the wizard
+var Environments = []string{"development", "staging", EnvProduction}
+
+// Load loads configuration from file and environment variables. When
+// FLIXSROTA_ENV is set, the matching profile file (for example
+// .flixsrota.production.yaml) is merged on top of the base config file.
 func Load(configPath string) (*Config, error) {
 	v := viper.New()
 
@@ -202,9 +640,10 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// Read environment variables
-	v.SetEnvPrefix("FLIXSROTA")
+	v.SetEnvPrefix(EnvPrefix)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	bindEnv(v, cfg)
 
 	// Read config file
 	if err := v.ReadInConfig(); err != nil {
@@ -214,21 +653,118 @@ func Load(configPath string) (*Config, error) {
 		} else {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
+	} else if err := expandConfigFile(v); err != nil {
+		return nil, err
+	}
+
+	// Merge environment profile
+	env := os.Getenv(EnvVar)
+	if env != "" {
+		basePath := v.ConfigFileUsed()
+		if basePath == "" {
+			basePath = configPath
+		}
+		if err := mergeProfile(v, ProfilePath(basePath, env)); err != nil {
+			return nil, err
+		}
 	}
 
 	// Unmarshal into config struct
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.FilePath = v.ConfigFileUsed()
+
+	// Decrypt secrets stored as Vault transit ciphertext
+	if err := decryptVaultSecrets(cfg); err != nil {
+		return nil, err
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	if env == EnvProduction {
+		if err := cfg.ValidateProduction(); err != nil {
+			return nil, fmt.Errorf("production profile validation failed: %w", err)
+		}
+	}
+
 	return cfg, nil
 }
 
+// ProfilePath returns the profile file for an environment next to the base
+// config file, e.g. ~/.flixsrota.yaml -> ~/.flixsrota.production.yaml
+func ProfilePath(configPath, env string) string {
+	if configPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			homeDir = "."
+		}
+		configPath = filepath.Join(homeDir, ".flixsrota.yaml")
+	}
+
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + env + ext
+}
+
+// mergeProfile merges an environment profile file into the loaded config.
+// A missing profile file is not an error.
+func mergeProfile(v *viper.Viper, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read profile file: %w", err)
+	}
+
+	expanded, err := expandYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse profile file: %w", err)
+	}
+
+	if err := v.MergeConfig(bytes.NewReader(expanded)); err != nil {
+		return fmt.Errorf("failed to merge profile file: %w", err)
+	}
+
+	return nil
+}
+
+// DeepMerge returns a copy of base with every non-zero field of override
+// applied on top. Nested structs are merged field by field, while non-empty
+// maps and slices replace the base value. Since false is the zero value,
+// boolean fields can only be switched on by an override.
+func DeepMerge(base, override *Config) *Config {
+	merged := *base
+	if override != nil {
+		mergeValue(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(override).Elem())
+	}
+	return &merged
+}
+
+// mergeValue copies the non-zero fields of src into dst
+func mergeValue(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		srcField := src.Field(i)
+		dstField := dst.Field(i)
+
+		switch srcField.Kind() {
+		case reflect.Struct:
+			mergeValue(dstField, srcField)
+		case reflect.Map, reflect.Slice:
+			if srcField.Len() > 0 {
+				dstField.Set(srcField)
+			}
+		default:
+			if !srcField.IsZero() {
+				dstField.Set(srcField)
+			}
+		}
+	}
+}
+
 // Save saves configuration to file
 func Save(cfg *Config, path string) error {
 	data, err := yaml.Marshal(cfg)
@@ -249,6 +785,41 @@ func Save(cfg *Config, path string) error {
 	return nil
 }
 
+// expandConfigFile re-reads the config file with YAML anchors, aliases and
+// merge keys resolved, since Viper does not apply merge keys itself
+func expandConfigFile(v *viper.Viper) error {
+	data, err := os.ReadFile(v.ConfigFileUsed())
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	expanded, err := expandYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if err := v.ReadConfig(bytes.NewReader(expanded)); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return nil
+}
+
+// expandYAML decodes and re-encodes a YAML document, resolving anchors,
+// aliases and merge keys into plain values
+func expandYAML(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return data, nil
+	}
+
+	return yaml.Marshal(doc)
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.GRPC.Port <= 0 || c.GRPC.Port > 65535 {
@@ -263,10 +834,399 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max workers must be greater than or equal to min workers")
 	}
 
+	switch c.Worker.DispatchAlgorithm {
+	case "priority", "fifo", "round-robin":
+	default:
+		return fmt.Errorf("unknown dispatch algorithm: %s", c.Worker.DispatchAlgorithm)
+	}
+
+	if c.Worker.StuckJobTimeout < 0 {
+		return fmt.Errorf("stuck job timeout cannot be negative")
+	}
+
+	if c.Worker.StaleJobCheckInterval < 0 {
+		return fmt.Errorf("stale job check interval cannot be negative")
+	}
+	// A shorter limit would requeue jobs FFmpeg is still allowed to work on
+	if c.Worker.StaleJobCheckInterval > 0 && c.Worker.MaxJobProcessingTime < c.FFmpeg.Timeout {
+		return fmt.Errorf("max job processing time (%ds) must be at least the FFmpeg timeout (%ds)",
+			c.Worker.MaxJobProcessingTime, c.FFmpeg.Timeout)
+	}
+
 	if c.FFmpeg.Timeout <= 0 {
 		return fmt.Errorf("FFmpeg timeout must be positive")
 	}
 
+	if c.FFmpeg.Normalization.DenoiseStrength < 0 {
+		return fmt.Errorf("FFmpeg denoise strength cannot be negative")
+	}
+
+	if (c.FFmpeg.ConvertVFRtoCFR || c.FFmpeg.ForceCFR) && c.FFmpeg.TargetFPS <= 0 {
+		return fmt.Errorf("FFmpeg target FPS must be positive")
+	}
+
+	autoKeyframes := c.FFmpeg.HLS.AutoKeyframeInterval
+	if err := validateSegmentDuration("default", c.FFmpeg.HLS.SegmentDuration, autoKeyframes); err != nil {
+		return err
+	}
+	for _, quality := range sortedKeys(c.FFmpeg.HLS.SegmentDurationByQuality) {
+		if err := validateSegmentDuration(quality, c.FFmpeg.HLS.SegmentDurationByQuality[quality], autoKeyframes); err != nil {
+			return err
+		}
+	}
+	if err := c.FFmpeg.HLS.validateNames(); err != nil {
+		return err
+	}
+
+	if fm := c.FFmpeg.FragmentedMP4; fm.FragmentDuration <= 0 || fm.FragmentDuration%HLSKeyframeIntervalSeconds != 0 {
+		return fmt.Errorf("fragmented MP4 fragment duration (%ds) must be a positive multiple of the %ds keyframe interval",
+			fm.FragmentDuration, HLSKeyframeIntervalSeconds)
+	}
+	if c.FFmpeg.FragmentedMP4.DefaultSampleDuration < 0 {
+		return fmt.Errorf("fragmented MP4 default sample duration cannot be negative")
+	}
+	if c.FFmpeg.FragmentedMP4.Enabled && !hasEnabledQuality(c.FFmpeg.Qualities) {
+		return fmt.Errorf("fragmented MP4 output requires at least one enabled video quality")
+	}
+
+	if an := c.FFmpeg.AudioNormalization; an.Enabled {
+		// Ranges accepted by the loudnorm filter
+		if an.Target < -70 || an.Target > -5 {
+			return fmt.Errorf("audio normalization target must be between -70 and -5 LUFS")
+		}
+		if an.TP < -9 || an.TP > 0 {
+			return fmt.Errorf("audio normalization true peak must be between -9 and 0 dBTP")
+		}
+		if an.LRA < 1 || an.LRA > 50 {
+			return fmt.Errorf("audio normalization loudness range must be between 1 and 50 LU")
+		}
+	}
+
+	if c.FFmpeg.CircuitBreaker.Enabled {
+		if c.FFmpeg.CircuitBreaker.ThresholdFailures <= 0 {
+			return fmt.Errorf("FFmpeg circuit breaker threshold must be positive")
+		}
+		if c.FFmpeg.CircuitBreaker.RecoveryInterval <= 0 {
+			return fmt.Errorf("FFmpeg circuit breaker recovery interval must be positive")
+		}
+	}
+
+	if c.FFmpeg.OutputDirectoryTemplate != "" {
+		if _, err := ParseOutputDirectoryTemplate(c.FFmpeg.OutputDirectoryTemplate); err != nil {
+			return err
+		}
+	}
+
+	if c.Queue.Adapter == "multi" {
+		if err := validateMultiQueue(c.MultiQueue); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Queue.PriorityLanes) > 0 {
+		if c.Queue.Adapter != "memory" {
+			return fmt.Errorf("priority lanes cannot be used with the %s queue adapter", c.Queue.Adapter)
+		}
+		if err := validatePriorityLanes(c.Queue.PriorityLanes); err != nil {
+			return err
+		}
+	}
+
+	if c.Queue.CompressionEnabled {
+		switch c.Queue.CompressionAlgorithm {
+		case "gzip":
+		case "zstd":
+			return fmt.Errorf("zstd queue compression is not supported yet")
+		default:
+			return fmt.Errorf("unknown queue compression algorithm: %s", c.Queue.CompressionAlgorithm)
+		}
+	}
+
+	if c.Storage.Quota.MaxBytesPerTenant < 0 || c.Storage.Quota.MaxFilesPerTenant < 0 {
+		return fmt.Errorf("storage quota limits cannot be negative")
+	}
+
+	if cache := c.Storage.Cache; cache.Enabled {
+		if cache.CachePath == "" {
+			return fmt.Errorf("storage cache path is required")
+		}
+		if cache.MaxCacheSizeGB <= 0 {
+			return fmt.Errorf("storage cache size must be positive")
+		}
+		if cache.WarmJobs < 0 {
+			return fmt.Errorf("storage cache warm jobs cannot be negative")
+		}
+	}
+
+	retry := c.Storage.UploadRetry
+	if retry.MaxRetries < 0 {
+		return fmt.Errorf("storage upload max retries cannot be negative")
+	}
+	if retry.MaxRetries > 0 {
+		if retry.InitialDelayMs <= 0 || retry.MaxDelayMs <= 0 {
+			return fmt.Errorf("storage upload retry delays must be positive")
+		}
+		if retry.MaxDelayMs < retry.InitialDelayMs {
+			return fmt.Errorf("storage upload max delay must not be less than the initial delay")
+		}
+		if retry.Multiplier < 1 {
+			return fmt.Errorf("storage upload retry multiplier must be at least 1")
+		}
+	}
+
+	if c.Storage.CopyConcurrency < 1 {
+		return fmt.Errorf("storage copy concurrency must be at least 1")
+	}
+
+	if c.Storage.Local.Cleanup.Enabled {
+		if c.Storage.Local.Cleanup.IntervalMinutes <= 0 {
+			return fmt.Errorf("temp file cleanup interval must be positive")
+		}
+		if c.Storage.Local.Cleanup.MaxAgeHours <= 0 {
+			return fmt.Errorf("temp file cleanup max age must be positive")
+		}
+	}
+
+	if c.Storage.UseStreamingInput && c.Storage.Adapter != "s3" {
+		return fmt.Errorf("streaming input is only supported by the s3 storage adapter")
+	}
+	if c.Storage.S3.DirectS3Mode {
+		if c.Storage.Adapter != "s3" {
+			return fmt.Errorf("storage.s3.direct_s3_mode is only supported by the s3 storage adapter")
+		}
+		if !c.FFmpeg.AllowRemoteInput {
+			return fmt.Errorf("storage.s3.direct_s3_mode requires ffmpeg.allow_remote_input")
+		}
+	}
+
+	for i, fallback := range c.Storage.Fallback {
+		if fallback.Adapter == "" {
+			return fmt.Errorf("fallback storage %d has no adapter", i)
+		}
+		if len(fallback.Fallback) > 0 {
+			return fmt.Errorf("fallback storage %d cannot have its own fallbacks", i)
+		}
+		s3 := fallback.S3
+		if err := validateAWSCredentials(fmt.Sprintf("storage.fallback[%d].s3", i), s3.UseInstanceRole, s3.AccessKeyID, s3.SecretAccessKey); err != nil {
+			return err
+		}
+		if err := s3.SSE.validate(fmt.Sprintf("storage.fallback[%d].s3.sse", i)); err != nil {
+			return err
+		}
+		if err := fallback.GCS.validateImpersonation(fmt.Sprintf("storage.fallback[%d].gcs", i)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Storage.GCS.validateImpersonation("storage.gcs"); err != nil {
+		return err
+	}
+	s3 := c.Storage.S3
+	if err := validateAWSCredentials("storage.s3", s3.UseInstanceRole, s3.AccessKeyID, s3.SecretAccessKey); err != nil {
+		return err
+	}
+	if err := s3.SSE.validate("storage.s3.sse"); err != nil {
+		return err
+	}
+	sqs := c.Queue.SQS
+	if err := validateAWSCredentials("queue.sqs", sqs.UseInstanceRole, sqs.AccessKeyID, sqs.SecretAccessKey); err != nil {
+		return err
+	}
+	if err := sqs.validate("queue.sqs"); err != nil {
+		return err
+	}
+
+	if c.FFmpeg.LogRetentionHours < 0 {
+		return fmt.Errorf("FFmpeg log retention hours cannot be negative")
+	}
+
+	if c.FFmpeg.ThreadsPerJob < 0 || c.FFmpeg.ThreadsPerJob > runtime.NumCPU() {
+		return fmt.Errorf("FFmpeg threads per job must be between 0 and %d", runtime.NumCPU())
+	}
+
+	if c.FFmpeg.NicePriority < 0 || c.FFmpeg.NicePriority > 19 {
+		return fmt.Errorf("FFmpeg nice priority must be between 0 and 19")
+	}
+
+	if err := ValidateCodec(c.FFmpeg.VideoCodec, c.FFmpeg.HardwareAccel); err != nil {
+		return err
+	}
+
+	if err := ValidateQualities(c.FFmpeg.Qualities); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(c.FFmpeg.Presets) {
+		preset := c.FFmpeg.Presets[name]
+		if err := ValidatePreset(name, preset); err != nil {
+			return err
+		}
+		// Presets without qualities use ffmpeg.qualities
+		if preset.OutputFormat == OutputFormatFMP4 && len(preset.Qualities) == 0 && !hasEnabledQuality(c.FFmpeg.Qualities) {
+			return fmt.Errorf("preset %s: fmp4 output requires at least one enabled video quality", name)
+		}
+	}
+
+	if maxCRF := VideoCodecs[c.FFmpeg.VideoCodec].MaxCRF; c.FFmpeg.CRF < 0 || c.FFmpeg.CRF > maxCRF {
+		return fmt.Errorf("CRF for %s must be between 0 and %d", c.FFmpeg.VideoCodec, maxCRF)
+	}
+
+	if c.GRPC.MaxSubscribers < 0 {
+		return fmt.Errorf("max subscribers cannot be negative")
+	}
+
+	if c.Metrics.MaxWebSocketConns < 0 {
+		return fmt.Errorf("max WebSocket connections cannot be negative")
+	}
+
+	if (c.GRPC.TLSCertFile == "") != (c.GRPC.TLSKeyFile == "") {
+		return fmt.Errorf("gRPC TLS requires both a certificate and a key file")
+	}
+
+	if c.GRPC.AdminAPIKey != "" {
+		if c.GRPC.AdminPort <= 0 || c.GRPC.AdminPort > 65535 {
+			return fmt.Errorf("invalid admin gRPC port: %d", c.GRPC.AdminPort)
+		}
+		if c.GRPC.AdminPort == c.GRPC.Port {
+			return fmt.Errorf("admin gRPC port must differ from the gRPC port")
+		}
+	}
+
+	if c.Billing.ComputeMinuteCost < 0 || c.Billing.StorageGBCost < 0 {
+		return fmt.Errorf("billing costs cannot be negative")
+	}
+	if c.Billing.Enabled && c.Queue.Adapter != "redis" {
+		return fmt.Errorf("billing requires the redis queue adapter")
+	}
+
+	if influx := c.Metrics.InfluxDB; influx.Enabled {
+		if influx.URL == "" || influx.Org == "" || influx.Bucket == "" {
+			return fmt.Errorf("metrics.influxdb requires url, org and bucket")
+		}
+		if influx.FlushInterval <= 0 || influx.BatchSize <= 0 {
+			return fmt.Errorf("metrics.influxdb flush interval and batch size must be positive")
+		}
+		if influx.RetryBuffer < 0 {
+			return fmt.Errorf("metrics.influxdb retry buffer cannot be negative")
+		}
+	}
+
+	switch c.CrashReporter.Type {
+	case "log":
+	case "slack":
+		if c.CrashReporter.SlackWebhookURL == "" {
+			return fmt.Errorf("crash_reporter.slack_webhook_url is required by the slack crash reporter")
+		}
+	case "sentry":
+		if c.CrashReporter.SentryDSN == "" {
+			return fmt.Errorf("crash_reporter.sentry_dsn is required by the sentry crash reporter")
+		}
+	default:
+		return fmt.Errorf("unknown crash reporter: %s", c.CrashReporter.Type)
+	}
+
+	if c.Admin.EnablePprof {
+		if c.Admin.PProfPort <= 0 || c.Admin.PProfPort > 65535 {
+			return fmt.Errorf("invalid admin pprof port: %d", c.Admin.PProfPort)
+		}
+		if c.Metrics.Enabled && c.Admin.PProfPort == c.Metrics.Port {
+			return fmt.Errorf("admin pprof port must differ from the metrics port")
+		}
+		if c.Admin.PprofToken == "" {
+			return fmt.Errorf("admin.pprof_token is required when pprof is enabled")
+		}
+	}
+
+	return nil
+}
+
+// ValidateProduction checks the additional requirements of the production profile
+func (c *Config) ValidateProduction() error {
+	if c.GRPC.EnableReflection {
+		return fmt.Errorf("gRPC reflection must be disabled in production")
+	}
+
+	if c.GRPC.TLSCertFile == "" || c.GRPC.TLSKeyFile == "" {
+		return fmt.Errorf("gRPC TLS must be enabled in production")
+	}
+
+	return nil
+}
+
+// validateSegmentDuration checks that an HLS segment duration is positive and
+// a multiple of the keyframe interval, unless the keyframe interval follows
+// the segment duration
+func validateSegmentDuration(quality string, seconds int, autoKeyframes bool) error {
+	if seconds <= 0 {
+		return fmt.Errorf("HLS segment duration for %s must be positive", quality)
+	}
+	if !autoKeyframes && seconds%HLSKeyframeIntervalSeconds != 0 {
+		return fmt.Errorf("HLS segment duration for %s (%ds) must be a multiple of the %ds keyframe interval",
+			quality, seconds, HLSKeyframeIntervalSeconds)
+	}
+	return nil
+}
+
+// segmentNumberVerb matches the %d verb FFmpeg replaces with the segment
+// number, with optional zero padding such as %05d
+var segmentNumberVerb = regexp.MustCompile(`%0?[0-9]*d`)
+
+// validateNames checks that the segment and playlist names contain the
+// format verbs FFmpeg needs to keep the files of each variant apart
+func (h HLSConfig) validateNames() error {
+	if !strings.Contains(h.SegmentPattern, "%v") || !segmentNumberVerb.MatchString(h.SegmentPattern) {
+		return fmt.Errorf("HLS segment pattern %q must contain %%v and a %%d verb", h.SegmentPattern)
+	}
+	if !strings.Contains(h.VariantPlaylistName, "%v") {
+		return fmt.Errorf("HLS variant playlist name %q must contain %%v", h.VariantPlaylistName)
+	}
+	if h.MasterPlaylistName == "" || strings.ContainsAny(h.MasterPlaylistName, "%/\\") {
+		return fmt.Errorf("HLS master playlist name %q must be a file name without %% verbs", h.MasterPlaylistName)
+	}
+	return nil
+}
+
+// validateMultiQueue validates the queues used by the multi queue adapter
+func validateMultiQueue(queues []QueueConfig) error {
+	if len(queues) == 0 {
+		return fmt.Errorf("multi queue adapter requires at least one entry in multi_queue")
+	}
+
+	names := make(map[string]bool)
+	for i, q := range queues {
+		if q.Name == "" {
+			return fmt.Errorf("multi_queue entry %d has no name", i)
+		}
+		if names[q.Name] {
+			return fmt.Errorf("duplicate multi_queue name: %s", q.Name)
+		}
+		names[q.Name] = true
+
+		if q.Adapter == "" || q.Adapter == "multi" {
+			return fmt.Errorf("multi_queue entry %s has invalid adapter: %q", q.Name, q.Adapter)
+		}
+
+		sqs := q.SQS
+		if err := validateAWSCredentials(fmt.Sprintf("multi_queue entry %s sqs", q.Name), sqs.UseInstanceRole, sqs.AccessKeyID, sqs.SecretAccessKey); err != nil {
+			return err
+		}
+		if err := sqs.validate(fmt.Sprintf("multi_queue entry %s sqs", q.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateAWSCredentials rejects access keys set together with the instance
+// role, and an access key ID without its secret or the reverse
+func validateAWSCredentials(field string, useInstanceRole bool, accessKeyID, secretAccessKey string) error {
+	if useInstanceRole && (accessKeyID != "" || secretAccessKey != "") {
+		return fmt.Errorf("%s: use_instance_role cannot be combined with access_key_id or secret_access_key", field)
+	}
+	if (accessKeyID == "") != (secretAccessKey == "") {
+		return fmt.Errorf("%s: access_key_id and secret_access_key must be set together", field)
+	}
 	return nil
 }
 
@@ -277,6 +1237,11 @@ func setDefaults(v *viper.Viper, cfg *Config) {
 	v.SetDefault("grpc.port", cfg.GRPC.Port)
 	v.SetDefault("grpc.max_concurrent", cfg.GRPC.MaxConcurrent)
 	v.SetDefault("grpc.enable_reflection", cfg.GRPC.EnableReflection)
+	v.SetDefault("grpc.tls_cert_file", cfg.GRPC.TLSCertFile)
+	v.SetDefault("grpc.tls_key_file", cfg.GRPC.TLSKeyFile)
+	v.SetDefault("grpc.max_subscribers", cfg.GRPC.MaxSubscribers)
+	v.SetDefault("grpc.admin_port", cfg.GRPC.AdminPort)
+	v.SetDefault("grpc.admin_api_key", cfg.GRPC.AdminAPIKey)
 
 	// Queue defaults
 	v.SetDefault("queue.adapter", cfg.Queue.Adapter)
@@ -284,33 +1249,111 @@ func setDefaults(v *viper.Viper, cfg *Config) {
 	v.SetDefault("queue.redis.password", cfg.Queue.Redis.Password)
 	v.SetDefault("queue.redis.db", cfg.Queue.Redis.DB)
 	v.SetDefault("queue.redis.pool_size", cfg.Queue.Redis.PoolSize)
+	v.SetDefault("queue.sqs.max_receive_count", cfg.Queue.SQS.MaxReceiveCount)
+	v.SetDefault("queue.compression_enabled", cfg.Queue.CompressionEnabled)
+	v.SetDefault("queue.compression_algorithm", cfg.Queue.CompressionAlgorithm)
 
 	// Storage defaults
 	v.SetDefault("storage.adapter", cfg.Storage.Adapter)
 	v.SetDefault("storage.local.base_path", cfg.Storage.Local.BasePath)
 	v.SetDefault("storage.local.temp_path", cfg.Storage.Local.TempPath)
+	v.SetDefault("storage.local.cleanup.enabled", cfg.Storage.Local.Cleanup.Enabled)
+	v.SetDefault("storage.local.cleanup.interval_minutes", cfg.Storage.Local.Cleanup.IntervalMinutes)
+	v.SetDefault("storage.local.cleanup.max_age_hours", cfg.Storage.Local.Cleanup.MaxAgeHours)
+	v.SetDefault("storage.s3.sse.enabled", cfg.Storage.S3.SSE.Enabled)
+	v.SetDefault("storage.s3.sse.algorithm", cfg.Storage.S3.SSE.Algorithm)
+	v.SetDefault("storage.s3.direct_s3_mode", cfg.Storage.S3.DirectS3Mode)
+	v.SetDefault("storage.cache.enabled", cfg.Storage.Cache.Enabled)
+	v.SetDefault("storage.cache.cache_path", cfg.Storage.Cache.CachePath)
+	v.SetDefault("storage.cache.max_cache_size_gb", cfg.Storage.Cache.MaxCacheSizeGB)
+	v.SetDefault("storage.cache.warm_jobs", cfg.Storage.Cache.WarmJobs)
+	v.SetDefault("storage.upload_retry.max_retries", cfg.Storage.UploadRetry.MaxRetries)
+	v.SetDefault("storage.upload_retry.initial_delay_ms", cfg.Storage.UploadRetry.InitialDelayMs)
+	v.SetDefault("storage.upload_retry.max_delay_ms", cfg.Storage.UploadRetry.MaxDelayMs)
+	v.SetDefault("storage.upload_retry.multiplier", cfg.Storage.UploadRetry.Multiplier)
+	v.SetDefault("storage.copy_concurrency", cfg.Storage.CopyConcurrency)
 
 	// FFmpeg defaults
 	v.SetDefault("ffmpeg.executable_path", cfg.FFmpeg.ExecutablePath)
 	v.SetDefault("ffmpeg.timeout", cfg.FFmpeg.Timeout)
 	v.SetDefault("ffmpeg.qualities", cfg.FFmpeg.Qualities)
+	v.SetDefault("ffmpeg.capture_log", cfg.FFmpeg.CaptureLog)
+	v.SetDefault("ffmpeg.log_retention_hours", cfg.FFmpeg.LogRetentionHours)
+	v.SetDefault("ffmpeg.threads_per_job", cfg.FFmpeg.ThreadsPerJob)
+	v.SetDefault("ffmpeg.nice_priority", cfg.FFmpeg.NicePriority)
+	v.SetDefault("ffmpeg.use_ionice", cfg.FFmpeg.UseIonice)
+	v.SetDefault("ffmpeg.video_codec", cfg.FFmpeg.VideoCodec)
+	v.SetDefault("ffmpeg.crf", cfg.FFmpeg.CRF)
+	v.SetDefault("ffmpeg.hardware_accel", cfg.FFmpeg.HardwareAccel)
+	v.SetDefault("ffmpeg.allow_remote_input", cfg.FFmpeg.AllowRemoteInput)
+	v.SetDefault("ffmpeg.auto_detect_hardware", cfg.FFmpeg.AutoDetectHWAccel)
+	v.SetDefault("ffmpeg.validate_input", cfg.FFmpeg.ValidateInput)
+	v.SetDefault("ffmpeg.normalization.auto_rotate", cfg.FFmpeg.Normalization.AutoRotate)
+	v.SetDefault("ffmpeg.normalization.deinterlace", cfg.FFmpeg.Normalization.Deinterlace)
+	v.SetDefault("ffmpeg.normalization.denoise_strength", cfg.FFmpeg.Normalization.DenoiseStrength)
+	v.SetDefault("ffmpeg.output_directory_template", cfg.FFmpeg.OutputDirectoryTemplate)
+	v.SetDefault("ffmpeg.enable_quality_metrics", cfg.FFmpeg.EnableQualityMetrics)
+	v.SetDefault("ffmpeg.align_keyframes_to_chapters", cfg.FFmpeg.AlignKeyframesToChapters)
+	v.SetDefault("ffmpeg.convert_vfr_to_cfr", cfg.FFmpeg.ConvertVFRtoCFR)
+	v.SetDefault("ffmpeg.force_cfr", cfg.FFmpeg.ForceCFR)
+	v.SetDefault("ffmpeg.target_fps", cfg.FFmpeg.TargetFPS)
+	v.SetDefault("ffmpeg.multi_language_audio", cfg.FFmpeg.MultiLanguageAudio)
+	v.SetDefault("ffmpeg.hls.segment_duration", cfg.FFmpeg.HLS.SegmentDuration)
+	v.SetDefault("ffmpeg.hls.segment_pattern", cfg.FFmpeg.HLS.SegmentPattern)
+	v.SetDefault("ffmpeg.hls.master_playlist_name", cfg.FFmpeg.HLS.MasterPlaylistName)
+	v.SetDefault("ffmpeg.hls.variant_playlist_name", cfg.FFmpeg.HLS.VariantPlaylistName)
+	v.SetDefault("ffmpeg.hls.auto_keyframe_interval", cfg.FFmpeg.HLS.AutoKeyframeInterval)
+	v.SetDefault("ffmpeg.fragmented_mp4.enabled", cfg.FFmpeg.FragmentedMP4.Enabled)
+	v.SetDefault("ffmpeg.fragmented_mp4.fragment_duration", cfg.FFmpeg.FragmentedMP4.FragmentDuration)
+	v.SetDefault("ffmpeg.fragmented_mp4.default_sample_duration", cfg.FFmpeg.FragmentedMP4.DefaultSampleDuration)
+	v.SetDefault("ffmpeg.audio_normalization.enabled", cfg.FFmpeg.AudioNormalization.Enabled)
+	v.SetDefault("ffmpeg.audio_normalization.target", cfg.FFmpeg.AudioNormalization.Target)
+	v.SetDefault("ffmpeg.audio_normalization.tp", cfg.FFmpeg.AudioNormalization.TP)
+	v.SetDefault("ffmpeg.audio_normalization.lra", cfg.FFmpeg.AudioNormalization.LRA)
+	v.SetDefault("ffmpeg.circuit_breaker.enabled", cfg.FFmpeg.CircuitBreaker.Enabled)
+	v.SetDefault("ffmpeg.circuit_breaker.threshold_failures", cfg.FFmpeg.CircuitBreaker.ThresholdFailures)
+	v.SetDefault("ffmpeg.circuit_breaker.recovery_interval", cfg.FFmpeg.CircuitBreaker.RecoveryInterval)
 
 	// Worker defaults
 	v.SetDefault("worker.min_workers", cfg.Worker.MinWorkers)
 	v.SetDefault("worker.max_workers", cfg.Worker.MaxWorkers)
 	v.SetDefault("worker.queue_size", cfg.Worker.QueueSize)
 	v.SetDefault("worker.idle_timeout", cfg.Worker.IdleTimeout)
+	v.SetDefault("worker.stuck_job_timeout", cfg.Worker.StuckJobTimeout)
+	v.SetDefault("worker.stale_job_check_interval", cfg.Worker.StaleJobCheckInterval)
+	v.SetDefault("worker.max_job_processing_time", cfg.Worker.MaxJobProcessingTime)
+	v.SetDefault("worker.dispatch_algorithm", cfg.Worker.DispatchAlgorithm)
 
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", cfg.Metrics.Enabled)
 	v.SetDefault("metrics.port", cfg.Metrics.Port)
 	v.SetDefault("metrics.path", cfg.Metrics.Path)
 	v.SetDefault("metrics.collect_interval", cfg.Metrics.CollectInterval)
+	v.SetDefault("metrics.max_websocket_conns", cfg.Metrics.MaxWebSocketConns)
+	v.SetDefault("metrics.influxdb.enabled", cfg.Metrics.InfluxDB.Enabled)
+	v.SetDefault("metrics.influxdb.flush_interval", cfg.Metrics.InfluxDB.FlushInterval)
+	v.SetDefault("metrics.influxdb.batch_size", cfg.Metrics.InfluxDB.BatchSize)
+	v.SetDefault("metrics.influxdb.retry_buffer", cfg.Metrics.InfluxDB.RetryBuffer)
 
 	// Logging defaults
 	v.SetDefault("logging.level", cfg.Logging.Level)
 	v.SetDefault("logging.format", cfg.Logging.Format)
 	v.SetDefault("logging.output_path", cfg.Logging.OutputPath)
+
+	// Vault defaults
+	v.SetDefault("vault.enabled", cfg.Vault.Enabled)
+	v.SetDefault("vault.address", cfg.Vault.Address)
+	v.SetDefault("vault.token", cfg.Vault.Token)
+	v.SetDefault("vault.transit_key_name", cfg.Vault.TransitKeyName)
+
+	// Billing defaults
+	v.SetDefault("billing.enabled", cfg.Billing.Enabled)
+	v.SetDefault("billing.compute_minute_cost", cfg.Billing.ComputeMinuteCost)
+	v.SetDefault("billing.storage_gb_cost", cfg.Billing.StorageGBCost)
+	v.SetDefault("crash_reporter.type", cfg.CrashReporter.Type)
+	v.SetDefault("crash_reporter.rethrow_panic", cfg.CrashReporter.RethrowPanic)
+	v.SetDefault("admin.enable_pprof", cfg.Admin.EnablePprof)
+	v.SetDefault("admin.pprof_port", cfg.Admin.PProfPort)
 }
 
 // GetString returns a string value from environment or config
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAMLAnchors(t *testing.T) {
+	// The common bucket settings are anchored once and merged into both the
+	// s3 and gcs sections; gcs overrides the bucket
+	data := `
+x-common-storage: &common-storage
+  bucket: shared-media
+  region: eu-west-1
+
+storage:
+  adapter: local
+  s3:
+    <<: *common-storage
+    access_key_id: shared-key
+    secret_access_key: shared-secret
+  gcs:
+    <<: *common-storage
+    bucket: shared-media-gcs
+    project_id: media-project
+`
+	path := filepath.Join(t.TempDir(), "flixsrota.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	s3 := cfg.Storage.S3
+	if s3.Bucket != "shared-media" || s3.Region != "eu-west-1" || s3.AccessKeyID != "shared-key" {
+		t.Errorf("storage.s3 = %+v, want the common storage settings and access_key_id", s3)
+	}
+	gcs := cfg.Storage.GCS
+	if gcs.Bucket != "shared-media-gcs" || gcs.ProjectID != "media-project" {
+		t.Errorf("storage.gcs = %+v, want the overridden bucket and project_id", gcs)
+	}
+}
+
+func TestExpandYAMLInvalid(t *testing.T) {
+	if _, err := expandYAML([]byte("storage:\n  s3: *missing\n")); err == nil {
+		t.Errorf("expandYAML() of an unknown alias succeeded")
+	}
+}
+
+func TestHLSConfigValidateNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(h *HLSConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(h *HLSConfig) {}},
+		{name: "padded segment number", modify: func(h *HLSConfig) { h.SegmentPattern = "hls/%v/seg_%05d.ts" }},
+		{name: "segment without variant", modify: func(h *HLSConfig) { h.SegmentPattern = "data%02d.ts" }, wantErr: true},
+		{name: "segment without number", modify: func(h *HLSConfig) { h.SegmentPattern = "stream_%v/data.ts" }, wantErr: true},
+		{name: "variant playlist without variant", modify: func(h *HLSConfig) { h.VariantPlaylistName = "stream.m3u8" }, wantErr: true},
+		{name: "empty master playlist", modify: func(h *HLSConfig) { h.MasterPlaylistName = "" }, wantErr: true},
+		{name: "master playlist in a directory", modify: func(h *HLSConfig) { h.MasterPlaylistName = "hls/master.m3u8" }, wantErr: true},
+		{name: "master playlist with a verb", modify: func(h *HLSConfig) { h.MasterPlaylistName = "master_%v.m3u8" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := DefaultConfig().FFmpeg.HLS
+			tt.modify(&h)
+			if err := h.validateNames(); (err != nil) != tt.wantErr {
+				t.Errorf("validateNames() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAWSCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "access keys", modify: func(cfg *Config) {
+			cfg.Storage.S3.AccessKeyID = "id"
+			cfg.Storage.S3.SecretAccessKey = "secret"
+		}},
+		{name: "instance role", modify: func(cfg *Config) {
+			cfg.Storage.S3.UseInstanceRole = true
+			cfg.Queue.SQS.UseInstanceRole = true
+		}},
+		{name: "instance role with keys", modify: func(cfg *Config) {
+			cfg.Storage.S3.UseInstanceRole = true
+			cfg.Storage.S3.AccessKeyID = "id"
+			cfg.Storage.S3.SecretAccessKey = "secret"
+		}, wantErr: "storage.s3: use_instance_role cannot be combined"},
+		{name: "key without secret", modify: func(cfg *Config) {
+			cfg.Queue.SQS.AccessKeyID = "id"
+		}, wantErr: "queue.sqs: access_key_id and secret_access_key must be set together"},
+		{name: "fallback", modify: func(cfg *Config) {
+			fallback := StorageConfig{Adapter: "s3"}
+			fallback.S3.UseInstanceRole = true
+			fallback.S3.SecretAccessKey = "secret"
+			cfg.Storage.Fallback = []StorageConfig{fallback}
+		}, wantErr: "storage.fallback[0].s3: use_instance_role"},
+		{name: "multi queue entry", modify: func(cfg *Config) {
+			entry := QueueConfig{Name: "bulk", Adapter: "sqs"}
+			entry.SQS.SecretAccessKey = "secret"
+			cfg.Queue.Adapter = "multi"
+			cfg.MultiQueue = []QueueConfig{entry}
+		}, wantErr: "multi_queue entry bulk sqs: access_key_id and secret_access_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGCSImpersonation(t *testing.T) {
+	tenants := map[string]string{"acme": "acme@project.iam.gserviceaccount.com"}
+
+	tests := []struct {
+		name    string
+		gcs     GCSStorageConfig
+		wantErr string
+	}{
+		{name: "shared account", gcs: GCSStorageConfig{ImpersonateServiceAccount: "worker@project.iam.gserviceaccount.com"}},
+		{name: "per tenant", gcs: GCSStorageConfig{ImpersonateByTenant: true, TenantImpersonationMap: tenants}},
+		{name: "invalid account", gcs: GCSStorageConfig{ImpersonateServiceAccount: "worker"}, wantErr: "not a service account email"},
+		{name: "per tenant without map", gcs: GCSStorageConfig{ImpersonateByTenant: true}, wantErr: "requires tenant_impersonation_map"},
+		{
+			name:    "invalid tenant account",
+			gcs:     GCSStorageConfig{ImpersonateByTenant: true, TenantImpersonationMap: map[string]string{"acme": "acme @project.iam"}},
+			wantErr: "for tenant acme is not a service account email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Storage.GCS = tt.gcs
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	gcs := GCSStorageConfig{ImpersonateServiceAccount: "worker@project.iam.gserviceaccount.com", ImpersonateByTenant: true, TenantImpersonationMap: tenants}
+	if account, err := gcs.ServiceAccountForTenant("acme"); err != nil || account != tenants["acme"] {
+		t.Errorf("ServiceAccountForTenant(acme) = %s, %v; want the mapped account", account, err)
+	}
+	// Unmapped tenants never fall back to the shared account
+	for _, tenantID := range []string{"", "other"} {
+		if account, err := gcs.ServiceAccountForTenant(tenantID); err == nil {
+			t.Errorf("ServiceAccountForTenant(%q) = %s, want an error", tenantID, account)
+		}
+	}
+}
+
+func TestValidateSegmentDuration(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FFmpeg.HLS.SegmentDuration = 5
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "multiple of the 2s keyframe interval") {
+		t.Errorf("Validate() of a 5s segment error = %v, want the keyframe interval reported", err)
+	}
+
+	cfg.FFmpeg.HLS.AutoKeyframeInterval = true
+	cfg.FFmpeg.HLS.SegmentDurationByQuality = map[string]int{"720p": 3}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with auto_keyframe_interval error = %v", err)
+	}
+
+	cfg.FFmpeg.HLS.SegmentDurationByQuality = map[string]int{"720p": 0}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "for 720p must be positive") {
+		t.Errorf("Validate() of a 0s segment error = %v", err)
+	}
+}
+
+func TestValidateMaxJobProcessingTime(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Worker.MaxJobProcessingTime = cfg.FFmpeg.Timeout - 1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "must be at least the FFmpeg timeout") {
+		t.Errorf("Validate() error = %v, want the limit below the FFmpeg timeout rejected", err)
+	}
+
+	// The limit is unused with the reaper disabled
+	cfg.Worker.StaleJobCheckInterval = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with the reaper disabled error = %v", err)
+	}
+}
+
+func TestValidateS3Encryption(t *testing.T) {
+	tests := []struct {
+		name    string
+		sse     SSEConfig
+		wantErr string
+	}{
+		{name: "disabled", sse: SSEConfig{Algorithm: "unknown"}},
+		{name: "S3 managed keys", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmAES256}},
+		{name: "KMS", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmKMS, KMSKeyID: "alias/outputs"}},
+		{name: "client-side", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmCSE, KMSKeyID: "arn:aws:kms:us-east-1:123:key/abc"}},
+		{name: "KMS without key", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmKMS},
+			wantErr: "storage.s3.sse.kms_key_id is required for algorithm aws:kms"},
+		{name: "client-side without key", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmCSE},
+			wantErr: "kms_key_id is required for algorithm S3-CSE"},
+		{name: "S3 managed keys with key", sse: SSEConfig{Enabled: true, Algorithm: SSEAlgorithmAES256, KMSKeyID: "alias/outputs"},
+			wantErr: "storage.s3.sse.kms_key_id cannot be used with algorithm AES256"},
+		{name: "unknown algorithm", sse: SSEConfig{Enabled: true, Algorithm: "DES"},
+			wantErr: "storage.s3.sse.algorithm must be AES256, aws:kms or S3-CSE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Storage.S3.SSE = tt.sse
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Fallback storages are checked too
+	cfg := DefaultConfig()
+	fallback := StorageConfig{Adapter: "s3"}
+	fallback.S3.SSE = SSEConfig{Enabled: true, Algorithm: SSEAlgorithmKMS}
+	cfg.Storage.Fallback = []StorageConfig{fallback}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "storage.fallback[0].s3.sse.kms_key_id") {
+		t.Errorf("Validate() error = %v, want the fallback's missing KMS key", err)
+	}
+
+	if got := DefaultConfig().Storage.S3.SSE; got.Enabled || got.Algorithm != SSEAlgorithmAES256 {
+		t.Errorf("default sse = %+v, want AES256 and disabled", got)
+	}
+}
+
+func TestValidateDirectS3Mode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.Adapter = "s3"
+	cfg.Storage.S3.DirectS3Mode = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "requires ffmpeg.allow_remote_input") {
+		t.Errorf("Validate() error = %v, want allow_remote_input required", err)
+	}
+
+	cfg.FFmpeg.AllowRemoteInput = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	cfg.Storage.Adapter = "local"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "only supported by the s3 storage adapter") {
+		t.Errorf("Validate() error = %v, want the s3 adapter required", err)
+	}
+}
+
+func TestValidateCrashReporter(t *testing.T) {
+	tests := []struct {
+		reporter CrashReporterConfig
+		wantErr  string
+	}{
+		{reporter: CrashReporterConfig{Type: "log"}},
+		{reporter: CrashReporterConfig{Type: "slack", SlackWebhookURL: "https://hooks.example.com/x"}},
+		{reporter: CrashReporterConfig{Type: "sentry", SentryDSN: "https://key@sentry.example.com/1"}},
+		{reporter: CrashReporterConfig{Type: "slack"}, wantErr: "crash_reporter.slack_webhook_url is required"},
+		{reporter: CrashReporterConfig{Type: "sentry"}, wantErr: "crash_reporter.sentry_dsn is required"},
+		{reporter: CrashReporterConfig{Type: "pager"}, wantErr: "unknown crash reporter: pager"},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.CrashReporter = tt.reporter
+		err := cfg.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate() with %+v error = %v", tt.reporter, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate() with %+v error = %v, want one containing %q", tt.reporter, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateInfluxDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(influx *InfluxDBConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(influx *InfluxDBConfig) {}},
+		{name: "no bucket", modify: func(influx *InfluxDBConfig) { influx.Bucket = "" },
+			wantErr: "metrics.influxdb requires url, org and bucket"},
+		{name: "zero batch size", modify: func(influx *InfluxDBConfig) { influx.BatchSize = 0 },
+			wantErr: "batch size must be positive"},
+		{name: "negative retry buffer", modify: func(influx *InfluxDBConfig) { influx.RetryBuffer = -1 },
+			wantErr: "retry buffer cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			influx := &cfg.Metrics.InfluxDB
+			influx.Enabled = true
+			influx.URL = "http://influxdb:8086"
+			influx.Org = "media"
+			influx.Bucket = "flixsrota"
+			tt.modify(influx)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAdminPprof(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   AdminConfig
+		wantErr string
+	}{
+		{name: "disabled", admin: AdminConfig{PProfPort: 0}},
+		{name: "enabled", admin: AdminConfig{EnablePprof: true, PProfPort: 6060, PprofToken: "secret"}},
+		{name: "no token", admin: AdminConfig{EnablePprof: true, PProfPort: 6060},
+			wantErr: "admin.pprof_token is required"},
+		{name: "invalid port", admin: AdminConfig{EnablePprof: true, PProfPort: 70000, PprofToken: "secret"},
+			wantErr: "invalid admin pprof port"},
+		{name: "metrics port", admin: AdminConfig{EnablePprof: true, PProfPort: 9090, PprofToken: "secret"},
+			wantErr: "must differ from the metrics port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Metrics.Port = 9090
+			cfg.Admin = tt.admin
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSQSDeadLetterQueue(t *testing.T) {
+	const queueURL = "https://sqs.us-east-1.amazonaws.com/123/jobs"
+	const dlqURL = "https://sqs.us-east-1.amazonaws.com/123/jobs-dlq"
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "no dead-letter queue", modify: func(cfg *Config) {
+			cfg.Queue.SQS.MaxReceiveCount = 0
+		}},
+		{name: "dead-letter queue", modify: func(cfg *Config) {
+			cfg.Queue.SQS.DLQUrl = dlqURL
+		}},
+		{name: "same queue", modify: func(cfg *Config) {
+			cfg.Queue.SQS.DLQUrl = queueURL
+		}, wantErr: "queue.sqs.dlq_url must differ from the queue URL"},
+		{name: "receive count too high", modify: func(cfg *Config) {
+			cfg.Queue.SQS.DLQUrl = dlqURL
+			cfg.Queue.SQS.MaxReceiveCount = 1001
+		}, wantErr: "queue.sqs.max_receive_count must be between 1 and 1000, got 1001"},
+		{name: "multi queue entry", modify: func(cfg *Config) {
+			entry := QueueConfig{Name: "bulk", Adapter: "sqs"}
+			entry.SQS.QueueURL = queueURL
+			entry.SQS.DLQUrl = dlqURL
+			cfg.Queue.Adapter = "multi"
+			cfg.MultiQueue = []QueueConfig{entry}
+		}, wantErr: "multi_queue entry bulk sqs.max_receive_count must be between 1 and 1000, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Queue.SQS.QueueURL = queueURL
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePriorityLanes(t *testing.T) {
+	lanes := map[string]PriorityLaneConfig{
+		"urgent": {MinPriority: 10, MaxPriority: 100, WorkerAllocation: 70},
+		"bulk":   {MinPriority: 0, MaxPriority: 9, WorkerAllocation: 30},
+	}
+
+	tests := []struct {
+		name    string
+		adapter string
+		lanes   map[string]PriorityLaneConfig
+		wantErr string
+	}{
+		{name: "memory", adapter: "memory", lanes: lanes},
+		{name: "redis", adapter: "redis", lanes: lanes, wantErr: "redis queue adapter"},
+		{name: "kafka", adapter: "kafka", lanes: lanes, wantErr: "kafka queue adapter"},
+		{name: "multi", adapter: "multi", lanes: lanes, wantErr: "multi queue adapter"},
+		{
+			name:    "overlapping ranges",
+			adapter: "memory",
+			lanes: map[string]PriorityLaneConfig{
+				"urgent": {MinPriority: 5, MaxPriority: 100, WorkerAllocation: 70},
+				"bulk":   {MinPriority: 0, MaxPriority: 9, WorkerAllocation: 30},
+			},
+			wantErr: "overlap",
+		},
+		{
+			name:    "allocations below 100",
+			adapter: "memory",
+			lanes: map[string]PriorityLaneConfig{
+				"urgent": {MinPriority: 10, MaxPriority: 100, WorkerAllocation: 50},
+				"bulk":   {MinPriority: 0, MaxPriority: 9, WorkerAllocation: 30},
+			},
+			wantErr: "add up to 80",
+		},
+		{
+			name:    "inverted range",
+			adapter: "memory",
+			lanes: map[string]PriorityLaneConfig{
+				"all": {MinPriority: 10, MaxPriority: 0, WorkerAllocation: 100},
+			},
+			wantErr: "above max priority",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Queue.Adapter = tt.adapter
+			cfg.Queue.PriorityLanes = tt.lanes
+			if tt.adapter == "multi" {
+				cfg.MultiQueue = []QueueConfig{{Name: "only", Adapter: "memory"}}
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Paths used inside the generated containers
+const (
+	containerConfigPath  = "/etc/flixsrota/config.yaml"
+	containerStoragePath = "/data/storage"
+	containerTempPath    = "/data/temp"
+)
+
+// DefaultDeployImage is the Flixsrota image used by generated deployments,
+// as built with `docker build -t flixsrota .`
+const DefaultDeployImage = "flixsrota:latest"
+
+// DeployOptions selects the services of a generated deployment
+type DeployOptions struct {
+	// Queue and Storage override the configured adapters when set
+	Queue   string
+	Storage string
+	// Image is the Flixsrota image, DefaultDeployImage when empty
+	Image          string
+	WithPrometheus bool
+	WithGrafana    bool
+	// SecretName is the Kubernetes Secret holding the secret environment
+	// variables, as written by `config export-env --format k8s-secret`
+	SecretName string
+}
+
+// deployment is the configuration a generated deployment runs with
+type deployment struct {
+	opts DeployOptions
+	// config is the YAML mounted into the container, without secrets
+	config []byte
+	// secrets are the environment variables the secrets are injected from
+	secrets []string
+	cfg     Config
+}
+
+// newDeployment adapts cfg to run in a container next to the generated
+// services: the gRPC server listens on all interfaces, Redis is reached by
+// its service name, local storage lives on a volume and logs go to stdout.
+// Every non-empty secret is removed from the config file and injected from
+// its environment variable instead.
+func newDeployment(cfg *Config, opts DeployOptions) (*deployment, error) {
+	c := *cfg
+	if opts.Queue != "" {
+		c.Queue.Adapter = opts.Queue
+	}
+	if opts.Storage != "" {
+		c.Storage.Adapter = opts.Storage
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultDeployImage
+	}
+
+	switch c.Queue.Adapter {
+	case "redis", "memory", "kafka", "sqs":
+	default:
+		return nil, fmt.Errorf("unsupported queue adapter for deployment: %s", c.Queue.Adapter)
+	}
+	switch c.Storage.Adapter {
+	case "local", "s3", "gcs":
+	default:
+		return nil, fmt.Errorf("unsupported storage adapter for deployment: %s", c.Storage.Adapter)
+	}
+
+	c.GRPC.Address = "0.0.0.0"
+	if c.Queue.Adapter == "redis" {
+		c.Queue.Redis.Address = "redis:6379"
+	}
+	c.Storage.Local.BasePath = containerStoragePath
+	c.Storage.Local.TempPath = containerTempPath
+	c.Logging.OutputPath = ""
+	c.FFmpeg.ExecutablePath = "ffmpeg"
+
+	data, err := yaml.Marshal(&c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	d := &deployment{opts: opts, cfg: c}
+	stripSecrets(&doc, "", &d.secrets)
+	sort.Strings(d.secrets)
+
+	if d.config, err = yaml.Marshal(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return d, nil
+}
+
+// stripSecrets empties the non-empty secret values below node and records
+// the environment variables they are read from instead
+func stripSecrets(node *yaml.Node, path string, names *[]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			stripSecrets(child, path, names)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := key.Value
+			if path != "" {
+				fieldPath = path + "." + key.Value
+			}
+			if value.Kind == yaml.ScalarNode && value.Value != "" && sensitiveKey(key.Value) {
+				value.Value = ""
+				value.Tag = "!!str"
+				value.Style = 0
+				*names = append(*names, EnvName(fieldPath))
+				continue
+			}
+			stripSecrets(value, fieldPath, names)
+		}
+	}
+}
+
+// prometheusConfig returns a scrape configuration for the Flixsrota metrics
+// endpoint at host
+func (d *deployment) prometheusConfig(host string) string {
+	return fmt.Sprintf(`global:
+  scrape_interval: 15s
+scrape_configs:
+  - job_name: flixsrota
+    metrics_path: %s
+    static_configs:
+      - targets: ["%s:%d"]
+`, d.cfg.Metrics.Path, host, d.cfg.Metrics.Port)
+}
+
+// grafanaDatasource provisions Prometheus as the default Grafana datasource
+const grafanaDatasource = `apiVersion: 1
+datasources:
+  - name: Prometheus
+    type: prometheus
+    access: proxy
+    url: http://prometheus:9090
+    isDefault: true
+`
+
+// GenerateCompose returns a docker-compose.yml running Flixsrota with the
+// services its configuration needs: Redis for the redis queue, and
+// optionally Prometheus scraping the metrics endpoint and Grafana reading
+// from Prometheus. Secrets are passed as environment variables taken from
+// the shell or a .env file next to the compose file.
+func GenerateCompose(cfg *Config, opts DeployOptions) ([]byte, error) {
+	d, err := newDeployment(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := make(map[string]string, len(d.secrets))
+	for _, name := range d.secrets {
+		environment[name] = "${" + name + ":?set " + name + "}"
+	}
+
+	ports := []string{fmt.Sprintf("%d:%d", d.cfg.GRPC.Port, d.cfg.GRPC.Port)}
+	if d.cfg.Metrics.Enabled {
+		ports = append(ports, fmt.Sprintf("%d:%d", d.cfg.Metrics.Port, d.cfg.Metrics.Port))
+	}
+
+	flixsrota := map[string]interface{}{
+		"image":   d.opts.Image,
+		"command": []string{"serve", "--config", containerConfigPath, "--no-banner"},
+		"restart": "unless-stopped",
+		"ports":   ports,
+		"configs": []map[string]string{{"source": "flixsrota_config", "target": containerConfigPath}},
+	}
+	if len(environment) > 0 {
+		flixsrota["environment"] = environment
+	}
+
+	services := map[string]interface{}{"flixsrota": flixsrota}
+	volumes := map[string]interface{}{}
+	configs := map[string]interface{}{
+		"flixsrota_config": map[string]string{"content": string(d.config)},
+	}
+
+	if d.cfg.Storage.Adapter == "local" {
+		flixsrota["volumes"] = []string{
+			"flixsrota-storage:" + containerStoragePath,
+			"flixsrota-temp:" + containerTempPath,
+		}
+		volumes["flixsrota-storage"] = map[string]interface{}{}
+		volumes["flixsrota-temp"] = map[string]interface{}{}
+	}
+
+	if d.cfg.Queue.Adapter == "redis" {
+		redis := map[string]interface{}{
+			"image":   "redis:7-alpine",
+			"restart": "unless-stopped",
+			"volumes": []string{"redis-data:/data"},
+			"healthcheck": map[string]interface{}{
+				"test":     []string{"CMD", "redis-cli", "ping"},
+				"interval": "10s",
+				"timeout":  "5s",
+				"retries":  5,
+			},
+		}
+		password := EnvName("queue.redis.password")
+		if _, ok := environment[password]; ok {
+			redis["command"] = []string{"redis-server", "--requirepass", "${" + password + "}"}
+			redis["healthcheck"].(map[string]interface{})["test"] = []string{"CMD-SHELL", "redis-cli -a \"$$REDIS_PASSWORD\" ping"}
+			redis["environment"] = map[string]string{"REDIS_PASSWORD": "${" + password + "}"}
+		}
+		services["redis"] = redis
+		volumes["redis-data"] = map[string]interface{}{}
+		flixsrota["depends_on"] = map[string]interface{}{
+			"redis": map[string]string{"condition": "service_healthy"},
+		}
+	}
+
+	if d.opts.WithPrometheus || d.opts.WithGrafana {
+		services["prometheus"] = map[string]interface{}{
+			"image":      "prom/prometheus:latest",
+			"restart":    "unless-stopped",
+			"ports":      []string{"9091:9090"},
+			"configs":    []map[string]string{{"source": "prometheus_config", "target": "/etc/prometheus/prometheus.yml"}},
+			"volumes":    []string{"prometheus-data:/prometheus"},
+			"depends_on": []string{"flixsrota"},
+		}
+		configs["prometheus_config"] = map[string]string{"content": d.prometheusConfig("flixsrota")}
+		volumes["prometheus-data"] = map[string]interface{}{}
+	}
+
+	if d.opts.WithGrafana {
+		services["grafana"] = map[string]interface{}{
+			"image":   "grafana/grafana:latest",
+			"restart": "unless-stopped",
+			"ports":   []string{"3000:3000"},
+			"environment": map[string]string{
+				"GF_SECURITY_ADMIN_PASSWORD": "${GRAFANA_ADMIN_PASSWORD:-admin}",
+			},
+			"configs":    []map[string]string{{"source": "grafana_datasource", "target": "/etc/grafana/provisioning/datasources/prometheus.yaml"}},
+			"volumes":    []string{"grafana-data:/var/lib/grafana"},
+			"depends_on": []string{"prometheus"},
+		}
+		configs["grafana_datasource"] = map[string]string{"content": grafanaDatasource}
+		volumes["grafana-data"] = map[string]interface{}{}
+	}
+
+	compose := map[string]interface{}{
+		"services": services,
+		"configs":  configs,
+	}
+	if len(volumes) > 0 {
+		compose["volumes"] = volumes
+	}
+
+	out, err := yaml.Marshal(compose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal compose file: %w", err)
+	}
+	return out, nil
+}
+
+// GenerateK8sManifests returns the Kubernetes equivalent of GenerateCompose:
+// a ConfigMap with the configuration, a Deployment and Service for
+// Flixsrota, a PersistentVolumeClaim for local storage and a Redis
+// Deployment and Service for the redis queue. Secrets are read from the
+// Secret named in the options. With Prometheus enabled the pods carry the
+// prometheus.io scrape annotations instead of a Prometheus deployment.
+func GenerateK8sManifests(cfg *Config, opts DeployOptions) ([]byte, error) {
+	d, err := newDeployment(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	secretName := d.opts.SecretName
+	if secretName == "" {
+		secretName = "flixsrota-config"
+	}
+
+	labels := map[string]string{"app": "flixsrota"}
+	var env []map[string]interface{}
+	for _, name := range d.secrets {
+		env = append(env, map[string]interface{}{
+			"name": name,
+			"valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]string{"name": secretName, "key": name},
+			},
+		})
+	}
+
+	containerPorts := []map[string]interface{}{{"name": "grpc", "containerPort": d.cfg.GRPC.Port}}
+	servicePorts := []map[string]interface{}{{"name": "grpc", "port": d.cfg.GRPC.Port, "targetPort": "grpc"}}
+	if d.cfg.Metrics.Enabled {
+		containerPorts = append(containerPorts, map[string]interface{}{"name": "metrics", "containerPort": d.cfg.Metrics.Port})
+		servicePorts = append(servicePorts, map[string]interface{}{"name": "metrics", "port": d.cfg.Metrics.Port, "targetPort": "metrics"})
+	}
+
+	volumeMounts := []map[string]interface{}{{"name": "config", "mountPath": containerConfigPath, "subPath": "config.yaml", "readOnly": true}}
+	volumes := []map[string]interface{}{{"name": "config", "configMap": map[string]string{"name": "flixsrota-config"}}}
+	if d.cfg.Storage.Adapter == "local" {
+		volumeMounts = append(volumeMounts,
+			map[string]interface{}{"name": "storage", "mountPath": containerStoragePath},
+			map[string]interface{}{"name": "temp", "mountPath": containerTempPath})
+		volumes = append(volumes,
+			map[string]interface{}{"name": "storage", "persistentVolumeClaim": map[string]string{"claimName": "flixsrota-storage"}},
+			map[string]interface{}{"name": "temp", "emptyDir": map[string]string{}})
+	}
+
+	container := map[string]interface{}{
+		"name":         "flixsrota",
+		"image":        d.opts.Image,
+		"args":         []string{"serve", "--config", containerConfigPath, "--no-banner"},
+		"ports":        containerPorts,
+		"volumeMounts": volumeMounts,
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+
+	podMetadata := map[string]interface{}{"labels": labels}
+	if d.opts.WithPrometheus && d.cfg.Metrics.Enabled {
+		podMetadata["annotations"] = map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   strconv.Itoa(d.cfg.Metrics.Port),
+			"prometheus.io/path":   d.cfg.Metrics.Path,
+		}
+	}
+
+	manifests := []interface{}{
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]string{"name": "flixsrota-config"},
+			"data":       map[string]string{"config.yaml": string(d.config)},
+		},
+		map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "flixsrota", "labels": labels},
+			"spec": map[string]interface{}{
+				"replicas": 1,
+				"selector": map[string]interface{}{"matchLabels": labels},
+				"template": map[string]interface{}{
+					"metadata": podMetadata,
+					"spec": map[string]interface{}{
+						"containers": []interface{}{container},
+						"volumes":    volumes,
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "flixsrota", "labels": labels},
+			"spec":       map[string]interface{}{"selector": labels, "ports": servicePorts},
+		},
+	}
+
+	if d.cfg.Storage.Adapter == "local" {
+		manifests = append(manifests, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]string{"name": "flixsrota-storage"},
+			"spec": map[string]interface{}{
+				"accessModes": []string{"ReadWriteOnce"},
+				"resources":   map[string]interface{}{"requests": map[string]string{"storage": "50Gi"}},
+			},
+		})
+	}
+
+	if d.cfg.Queue.Adapter == "redis" {
+		manifests = append(manifests, redisManifests(d.secrets, secretName)...)
+	}
+
+	var buf bytes.Buffer
+	for i, manifest := range manifests {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		out, err := yaml.Marshal(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		buf.Write(out)
+	}
+	return buf.Bytes(), nil
+}
+
+// redisManifests returns a single-replica Redis Deployment and Service named
+// redis, protected by the queue password when one is configured
+func redisManifests(secrets []string, secretName string) []interface{} {
+	labels := map[string]string{"app": "flixsrota-redis"}
+	container := map[string]interface{}{
+		"name":  "redis",
+		"image": "redis:7-alpine",
+		"ports": []map[string]interface{}{{"name": "redis", "containerPort": 6379}},
+	}
+
+	password := EnvName("queue.redis.password")
+	for _, name := range secrets {
+		if name != password {
+			continue
+		}
+		container["env"] = []map[string]interface{}{{
+			"name": "REDIS_PASSWORD",
+			"valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]string{"name": secretName, "key": password},
+			},
+		}}
+		container["args"] = []string{"--requirepass", "$(REDIS_PASSWORD)"}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "redis", "labels": labels},
+			"spec": map[string]interface{}{
+				"replicas": 1,
+				"selector": map[string]interface{}{"matchLabels": labels},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": labels},
+					"spec":     map[string]interface{}{"containers": []interface{}{container}},
+				},
+			},
+		},
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "redis", "labels": labels},
+			"spec": map[string]interface{}{
+				"selector": labels,
+				"ports":    []map[string]interface{}{{"name": "redis", "port": 6379, "targetPort": "redis"}},
+			},
+		},
+	}
+}
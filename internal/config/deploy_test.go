@@ -0,0 +1,156 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeFile is the part of a generated docker-compose.yml the tests read
+type composeFile struct {
+	Services map[string]struct {
+		Image       string            `yaml:"image"`
+		Command     []string          `yaml:"command"`
+		Environment map[string]string `yaml:"environment"`
+		Volumes     []string          `yaml:"volumes"`
+		DependsOn   interface{}       `yaml:"depends_on"`
+	} `yaml:"services"`
+	Configs map[string]struct {
+		Content string `yaml:"content"`
+	} `yaml:"configs"`
+	Volumes map[string]interface{} `yaml:"volumes"`
+}
+
+func TestGenerateCompose(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Queue.Adapter = "redis"
+	cfg.Queue.Redis.Password = "hunter2"
+	cfg.Storage.Adapter = "local"
+
+	out, err := GenerateCompose(cfg, DeployOptions{WithGrafana: true})
+	if err != nil {
+		t.Fatalf("GenerateCompose() error = %v", err)
+	}
+	if strings.Contains(string(out), "hunter2") {
+		t.Errorf("compose file contains the Redis password")
+	}
+	var compose composeFile
+	if err := yaml.Unmarshal(out, &compose); err != nil {
+		t.Fatalf("compose file is not YAML: %v", err)
+	}
+
+	for _, name := range []string{"flixsrota", "redis", "prometheus", "grafana"} {
+		if _, ok := compose.Services[name]; !ok {
+			t.Errorf("service %s is missing", name)
+		}
+	}
+	flixsrota := compose.Services["flixsrota"]
+	if flixsrota.Image != DefaultDeployImage {
+		t.Errorf("image = %s, want %s", flixsrota.Image, DefaultDeployImage)
+	}
+	password := EnvName("queue.redis.password")
+	if !strings.HasPrefix(flixsrota.Environment[password], "${"+password) {
+		t.Errorf("environment = %v, want the Redis password injected from %s", flixsrota.Environment, password)
+	}
+	if _, ok := compose.Volumes["flixsrota-storage"]; !ok || len(flixsrota.Volumes) != 2 {
+		t.Errorf("volumes = %v, %v; want the storage and temp volumes", flixsrota.Volumes, compose.Volumes)
+	}
+	if redis := compose.Services["redis"]; len(redis.Command) < 2 || redis.Command[1] != "--requirepass" {
+		t.Errorf("redis command = %v, want it to require the password", redis.Command)
+	}
+
+	var embedded Config
+	if err := yaml.Unmarshal([]byte(compose.Configs["flixsrota_config"].Content), &embedded); err != nil {
+		t.Fatalf("embedded config is not YAML: %v", err)
+	}
+	if embedded.Queue.Redis.Address != "redis:6379" || embedded.Storage.Local.BasePath != containerStoragePath {
+		t.Errorf("embedded config uses %s and %s, want the container addresses", embedded.Queue.Redis.Address, embedded.Storage.Local.BasePath)
+	}
+	if embedded.Queue.Redis.Password != "" {
+		t.Errorf("embedded config has a Redis password")
+	}
+	if !strings.Contains(compose.Configs["prometheus_config"].Content, "flixsrota:") {
+		t.Errorf("prometheus config does not scrape flixsrota:\n%s", compose.Configs["prometheus_config"].Content)
+	}
+}
+
+func TestGenerateComposeWithoutRedis(t *testing.T) {
+	cfg := DefaultConfig()
+	out, err := GenerateCompose(cfg, DeployOptions{Queue: "memory", Storage: "s3", Image: "example/flixsrota:1.0"})
+	if err != nil {
+		t.Fatalf("GenerateCompose() error = %v", err)
+	}
+	var compose composeFile
+	if err := yaml.Unmarshal(out, &compose); err != nil {
+		t.Fatalf("compose file is not YAML: %v", err)
+	}
+	if len(compose.Services) != 1 || compose.Services["flixsrota"].Image != "example/flixsrota:1.0" {
+		t.Errorf("services = %v, want only flixsrota with the given image", compose.Services)
+	}
+	if len(compose.Volumes) != 0 {
+		t.Errorf("volumes = %v, want none for S3 storage", compose.Volumes)
+	}
+}
+
+func TestGenerateDeploymentUnsupportedAdapter(t *testing.T) {
+	cfg := DefaultConfig()
+	if _, err := GenerateCompose(cfg, DeployOptions{Queue: "nats"}); err == nil || !strings.Contains(err.Error(), "unsupported queue adapter") {
+		t.Errorf("GenerateCompose() error = %v, want an unsupported queue adapter", err)
+	}
+	if _, err := GenerateK8sManifests(cfg, DeployOptions{Storage: "ftp"}); err == nil || !strings.Contains(err.Error(), "unsupported storage adapter") {
+		t.Errorf("GenerateK8sManifests() error = %v, want an unsupported storage adapter", err)
+	}
+}
+
+func TestGenerateK8sManifests(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Queue.Adapter = "redis"
+	cfg.Queue.Redis.Password = "hunter2"
+	cfg.Storage.Adapter = "local"
+	cfg.Metrics.Enabled = true
+
+	out, err := GenerateK8sManifests(cfg, DeployOptions{WithPrometheus: true, SecretName: "flixsrota-secrets"})
+	if err != nil {
+		t.Fatalf("GenerateK8sManifests() error = %v", err)
+	}
+	if strings.Contains(string(out), "hunter2") {
+		t.Errorf("manifests contain the Redis password")
+	}
+
+	var kinds []string
+	manifests := map[string]map[string]interface{}{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(out)))
+	for {
+		var manifest map[string]interface{}
+		if err := decoder.Decode(&manifest); err != nil {
+			break
+		}
+		kind := manifest["kind"].(string)
+		name := manifest["metadata"].(map[string]interface{})["name"].(string)
+		kinds = append(kinds, kind+"/"+name)
+		manifests[kind+"/"+name] = manifest
+	}
+	want := "ConfigMap/flixsrota-config Deployment/flixsrota Service/flixsrota PersistentVolumeClaim/flixsrota-storage Deployment/redis Service/redis"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("manifests = %s, want %s", got, want)
+	}
+
+	template := manifests["Deployment/flixsrota"]["spec"].(map[string]interface{})["template"].(map[string]interface{})
+	annotations, _ := template["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if annotations["prometheus.io/scrape"] != "true" {
+		t.Errorf("pod annotations = %v, want the Prometheus scrape annotations", annotations)
+	}
+
+	container := template["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	env := container["env"].([]interface{})[0].(map[string]interface{})
+	ref := env["valueFrom"].(map[string]interface{})["secretKeyRef"].(map[string]interface{})
+	if env["name"] != EnvName("queue.redis.password") || ref["name"] != "flixsrota-secrets" {
+		t.Errorf("env = %v, want the Redis password from the flixsrota-secrets Secret", env)
+	}
+
+	config := manifests["ConfigMap/flixsrota-config"]["data"].(map[string]interface{})["config.yaml"].(string)
+	if !strings.Contains(config, "redis:6379") {
+		t.Errorf("ConfigMap does not point the queue at the redis service:\n%s", config)
+	}
+}
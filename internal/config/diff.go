@@ -0,0 +1,71 @@
+package config
+
+import "reflect"
+
+// FieldDiff is a config field whose value differs between two configs
+type FieldDiff struct {
+	// Path is the field path, e.g. "grpc.port"
+	Path string
+	Old  interface{}
+	New  interface{}
+	// Sensitive is set for credentials, whose values must not be printed
+	Sensitive bool
+}
+
+// Diff returns the fields that differ between two configs, in the order
+// they are declared. Maps and slices are compared as a whole.
+func Diff(old, updated *Config) []FieldDiff {
+	return diffFields(reflect.ValueOf(*old), reflect.ValueOf(*updated), "")
+}
+
+// diffFields compares two struct values field by field
+func diffFields(old, updated reflect.Value, prefix string) []FieldDiff {
+	var diffs []FieldDiff
+
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			diffs = append(diffs, diffFields(old.Field(i), updated.Field(i), path)...)
+		} else if !equalValues(old.Field(i), updated.Field(i)) {
+			diffs = append(diffs, FieldDiff{
+				Path:      path,
+				Old:       old.Field(i).Interface(),
+				New:       updated.Field(i).Interface(),
+				Sensitive: sensitiveKey(name),
+			})
+		}
+	}
+
+	return diffs
+}
+
+// equalValues compares two field values, treating nil and empty maps and
+// slices as equal, as a config loaded from YAML has empty lists where the
+// defaults have none
+func equalValues(old, updated reflect.Value) bool {
+	switch old.Kind() {
+	case reflect.Map, reflect.Slice:
+		if old.Len() == 0 && updated.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(old.Interface(), updated.Interface())
+}
+
+// DisplayValue returns a field value for printing, with credentials replaced
+// by RedactedValue. Unset credentials are shown empty.
+func (d FieldDiff) DisplayValue(value interface{}) interface{} {
+	if d.Sensitive && value != "" {
+		return RedactedValue
+	}
+	return value
+}
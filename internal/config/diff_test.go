@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := DefaultConfig()
+	updated := DefaultConfig()
+	updated.GRPC.Port = 6000
+	updated.Queue.Redis.Password = "hunter2"
+	updated.Storage.Local.BasePath = "/srv/media"
+	updated.FFmpeg.Qualities = map[string]bool{"720p": true}
+
+	diffs := Diff(old, updated)
+	var paths []string
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+	// Fields are reported in declaration order
+	want := "grpc.port queue.redis.password storage.local.base_path ffmpeg.qualities"
+	if got := strings.Join(paths, " "); got != want {
+		t.Fatalf("Diff() paths = %s, want %s", got, want)
+	}
+	if got := strings.Join(ChangedFields(old, updated), " "); got != want {
+		t.Errorf("ChangedFields() = %s, want %s", got, want)
+	}
+
+	if diffs[0].Old != old.GRPC.Port || diffs[0].New != 6000 || diffs[0].Sensitive {
+		t.Errorf("grpc.port diff = %+v", diffs[0])
+	}
+	password := diffs[1]
+	if !password.Sensitive || password.DisplayValue(password.New) != RedactedValue || password.DisplayValue(password.Old) != "" {
+		t.Errorf("password diff = %+v, want a sensitive field shown redacted and empty when unset", password)
+	}
+
+	if diffs := Diff(old, DefaultConfig()); len(diffs) != 0 {
+		t.Errorf("Diff() of equal configs = %+v", diffs)
+	}
+}
+
+func TestDiffRedactedSnapshot(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	cfg := DefaultConfig()
+	cfg.Queue.Redis.Password = "hunter2"
+	cfg.GRPC.Port = 6000
+
+	// A snapshot loads back equal to the config, apart from the redacted
+	// secret, which the drift command ignores while the current value is set
+	data, err := RedactedYAML(cfg)
+	if err != nil {
+		t.Fatalf("RedactedYAML() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of the snapshot error = %v", err)
+	}
+
+	diffs := Diff(baseline, cfg)
+	if len(diffs) != 1 || diffs[0].Path != "queue.redis.password" || diffs[0].Old != RedactedValue || !diffs[0].Sensitive {
+		t.Errorf("Diff() from the snapshot = %+v, want only the redacted password", diffs)
+	}
+}
+
+func TestDiffEmptyLists(t *testing.T) {
+	old := DefaultConfig()
+	updated := DefaultConfig()
+	old.Queue.Kafka.Brokers = nil
+	updated.Queue.Kafka.Brokers = []string{}
+	if diffs := Diff(old, updated); len(diffs) != 0 {
+		t.Errorf("Diff() of nil and empty lists = %+v, want none", diffs)
+	}
+
+	updated.Queue.Kafka.Brokers = []string{"kafka:9092"}
+	if diffs := Diff(old, updated); len(diffs) != 1 || diffs[0].Path != "queue.kafka.brokers" {
+		t.Errorf("Diff() = %+v, want the added broker", diffs)
+	}
+}
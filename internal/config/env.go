@@ -0,0 +1,149 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables read by Load
+const EnvPrefix = "FLIXSROTA"
+
+// Environment export formats accepted by FormatEnv
+const (
+	EnvFormatDotenv    = "dotenv"
+	EnvFormatShell     = "shell"
+	EnvFormatK8sSecret = "k8s-secret"
+)
+
+// EnvName returns the environment variable Load reads a field from, e.g.
+// grpc.port -> FLIXSROTA_GRPC_PORT
+func EnvName(fieldPath string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(fieldPath, ".", "_"))
+}
+
+// ToEnv returns every config field as an environment variable that Load
+// reads back. Lists are comma separated. Maps, such as ffmpeg.qualities, and
+// lists of structs, such as multi_queue, cannot be set from the environment
+// and are left out.
+func (c *Config) ToEnv() map[string]string {
+	env := make(map[string]string)
+	for fieldPath, value := range c.envValues() {
+		env[EnvName(fieldPath)] = value
+	}
+	return env
+}
+
+// envValues returns the config fields that can be set from the environment,
+// keyed by field path
+func (c *Config) envValues() map[string]string {
+	values := make(map[string]string)
+	envFields(reflect.ValueOf(*c), "", values)
+	return values
+}
+
+// bindEnv registers every field that can be set from the environment with
+// v. AutomaticEnv only looks up keys viper already knows, which would
+// otherwise miss fields without a default.
+func bindEnv(v *viper.Viper, cfg *Config) {
+	for fieldPath := range cfg.envValues() {
+		v.BindEnv(fieldPath)
+	}
+}
+
+// envFields adds the mapstructure-tagged fields of a struct value to values
+func envFields(v reflect.Value, prefix string, values map[string]string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		envValue(v.Field(i), name, values)
+	}
+}
+
+// envValue adds a field value to values under its field path
+func envValue(v reflect.Value, name string, values map[string]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		envFields(v, name, values)
+	case reflect.Map:
+		return
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			return
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		values[name] = strings.Join(items, ",")
+	default:
+		values[name] = fmt.Sprint(v.Interface())
+	}
+}
+
+// FormatEnv renders environment variables, sorted by name, as a .env file,
+// shell export statements or a Kubernetes Secret manifest named secretName
+func FormatEnv(env map[string]string, format, secretName string) ([]byte, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	switch format {
+	case EnvFormatDotenv:
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s=%s\n", name, dotenvQuote(env[name]))
+		}
+	case EnvFormatShell:
+		for _, name := range names {
+			fmt.Fprintf(&buf, "export %s=%s\n", name, shellQuote(env[name]))
+		}
+	case EnvFormatK8sSecret:
+		data := make(map[string]string, len(env))
+		for name, value := range env {
+			data[name] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		secret := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]string{"name": secretName},
+			"type":       "Opaque",
+			"data":       data,
+		}
+		out, err := yaml.Marshal(secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal secret: %w", err)
+		}
+		buf.Write(out)
+	default:
+		return nil, fmt.Errorf("unknown env format %q (supported: %s, %s, %s)", format, EnvFormatDotenv, EnvFormatShell, EnvFormatK8sSecret)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// dotenvQuote double-quotes a value for a .env file, escaping the characters
+// dotenv parsers interpret inside double quotes
+func dotenvQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `$`, `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// shellQuote single-quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
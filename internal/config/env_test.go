@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		fieldPath string
+		want      string
+	}{
+		{fieldPath: "grpc.port", want: "FLIXSROTA_GRPC_PORT"},
+		{fieldPath: "queue.kafka.brokers", want: "FLIXSROTA_QUEUE_KAFKA_BROKERS"},
+		{fieldPath: "storage.local.base_path", want: "FLIXSROTA_STORAGE_LOCAL_BASE_PATH"},
+	}
+
+	for _, tt := range tests {
+		if got := EnvName(tt.fieldPath); got != tt.want {
+			t.Errorf("EnvName(%q) = %q, want %q", tt.fieldPath, got, tt.want)
+		}
+	}
+}
+
+func TestConfigToEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GRPC.Port = 9999
+	cfg.Queue.Kafka.Brokers = []string{"kafka-1:9092", "kafka-2:9092"}
+
+	env := cfg.ToEnv()
+	if got := env["FLIXSROTA_GRPC_PORT"]; got != "9999" {
+		t.Errorf("FLIXSROTA_GRPC_PORT = %q, want 9999", got)
+	}
+	if got := env["FLIXSROTA_QUEUE_KAFKA_BROKERS"]; got != "kafka-1:9092,kafka-2:9092" {
+		t.Errorf("FLIXSROTA_QUEUE_KAFKA_BROKERS = %q, want a comma separated list", got)
+	}
+	if _, ok := env["FLIXSROTA_FFMPEG_QUALITIES"]; ok {
+		t.Errorf("ToEnv() exported the ffmpeg.qualities map")
+	}
+}
+
+func TestConfigToEnvRoundTrip(t *testing.T) {
+	want := DefaultConfig()
+	want.GRPC.Port = 9999
+	want.Queue.Kafka.Brokers = []string{"kafka-1:9092", "kafka-2:9092"}
+	want.Queue.Redis.Password = `p'a"ss$word`
+
+	for name, value := range want.ToEnv() {
+		t.Setenv(name, value)
+	}
+
+	path := filepath.Join(t.TempDir(), "flixsrota.yaml")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	gotEnv, wantEnv := got.ToEnv(), want.ToEnv()
+	for name, value := range wantEnv {
+		if gotEnv[name] != value {
+			t.Errorf("%s = %q after Load(), want %q", name, gotEnv[name], value)
+		}
+	}
+}
+
+func TestFormatEnv(t *testing.T) {
+	env := map[string]string{
+		"FLIXSROTA_GRPC_PORT":            "50051",
+		"FLIXSROTA_QUEUE_REDIS_PASSWORD": `it's "$secret"`,
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: EnvFormatDotenv,
+			want:   "FLIXSROTA_GRPC_PORT=\"50051\"\nFLIXSROTA_QUEUE_REDIS_PASSWORD=\"it's \\\"\\$secret\\\"\"\n",
+		},
+		{
+			format: EnvFormatShell,
+			want:   "export FLIXSROTA_GRPC_PORT='50051'\nexport FLIXSROTA_QUEUE_REDIS_PASSWORD='it'\\''s \"$secret\"'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := FormatEnv(env, tt.format, "")
+			if err != nil {
+				t.Fatalf("FormatEnv() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("FormatEnv() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	t.Run(EnvFormatK8sSecret, func(t *testing.T) {
+		out, err := FormatEnv(env, EnvFormatK8sSecret, "flixsrota-config")
+		if err != nil {
+			t.Fatalf("FormatEnv() error = %v", err)
+		}
+		var secret struct {
+			Kind     string            `yaml:"kind"`
+			Metadata map[string]string `yaml:"metadata"`
+			Data     map[string]string `yaml:"data"`
+		}
+		if err := yaml.Unmarshal(out, &secret); err != nil {
+			t.Fatalf("yaml.Unmarshal() error = %v", err)
+		}
+		if secret.Kind != "Secret" || secret.Metadata["name"] != "flixsrota-config" {
+			t.Errorf("secret = %+v, want a Secret named flixsrota-config", secret)
+		}
+		if got := secret.Data["FLIXSROTA_GRPC_PORT"]; got != "NTAwNTE=" {
+			t.Errorf("data[FLIXSROTA_GRPC_PORT] = %q, want base64 of 50051", got)
+		}
+	})
+
+	if _, err := FormatEnv(env, "xml", ""); err == nil || !strings.Contains(err.Error(), "unknown env format") {
+		t.Errorf("FormatEnv(xml) error = %v, want an unknown format error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LintSeverity is how serious a lint finding is
+type LintSeverity string
+
+const (
+	// LintSeverityWarning marks settings that are likely wrong but may work
+	LintSeverityWarning LintSeverity = "warning"
+	// LintSeverityError marks settings that cannot work
+	LintSeverityError LintSeverity = "error"
+)
+
+// LintWarning is a likely mistake found in a configuration
+type LintWarning struct {
+	Field    string
+	Message  string
+	Severity LintSeverity
+}
+
+var (
+	// s3BucketPattern follows the AWS bucket naming rules
+	s3BucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	// gcsProjectIDPattern follows the Google Cloud project ID rules
+	gcsProjectIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+)
+
+// Lint checks a configuration for common mistakes that Validate does not
+// catch, such as malformed addresses, invalid bucket names and ports that are
+// already in use on this machine
+func Lint(cfg *Config) []LintWarning {
+	var warnings []LintWarning
+
+	if cfg.Queue.Adapter == "redis" {
+		warnings = append(warnings, lintHostPort("queue.redis.address", cfg.Queue.Redis.Address)...)
+	}
+	for i, q := range cfg.MultiQueue {
+		if q.Adapter == "redis" {
+			warnings = append(warnings, lintHostPort(fmt.Sprintf("multi_queue[%d].redis.address", i), q.Redis.Address)...)
+		}
+	}
+
+	switch cfg.Storage.Adapter {
+	case "s3":
+		warnings = append(warnings, lintS3Bucket("storage.s3.bucket", cfg.Storage.S3.Bucket)...)
+	case "gcs":
+		if !gcsProjectIDPattern.MatchString(cfg.Storage.GCS.ProjectID) {
+			warnings = append(warnings, LintWarning{
+				Field:    "storage.gcs.project_id",
+				Message:  fmt.Sprintf("%q is not a valid project ID: use 6-30 lowercase letters, digits and hyphens, starting with a letter", cfg.Storage.GCS.ProjectID),
+				Severity: LintSeverityError,
+			})
+		}
+	}
+
+	warnings = append(warnings, lintPortFree("grpc.port", cfg.GRPC.Address, cfg.GRPC.Port)...)
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Port == cfg.GRPC.Port {
+			warnings = append(warnings, LintWarning{
+				Field:    "metrics.port",
+				Message:  fmt.Sprintf("port %d is also used by the gRPC server", cfg.Metrics.Port),
+				Severity: LintSeverityError,
+			})
+		} else {
+			warnings = append(warnings, lintPortFree("metrics.port", "", cfg.Metrics.Port)...)
+		}
+	}
+
+	return warnings
+}
+
+// HasLintErrors reports whether any finding is an error
+func HasLintErrors(warnings []LintWarning) bool {
+	for _, w := range warnings {
+		if w.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintHostPort checks that an address has the host:port form
+func lintHostPort(field, address string) []LintWarning {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return []LintWarning{{Field: field, Message: fmt.Sprintf("%q is not in host:port form", address), Severity: LintSeverityError}}
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return []LintWarning{{Field: field, Message: fmt.Sprintf("%q has an invalid port", address), Severity: LintSeverityError}}
+	}
+	if host == "" {
+		return []LintWarning{{Field: field, Message: fmt.Sprintf("%q has no host", address), Severity: LintSeverityWarning}}
+	}
+	return nil
+}
+
+// lintS3Bucket checks a bucket name against the AWS naming rules
+func lintS3Bucket(field, bucket string) []LintWarning {
+	invalid := func(reason string) []LintWarning {
+		return []LintWarning{{Field: field, Message: fmt.Sprintf("%q is not a valid bucket name: %s", bucket, reason), Severity: LintSeverityError}}
+	}
+
+	switch {
+	case bucket == "":
+		return []LintWarning{{Field: field, Message: "bucket name is required", Severity: LintSeverityError}}
+	case strings.ContainsAny(bucket, " \t"):
+		return invalid("spaces are not allowed")
+	case strings.ToLower(bucket) != bucket:
+		return invalid("uppercase letters are not allowed")
+	case !s3BucketPattern.MatchString(bucket):
+		return invalid("use 3-63 lowercase letters, digits, dots and hyphens, starting and ending with a letter or digit")
+	case strings.Contains(bucket, ".."):
+		return invalid("adjacent dots are not allowed")
+	case net.ParseIP(bucket) != nil:
+		return invalid("IP addresses are not allowed")
+	}
+	return nil
+}
+
+// lintPortFree warns when a port cannot be listened on, usually because
+// another process, such as a running server, already uses it
+func lintPortFree(field, host string, port int) []LintWarning {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return []LintWarning{{Field: field, Message: fmt.Sprintf("port %d is not available: %v", port, err), Severity: LintSeverityWarning}}
+	}
+	listener.Close()
+	return nil
+}
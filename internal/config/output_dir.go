@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// OutputManifestName is the file name of the manifest written to the output
+// directory of every completed job
+const OutputManifestName = "manifest.json"
+
+// OutputDirectoryValues are the fields available to the output directory template
+type OutputDirectoryValues struct {
+	JobID    string
+	TenantID string
+	Year     string
+	Month    string
+	Day      string
+	Metadata map[string]string
+}
+
+// JobOutputDirectoryValues returns the template fields of a job created at created
+func JobOutputDirectoryValues(jobID, tenantID string, created time.Time, metadata map[string]string) OutputDirectoryValues {
+	return OutputDirectoryValues{
+		JobID:    jobID,
+		TenantID: tenantID,
+		Year:     created.Format("2006"),
+		Month:    created.Format("01"),
+		Day:      created.Format("02"),
+		Metadata: metadata,
+	}
+}
+
+// ParseOutputDirectoryTemplate parses an output directory template such as
+// {{.TenantID}}/{{.Year}}/{{.Month}}/{{.JobID}} and checks that it expands
+// to a path inside the output directory
+func ParseOutputDirectoryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output_directory").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output directory template: %w", err)
+	}
+
+	sample := OutputDirectoryValues{
+		JobID:    "job",
+		TenantID: "tenant",
+		Year:     "2006",
+		Month:    "01",
+		Day:      "02",
+		Metadata: map[string]string{},
+	}
+	if _, err := ExpandOutputDirectory(tmpl, sample); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// ExpandOutputDirectory expands an output directory template. Values are
+// sanitized so they cannot add path segments, and the result is rejected
+// if it escapes the output directory.
+func ExpandOutputDirectory(tmpl *template.Template, values OutputDirectoryValues) (string, error) {
+	safe := OutputDirectoryValues{
+		JobID:    pathSegment(values.JobID),
+		TenantID: pathSegment(values.TenantID),
+		Year:     pathSegment(values.Year),
+		Month:    pathSegment(values.Month),
+		Day:      pathSegment(values.Day),
+		Metadata: make(map[string]string, len(values.Metadata)),
+	}
+	for k, v := range values.Metadata {
+		safe.Metadata[k] = pathSegment(v)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, safe); err != nil {
+		return "", fmt.Errorf("failed to expand output directory template: %w", err)
+	}
+
+	if strings.Contains(b.String(), `\`) {
+		return "", fmt.Errorf("output directory %q must use forward slashes", b.String())
+	}
+
+	// Empty segments, e.g. from missing metadata, would make the path
+	// absolute or collapse levels, so they are replaced like empty values
+	segments := strings.Split(b.String(), "/")
+	for i, segment := range segments {
+		if segment == "" {
+			segments[i] = "_"
+		}
+	}
+
+	dir := path.Clean(strings.Join(segments, "/"))
+	if dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("output directory %q escapes the output path", dir)
+	}
+
+	return dir, nil
+}
+
+// pathSegment makes a template value safe to use as a single, non-empty path segment
+func pathSegment(value string) string {
+	value = strings.NewReplacer("/", "_", `\`, "_").Replace(value)
+	if value == "" || value == "." || value == ".." {
+		return "_"
+	}
+	return value
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandOutputDirectory(t *testing.T) {
+	created := time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		jobID    string
+		tenantID string
+		metadata map[string]string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "tenant and date",
+			template: "{{.TenantID}}/{{.Year}}/{{.Month}}/{{.Day}}/{{.JobID}}",
+			jobID:    "job-1",
+			tenantID: "acme",
+			want:     "acme/2024/03/07/job-1",
+		},
+		{
+			name:     "metadata",
+			template: `{{.TenantID}}/{{index .Metadata "campaign"}}`,
+			tenantID: "acme",
+			metadata: map[string]string{"campaign": "spring"},
+			want:     "acme/spring",
+		},
+		{
+			name:     "missing tenant",
+			template: "{{.TenantID}}/{{.JobID}}",
+			jobID:    "job-1",
+			want:     "_/job-1",
+		},
+		{
+			name:     "missing metadata",
+			template: `{{index .Metadata "campaign"}}/{{.JobID}}`,
+			jobID:    "job-1",
+			want:     "_/job-1",
+		},
+		{
+			name:     "tenant with slashes",
+			template: "{{.TenantID}}/{{.JobID}}",
+			jobID:    "job-1",
+			tenantID: "acme/../../etc",
+			want:     "acme_.._.._etc/job-1",
+		},
+		{
+			name:     "dot-dot tenant",
+			template: "{{.TenantID}}/{{.JobID}}",
+			jobID:    "job-1",
+			tenantID: "..",
+			want:     "_/job-1",
+		},
+		{
+			name:     "dot-dot metadata",
+			template: `{{index .Metadata "campaign"}}`,
+			metadata: map[string]string{"campaign": ".."},
+			want:     "_",
+		},
+		{
+			name:     "template traversal",
+			template: "../{{.JobID}}",
+			jobID:    "job-1",
+			wantErr:  "escapes the output path",
+		},
+		{
+			name:     "nested template traversal",
+			template: "{{.TenantID}}/../../{{.JobID}}",
+			jobID:    "job-1",
+			tenantID: "acme",
+			wantErr:  "escapes the output path",
+		},
+		{
+			name:     "traversal inside the output directory",
+			template: "{{.TenantID}}/../{{.JobID}}",
+			jobID:    "job-1",
+			tenantID: "acme",
+			want:     "job-1",
+		},
+		{
+			name:     "backslashes",
+			template: `{{.TenantID}}\{{.JobID}}`,
+			jobID:    "job-1",
+			tenantID: "acme",
+			wantErr:  "forward slashes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseOutputDirectoryTemplate(tt.template)
+			if err == nil {
+				var dir string
+				dir, err = ExpandOutputDirectory(tmpl, OutputDirectoryValues{
+					JobID:    tt.jobID,
+					TenantID: tt.tenantID,
+					Year:     created.Format("2006"),
+					Month:    created.Format("01"),
+					Day:      created.Format("02"),
+					Metadata: tt.metadata,
+				})
+				if err == nil && dir != tt.want {
+					t.Errorf("ExpandOutputDirectory() = %q, want %q", dir, tt.want)
+				}
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseOutputDirectoryTemplateInvalid(t *testing.T) {
+	for _, text := range []string{"{{.TenantID", "{{.Unknown}}"} {
+		if _, err := ParseOutputDirectoryTemplate(text); err == nil {
+			t.Errorf("ParseOutputDirectoryTemplate(%q) succeeded", text)
+		}
+	}
+}
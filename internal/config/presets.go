@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Output formats a transcode preset can produce
+const (
+	// OutputFormatHLS is adaptive HLS with video and audio renditions
+	OutputFormatHLS = "hls"
+	// OutputFormatAudio is HLS with only the audio renditions
+	OutputFormatAudio = "audio"
+	// OutputFormatFMP4 is one fragmented MP4 per quality with a DASH manifest
+	OutputFormatFMP4 = "fmp4"
+)
+
+// Containers a job can request for each quality, besides OutputFormatHLS
+const (
+	// QualityFormatMP4 writes the quality as a progressive MP4 file
+	QualityFormatMP4 = "mp4"
+	// QualityFormatMKV writes the quality as a Matroska file
+	QualityFormatMKV = "mkv"
+)
+
+// TranscodePreset is a named combination of output settings applied to a job.
+// Empty fields keep the server configuration.
+type TranscodePreset struct {
+	Description        string   `mapstructure:"description" yaml:"description,omitempty" doc:"What the preset is for"`
+	Qualities          []string `mapstructure:"qualities" yaml:"qualities,omitempty" doc:"Output qualities, replacing ffmpeg.qualities"`
+	VideoCodec         string   `mapstructure:"video_codec" yaml:"video_codec,omitempty" doc:"Output video codec" schema:"enum=h264|h265|vp9|av1"`
+	OutputFormat       string   `mapstructure:"output_format" yaml:"output_format,omitempty" doc:"hls for video and audio, audio for audio-only HLS, fmp4 for fragmented MP4 with a DASH manifest" schema:"enum=hls|audio|fmp4"`
+	TwoPass            bool     `mapstructure:"two_pass" yaml:"two_pass,omitempty" doc:"Two-pass video encoding (not supported yet)"`
+	Lossless           bool     `mapstructure:"lossless" yaml:"lossless,omitempty" doc:"Lossless software H.264 or H.265 encoding"`
+	HardwareAccel      string   `mapstructure:"hardware_accel" yaml:"hardware_accel,omitempty" doc:"Hardware encoder, none for software encoding" schema:"enum=none|nvenc|qsv|vaapi|videotoolbox"`
+	AudioNormalization bool     `mapstructure:"audio_normalization" yaml:"audio_normalization,omitempty" doc:"Normalize loudness using ffmpeg.audio_normalization targets"`
+}
+
+// BuiltinPresets are available without configuration. Presets configured
+// under ffmpeg.presets with the same name replace them.
+var BuiltinPresets = map[string]TranscodePreset{
+	"web-hd": {
+		Description:        "H.264 HLS up to 1080p with normalized audio",
+		Qualities:          []string{"480p", "720p", "1080p"},
+		VideoCodec:         "h264",
+		OutputFormat:       OutputFormatHLS,
+		AudioNormalization: true,
+	},
+	"mobile": {
+		Description:  "Small H.264 HLS renditions for mobile networks",
+		Qualities:    []string{"360p", "480p"},
+		VideoCodec:   "h264",
+		OutputFormat: OutputFormatHLS,
+	},
+	"archive-lossless": {
+		Description:   "Lossless H.265 at 1080p for archival",
+		Qualities:     []string{"1080p"},
+		VideoCodec:    "h265",
+		OutputFormat:  OutputFormatHLS,
+		Lossless:      true,
+		HardwareAccel: "none",
+	},
+	"podcast-audio-only": {
+		Description:        "Audio-only HLS with normalized loudness",
+		OutputFormat:       OutputFormatAudio,
+		AudioNormalization: true,
+	},
+}
+
+// Preset returns the configured or built-in preset with the given name
+func (c FFmpegConfig) Preset(name string) (TranscodePreset, bool) {
+	if preset, ok := c.Presets[name]; ok {
+		return preset, true
+	}
+	preset, ok := BuiltinPresets[name]
+	return preset, ok
+}
+
+// PresetNames returns the names of all configured and built-in presets in
+// sorted order
+func (c FFmpegConfig) PresetNames() []string {
+	names := sortedKeys(BuiltinPresets)
+	for name := range c.Presets {
+		if _, ok := BuiltinPresets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidatePreset checks that a preset only uses supported settings
+func ValidatePreset(name string, preset TranscodePreset) error {
+	for _, quality := range preset.Qualities {
+		if _, ok := LookupQuality(quality); !ok {
+			return fmt.Errorf("preset %s: unrecognised quality %q", name, quality)
+		}
+	}
+
+	hardwareAccel := preset.HardwareAccel
+	if hardwareAccel == "none" {
+		hardwareAccel = ""
+	}
+	if preset.VideoCodec != "" {
+		if err := ValidateCodec(preset.VideoCodec, hardwareAccel); err != nil {
+			return fmt.Errorf("preset %s: %w", name, err)
+		}
+	} else if hardwareAccel != "" {
+		if _, ok := HardwareEncoders[hardwareAccel]; !ok {
+			return fmt.Errorf("preset %s: unsupported hardware acceleration %q", name, hardwareAccel)
+		}
+	}
+
+	switch preset.OutputFormat {
+	case "", OutputFormatHLS, OutputFormatAudio, OutputFormatFMP4:
+	default:
+		return fmt.Errorf("preset %s: unsupported output format %q (supported: hls, audio, fmp4)", name, preset.OutputFormat)
+	}
+
+	if preset.TwoPass {
+		return fmt.Errorf("preset %s: two-pass encoding is not supported yet", name)
+	}
+
+	if preset.Lossless {
+		if hardwareAccel != "" {
+			return fmt.Errorf("preset %s: lossless encoding requires software encoding", name)
+		}
+		if preset.VideoCodec != "h264" && preset.VideoCodec != "h265" {
+			return fmt.Errorf("preset %s: lossless encoding requires the h264 or h265 video codec", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quality describes an output rendition
+type Quality struct {
+	// Width and Height are the output frame size in pixels
+	Width  int
+	Height int
+	// Bitrate is the target video bitrate passed to the encoder
+	Bitrate string
+}
+
+// Qualities lists the supported output qualities. Keys are lower case since
+// the config loader lower-cases map keys; the K names are aliases for the
+// matching p names, except 2k which is the DCI resolution.
+var Qualities = map[string]Quality{
+	"360p":  {Width: 640, Height: 360, Bitrate: "1M"},
+	"480p":  {Width: 854, Height: 480, Bitrate: "1.5M"},
+	"720p":  {Width: 1280, Height: 720, Bitrate: "3M"},
+	"1080p": {Width: 1920, Height: 1080, Bitrate: "5M"},
+	"2k":    {Width: 2048, Height: 1080, Bitrate: "7M"},
+	"2160p": {Width: 3840, Height: 2160, Bitrate: "10M"},
+	"4k":    {Width: 3840, Height: 2160, Bitrate: "10M"},
+	"4320p": {Width: 7680, Height: 4320, Bitrate: "20M"},
+	"8k":    {Width: 7680, Height: 4320, Bitrate: "20M"},
+}
+
+// LookupQuality returns the definition of a quality name, ignoring case
+func LookupQuality(name string) (Quality, bool) {
+	q, ok := Qualities[strings.ToLower(name)]
+	return q, ok
+}
+
+// hasEnabledQuality reports whether any quality of a qualities map is enabled
+func hasEnabledQuality(qualities map[string]bool) bool {
+	for _, enabled := range qualities {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateQualities checks that every key of a qualities map is a supported
+// quality, listing all unrecognised keys in the error
+func ValidateQualities(qualities map[string]bool) error {
+	var unknown []string
+	for _, name := range sortedKeys(qualities) {
+		if _, ok := LookupQuality(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unrecognised FFmpeg qualities %v (supported: %v)", unknown, sortedKeys(Qualities))
+	}
+	return nil
+}
+
+// ValidateQualityOutputFormats checks the per-quality containers of a job.
+// Every key must be a supported quality and every value hls, mp4 or mkv. HLS
+// writes playlists and segment directories while MP4 and MKV write one file
+// per quality, so a job cannot mix them.
+func ValidateQualityOutputFormats(formats map[string]string) error {
+	var hls, files bool
+	for _, quality := range sortedKeys(formats) {
+		if _, ok := LookupQuality(quality); !ok {
+			return fmt.Errorf("unrecognised quality %q (supported: %v)", quality, sortedKeys(Qualities))
+		}
+
+		switch formats[quality] {
+		case OutputFormatHLS:
+			hls = true
+		case QualityFormatMP4, QualityFormatMKV:
+			files = true
+		default:
+			return fmt.Errorf("quality %s: unsupported output format %q (supported: hls, mp4, mkv)", quality, formats[quality])
+		}
+	}
+
+	if hls && files {
+		return fmt.Errorf("mp4 and mkv outputs cannot be mixed with hls in the same job")
+	}
+	return nil
+}
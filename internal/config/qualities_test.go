@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateQualityOutputFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats map[string]string
+		wantErr string
+	}{
+		{name: "hls", formats: map[string]string{"720p": "hls", "360p": "hls"}},
+		{name: "files", formats: map[string]string{"720p": "mp4", "1080p": "mkv"}},
+		{name: "mixed", formats: map[string]string{"720p": "hls", "1080p": "mp4"}, wantErr: "cannot be mixed"},
+		{name: "unknown quality", formats: map[string]string{"archive": "mp4"}, wantErr: "unrecognised quality"},
+		{name: "unknown format", formats: map[string]string{"720p": "avi"}, wantErr: "unsupported output format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQualityOutputFormats(tt.formats)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateQualityOutputFormats() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateQualityOutputFormats() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
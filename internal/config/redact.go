@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces secrets in redacted config dumps
+const RedactedValue = "REDACTED"
+
+// RedactedYAML returns cfg as YAML with every password, secret, API key and
+// token replaced by RedactedValue, so it can be shared for troubleshooting.
+// Empty values are left empty to show they are unset.
+func RedactedYAML(cfg *Config) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	redactNode(&doc)
+
+	return yaml.Marshal(&doc)
+}
+
+// redactNode replaces the scalar values of sensitive keys below node
+func redactNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Value != "" && sensitiveKey(key.Value) {
+				value.Value = RedactedValue
+				value.Tag = "!!str"
+				value.Style = 0
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		redactNode(child)
+	}
+}
+
+// sensitiveKey reports whether a config key holds a credential, such as
+// password, secret_access_key, admin_api_key, token or a URL embedding one
+func sensitiveKey(key string) bool {
+	return key == "password" || key == "token" ||
+		strings.Contains(key, "secret") || strings.HasSuffix(key, "api_key") ||
+		key == "slack_webhook_url" || key == "sentry_dsn"
+}
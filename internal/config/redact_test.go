@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedactedYAML(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Queue.Redis.Password = "hunter2"
+	cfg.Storage.S3.AccessKeyID = "AKIAEXAMPLE"
+	cfg.Storage.S3.SecretAccessKey = "s3-secret"
+	cfg.GRPC.AdminAPIKey = "admin-key"
+
+	data, err := RedactedYAML(cfg)
+	if err != nil {
+		t.Fatalf("RedactedYAML() error = %v", err)
+	}
+	for _, secret := range []string{"hunter2", "s3-secret", "admin-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("RedactedYAML() contains %q", secret)
+		}
+	}
+
+	var redacted Config
+	if err := yaml.Unmarshal(data, &redacted); err != nil {
+		t.Fatalf("redacted config is not YAML: %v", err)
+	}
+	if redacted.Queue.Redis.Password != RedactedValue || redacted.Storage.S3.SecretAccessKey != RedactedValue {
+		t.Errorf("secrets = %q, %q; want %s", redacted.Queue.Redis.Password, redacted.Storage.S3.SecretAccessKey, RedactedValue)
+	}
+	// Non-secret values are kept, and unset secrets stay empty
+	if redacted.Storage.S3.AccessKeyID != "AKIAEXAMPLE" {
+		t.Errorf("access key ID = %q, want it kept", redacted.Storage.S3.AccessKeyID)
+	}
+	if redacted.Queue.SQS.SecretAccessKey != "" {
+		t.Errorf("unset SQS secret = %q, want it left empty", redacted.Queue.SQS.SecretAccessKey)
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultCiphertextPrefix starts every value encrypted by the Vault transit engine
+const VaultCiphertextPrefix = "vault:v1:"
+
+// vaultRequestTimeout bounds each transit API call
+const vaultRequestTimeout = 10 * time.Second
+
+// VaultConfig contains HashiCorp Vault settings for decrypting secrets stored
+// in the config file as transit ciphertext
+type VaultConfig struct {
+	Enabled        bool   `mapstructure:"enabled" yaml:"enabled" doc:"Decrypt vault:v1: secrets through the Vault transit engine"`
+	Address        string `mapstructure:"address" yaml:"address" doc:"Vault server address, defaults to VAULT_ADDR"`
+	Token          string `mapstructure:"token" yaml:"token,omitempty" doc:"Vault token, defaults to VAULT_TOKEN"`
+	TransitKeyName string `mapstructure:"transit_key_name" yaml:"transit_key_name" doc:"Name of the transit encryption key"`
+}
+
+// VaultClient encrypts and decrypts values with a Vault transit key
+type VaultClient struct {
+	address    string
+	token      string
+	keyName    string
+	httpClient *http.Client
+}
+
+// NewVaultClient creates a transit client, taking the address and token from
+// VAULT_ADDR and VAULT_TOKEN when they are not set in cfg
+func NewVaultClient(cfg VaultConfig) (*VaultClient, error) {
+	address := cfg.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if address == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault token is required")
+	}
+	if cfg.TransitKeyName == "" {
+		return nil, fmt.Errorf("vault transit key name is required")
+	}
+
+	return &VaultClient{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		keyName:    cfg.TransitKeyName,
+		httpClient: &http.Client{Timeout: vaultRequestTimeout},
+	}, nil
+}
+
+// Encrypt returns the vault:v1: ciphertext of plaintext
+func (c *VaultClient) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext))}
+	if err := c.transit(ctx, "encrypt", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.Ciphertext, nil
+}
+
+// Decrypt returns the plaintext of a vault:v1: ciphertext
+func (c *VaultClient) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := c.transit(ctx, "decrypt", map[string]string{"ciphertext": ciphertext}, &resp); err != nil {
+		return "", err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("invalid plaintext from vault: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// transit calls a transit engine endpoint for the client's key
+func (c *VaultClient) transit(ctx context.Context, operation string, body, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/transit/%s/%s", c.address, operation, c.keyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s request failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("vault %s failed: %s: %s", operation, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid vault %s response: %w", operation, err)
+	}
+	return nil
+}
+
+// secretFields returns pointers to the config values holding credentials
+func secretFields(cfg *Config) []*string {
+	fields := []*string{
+		&cfg.Queue.Redis.Password,
+		&cfg.Storage.S3.SecretAccessKey,
+		&cfg.GRPC.AdminAPIKey,
+		&cfg.CrashReporter.SlackWebhookURL,
+		&cfg.CrashReporter.SentryDSN,
+		&cfg.Metrics.InfluxDB.Token,
+		&cfg.Admin.PprofToken,
+	}
+	for i := range cfg.MultiQueue {
+		fields = append(fields, &cfg.MultiQueue[i].Redis.Password)
+	}
+	for i := range cfg.Storage.Fallback {
+		fields = append(fields, &cfg.Storage.Fallback[i].S3.SecretAccessKey)
+	}
+	return fields
+}
+
+// EncryptWithVault returns a copy of cfg with every secret replaced by its
+// vault:v1: ciphertext. Empty and already encrypted secrets are left as they are.
+func EncryptWithVault(cfg *Config, client *VaultClient) (*Config, error) {
+	encrypted := *cfg
+	encrypted.MultiQueue = append([]QueueConfig(nil), cfg.MultiQueue...)
+	encrypted.Storage.Fallback = append([]StorageConfig(nil), cfg.Storage.Fallback...)
+
+	ctx := context.Background()
+	for _, field := range secretFields(&encrypted) {
+		if *field == "" || strings.HasPrefix(*field, VaultCiphertextPrefix) {
+			continue
+		}
+
+		ciphertext, err := client.Encrypt(ctx, *field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		}
+		*field = ciphertext
+	}
+
+	return &encrypted, nil
+}
+
+// decryptVaultSecrets replaces vault:v1: secrets with their plaintext. A
+// Vault client is only created when an encrypted secret is found.
+func decryptVaultSecrets(cfg *Config) error {
+	var client *VaultClient
+	ctx := context.Background()
+
+	for _, field := range secretFields(cfg) {
+		if !strings.HasPrefix(*field, VaultCiphertextPrefix) {
+			continue
+		}
+
+		if client == nil {
+			if !cfg.Vault.Enabled {
+				return fmt.Errorf("config contains vault encrypted secrets but vault is not enabled")
+			}
+			var err error
+			if client, err = NewVaultClient(cfg.Vault); err != nil {
+				return err
+			}
+		}
+
+		plaintext, err := client.Decrypt(ctx, *field)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret: %w", err)
+		}
+		*field = plaintext
+	}
+
+	return nil
+}
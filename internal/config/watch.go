@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the config file must stay unchanged before it is reloaded
+const watchDebounce = 500 * time.Millisecond
+
+// mutableFields are the config fields that can be applied without a restart
+var mutableFields = map[string]bool{
+	"logging.level":      true,
+	"worker.min_workers": true,
+	"worker.max_workers": true,
+	"ffmpeg.timeout":     true,
+}
+
+// IsMutableField reports whether the field at fieldPath, e.g. "worker.max_workers",
+// can be changed at runtime. Other fields, such as ports and adapters,
+// require a restart.
+func IsMutableField(fieldPath string) bool {
+	return mutableFields[fieldPath]
+}
+
+// ChangedFields returns the paths of the fields that differ between two
+// configs, e.g. "grpc.port". Maps and slices are compared as a whole.
+func ChangedFields(old, updated *Config) []string {
+	var changed []string
+	for _, diff := range Diff(old, updated) {
+		changed = append(changed, diff.Path)
+	}
+	return changed
+}
+
+// watcher reloads a config file when it changes
+type watcher struct {
+	fsw      *fsnotify.Watcher
+	path     string
+	onChange func(*Config)
+
+	mu    sync.Mutex
+	timer *time.Timer
+	done  chan struct{}
+
+	// reloadMu serializes reloads so onChange is never called concurrently
+	reloadMu sync.Mutex
+}
+
+// Watch reloads the config file at path whenever it changes and passes the
+// new config to onChange. Bursts of changes are debounced, and changes that
+// fail to load or validate are ignored. Close the returned io.Closer to stop
+// watching.
+func Watch(path string, onChange func(*Config)) (io.Closer, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	// Watch the directory, since editors often replace the file instead of writing it
+	if err := fsw.Add(filepath.Dir(path)); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	w := &watcher{
+		fsw:      fsw,
+		path:     path,
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// run schedules a reload for every event on the config file
+func (w *watcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			w.schedule()
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// schedule reloads the config once no further changes arrive within watchDebounce
+func (w *watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(watchDebounce, w.reload)
+}
+
+// reload loads the config file and reports it if it is valid
+func (w *watcher) reload() {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+
+	cfg, err := Load(w.path)
+	if err != nil {
+		return
+	}
+	w.onChange(cfg)
+}
+
+// Close stops watching the config file
+func (w *watcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
+	close(w.done)
+	return w.fsw.Close()
+}
@@ -35,8 +35,9 @@ func promptQualities(qualities []string, defaultSelected []string) map[string]bo
 	return result
 }
 
-// RunWizard runs the interactive configuration wizard
-func RunWizard(configPath string) error {
+// RunWizard runs the interactive configuration wizard. The configuration is
+// not saved when the linter reports errors, unless force is set.
+func RunWizard(configPath string, force bool) error {
 	fmt.Println("🎬 Flixsrota Configuration Wizard")
 	fmt.Println("==================================")
 	fmt.Println()
@@ -52,6 +53,12 @@ func RunWizard(configPath string) error {
 		configPath = filepath.Join(homeDir, ".flixsrota.yaml")
 	}
 
+	// Environment profile
+	env := promptChoice("Environment", Environments, Environments[0])
+	if env != Environments[0] {
+		configPath = ProfilePath(configPath, env)
+	}
+
 	fmt.Printf("Configuration will be saved to: %s\n", configPath)
 	fmt.Println()
 
@@ -60,6 +67,12 @@ func RunWizard(configPath string) error {
 	fmt.Println("----------------------------")
 	cfg.GRPC.Address = promptString("Server address", cfg.GRPC.Address)
 	cfg.GRPC.Port = promptInt("Server port", cfg.GRPC.Port)
+	if env == EnvProduction {
+		// Production requires TLS and no reflection
+		cfg.GRPC.EnableReflection = false
+		cfg.GRPC.TLSCertFile = promptString("TLS certificate file", "/etc/flixsrota/tls.crt")
+		cfg.GRPC.TLSKeyFile = promptString("TLS key file", "/etc/flixsrota/tls.key")
+	}
 	fmt.Println()
 
 	// Queue Configuration
@@ -79,6 +92,7 @@ func RunWizard(configPath string) error {
 	case "sqs":
 		cfg.Queue.SQS.Region = promptString("AWS region", "us-east-1")
 		cfg.Queue.SQS.QueueURL = promptString("SQS queue URL", "")
+		cfg.Queue.SQS.UseInstanceRole, cfg.Queue.SQS.AccessKeyID, cfg.Queue.SQS.SecretAccessKey = promptAWSCredentials()
 	}
 	fmt.Println()
 
@@ -95,6 +109,7 @@ func RunWizard(configPath string) error {
 	case "s3":
 		cfg.Storage.S3.Region = promptString("AWS region", "us-east-1")
 		cfg.Storage.S3.Bucket = promptString("S3 bucket name", "")
+		cfg.Storage.S3.UseInstanceRole, cfg.Storage.S3.AccessKeyID, cfg.Storage.S3.SecretAccessKey = promptAWSCredentials()
 	case "gcs":
 		cfg.Storage.GCS.ProjectID = promptString("Google Cloud project ID", "")
 		cfg.Storage.GCS.Bucket = promptString("GCS bucket name", "")
@@ -123,6 +138,20 @@ func RunWizard(configPath string) error {
 	cfg.Worker.MaxWorkers = promptInt("Maximum workers", cfg.Worker.MaxWorkers)
 	fmt.Println()
 
+	// Worker Affinity
+	fmt.Println("🏷️  Worker Affinity")
+	fmt.Println("-------------------")
+	fmt.Println("Jobs that require labels only run on workers that have all of them.")
+	cfg.Worker.WorkerLabels = parseLabels(promptString("Worker labels (key=value, comma-separated)", ""))
+	fmt.Println()
+
+	// Check for common mistakes before saving
+	warnings := Lint(cfg)
+	printLintWarnings(warnings)
+	if HasLintErrors(warnings) && !force {
+		return fmt.Errorf("configuration has lint errors, fix them or rerun with --force")
+	}
+
 	// Save configuration
 	if err := Save(cfg, configPath); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
@@ -132,12 +161,41 @@ func RunWizard(configPath string) error {
 	fmt.Printf("📁 Location: %s\n", configPath)
 	fmt.Println()
 	fmt.Println("🚀 You can now start Flixsrota with:")
-	fmt.Printf("   flixsrota serve --config %s\n", configPath)
+	if env != Environments[0] {
+		fmt.Printf("   %s=%s flixsrota serve\n", EnvVar, env)
+	} else {
+		fmt.Printf("   flixsrota serve --config %s\n", configPath)
+	}
 	fmt.Println()
 
 	return nil
 }
 
+// ANSI colors for lint findings
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// printLintWarnings prints lint errors in red and warnings in yellow
+func printLintWarnings(warnings []LintWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Println("🔍 Configuration Lint")
+	fmt.Println("---------------------")
+	for _, w := range warnings {
+		color := colorYellow
+		if w.Severity == LintSeverityError {
+			color = colorRed
+		}
+		fmt.Printf("%s%s: %s: %s%s\n", color, w.Severity, w.Field, w.Message, colorReset)
+	}
+	fmt.Println()
+}
+
 // promptString prompts for a string input
 func promptString(prompt, defaultValue string) string {
 	reader := bufio.NewReader(os.Stdin)
@@ -150,6 +208,24 @@ func promptString(prompt, defaultValue string) string {
 	return input
 }
 
+// parseLabels parses comma-separated key=value pairs, skipping malformed ones
+func parseLabels(input string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(input, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
 // promptInt prompts for an integer input
 func promptInt(prompt string, defaultValue int) int {
 	reader := bufio.NewReader(os.Stdin)
@@ -211,6 +287,22 @@ func promptChoice(prompt string, choices []string, defaultValue string) string {
 	return defaultValue
 }
 
+// AWS credential choices offered by the wizard
+const (
+	awsCredentialsIAMRole    = "IAM role"
+	awsCredentialsAccessKeys = "Access keys"
+)
+
+// promptAWSCredentials asks how to authenticate with AWS. The IAM role uses
+// the default credential chain, so no keys are prompted for.
+func promptAWSCredentials() (useInstanceRole bool, accessKeyID, secretAccessKey string) {
+	choice := promptChoice("AWS credentials", []string{awsCredentialsIAMRole, awsCredentialsAccessKeys}, awsCredentialsIAMRole)
+	if choice == awsCredentialsIAMRole {
+		return true, "", ""
+	}
+	return false, promptString("AWS access key ID", ""), promptPassword("AWS secret access key")
+}
+
 // promptPassword prompts for a password input
 func promptPassword(prompt string) string {
 	fmt.Printf("%s: ", prompt)
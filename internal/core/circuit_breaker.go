@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+)
+
+// ErrFFmpegUnavailable is returned instead of running FFmpeg while the
+// circuit breaker is open
+var ErrFFmpegUnavailable = errors.New("FFmpeg is unavailable")
+
+// breakerState is the state of the FFmpeg circuit breaker, using the values
+// exported in the flixsrota_ffmpeg_circuit_breaker_state metric
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// ffmpegProbeTimeout bounds the ffmpeg -version probe
+const ffmpegProbeTimeout = 10 * time.Second
+
+// FFmpegCircuitBreaker stops FFmpeg executions after repeated failures of the
+// binary itself, such as a missing executable or missing shared libraries.
+// Failures caused by the input are not counted. While open, ffmpeg -version
+// is probed every recovery interval and the breaker closes once it succeeds.
+type FFmpegCircuitBreaker struct {
+	executablePath   string
+	threshold        int
+	recoveryInterval time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewFFmpegCircuitBreaker creates a closed circuit breaker for the FFmpeg binary
+func NewFFmpegCircuitBreaker(cfg config.CircuitBreakerConfig, executablePath string) *FFmpegCircuitBreaker {
+	metrics.SetFFmpegCircuitBreakerState(int(breakerClosed))
+
+	return &FFmpegCircuitBreaker{
+		executablePath:   executablePath,
+		threshold:        cfg.ThresholdFailures,
+		recoveryInterval: time.Duration(cfg.RecoveryInterval) * time.Second,
+	}
+}
+
+// Allow returns ErrFFmpegUnavailable if FFmpeg should not be run. Once the
+// recovery interval has passed the breaker is half-open and the first caller
+// probes the binary; other callers are rejected until the probe finishes.
+func (cb *FFmpegCircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	switch cb.state {
+	case breakerClosed:
+		cb.mu.Unlock()
+		return nil
+	case breakerOpen:
+		if time.Since(cb.openedAt) >= cb.recoveryInterval {
+			break
+		}
+		fallthrough
+	default:
+		cb.mu.Unlock()
+		return ErrFFmpegUnavailable
+	}
+	cb.setState(breakerHalfOpen)
+	cb.mu.Unlock()
+
+	err := cb.probe()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if err != nil {
+		cb.trip()
+		return ErrFFmpegUnavailable
+	}
+	cb.failures = 0
+	cb.setState(breakerClosed)
+	return nil
+}
+
+// Record records the outcome of an FFmpeg execution and reports whether it
+// opened the breaker
+func (cb *FFmpegCircuitBreaker) Record(err error) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !isFFmpegBinaryFailure(err) {
+		cb.failures = 0
+		return false
+	}
+
+	cb.failures++
+	if cb.state != breakerClosed || cb.failures < cb.threshold {
+		return false
+	}
+	cb.trip()
+	return true
+}
+
+// trip opens the breaker. Must be called with mu held.
+func (cb *FFmpegCircuitBreaker) trip() {
+	cb.openedAt = time.Now()
+	cb.setState(breakerOpen)
+}
+
+// setState changes the state and its metric. Must be called with mu held.
+func (cb *FFmpegCircuitBreaker) setState(state breakerState) {
+	cb.state = state
+	metrics.SetFFmpegCircuitBreakerState(int(state))
+}
+
+// probe checks that the FFmpeg binary can run at all
+func (cb *FFmpegCircuitBreaker) probe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ffmpegProbeTimeout)
+	defer cancel()
+
+	return exec.CommandContext(ctx, cb.executablePath, "-version").Run()
+}
+
+// isFFmpegBinaryFailure reports whether an execution error means the binary
+// could not run, as opposed to FFmpeg rejecting the input. Exit codes 126 and
+// 127 are used when the executable or one of its libraries cannot be loaded.
+func isFFmpegBinaryFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		return code == 126 || code == 127
+	}
+
+	// The process could not be started
+	return true
+}
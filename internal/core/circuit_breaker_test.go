@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFFmpegExecutorOpensCircuitBreaker(t *testing.T) {
+	// Exit code 127 is a binary failure, such as a missing shared library
+	cfg, _ := fakeFFmpeg(t, "{}", 127)
+	cfg.CircuitBreaker.ThresholdFailures = 2
+	cfg.CircuitBreaker.RecoveryInterval = 3600
+
+	core, logs := observer.New(zap.ErrorLevel)
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.New(core))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := fe.Execute(ctx, newTestJob(t), nil); err == nil || errors.Is(err, ErrFFmpegUnavailable) {
+			t.Fatalf("Execute() #%d error = %v, want the FFmpeg failure", i+1, err)
+		}
+	}
+	if n := logs.FilterMessageSnippet("circuit breaker opened").Len(); n != 1 {
+		t.Errorf("logged %d circuit breaker openings, want 1", n)
+	}
+
+	if err := fe.Execute(ctx, newTestJob(t), nil); !errors.Is(err, ErrFFmpegUnavailable) {
+		t.Errorf("Execute() with the breaker open error = %v, want ErrFFmpegUnavailable", err)
+	}
+}
@@ -0,0 +1,357 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// mp4Movflags makes every MP4 rendition a fragmented, progressively playable
+// file. frag_keyframe starts fragments on I-frames and global_sidx writes the
+// segment index that the DASH on-demand manifest points players to.
+const mp4Movflags = "frag_keyframe+empty_moov+default_base_moof+faststart+global_sidx"
+
+// mp4AudioBitrate is the bitrate of the audio muxed into every MP4 rendition
+const mp4AudioBitrate = "96k"
+
+// mp4SampleTimescale is the video timescale used with a fixed sample duration
+const mp4SampleTimescale = 90000
+
+// dashCodecs are the RFC 6381 codecs values of the encoder output, as
+// advertised in the DASH manifest
+var dashCodecs = map[string]string{
+	"h264":    "avc1.640028",
+	"h265":    "hvc1.1.6.L120.90",
+	"vp9":     "vp09.00.40.08",
+	"av1":     "av01.0.08M.08",
+	"aac":     "mp4a.40.2",
+	"libopus": "opus",
+}
+
+// fragmentedMP4 reports whether the executor writes fragmented MP4 renditions
+// instead of HLS
+func (fe *FFmpegExecutor) fragmentedMP4() bool {
+	return fe.config.FragmentedMP4.Enabled && !fe.audioOnly
+}
+
+// enabledQualities returns the enabled video qualities in ascending bitrate order
+func (fe *FFmpegExecutor) enabledQualities() []string {
+	var qualities []string
+	for quality, enabled := range fe.config.Qualities {
+		if _, ok := config.LookupQuality(quality); ok && enabled {
+			qualities = append(qualities, quality)
+		}
+	}
+	sort.Slice(qualities, func(i, j int) bool {
+		qi, _ := config.LookupQuality(qualities[i])
+		qj, _ := config.LookupQuality(qualities[j])
+		if bi, bj := parseBitrate(qi.Bitrate), parseBitrate(qj.Bitrate); bi != bj {
+			return bi < bj
+		}
+		return qualities[i] < qualities[j]
+	})
+	return qualities
+}
+
+// dashManifestPath returns the manifest path for a job output path
+func dashManifestPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".mpd"
+}
+
+// mp4RenditionPath returns the MP4 file of a quality, written next to the
+// manifest of the job output path
+func mp4RenditionPath(outputPath, quality string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_" + quality + ".mp4"
+}
+
+// buildMP4Args returns one fragmented MP4 output per rendition, each with the
+// video stream and the audio muxed in so it also plays as a progressive
+// download. Fragments last at least FragmentDuration and start on a keyframe,
+// so their boundaries line up with the encoder's I-frames.
+func (fe *FFmpegExecutor) buildMP4Args(codec string, renditions []hlsRendition, audioFilter, outputPath string) []string {
+	fm := fe.config.FragmentedMP4
+	audioCodec := config.VideoCodecs[codec].AudioCodec
+
+	var args []string
+	for _, r := range renditions {
+		args = append(args, fmt.Sprintf("-map %s %s", r.label, fe.videoEncoderArgs(codec, 0, r.bitrate, r.gop)+forceKeyFramesArgs(0, r.keyframes)))
+
+		// A fixed sample duration lets every fragment use the default from trex
+		if fm.DefaultSampleDuration > 0 {
+			args = append(args,
+				fmt.Sprintf("-video_track_timescale %d", mp4SampleTimescale),
+				fmt.Sprintf("-r %d/%d", mp4SampleTimescale, fm.DefaultSampleDuration))
+		}
+
+		args = append(args, fmt.Sprintf("-map a:0 -c:a:0 %s -b:a:0 %s -ac 2", audioCodec, mp4AudioBitrate))
+		if audioFilter != "" {
+			args = append(args, "-filter:a", audioFilter)
+		}
+
+		args = append(args,
+			"-f mp4",
+			"-movflags "+mp4Movflags,
+			fmt.Sprintf("-min_frag_duration %d", fm.FragmentDuration*1000000),
+			mp4RenditionPath(outputPath, r.quality),
+		)
+	}
+
+	return args
+}
+
+// mpd is a static DASH on-demand manifest. Each representation is a single
+// MP4 file with the video and audio muxed together.
+type mpd struct {
+	XMLName                   xml.Name  `xml:"MPD"`
+	Xmlns                     string    `xml:"xmlns,attr"`
+	Profiles                  string    `xml:"profiles,attr"`
+	Type                      string    `xml:"type,attr"`
+	MinBufferTime             string    `xml:"minBufferTime,attr"`
+	MediaPresentationDuration string    `xml:"mediaPresentationDuration,attr"`
+	Period                    mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	AdaptationSet mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	MimeType                string                `xml:"mimeType,attr"`
+	SubsegmentAlignment     bool                  `xml:"subsegmentAlignment,attr"`
+	SubsegmentStartsWithSAP int                   `xml:"subsegmentStartsWithSAP,attr"`
+	ContentComponents       []mpdContentComponent `xml:"ContentComponent"`
+	Representations         []mpdRepresentation   `xml:"Representation"`
+}
+
+type mpdContentComponent struct {
+	ID          int    `xml:"id,attr"`
+	ContentType string `xml:"contentType,attr"`
+}
+
+type mpdRepresentation struct {
+	ID          string         `xml:"id,attr"`
+	Codecs      string         `xml:"codecs,attr"`
+	Bandwidth   int64          `xml:"bandwidth,attr"`
+	Width       int            `xml:"width,attr"`
+	Height      int            `xml:"height,attr"`
+	BaseURL     string         `xml:"BaseURL"`
+	SegmentBase mpdSegmentBase `xml:"SegmentBase"`
+}
+
+type mpdSegmentBase struct {
+	IndexRange     string            `xml:"indexRange,attr"`
+	Initialization mpdInitialization `xml:"Initialization"`
+}
+
+type mpdInitialization struct {
+	Range string `xml:"range,attr"`
+}
+
+// writeDASHManifest writes the DASH manifest for the MP4 renditions of
+// outputPath, reading the byte ranges of their headers and segment indexes
+func (fe *FFmpegExecutor) writeDASHManifest(outputPath, codec string) error {
+	fm := fe.config.FragmentedMP4
+	audioCodec := config.VideoCodecs[codec].AudioCodec
+
+	manifest := mpd{
+		Xmlns:         "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:      "urn:mpeg:dash:profile:isoff-on-demand:2011",
+		Type:          "static",
+		MinBufferTime: fmt.Sprintf("PT%dS", fm.FragmentDuration),
+		Period: mpdPeriod{
+			AdaptationSet: mpdAdaptationSet{
+				MimeType:                "video/mp4",
+				SubsegmentAlignment:     true,
+				SubsegmentStartsWithSAP: 1,
+				ContentComponents: []mpdContentComponent{
+					{ID: 1, ContentType: "video"},
+					{ID: 2, ContentType: "audio"},
+				},
+			},
+		},
+	}
+
+	var duration float64
+	for _, quality := range fe.enabledQualities() {
+		preset, _ := config.LookupQuality(quality)
+		path := mp4RenditionPath(outputPath, quality)
+
+		layout, err := readMP4Layout(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if layout.duration > duration {
+			duration = layout.duration
+		}
+
+		manifest.Period.AdaptationSet.Representations = append(manifest.Period.AdaptationSet.Representations, mpdRepresentation{
+			ID:        quality,
+			Codecs:    dashCodecs[codec] + "," + dashCodecs[audioCodec],
+			Bandwidth: parseBitrate(preset.Bitrate) + parseBitrate(mp4AudioBitrate),
+			Width:     preset.Width,
+			Height:    preset.Height,
+			BaseURL:   filepath.Base(path),
+			SegmentBase: mpdSegmentBase{
+				IndexRange:     fmt.Sprintf("%d-%d", layout.sidxStart, layout.sidxEnd-1),
+				Initialization: mpdInitialization{Range: fmt.Sprintf("0-%d", layout.initEnd-1)},
+			},
+		})
+	}
+	manifest.MediaPresentationDuration = fmt.Sprintf("PT%.3fS", duration)
+
+	data, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dashManifestPath(outputPath), append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+// mp4Layout is the position of the header and segment index of a fragmented
+// MP4 file, as byte offsets with exclusive ends
+type mp4Layout struct {
+	initEnd   int64
+	sidxStart int64
+	sidxEnd   int64
+	// duration in seconds, from the segment index
+	duration float64
+}
+
+// readMP4Layout scans the top-level boxes of an MP4 file up to its first
+// fragment for the moov and sidx boxes
+func readMP4Layout(path string) (mp4Layout, error) {
+	var layout mp4Layout
+
+	file, err := os.Open(path)
+	if err != nil {
+		return layout, err
+	}
+	defer file.Close()
+
+	var offset int64
+	for layout.initEnd == 0 || layout.sidxEnd == 0 {
+		var header [16]byte
+		if _, err := file.ReadAt(header[:8], offset); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return layout, err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		if size == 1 {
+			if _, err := file.ReadAt(header[8:16], offset+8); err != nil {
+				return layout, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize {
+			return layout, fmt.Errorf("invalid %q box at offset %d", boxType, offset)
+		}
+
+		switch boxType {
+		case "moov":
+			layout.initEnd = offset + size
+		case "sidx":
+			duration, err := readSidxDuration(io.NewSectionReader(file, offset+headerSize, size-headerSize))
+			if err != nil {
+				return layout, err
+			}
+			layout.sidxStart, layout.sidxEnd, layout.duration = offset, offset+size, duration
+		case "moof", "mdat":
+			// The header and index precede the first fragment
+			return layout, fmt.Errorf("no %s box before the first fragment", missingMP4Box(layout))
+		}
+
+		offset += size
+	}
+
+	if layout.initEnd == 0 || layout.sidxEnd == 0 {
+		return layout, fmt.Errorf("no %s box found", missingMP4Box(layout))
+	}
+	return layout, nil
+}
+
+// missingMP4Box names the box readMP4Layout has not found yet
+func missingMP4Box(layout mp4Layout) string {
+	if layout.initEnd == 0 {
+		return "moov"
+	}
+	return "sidx"
+}
+
+// readSidxDuration returns the total duration in seconds of the
+// subsegments referenced by a sidx box body
+func readSidxDuration(r io.Reader) (float64, error) {
+	var fixed struct {
+		VersionFlags uint32
+		ReferenceID  uint32
+		Timescale    uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return 0, fmt.Errorf("invalid sidx box: %w", err)
+	}
+	if fixed.Timescale == 0 {
+		return 0, fmt.Errorf("invalid sidx box: zero timescale")
+	}
+
+	// Skip earliest_presentation_time and first_offset, 32 or 64 bits each
+	skip := 8
+	if fixed.VersionFlags>>24 == 1 {
+		skip = 16
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(skip)); err != nil {
+		return 0, fmt.Errorf("invalid sidx box: %w", err)
+	}
+
+	var counts struct {
+		Reserved       uint16
+		ReferenceCount uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &counts); err != nil {
+		return 0, fmt.Errorf("invalid sidx box: %w", err)
+	}
+
+	var total uint64
+	for i := 0; i < int(counts.ReferenceCount); i++ {
+		var ref struct {
+			TypeAndSize        uint32
+			SubsegmentDuration uint32
+			SAP                uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &ref); err != nil {
+			return 0, fmt.Errorf("invalid sidx box: %w", err)
+		}
+		total += uint64(ref.SubsegmentDuration)
+	}
+
+	return float64(total) / float64(fixed.Timescale), nil
+}
+
+// parseBitrate parses an FFmpeg bitrate such as "1.5M" or "96k" into bits
+// per second, returning 0 if it is malformed
+func parseBitrate(bitrate string) int64 {
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(bitrate, "k"):
+		multiplier = 1e3
+	case strings.HasSuffix(bitrate, "M"):
+		multiplier = 1e6
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimRight(bitrate, "kM"), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * multiplier)
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// Dispatcher decides which queued job is handed to the next free worker
+type Dispatcher interface {
+	// Next returns the next job to process, or nil if none is available
+	Next(ctx context.Context, q queue.Queue) (*queue.Job, error)
+}
+
+// DispatchFlusher is implemented by dispatchers that hold dequeued jobs
+type DispatchFlusher interface {
+	// Flush returns held jobs to the queue
+	Flush(ctx context.Context, q queue.Queue) error
+}
+
+// NewDispatcher creates the dispatcher for a dispatch algorithm. With queues
+// implementing queue.Selector the reordering dispatchers choose among every
+// queued job; with other queues they only reorder a lookahead of up to
+// lookahead jobs dequeued in priority order. Unknown algorithms fall back to
+// the queue's priority order; the configuration rejects them.
+func NewDispatcher(algorithm string, lookahead int) Dispatcher {
+	switch algorithm {
+	case "fifo":
+		return &FIFODispatcher{buffer: jobBuffer{size: lookahead}}
+	case "round-robin":
+		return &RoundRobinDispatcher{buffer: jobBuffer{size: lookahead}}
+	default:
+		return PriorityDispatcher{}
+	}
+}
+
+// PriorityDispatcher uses the queue's built-in priority ordering
+type PriorityDispatcher struct{}
+
+// Next dequeues the highest priority job
+func (PriorityDispatcher) Next(ctx context.Context, q queue.Queue) (*queue.Job, error) {
+	return q.Dequeue(ctx)
+}
+
+// FIFODispatcher hands out jobs in the order they were created, ignoring priority
+type FIFODispatcher struct {
+	buffer jobBuffer
+}
+
+// Next returns the oldest queued job, or the oldest buffered one when the
+// queue cannot select jobs
+func (d *FIFODispatcher) Next(ctx context.Context, q queue.Queue) (*queue.Job, error) {
+	anyJob := func(job *queue.Job) bool { return true }
+
+	if selector, ok := q.(queue.Selector); ok && len(d.buffer.jobs) == 0 {
+		return selector.DequeueSelected(ctx, func(jobs []*queue.Job) int {
+			return oldestJob(jobs, anyJob)
+		})
+	}
+
+	if err := d.buffer.fill(ctx, q); err != nil {
+		return nil, err
+	}
+	return d.buffer.take(anyJob), nil
+}
+
+// Flush returns buffered jobs to the queue
+func (d *FIFODispatcher) Flush(ctx context.Context, q queue.Queue) error {
+	return d.buffer.flush(ctx, q)
+}
+
+// RoundRobinDispatcher cycles through tenants so that no single tenant
+// monopolizes the workers. Jobs of the same tenant are handed out oldest first.
+type RoundRobinDispatcher struct {
+	buffer     jobBuffer
+	lastTenant string
+}
+
+// Next returns the oldest queued job of the tenant following the last one
+// served, choosing among the buffered jobs when the queue cannot select jobs
+func (d *RoundRobinDispatcher) Next(ctx context.Context, q queue.Queue) (*queue.Job, error) {
+	var job *queue.Job
+	if selector, ok := q.(queue.Selector); ok && len(d.buffer.jobs) == 0 {
+		var err error
+		job, err = selector.DequeueSelected(ctx, func(jobs []*queue.Job) int {
+			tenant := nextTenant(jobs, d.lastTenant)
+			return oldestJob(jobs, func(job *queue.Job) bool {
+				return job.Metadata[queue.MetadataTenantID] == tenant
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if err := d.buffer.fill(ctx, q); err != nil {
+			return nil, err
+		}
+		tenant := nextTenant(d.buffer.jobs, d.lastTenant)
+		job = d.buffer.take(func(job *queue.Job) bool {
+			return job.Metadata[queue.MetadataTenantID] == tenant
+		})
+	}
+
+	if job != nil {
+		d.lastTenant = job.Metadata[queue.MetadataTenantID]
+	}
+	return job, nil
+}
+
+// Flush returns buffered jobs to the queue
+func (d *RoundRobinDispatcher) Flush(ctx context.Context, q queue.Queue) error {
+	return d.buffer.flush(ctx, q)
+}
+
+// nextTenant returns the smallest tenant of the jobs after last, wrapping
+// around to the smallest one
+func nextTenant(jobs []*queue.Job, last string) string {
+	var next, first string
+	hasNext := false
+	for i, job := range jobs {
+		tenant := job.Metadata[queue.MetadataTenantID]
+		if i == 0 || tenant < first {
+			first = tenant
+		}
+		if tenant > last && (!hasNext || tenant < next) {
+			next, hasNext = tenant, true
+		}
+	}
+	if !hasNext {
+		return first
+	}
+	return next
+}
+
+// oldestJob returns the index of the oldest job matching the predicate, or -1
+func oldestJob(jobs []*queue.Job, match func(*queue.Job) bool) int {
+	index := -1
+	for i, job := range jobs {
+		if match(job) && (index < 0 || job.CreatedAt.Before(jobs[index].CreatedAt)) {
+			index = i
+		}
+	}
+	return index
+}
+
+// jobBuffer holds jobs dequeued ahead of dispatch so they can be reordered
+type jobBuffer struct {
+	size int
+	jobs []*queue.Job
+}
+
+// fill dequeues jobs until the buffer is full or the queue is empty
+func (b *jobBuffer) fill(ctx context.Context, q queue.Queue) error {
+	for len(b.jobs) < b.size {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+		b.jobs = append(b.jobs, job)
+	}
+	return nil
+}
+
+// take removes and returns the oldest job matching the predicate
+func (b *jobBuffer) take(match func(*queue.Job) bool) *queue.Job {
+	index := oldestJob(b.jobs, match)
+	if index < 0 {
+		return nil
+	}
+
+	job := b.jobs[index]
+	b.jobs = append(b.jobs[:index], b.jobs[index+1:]...)
+	return job
+}
+
+// flush re-enqueues all buffered jobs
+func (b *jobBuffer) flush(ctx context.Context, q queue.Queue) error {
+	var errs []error
+	for _, job := range b.jobs {
+		if err := queue.Requeue(ctx, q, job); err != nil {
+			errs = append(errs, fmt.Errorf("failed to requeue job %s: %w", job.ID, err))
+		}
+	}
+	b.jobs = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// selectingQueue is an in-memory queue that dequeues by priority and
+// implements queue.Selector
+type selectingQueue struct {
+	queue.Queue
+	mu     sync.Mutex
+	queued []*queue.Job
+}
+
+func (q *selectingQueue) Enqueue(ctx context.Context, job *queue.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queued = append(q.queued, job)
+	sort.SliceStable(q.queued, func(i, j int) bool { return q.queued[i].Priority > q.queued[j].Priority })
+	return nil
+}
+
+func (q *selectingQueue) Dequeue(ctx context.Context) (*queue.Job, error) {
+	return q.DequeueSelected(ctx, func(jobs []*queue.Job) int { return 0 })
+}
+
+func (q *selectingQueue) DequeueSelected(ctx context.Context, pick func(jobs []*queue.Job) int) (*queue.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queued) == 0 {
+		return nil, nil
+	}
+	i := pick(q.queued)
+	if i < 0 {
+		return nil, nil
+	}
+	job := q.queued[i]
+	q.queued = append(q.queued[:i], q.queued[i+1:]...)
+	return job, nil
+}
+
+func (q *selectingQueue) GetQueueDepth(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queued), nil
+}
+
+// plainQueue hides the optional interfaces of a queue, like an adapter that
+// only implements queue.Queue
+type plainQueue struct {
+	queue.Queue
+}
+
+// enqueueDispatchJobs enqueues jobs whose priority rises with their age, so
+// priority order is the reverse of creation order
+func enqueueDispatchJobs(t *testing.T, q queue.Queue, tenants ...string) {
+	t.Helper()
+	base := time.Now()
+	for i, tenant := range tenants {
+		job := &queue.Job{
+			ID:        fmt.Sprintf("%s-%c", tenant, 'a'+i),
+			Priority:  i,
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			Metadata:  map[string]string{queue.MetadataTenantID: tenant},
+		}
+		if err := q.Enqueue(context.Background(), job); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+}
+
+// dispatchAll returns the IDs of the jobs handed out until the queue is empty
+func dispatchAll(t *testing.T, d Dispatcher, q queue.Queue) []string {
+	t.Helper()
+	var ids []string
+	for {
+		job, err := d.Next(context.Background(), q)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if job == nil {
+			return ids
+		}
+		ids = append(ids, job.ID)
+	}
+}
+
+func TestDispatchers(t *testing.T) {
+	tenants := []string{"x", "x", "x", "y", "z", "y"}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		plain     bool
+		want      []string
+	}{
+		{name: "priority", algorithm: "priority", want: []string{"y-f", "z-e", "y-d", "x-c", "x-b", "x-a"}},
+		{name: "fifo", algorithm: "fifo", want: []string{"x-a", "x-b", "x-c", "y-d", "z-e", "y-f"}},
+		{name: "round-robin", algorithm: "round-robin", want: []string{"x-a", "y-d", "z-e", "x-b", "y-f", "x-c"}},
+		// Without queue.Selector only the next two jobs by priority are reordered
+		{name: "fifo lookahead", algorithm: "fifo", plain: true, want: []string{"z-e", "y-d", "x-c", "x-b", "x-a", "y-f"}},
+		{name: "round-robin lookahead", algorithm: "round-robin", plain: true, want: []string{"y-f", "z-e", "x-c", "y-d", "x-a", "x-b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q queue.Queue = &selectingQueue{}
+			if tt.plain {
+				q = plainQueue{q}
+			}
+			enqueueDispatchJobs(t, q, tenants...)
+
+			got := dispatchAll(t, NewDispatcher(tt.algorithm, 2), q)
+			if len(got) != len(tt.want) {
+				t.Fatalf("dispatched %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("dispatched %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherFlush(t *testing.T) {
+	ctx := context.Background()
+	q := plainQueue{&selectingQueue{}}
+	enqueueDispatchJobs(t, q, "x", "y", "z")
+
+	d := NewDispatcher("fifo", 2)
+	if job, err := d.Next(ctx, q); err != nil || job == nil {
+		t.Fatalf("Next() = %v, %v; want a job", job, err)
+	}
+	if err := d.(DispatchFlusher).Flush(ctx, q); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if depth, _ := q.GetQueueDepth(ctx); depth != 2 {
+		t.Errorf("GetQueueDepth() = %d after flush, want 2", depth)
+	}
+}
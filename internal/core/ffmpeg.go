@@ -3,64 +3,226 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/recovery"
 	"go.uber.org/zap"
 )
 
 // FFmpegExecutor manages FFmpeg process execution
 type FFmpegExecutor struct {
 	config config.FFmpegConfig
+	logDir string
+	stats  *metrics.JobStatsAggregator
 	logger *zap.Logger
+
+	// breaker is nil when the circuit breaker is disabled
+	breaker *FFmpegCircuitBreaker
+
+	// lossless, audioOnly and fileFormats are set on per-job copies, see
+	// forJob. fileFormats maps each quality to its mp4 or mkv container when
+	// the job writes one file per quality instead of HLS.
+	lossless    bool
+	audioOnly   bool
+	fileFormats map[string]string
+
+	// timeout is the job timeout in seconds, which can change at runtime
+	timeout atomic.Int64
 }
 
-// NewFFmpegExecutor creates a new FFmpeg executor
-func NewFFmpegExecutor(config config.FFmpegConfig) *FFmpegExecutor {
-	return &FFmpegExecutor{
+// NewFFmpegExecutor creates a new FFmpeg executor that writes job logs to logDir
+// and records FFmpeg resource usage in stats
+func NewFFmpegExecutor(config config.FFmpegConfig, logDir string, stats *metrics.JobStatsAggregator, logger *zap.Logger) *FFmpegExecutor {
+	fe := &FFmpegExecutor{
 		config: config,
-		logger: zap.NewNop(), // Will be set by caller
+		logDir: logDir,
+		stats:  stats,
+		logger: logger,
 	}
+	fe.timeout.Store(int64(config.Timeout))
+
+	if config.CircuitBreaker.Enabled {
+		fe.breaker = NewFFmpegCircuitBreaker(config.CircuitBreaker, config.ExecutablePath)
+	}
+
+	return fe
 }
 
-// Execute runs an FFmpeg command for a job
-func (fe *FFmpegExecutor) Execute(ctx context.Context, job *queue.Job) error {
+// SetTimeout changes the timeout applied to jobs started from now on
+func (fe *FFmpegExecutor) SetTimeout(seconds int) {
+	fe.timeout.Store(int64(seconds))
+}
+
+// Execute runs an FFmpeg command for a job, reporting progress to onProgress
+func (fe *FFmpegExecutor) Execute(ctx context.Context, job *queue.Job, onProgress func(queue.ProgressSnapshot)) error {
+	return fe.forJob(job).execute(ctx, job, onProgress)
+}
+
+// execute runs FFmpeg with the executor's settings, which include the job's
+// overrides
+func (fe *FFmpegExecutor) execute(ctx context.Context, job *queue.Job, onProgress func(queue.ProgressSnapshot)) error {
 	fe.logger.Info("Executing FFmpeg command",
 		zap.String("job_id", job.ID),
 		zap.String("input_path", job.InputPath),
 		zap.String("output_path", job.OutputPath))
 
+	// Fail fast while the FFmpeg binary is known to be broken
+	if fe.breaker != nil {
+		if err := fe.breaker.Allow(); err != nil {
+			return err
+		}
+	}
+
+	// Resolve output codec
+	codec := job.VideoCodec()
+	if codec == "" {
+		codec = fe.config.VideoCodec
+	}
+	if err := config.ValidateCodec(codec, fe.config.HardwareAccel); err != nil {
+		return err
+	}
+
+	if fe.fragmentedMP4() && len(fe.enabledQualities()) == 0 {
+		return fmt.Errorf("fmp4 output requires at least one enabled video quality")
+	}
+	if fe.fileFormats != nil && fe.audioOnly {
+		return fmt.Errorf("per-quality mp4 and mkv output cannot be combined with audio-only output")
+	}
+
+	// Place the output in its templated subdirectory
+	if err := fe.resolveOutputPath(job); err != nil {
+		return err
+	}
+
+	// Resolve input normalization filters
+	normalize, err := fe.normalizationFilters(ctx, job.LocalInputPath())
+	if err != nil {
+		return err
+	}
+
+	// Read the input chapters to force keyframes at their starts, the frame
+	// rate to derive the keyframe interval from the segment duration and to
+	// detect variable frame rate input, and the audio streams to encode one
+	// rendition per language
+	probeVideo := (fe.config.AlignKeyframesToChapters || fe.config.HLS.AutoKeyframeInterval || fe.config.ConvertVFRtoCFR) && !fe.audioOnly
+	if probeVideo || fe.multiLanguageAudio(job) {
+		info, err := fe.Probe(ctx, job.LocalInputPath())
+		if err != nil {
+			return fmt.Errorf("failed to probe input: %w", err)
+		}
+		if err := recordStreamMetadata(job, info); err != nil {
+			return err
+		}
+		if info.VariableFrameRate {
+			fe.logger.Warn("Input has a variable frame rate",
+				zap.String("job_id", job.ID),
+				zap.Float64("frame_rate", info.FrameRate),
+				zap.Bool("converting", fe.config.ConvertVFRtoCFR))
+		}
+	}
+
+	// Convert to a constant frame rate after the other normalization filters,
+	// as deinterlacing doubles the frame rate
+	if fe.convertsToCFR(job) {
+		normalize = append(normalize, "fps="+strconv.Itoa(fe.config.TargetFPS))
+	}
+
+	// Measure the input loudness for the second loudnorm pass
+	if fe.config.AudioNormalization.Enabled {
+		if err := fe.measureLoudness(ctx, job); err != nil {
+			return fmt.Errorf("failed to measure input loudness: %w", err)
+		}
+	}
+
 	// Build FFmpeg command
-	args := fe.buildFFmpegArgs(job)
+	ffmpegArgs := fe.buildFFmpegArgs(job, codec, normalize)
+
+	// Report progress on stdout instead of stderr stats
+	args := append([]string{"-progress", "pipe:1", "-nostats"}, ffmpegArgs...)
 
 	// Create command with timeout
-	cmdCtx, cancel := context.WithTimeout(ctx, time.Duration(fe.config.Timeout)*time.Second)
+	cmdCtx, cancel := context.WithTimeout(ctx, time.Duration(fe.timeout.Load())*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, fe.config.ExecutablePath, args...)
+	name, args := fe.withPriority(fe.config.ExecutablePath, args)
+	cmd := exec.CommandContext(cmdCtx, name, args...)
+	setProcessAttributes(cmd)
+
+	// Progress is written to stdout as key=value lines and parsed in a goroutine
+	progress := NewFFmpegProgressParser(onProgress)
+	progressReader, progressWriter := io.Pipe()
+	progressDone := make(chan struct{})
+	recovery.SafeGo(fe.logger, func() {
+		defer close(progressDone)
+		progress.Scan(progressReader)
+	})
 
 	// Set up command output capture
-	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var stderr strings.Builder
+	output := &progressOutput{progress: progressWriter}
+	cmd.Stdout = output
+	cmd.Stderr = io.MultiWriter(&stderr, progress.StderrWriter())
+
+	// Capture full output to the job log file
+	if fe.config.CaptureLog {
+		logFile, err := fe.createJobLog(job.ID)
+		if err != nil {
+			fe.logger.Warn("Failed to create FFmpeg log file", zap.String("job_id", job.ID), zap.Error(err))
+		} else {
+			defer logFile.Close()
+			if err := writeLogCommand(logFile, ffmpegArgs); err != nil {
+				fe.logger.Warn("Failed to write FFmpeg command to log file", zap.String("job_id", job.ID), zap.Error(err))
+			}
+			output.log = logFile
+			cmd.Stderr = io.MultiWriter(&stderr, progress.StderrWriter(), logFile)
+
+			if job.Metadata == nil {
+				job.Metadata = make(map[string]string)
+			}
+			job.Metadata[queue.MetadataFFmpegLogPath] = logFile.Name()
+		}
+	}
 
 	fe.logger.Debug("FFmpeg command",
 		zap.String("executable", fe.config.ExecutablePath),
 		zap.Strings("args", args))
 
 	// Execute command
-	if err := cmd.Run(); err != nil {
+	err = cmd.Run()
+	progressWriter.Close()
+	<-progressDone
+	if cmd.ProcessState != nil {
+		fe.recordResourceUsage(job, codec, cmd.ProcessState)
+	}
+	if fe.breaker != nil && fe.breaker.Record(err) {
+		fe.logger.Error("FFmpeg circuit breaker opened, rejecting jobs until ffmpeg -version succeeds",
+			zap.String("executable", fe.config.ExecutablePath),
+			zap.Error(err))
+	}
+	if err != nil {
 		fe.logger.Error("FFmpeg execution failed",
 			zap.String("job_id", job.ID),
 			zap.Error(err),
-			zap.String("stdout", stdout.String()),
 			zap.String("stderr", stderr.String()))
 		return fmt.Errorf("FFmpeg execution failed: %w (stderr: %s)", err, stderr.String())
 	}
 
+	if fe.fragmentedMP4() {
+		if err := fe.writeDASHManifest(job.OutputPath, codec); err != nil {
+			return fmt.Errorf("failed to write DASH manifest: %w", err)
+		}
+	}
+
 	fe.logger.Info("FFmpeg execution completed",
 		zap.String("job_id", job.ID),
 		zap.String("output_path", job.OutputPath))
@@ -68,17 +230,28 @@ func (fe *FFmpegExecutor) Execute(ctx context.Context, job *queue.Job) error {
 	return nil
 }
 
-// buildFFmpegArgs builds the FFmpeg command arguments
-func (fe *FFmpegExecutor) buildFFmpegArgs(job *queue.Job) []string {
+// buildFFmpegArgs builds the FFmpeg command arguments for the given video codec.
+// The normalize filters are applied to the input before it is split into qualities.
+func (fe *FFmpegExecutor) buildFFmpegArgs(job *queue.Job, codec string, normalize []string) []string {
 	var args []string
 
+	// VAAPI encoders need a device to upload frames to
+	if fe.config.HardwareAccel == "vaapi" {
+		args = append(args, "-vaapi_device", vaapiDevice)
+	}
+
+	// Rotation is corrected by the normalization filters instead
+	if fe.config.Normalization.AutoRotate {
+		args = append(args, "-noautorotate")
+	}
+
 	// Add input file
-	args = append(args, "-i", job.InputPath)
+	args = append(args, "-i", job.LocalInputPath())
 
 	// Build the filter_complex string dynamically
 	var filterComplexParts []string
-	var videoMapParts []string
-	var audioMapParts []string
+	var renditions []hlsRendition
+	keyframes := fe.chapterKeyframes(job)
 
 	// Keep track of the stream labels for video and audio (e.g., [v1out], [v2out], ...)
 	var videoStreamIndex int
@@ -86,59 +259,49 @@ func (fe *FFmpegExecutor) buildFFmpegArgs(job *queue.Job) []string {
 
 	// Build the filter_complex part (for splitting and scaling)
 	for quality := range fe.config.Qualities {
-		if fe.config.Qualities[quality] { // Only process enabled qualities
-			// For each quality, add a split and scale
-			var resolution string
-			var bitrate string
-			switch quality {
-			case "360p":
-				resolution = "854x480"
-				bitrate = "1M"
-			case "480p":
-				resolution = "1280x720"
-				bitrate = "1.5M"
-			case "720p":
-				resolution = "1280x720"
-				bitrate = "3M"
-			case "1080p":
-				resolution = "1920x1080"
-				bitrate = "5M"
-			case "2K":
-				resolution = "2048x1080"
-				bitrate = "7M"
-			case "4K":
-				resolution = "3840x2160"
-				bitrate = "10M"
-			case "8K":
-				resolution = "7680x4320"
-				bitrate = "20M"
-			default:
+		if fe.config.Qualities[quality] && !fe.audioOnly { // Only process enabled qualities
+			// Look up the output size and bitrate for this quality
+			preset, ok := config.LookupQuality(quality)
+			if !ok {
 				// If an unknown quality is found, skip
 				continue
 			}
 
 			// Add scale filter for this quality
+			upload := ""
+			if fe.config.HardwareAccel == "vaapi" {
+				upload = ",format=nv12,hwupload"
+			}
+			input := "[0:v]"
+			if len(normalize) > 0 {
+				input = fmt.Sprintf("[vin%d]", videoStreamIndex)
+			}
 			filterComplexParts = append(filterComplexParts,
-				fmt.Sprintf("[%d:v]scale=w=%s:h=%s[v%dout]", 0, resolution, resolution, videoStreamIndex),
+				fmt.Sprintf("%sscale=w=%d:h=%d%s[v%dout]", input, preset.Width, preset.Height, upload, videoStreamIndex),
 			)
 
-			// Add video mapping for this quality
-			videoMapParts = append(videoMapParts,
-				fmt.Sprintf("-map [v%dout] -c:v:%d libx264 -x264-params \"nal-hrd=cbr:force-cfr=1\" -b:v:%d %s -maxrate:v:%d %s -minrate:v:%d %s -bufsize:v:%d %s -preset slow -g 48 -sc_threshold 0 -keyint_min 48",
-					videoStreamIndex, videoStreamIndex, videoStreamIndex, bitrate, videoStreamIndex, bitrate, videoStreamIndex, bitrate, videoStreamIndex, bitrate),
-			)
+			// Remember the rendition so it can be mapped to its HLS muxer
+			renditions = append(renditions, hlsRendition{
+				quality:   quality,
+				label:     fmt.Sprintf("[v%dout]", videoStreamIndex),
+				bitrate:   preset.Bitrate,
+				keyframes: keyframes,
+				gop:       fe.keyframeInterval(job, quality),
+			})
 
 			// Increment the video stream index
 			videoStreamIndex++
 		}
 	}
 
-	// Add audio mappings (assuming you want to map the same audio for all streams)
-	audioMapParts = append(audioMapParts,
-		"-map a:0 -c:a:0 aac -b:a:0 96k -ac 2",
-		"-map a:0 -c:a:1 aac -b:a:1 96k -ac 2",
-		"-map a:0 -c:a:2 aac -b:a:2 48k -ac 2",
-	)
+	// Normalize the input once and split it between the qualities
+	if len(normalize) > 0 && videoStreamIndex > 0 {
+		split := fmt.Sprintf("[0:v]%s,split=%d", strings.Join(normalize, ","), videoStreamIndex)
+		for i := 0; i < videoStreamIndex; i++ {
+			split += fmt.Sprintf("[vin%d]", i)
+		}
+		filterComplexParts = append([]string{split}, filterComplexParts...)
+	}
 
 	// Combine all parts together
 	if len(filterComplexParts) > 0 {
@@ -146,24 +309,18 @@ func (fe *FFmpegExecutor) buildFFmpegArgs(job *queue.Job) []string {
 		args = append(args, strings.Join(filterComplexParts, "; ")+";")
 	}
 
-	// Add video mapping parts
-	args = append(args, videoMapParts...)
+	// MP4 and MKV files are written next to the output path, one per quality
+	if fe.fileFormats != nil {
+		return append(args, fe.buildFileArgs(codec, renditions, fe.audioTracks(job, codec), fe.loudnormFilter(job), job.OutputPath)...)
+	}
 
-	// Add audio mapping parts
-	args = append(args, audioMapParts...)
+	// Fragmented MP4 renditions are written next to the manifest at the output path
+	if fe.fragmentedMP4() {
+		return append(args, fe.buildMP4Args(codec, renditions, fe.loudnormFilter(job), job.OutputPath)...)
+	}
 
-	// HLS-specific options
-	args = append(args,
-		"-f hls",
-		"-hls_time 2",
-		"-hls_playlist_type vod",
-		"-hls_flags independent_segments",
-		"-hls_segment_type mpegts",
-		"-hls_segment_filename stream_%v/data%02d.ts",
-		"-master_pl_name srota.m3u8",
-		"-var_stream_map \"v:0,a:0 v:1,a:1 v:2,a:2 v:3,a:0 v:4,a:1 v:5,a:2 v:6,a:0 v:7,a:1\"",
-		"stream_%v.m3u8",
-	)
+	// Add stream mappings and HLS muxer options
+	args = append(args, fe.buildHLSArgs(codec, renditions, fe.audioTracks(job, codec), fe.loudnormFilter(job))...)
 
 	// Add output file
 	args = append(args, job.OutputPath)
@@ -171,6 +328,113 @@ func (fe *FFmpegExecutor) buildFFmpegArgs(job *queue.Job) []string {
 	return args
 }
 
+// vaapiDevice is the DRM render node used for VAAPI encoding
+const vaapiDevice = "/dev/dri/renderD128"
+
+// recordResourceUsage stores the CPU time and peak memory of a finished FFmpeg
+// process on the job and in the job stats
+func (fe *FFmpegExecutor) recordResourceUsage(job *queue.Job, codec string, state *os.ProcessState) {
+	usage := metrics.ResourceUsage{
+		UserCPUTime:   state.UserTime(),
+		SystemCPUTime: state.SystemTime(),
+		MaxRSSKB:      maxRSSKB(state),
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataFFmpegUserCPUMs] = strconv.FormatInt(usage.UserCPUTime.Milliseconds(), 10)
+	job.Metadata[queue.MetadataFFmpegSysCPUMs] = strconv.FormatInt(usage.SystemCPUTime.Milliseconds(), 10)
+	if usage.MaxRSSKB > 0 {
+		job.Metadata[queue.MetadataFFmpegMaxRSSKB] = strconv.FormatInt(usage.MaxRSSKB, 10)
+	}
+
+	if fe.stats != nil {
+		fe.stats.OnFFmpegResourceUsage(fe.qualityLabel(), codec, usage)
+	}
+}
+
+// qualityLabel returns the enabled output qualities as a sorted,
+// comma-separated metric label, since one FFmpeg process encodes them all
+func (fe *FFmpegExecutor) qualityLabel() string {
+	var qualities []string
+	for quality, enabled := range fe.config.Qualities {
+		if enabled {
+			qualities = append(qualities, quality)
+		}
+	}
+	sort.Strings(qualities)
+	return strings.Join(qualities, ",")
+}
+
+// hardwareQualityFlags maps hardware encoders to their constant quality option
+var hardwareQualityFlags = map[string]string{
+	"nvenc":        "-cq",
+	"qsv":          "-global_quality",
+	"vaapi":        "-qp",
+	"videotoolbox": "-q",
+}
+
+// defaultGOP is the keyframe interval in frames used unless it is derived
+// from the segment duration, see config.HLSKeyframeIntervalSeconds
+const defaultGOP = 48
+
+// videoEncoderArgs returns the encoder options for one output video stream,
+// with a keyframe every gop frames or defaultGOP when gop is 0
+func (fe *FFmpegExecutor) videoEncoderArgs(codec string, index int, bitrate string, gop int) string {
+	if gop <= 0 {
+		gop = defaultGOP
+	}
+	spec := config.VideoCodecs[codec]
+	crf := fe.config.CRF
+	if crf == 0 {
+		crf = spec.DefaultCRF
+	}
+
+	// Hardware encoders use their own quality scale, capped at the bitrate
+	if fe.config.HardwareAccel != "" {
+		encoder := config.HardwareEncoders[fe.config.HardwareAccel][codec]
+		return fmt.Sprintf("-c:v:%d %s %s:v:%d %d -maxrate:v:%d %s -bufsize:v:%d %s -g %d -keyint_min %d",
+			index, encoder, hardwareQualityFlags[fe.config.HardwareAccel], index, crf, index, bitrate, index, bitrate, gop, gop)
+	}
+
+	// Lossless output ignores the bitrate caps
+	if fe.lossless {
+		switch codec {
+		case "h265":
+			return fmt.Sprintf("-c:v:%d %s -x265-params \"lossless=1:keyint=%d:min-keyint=%d:scenecut=0\" -preset slow -tag:v:%d hvc1",
+				index, spec.Encoder, gop, gop, index)
+		case "h264":
+			return fmt.Sprintf("-c:v:%d %s -qp:v:%d 0 -preset slow -g %d -sc_threshold 0 -keyint_min %d",
+				index, spec.Encoder, index, gop, gop)
+		}
+	}
+
+	switch codec {
+	case "h265":
+		return fmt.Sprintf("-c:v:%d %s -x265-params \"keyint=%d:min-keyint=%d:scenecut=0\" -crf:v:%d %d -maxrate:v:%d %s -bufsize:v:%d %s -preset slow -tag:v:%d hvc1",
+			index, spec.Encoder, gop, gop, index, crf, index, bitrate, index, bitrate, index)
+	case "vp9":
+		return fmt.Sprintf("-c:v:%d %s -crf:v:%d %d -b:v:%d %s -row-mt 1 -g %d -keyint_min %d",
+			index, spec.Encoder, index, crf, index, bitrate, gop, gop)
+	case "av1":
+		return fmt.Sprintf("-c:v:%d %s -crf:v:%d %d -b:v:%d %s -cpu-used 4 -row-mt 1 -g %d -keyint_min %d",
+			index, spec.Encoder, index, crf, index, bitrate, gop, gop)
+	default:
+		return fmt.Sprintf("-c:v:%d %s -x264-params \"force-cfr=1\" -crf:v:%d %d -maxrate:v:%d %s -bufsize:v:%d %s -preset slow -g %d -sc_threshold 0 -keyint_min %d",
+			index, spec.Encoder, index, crf, index, bitrate, index, bitrate, gop, gop)
+	}
+}
+
+// hlsSegmentType returns the HLS segment container for a codec; only H.264
+// is carried in MPEG-TS, the other codecs require fragmented MP4
+func hlsSegmentType(codec string) string {
+	if codec == "h264" {
+		return "mpegts"
+	}
+	return "fmp4"
+}
+
 // Validate checks if FFmpeg is available and working
 func (fe *FFmpegExecutor) Validate() error {
 	cmd := exec.Command(fe.config.ExecutablePath, "-version")
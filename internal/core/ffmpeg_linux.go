@@ -0,0 +1,47 @@
+//go:build linux
+
+package core
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// withPriority wraps the FFmpeg command with nice and ionice as configured
+func (fe *FFmpegExecutor) withPriority(name string, args []string) (string, []string) {
+	if fe.config.UseIonice {
+		args = append([]string{"-c3", name}, args...)
+		name = "ionice"
+	}
+
+	if fe.config.NicePriority > 0 {
+		args = append([]string{"-n", strconv.Itoa(fe.config.NicePriority), name}, args...)
+		name = "nice"
+	}
+
+	return name, args
+}
+
+// setProcessAttributes kills FFmpeg if the server process dies, and starts
+// it in its own process group so cancelling the job also kills the processes
+// it runs under, such as nice and ionice
+func setProcessAttributes(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pdeathsig: syscall.SIGKILL,
+		Setpgid:   true,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// maxRSSKB returns the peak resident set size of a finished process in
+// kilobytes, which is the unit Linux reports it in
+func maxRSSKB(state *os.ProcessState) int64 {
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return rusage.Maxrss
+	}
+	return 0
+}
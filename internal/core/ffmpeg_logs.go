@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/ffmpeg"
+	"go.uber.org/zap"
+)
+
+// logCleanupInterval is how often expired FFmpeg logs are removed
+const logCleanupInterval = 1 * time.Hour
+
+// logCommandPrefix starts the first line of a job log, which records the
+// FFmpeg arguments as a JSON array so the job can be replayed
+const logCommandPrefix = "# ffmpeg command: "
+
+// ErrNoLoggedCommand is returned for job logs that do not record the FFmpeg
+// command, such as logs written by older versions
+var ErrNoLoggedCommand = errors.New("FFmpeg log does not record the command")
+
+// writeLogCommand writes the FFmpeg arguments as the header of a job log
+func writeLogCommand(w io.Writer, args []string) error {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", logCommandPrefix, encoded)
+	return err
+}
+
+// ParseLogCommand returns the FFmpeg arguments recorded at the top of a job
+// log, without the progress reporting and process priority options
+func ParseLogCommand(r io.Reader) ([]string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(line), logCommandPrefix)
+	if !ok {
+		return nil, ErrNoLoggedCommand
+	}
+
+	var args []string
+	if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+		return nil, fmt.Errorf("invalid FFmpeg command in log: %w", err)
+	}
+	return args, nil
+}
+
+// createJobLog creates the FFmpeg log file for a job
+func (fe *FFmpegExecutor) createJobLog(jobID string) (*os.File, error) {
+	path, err := ffmpeg.JobLogPath(fe.logDir, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(fe.logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	return file, nil
+}
+
+// RunLogCleanup periodically deletes FFmpeg logs older than the retention period
+func (fe *FFmpegExecutor) RunLogCleanup(ctx context.Context) {
+	if fe.config.LogRetentionHours <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(logCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		fe.cleanupLogs(time.Duration(fe.config.LogRetentionHours) * time.Hour)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanupLogs deletes FFmpeg logs older than maxAge
+func (fe *FFmpegExecutor) cleanupLogs(maxAge time.Duration) {
+	entries, err := os.ReadDir(fe.logDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fe.logger.Warn("Failed to read FFmpeg log directory", zap.Error(err))
+		}
+		return
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_ffmpeg.log") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(fe.logDir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			fe.logger.Warn("Failed to delete FFmpeg log", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		fe.logger.Debug("Deleted expired FFmpeg log", zap.String("path", path))
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package core
+
+import (
+	"os"
+	"os/exec"
+)
+
+// withPriority returns the FFmpeg command unchanged; nice and ionice are only applied on Linux
+func (fe *FFmpegExecutor) withPriority(name string, args []string) (string, []string) {
+	return name, args
+}
+
+// setProcessAttributes is a no-op outside Linux
+func setProcessAttributes(cmd *exec.Cmd) {}
+
+// maxRSSKB returns 0 outside Linux, where peak memory is not reported in kilobytes
+func maxRSSKB(state *os.ProcessState) int64 {
+	return 0
+}
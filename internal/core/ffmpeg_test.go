@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// vfrProbeOutput is ffprobe output for a variable frame rate input
+const vfrProbeOutput = `{
+  "format": {"format_name": "mov,mp4", "duration": "10.0", "size": "1000"},
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720,
+     "avg_frame_rate": "2997/125", "r_frame_rate": "30/1"}
+  ]
+}`
+
+// fakeFFmpeg installs ffmpeg and ffprobe scripts in a temporary directory and
+// returns an FFmpeg configuration using them. ffprobe prints probeOutput,
+// ffmpeg writes its arguments one per line to the returned file and exits
+// with exitCode.
+func fakeFFmpeg(t testing.TB, probeOutput string, exitCode int) (config.FFmpegConfig, string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+
+	scripts := map[string]string{
+		"ffprobe": "#!/bin/sh\ncat <<'EOF'\n" + probeOutput + "\nEOF\n",
+		"ffmpeg":  "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\nexit " + strconv.Itoa(exitCode) + "\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.DefaultConfig().FFmpeg
+	cfg.ExecutablePath = filepath.Join(dir, "ffmpeg")
+	cfg.CaptureLog = false
+	return cfg, argsFile
+}
+
+// readArgs returns the arguments the fake ffmpeg was last run with
+func readArgs(t testing.TB, argsFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("ffmpeg was not run: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func newTestJob(t testing.TB) *queue.Job {
+	t.Helper()
+	return &queue.Job{
+		ID:         "job-1",
+		InputPath:  filepath.Join(t.TempDir(), "input.mp4"),
+		OutputPath: filepath.Join(t.TempDir(), "output"),
+	}
+}
+
+func TestFFmpegExecutorThreadsPerJob(t *testing.T) {
+	cfg, argsFile := fakeFFmpeg(t, "{}", 0)
+	cfg.ThreadsPerJob = 2
+	cfg.Qualities = map[string]bool{"360p": true, "720p": true}
+
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+	if err := fe.Execute(context.Background(), newTestJob(t), nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	command := strings.Join(readArgs(t, argsFile), " ")
+	input := strings.Index(command, "-i ")
+	if input < 0 {
+		t.Fatalf("command has no input: %s", command)
+	}
+	if strings.Contains(command[:input], "-threads") {
+		t.Errorf("-threads is an input option, limiting only the decoder: %s", command)
+	}
+	for _, want := range []string{"-threads:v:0 2", "-threads:v:1 2"} {
+		if !strings.Contains(command[input:], want) {
+			t.Errorf("command does not limit the encoder with %q: %s", want, command)
+		}
+	}
+}
+
+func TestFFmpegExecutorLogsVariableFrameRate(t *testing.T) {
+	cfg, _ := fakeFFmpeg(t, vfrProbeOutput, 0)
+	cfg.ConvertVFRtoCFR = true
+
+	core, logs := observer.New(zap.WarnLevel)
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.New(core))
+
+	if err := fe.Execute(context.Background(), newTestJob(t), nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	entries := logs.FilterMessage("Input has a variable frame rate").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d variable frame rate warnings, want 1", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["job_id"] != "job-1" || fields["converting"] != true {
+		t.Errorf("warning fields = %v, want job_id job-1 and converting true", fields)
+	}
+}
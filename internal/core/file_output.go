@@ -0,0 +1,48 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// fileMuxers maps each per-quality container to its FFmpeg muxer and the
+// options written before the output file
+var fileMuxers = map[string]struct {
+	extension string
+	args      []string
+}{
+	config.QualityFormatMP4: {".mp4", []string{"-f mp4", "-movflags +faststart"}},
+	config.QualityFormatMKV: {".mkv", []string{"-f matroska"}},
+}
+
+// qualityFilePath returns the file a quality is written to, next to the job
+// output path, e.g. movie_720p.mkv
+func qualityFilePath(outputPath, quality, extension string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_" + quality + extension
+}
+
+// buildFileArgs returns one output per rendition in the container chosen for
+// its quality, each with the video stream and every audio track
+func (fe *FFmpegExecutor) buildFileArgs(codec string, renditions []hlsRendition, tracks []queue.AudioTrackConfig, audioFilter, outputPath string) []string {
+	var args []string
+	for _, r := range renditions {
+		muxer := fileMuxers[fe.fileFormats[r.quality]]
+
+		args = append(args, fmt.Sprintf("-map %s %s", r.label, fe.videoEncoderArgs(codec, 0, r.bitrate, r.gop)+forceKeyFramesArgs(0, r.keyframes)))
+		for i, track := range tracks {
+			args = append(args, audioTrackArgs(i, track))
+		}
+		if audioFilter != "" {
+			args = append(args, "-filter:a", audioFilter)
+		}
+
+		args = append(args, muxer.args...)
+		args = append(args, qualityFilePath(outputPath, r.quality, muxer.extension))
+	}
+
+	return args
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+func TestBuildFileArgs(t *testing.T) {
+	job := &queue.Job{ID: "job-1", InputPath: "in.mp4", OutputPath: "/out/movie"}
+	if err := job.SetQualityOutputFormats(map[string]string{"720p": "mp4", "360p": "mkv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The job's qualities replace the configured ones
+	command := commandLine(newTestExecutor("480p"), job)
+	for _, want := range []string{
+		"-f mp4 -movflags +faststart /out/movie_720p.mp4",
+		"-f matroska /out/movie_360p.mkv",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+	for _, unwanted := range []string{"-f hls", "movie_480p", "scale=w=854"} {
+		if strings.Contains(command, unwanted) {
+			t.Errorf("command contains %q:\n%s", unwanted, command)
+		}
+	}
+	// Each file carries the audio track
+	if n := strings.Count(command, "-c:a:0"); n != 2 {
+		t.Errorf("command has %d audio tracks, want one per file:\n%s", n, command)
+	}
+}
+
+func TestExecuteRejectsAudioOnlyFileOutput(t *testing.T) {
+	cfg, _ := fakeFFmpeg(t, "{}", 0)
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+
+	job := newTestJob(t)
+	job.Metadata = map[string]string{queue.MetadataOutputFormat: config.OutputFormatAudio}
+	if err := job.SetQualityOutputFormats(map[string]string{"720p": "mp4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fe.Execute(context.Background(), job, nil)
+	if err == nil || !strings.Contains(err.Error(), "audio-only") {
+		t.Errorf("Execute() error = %v, want the audio-only conflict", err)
+	}
+}
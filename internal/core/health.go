@@ -0,0 +1,162 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/preflight"
+	"go.uber.org/zap"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckInterval is how often the queue and storage health is refreshed
+const healthCheckInterval = 15 * time.Second
+
+// mainHealthService is the health check name of the job processing API
+const mainHealthService = "flixsrota.VideoProcessor"
+
+// degradedAfterFailures is how many consecutive failed connection tests of
+// the queue or storage mark the server as degraded
+const degradedAfterFailures = 3
+
+// queueHealthService returns the health check name of the queue adapter,
+// e.g. flixsrota.queue.redis
+func (s *Server) queueHealthService() string {
+	return "flixsrota.queue." + s.config.Queue.Adapter
+}
+
+// storageHealthService returns the health check name of the storage adapter,
+// e.g. flixsrota.storage.local
+func (s *Server) storageHealthService() string {
+	return "flixsrota.storage." + s.config.Storage.Adapter
+}
+
+// updateHealth tests the queue and storage connections and publishes their
+// status on the health service and in Prometheus. The server is degraded,
+// and the main service not serving, while either has failed
+// degradedAfterFailures tests in a row. The overall server status is serving
+// as long as the process runs.
+func (s *Server) updateHealth() {
+	results := preflight.Run(s.ctx, []preflight.Check{
+		preflight.QueueCheck(s.queue),
+		preflight.StorageCheck(s.storage),
+	})
+	// Tests fail once the server stops, keep the last status
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	statuses := map[string]bool{"": true}
+	degraded := false
+
+	s.healthMu.Lock()
+	for i, service := range []string{s.queueHealthService(), s.storageHealthService()} {
+		statuses[service] = results[i].Passed
+		metrics.SetConnectionTest(results[i].Name, results[i].Passed, results[i].Duration)
+
+		if results[i].Passed {
+			s.healthFailures[service] = 0
+			continue
+		}
+		s.healthFailures[service]++
+		if s.healthFailures[service] >= degradedAfterFailures {
+			degraded = true
+		}
+		s.logger.Warn("Health check failed",
+			zap.String("service", service),
+			zap.String("message", results[i].Message),
+			zap.Int("consecutive_failures", s.healthFailures[service]))
+	}
+	wasDegraded := s.degraded
+	s.degraded = degraded
+	s.healthResults = results
+	s.healthMu.Unlock()
+
+	if degraded && !wasDegraded {
+		s.logger.Error("Server degraded, queue or storage unreachable", zap.Int("consecutive_failures", degradedAfterFailures))
+	} else if !degraded && wasDegraded {
+		s.logger.Info("Server recovered from degraded state")
+	}
+	metrics.SetDegraded(degraded)
+	statuses[mainHealthService] = !degraded
+
+	for service, serving := range statuses {
+		status := healthgrpc.HealthCheckResponse_SERVING
+		if !serving {
+			status = healthgrpc.HealthCheckResponse_NOT_SERVING
+		}
+		s.healthServer.SetServingStatus(service, status)
+		if service != "" {
+			metrics.SetHealthStatus(service, serving)
+		}
+	}
+}
+
+// watchHealth refreshes the health status every health check interval
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.updateHealth()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readinessCheck is the latest connection test of a dependency
+type readinessCheck struct {
+	Name                string `json:"name"`
+	Passed              bool   `json:"passed"`
+	Message             string `json:"message"`
+	LatencyMs           int64  `json:"latency_ms"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+}
+
+// readinessResponse is the body of the readiness endpoint
+type readinessResponse struct {
+	Status string           `json:"status"`
+	Checks []readinessCheck `json:"checks"`
+}
+
+// serveReadiness reports the latest queue and storage connection tests. It
+// responds 503 until the first tests complete and while the server is
+// degraded.
+func (s *Server) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.healthMu.RLock()
+	resp := readinessResponse{Status: "ready", Checks: []readinessCheck{}}
+	services := []string{s.queueHealthService(), s.storageHealthService()}
+	for i, result := range s.healthResults {
+		resp.Checks = append(resp.Checks, readinessCheck{
+			Name:                result.Name,
+			Passed:              result.Passed,
+			Message:             result.Message,
+			LatencyMs:           result.Duration.Milliseconds(),
+			ConsecutiveFailures: s.healthFailures[services[i]],
+		})
+	}
+	switch {
+	case s.healthResults == nil:
+		resp.Status = "starting"
+	case s.degraded:
+		resp.Status = "degraded"
+	}
+	s.healthMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// unreachableQueue fails every depth check, like a queue whose backend is down
+type unreachableQueue struct {
+	queue.Queue
+}
+
+func (q unreachableQueue) GetQueueDepth(ctx context.Context) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+// newHealthTestServer returns a server with a memory queue and local storage
+func newHealthTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := storage.NewLocalStorage(dir, filepath.Join(dir, "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Queue.Adapter = "memory"
+	cfg.Storage.Adapter = "local"
+
+	return &Server{
+		config:         cfg,
+		logger:         zap.NewNop(),
+		healthServer:   health.NewServer(),
+		healthFailures: make(map[string]int),
+		queue:          queue.NewMemoryQueue(),
+		storage:        store,
+		ctx:            context.Background(),
+	}
+}
+
+func TestServerUpdateHealth(t *testing.T) {
+	s := newHealthTestServer(t)
+
+	check := func(service string, want healthgrpc.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		resp, err := s.healthServer.Check(context.Background(), &healthgrpc.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) error = %v", service, err)
+		}
+		if resp.Status != want {
+			t.Errorf("Check(%q) = %v, want %v", service, resp.Status, want)
+		}
+	}
+
+	s.updateHealth()
+	check("", healthgrpc.HealthCheckResponse_SERVING)
+	check(mainHealthService, healthgrpc.HealthCheckResponse_SERVING)
+	check("flixsrota.queue.memory", healthgrpc.HealthCheckResponse_SERVING)
+	check("flixsrota.storage.local", healthgrpc.HealthCheckResponse_SERVING)
+
+	// A failing queue is reported at once, the server is degraded after
+	// degradedAfterFailures failures in a row
+	healthy := s.queue
+	s.queue = unreachableQueue{healthy}
+	for i := 1; i < degradedAfterFailures; i++ {
+		s.updateHealth()
+	}
+	check("flixsrota.queue.memory", healthgrpc.HealthCheckResponse_NOT_SERVING)
+	check("flixsrota.storage.local", healthgrpc.HealthCheckResponse_SERVING)
+	check(mainHealthService, healthgrpc.HealthCheckResponse_SERVING)
+
+	s.updateHealth()
+	check("", healthgrpc.HealthCheckResponse_SERVING)
+	check(mainHealthService, healthgrpc.HealthCheckResponse_NOT_SERVING)
+
+	s.queue = healthy
+	s.updateHealth()
+	check(mainHealthService, healthgrpc.HealthCheckResponse_SERVING)
+	check("flixsrota.queue.memory", healthgrpc.HealthCheckResponse_SERVING)
+
+	s.healthServer.Shutdown()
+	check(mainHealthService, healthgrpc.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestServerReadiness(t *testing.T) {
+	s := newHealthTestServer(t)
+
+	ready := func() (int, readinessResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.serveReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var resp readinessResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode readiness response: %v", err)
+		}
+		return rec.Code, resp
+	}
+
+	if code, resp := ready(); code != http.StatusServiceUnavailable || resp.Status != "starting" {
+		t.Errorf("readiness before the first check = %d %+v, want 503 starting", code, resp)
+	}
+
+	s.updateHealth()
+	if code, resp := ready(); code != http.StatusOK || resp.Status != "ready" || len(resp.Checks) != 2 {
+		t.Errorf("readiness = %d %+v, want 200 ready with two checks", code, resp)
+	}
+
+	s.queue = unreachableQueue{s.queue}
+	for i := 0; i < degradedAfterFailures; i++ {
+		s.updateHealth()
+	}
+	code, resp := ready()
+	if code != http.StatusServiceUnavailable || resp.Status != "degraded" {
+		t.Errorf("readiness = %d %+v, want 503 degraded", code, resp)
+	}
+	if len(resp.Checks) == 2 && (resp.Checks[0].Passed || resp.Checks[0].ConsecutiveFailures != degradedAfterFailures) {
+		t.Errorf("queue check = %+v, want failed %d times in a row", resp.Checks[0], degradedAfterFailures)
+	}
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// hlsRendition is a scaled video stream that becomes one HLS variant
+type hlsRendition struct {
+	quality string
+	label   string
+	bitrate string
+	// keyframes lists the times keyframes are forced at, see chapterKeyframes
+	keyframes string
+	// gop is the keyframe interval in frames, 0 for the default
+	gop int
+}
+
+// keyframeInterval returns the keyframe interval in frames that makes every
+// HLS segment of a quality start on a keyframe, round(segment duration × fps)
+// of the probed input frame rate, or of the target frame rate of inputs
+// converted to a constant frame rate. It returns 0, the default interval, unless
+// hls.auto_keyframe_interval is set and the output is HLS.
+func (fe *FFmpegExecutor) keyframeInterval(job *queue.Job, quality string) int {
+	if !fe.config.HLS.AutoKeyframeInterval || fe.fragmentedMP4() || fe.fileFormats != nil {
+		return 0
+	}
+	fps, err := strconv.ParseFloat(job.Metadata[queue.MetadataSourceFrameRate], 64)
+	if fe.convertsToCFR(job) {
+		fps, err = float64(fe.config.TargetFPS), nil
+	}
+	if err != nil || fps <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(fe.config.HLS.SegmentDurationFor(quality)) * fps))
+}
+
+// defaultAudioBitrate is the bitrate of the stereo track encoded for jobs
+// without audio tracks
+const defaultAudioBitrate = "128k"
+
+// hlsAudioGroup is the HLS rendition group holding the audio tracks, which
+// every video variant refers to
+const hlsAudioGroup = "audio"
+
+// audioTracks returns the audio tracks requested for the job. When none were
+// requested it returns a stereo track in the codec's audio encoder for every
+// input audio stream if the job encodes multiple languages, and otherwise a
+// single one for the first stream.
+func (fe *FFmpegExecutor) audioTracks(job *queue.Job, codec string) []queue.AudioTrackConfig {
+	if tracks := job.AudioTracks(); len(tracks) > 0 {
+		return tracks
+	}
+
+	count := 1
+	if fe.multiLanguageAudio(job) && job.AudioTrackCount() > 1 {
+		count = job.AudioTrackCount()
+	}
+
+	var probed []string
+	if data := job.Metadata[queue.MetadataAudioLanguages]; data != "" {
+		_ = json.Unmarshal([]byte(data), &probed)
+	}
+	languages := job.AudioLanguageMap()
+
+	tracks := make([]queue.AudioTrackConfig, count)
+	for n := range tracks {
+		tracks[n] = queue.AudioTrackConfig{
+			Codec:    config.VideoCodecs[codec].AudioCodec,
+			Bitrate:  defaultAudioBitrate,
+			Channels: 2,
+			Stream:   n,
+		}
+		if count == 1 {
+			continue
+		}
+		if language, ok := languages[strconv.Itoa(n)]; ok {
+			tracks[n].Language = language
+		} else if n < len(probed) {
+			tracks[n].Language = probed[n]
+		}
+	}
+	return tracks
+}
+
+// multiLanguageAudio reports whether every input audio stream of the job is
+// encoded as its own track
+func (fe *FFmpegExecutor) multiLanguageAudio(job *queue.Job) bool {
+	return fe.config.MultiLanguageAudio || len(job.AudioLanguageMap()) > 0
+}
+
+// audioTrackArgs returns the mapping and encoder options of the nth audio
+// track of an output
+func audioTrackArgs(n int, track queue.AudioTrackConfig) string {
+	args := fmt.Sprintf("-map a:%d -c:a:%d %s", track.Stream, n, config.AudioEncoder(track.Codec))
+	if track.Bitrate != "" {
+		args += fmt.Sprintf(" -b:a:%d %s", n, track.Bitrate)
+	}
+	if track.Channels > 0 {
+		args += fmt.Sprintf(" -ac:a:%d %d", n, track.Channels)
+	}
+	if track.Language != "" {
+		args += fmt.Sprintf(" -metadata:s:a:%d language=%s", n, track.Language)
+	}
+	return args
+}
+
+// audioRenditionNames returns the HLS rendition name of each track: its
+// language, with the track number appended when several tracks share it, or
+// "" for tracks without a language, which keep their variant index. The name
+// also replaces %v in the track's playlist and segment file names.
+func audioRenditionNames(tracks []queue.AudioTrackConfig) []string {
+	uses := make(map[string]int)
+	for _, track := range tracks {
+		uses[track.Language]++
+	}
+
+	names := make([]string, len(tracks))
+	for i, track := range tracks {
+		switch {
+		case track.Language == "":
+		case uses[track.Language] > 1:
+			names[i] = fmt.Sprintf("%s_%d", track.Language, i)
+		default:
+			names[i] = track.Language
+		}
+	}
+	return names
+}
+
+// buildHLSArgs returns the stream mappings and HLS muxer options for the
+// renditions and audio tracks, applying audioFilter to the audio tracks if set. The hls
+// muxer only supports one segment duration, so when qualities are configured
+// with different durations they are grouped and each group gets its own
+// muxer and master playlist (srota_<N>s.m3u8).
+func (fe *FFmpegExecutor) buildHLSArgs(codec string, renditions []hlsRendition, tracks []queue.AudioTrackConfig, audioFilter string) []string {
+	groups := make(map[int][]hlsRendition)
+	for _, r := range renditions {
+		d := fe.config.HLS.SegmentDurationFor(r.quality)
+		groups[d] = append(groups[d], r)
+	}
+
+	if len(groups) <= 1 {
+		duration := fe.config.HLS.SegmentDuration
+		for d := range groups {
+			duration = d
+		}
+		return fe.hlsMuxerArgs(codec, renditions, tracks, audioFilter, duration, "")
+	}
+
+	durations := make([]int, 0, len(groups))
+	for d := range groups {
+		durations = append(durations, d)
+	}
+	sort.Ints(durations)
+
+	var args []string
+	for _, d := range durations {
+		args = append(args, fe.hlsMuxerArgs(codec, groups[d], tracks, audioFilter, d, fmt.Sprintf("_%ds", d))...)
+	}
+	return args
+}
+
+// hlsMuxerArgs returns the mappings and options for a single HLS muxer. The
+// suffix keeps segment and playlist names apart when several muxers write to
+// the same directory.
+func (fe *FFmpegExecutor) hlsMuxerArgs(codec string, renditions []hlsRendition, tracks []queue.AudioTrackConfig, audioFilter string, duration int, suffix string) []string {
+	var args []string
+
+	// Add video mappings, numbered per muxer
+	for i, r := range renditions {
+		args = append(args, fmt.Sprintf("-map %s %s", r.label, fe.videoEncoderArgs(codec, i, r.bitrate, r.gop)+forceKeyFramesArgs(i, r.keyframes)))
+
+		// Threads are limited per encoder, as -threads before -i only
+		// applies to the decoder
+		if fe.config.ThreadsPerJob > 0 {
+			args = append(args, fmt.Sprintf("-threads:v:%d %d", i, fe.config.ThreadsPerJob))
+		}
+	}
+
+	// Add audio mappings, one per track
+	for i, track := range tracks {
+		args = append(args, audioTrackArgs(i, track))
+	}
+	if audioFilter != "" {
+		args = append(args, "-filter:a", audioFilter)
+	}
+
+	// Audio-only output has one variant per track. Otherwise the tracks form
+	// an audio group that every video variant can switch between.
+	var streamMap []string
+	names := audioRenditionNames(tracks)
+	for i, track := range tracks {
+		entry := fmt.Sprintf("a:%d", i)
+		if len(renditions) > 0 {
+			entry += ",agroup:" + hlsAudioGroup
+			if track.Language != "" {
+				entry += ",language:" + track.Language
+			}
+		}
+		if names[i] != "" {
+			entry += ",name:" + names[i]
+		}
+		streamMap = append(streamMap, entry)
+	}
+	for i := range renditions {
+		streamMap = append(streamMap, fmt.Sprintf("v:%d,agroup:%s", i, hlsAudioGroup))
+	}
+
+	// HLS-specific options
+	args = append(args,
+		"-f hls",
+		fmt.Sprintf("-hls_time %d", duration),
+		"-hls_playlist_type vod",
+		"-hls_flags independent_segments",
+		"-hls_segment_type "+hlsSegmentType(codec),
+		"-hls_segment_filename "+variantName(fe.config.HLS.SegmentPattern, suffix),
+		"-master_pl_name "+masterPlaylistName(fe.config.HLS.MasterPlaylistName, suffix),
+		fmt.Sprintf("-var_stream_map \"%s\"", strings.Join(streamMap, " ")),
+		variantName(fe.config.HLS.VariantPlaylistName, suffix),
+	)
+
+	return args
+}
+
+// variantName applies a muxer suffix to a segment or variant playlist
+// pattern by appending it to the variant index, e.g. stream_%v_6s/data%02d.ts
+func variantName(pattern, suffix string) string {
+	return strings.Replace(pattern, "%v", "%v"+suffix, 1)
+}
+
+// masterPlaylistName applies a muxer suffix to the master playlist name
+// before its extension, e.g. srota_6s.m3u8
+func masterPlaylistName(name, suffix string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + suffix + ext
+}
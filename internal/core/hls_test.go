@@ -0,0 +1,176 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// newTestExecutor returns an executor encoding the given qualities
+func newTestExecutor(qualities ...string) *FFmpegExecutor {
+	cfg := config.DefaultConfig().FFmpeg
+	cfg.Qualities = make(map[string]bool)
+	for _, quality := range qualities {
+		cfg.Qualities[quality] = true
+	}
+	return NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+}
+
+// commandLine returns the FFmpeg arguments built for a job as one string
+func commandLine(fe *FFmpegExecutor, job *queue.Job) string {
+	return strings.Join(fe.forJob(job).buildFFmpegArgs(job, "h264", nil), " ")
+}
+
+func TestBuildHLSArgsAudioTracks(t *testing.T) {
+	job := &queue.Job{ID: "job-1", InputPath: "in.mp4", OutputPath: "out"}
+	err := job.SetAudioTracks([]queue.AudioTrackConfig{
+		{Codec: "aac", Bitrate: "128k", Channels: 2, Language: "en"},
+		{Codec: "eac3", Bitrate: "384k", Channels: 6, Language: "fr"},
+	})
+	if err != nil {
+		t.Fatalf("SetAudioTracks() error = %v", err)
+	}
+
+	command := commandLine(newTestExecutor("360p", "720p"), job)
+	for _, want := range []string{
+		"-map a:0 -c:a:0 aac -b:a:0 128k -ac:a:0 2 -metadata:s:a:0 language=en",
+		"-map a:0 -c:a:1 eac3 -b:a:1 384k -ac:a:1 6 -metadata:s:a:1 language=fr",
+		`-var_stream_map "a:0,agroup:audio,language:en,name:en a:1,agroup:audio,language:fr,name:fr v:0,agroup:audio v:1,agroup:audio"`,
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+}
+
+func TestBuildHLSArgsDefaultAudioTrack(t *testing.T) {
+	job := &queue.Job{ID: "job-1", InputPath: "in.mp4", OutputPath: "out"}
+
+	command := commandLine(newTestExecutor("360p", "480p", "720p", "1080p"), job)
+	if !strings.Contains(command, "-map a:0 -c:a:0 aac -b:a:0 128k -ac:a:0 2") {
+		t.Errorf("command has no default stereo track:\n%s", command)
+	}
+	if strings.Contains(command, "-c:a:1") {
+		t.Errorf("command has more than one audio track:\n%s", command)
+	}
+	// Every video variant shares the one audio track, however many qualities
+	// there are
+	if !strings.Contains(command, `-var_stream_map "a:0,agroup:audio v:0,agroup:audio v:1,agroup:audio v:2,agroup:audio v:3,agroup:audio"`) {
+		t.Errorf("command does not put the audio track in a shared group:\n%s", command)
+	}
+}
+
+func TestBuildHLSArgsNames(t *testing.T) {
+	job := &queue.Job{ID: "job-1", InputPath: "in.mp4", OutputPath: "out"}
+
+	fe := newTestExecutor("360p", "720p")
+	fe.config.HLS.SegmentPattern = "segments/%v/%05d.ts"
+	fe.config.HLS.MasterPlaylistName = "master.m3u8"
+	fe.config.HLS.VariantPlaylistName = "playlists/%v.m3u8"
+
+	command := commandLine(fe, job)
+	for _, want := range []string{
+		"-hls_segment_filename segments/%v/%05d.ts",
+		"-master_pl_name master.m3u8",
+		"playlists/%v.m3u8 out",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+
+	// Muxers for different segment durations add their suffix after %v
+	fe.config.HLS.SegmentDurationByQuality = map[string]int{"720p": 6}
+	command = commandLine(fe, job)
+	for _, want := range []string{
+		"-hls_segment_filename segments/%v_2s/%05d.ts",
+		"-master_pl_name master_2s.m3u8",
+		"playlists/%v_2s.m3u8",
+		"-hls_segment_filename segments/%v_6s/%05d.ts",
+		"-master_pl_name master_6s.m3u8",
+		"playlists/%v_6s.m3u8",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+}
+
+func TestBuildHLSArgsAutoKeyframeInterval(t *testing.T) {
+	fe := newTestExecutor("360p", "720p")
+	fe.config.HLS.AutoKeyframeInterval = true
+	fe.config.HLS.SegmentDuration = 5
+	fe.config.HLS.SegmentDurationByQuality = map[string]int{"720p": 3}
+
+	job := &queue.Job{ID: "job-1", InputPath: "in.mp4", OutputPath: "out",
+		Metadata: map[string]string{queue.MetadataSourceFrameRate: "29.97"}}
+
+	// round(5s × 29.97) for 360p and round(3s × 29.97) for 720p
+	command := commandLine(fe, job)
+	for _, want := range []string{"-g 150 -sc_threshold 0 -keyint_min 150", "-g 90 -sc_threshold 0 -keyint_min 90"} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+
+	h265 := strings.Join(fe.forJob(job).buildFFmpegArgs(job, "h265", nil), " ")
+	if !strings.Contains(h265, "keyint=150:min-keyint=150") {
+		t.Errorf("h265 command does not derive keyint from the segment duration:\n%s", h265)
+	}
+
+	// Without a probed frame rate, or with the option off, the default is kept
+	delete(job.Metadata, queue.MetadataSourceFrameRate)
+	if command := commandLine(fe, job); !strings.Contains(command, "-g 48 ") || strings.Contains(command, "-g 150") {
+		t.Errorf("command without a frame rate does not use the default interval:\n%s", command)
+	}
+	job.Metadata[queue.MetadataSourceFrameRate] = "25"
+	fe.config.HLS.AutoKeyframeInterval = false
+	if got := fe.keyframeInterval(job, "360p"); got != 0 {
+		t.Errorf("keyframeInterval() = %d with auto_keyframe_interval off, want 0", got)
+	}
+}
+
+func TestBuildHLSArgsMultiLanguageAudio(t *testing.T) {
+	fe := newTestExecutor("720p")
+	fe.config.MultiLanguageAudio = true
+	job := &queue.Job{ID: "job-1", InputPath: "in.mkv", OutputPath: "out", Metadata: map[string]string{
+		queue.MetadataAudioTrackCount: "3",
+		queue.MetadataAudioLanguages:  `["eng","",""]`,
+	}}
+	if err := job.SetAudioLanguageMap(map[string]string{"1": "es"}); err != nil {
+		t.Fatalf("SetAudioLanguageMap() error = %v", err)
+	}
+
+	// The language map overrides the probed tags, and the untagged third
+	// stream keeps its variant index
+	command := commandLine(fe, job)
+	for _, want := range []string{
+		"-map a:0 -c:a:0 aac -b:a:0 128k -ac:a:0 2 -metadata:s:a:0 language=eng",
+		"-map a:1 -c:a:1 aac -b:a:1 128k -ac:a:1 2 -metadata:s:a:1 language=es",
+		"-map a:2 -c:a:2 aac",
+		`-var_stream_map "a:0,agroup:audio,language:eng,name:eng a:1,agroup:audio,language:es,name:es a:2,agroup:audio v:0,agroup:audio"`,
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q:\n%s", want, command)
+		}
+	}
+
+	// Without the option or a language map only the first stream is encoded
+	fe.config.MultiLanguageAudio = false
+	delete(job.Metadata, queue.MetadataAudioLanguageMap)
+	if command := commandLine(fe, job); strings.Contains(command, "-c:a:1") {
+		t.Errorf("command encodes more than one stream:\n%s", command)
+	}
+}
+
+func TestAudioRenditionNames(t *testing.T) {
+	names := audioRenditionNames([]queue.AudioTrackConfig{
+		{Language: "en"}, {Language: "fr"}, {Language: "fr"}, {},
+	})
+	if got := strings.Join(names, ","); got != "en,fr_1,fr_2," {
+		t.Errorf("audioRenditionNames() = %s, want en,fr_1,fr_2 and an unnamed track", got)
+	}
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// ErrNoHardwareAccel is returned when no supported hardware encoder is found
+var ErrNoHardwareAccel = errors.New("no supported hardware encoder found")
+
+// DetectHardwareAccel probes the system for a hardware encoder and returns
+// the hardware_accel value to use for it
+func (fe *FFmpegExecutor) DetectHardwareAccel() (string, error) {
+	backend, err := detectHardwareAccel()
+	if err != nil {
+		return "", err
+	}
+
+	if err := config.ValidateCodec(fe.config.VideoCodec, backend); err != nil {
+		return "", fmt.Errorf("detected %s: %w", backend, err)
+	}
+
+	return backend, nil
+}
@@ -0,0 +1,8 @@
+//go:build darwin
+
+package core
+
+// detectHardwareAccel returns VideoToolbox, which every supported macOS has
+func detectHardwareAccel() (string, error) {
+	return "videotoolbox", nil
+}
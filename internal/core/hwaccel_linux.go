@@ -0,0 +1,35 @@
+//go:build linux
+
+package core
+
+import "os"
+
+// Device nodes and kernel modules that indicate a hardware encoder
+const (
+	nvidiaDevice    = "/dev/nvidia0"
+	intelI915Module = "/sys/module/i915"
+	intelXeModule   = "/sys/module/xe"
+)
+
+// detectHardwareAccel prefers NVENC, then Quick Sync on Intel GPUs and VAAPI
+// on other GPUs with a DRM render node
+func detectHardwareAccel() (string, error) {
+	if fileExists(nvidiaDevice) {
+		return "nvenc", nil
+	}
+
+	if fileExists(vaapiDevice) {
+		if fileExists(intelI915Module) || fileExists(intelXeModule) {
+			return "qsv", nil
+		}
+		return "vaapi", nil
+	}
+
+	return "", ErrNoHardwareAccel
+}
+
+// fileExists reports whether a device node or directory exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package core
+
+// detectHardwareAccel finds nothing on other platforms. Windows DXVA2 only
+// accelerates decoding, so it has no encoder to select.
+func detectHardwareAccel() (string, error) {
+	return "", ErrNoHardwareAccel
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnrecognizedInput is returned for inputs that do not start with the
+// magic bytes of a known video container
+var ErrUnrecognizedInput = errors.New("input is not a recognized video container")
+
+// ErrInputOutsideBasePath is returned for inputs that escape the local
+// storage base path through .. elements or symlinks
+var ErrInputOutsideBasePath = errors.New("input is outside the storage base path")
+
+// inputHeaderSize is how many leading bytes of an input are inspected
+const inputHeaderSize = 16
+
+// containerSignatures match the header of each accepted container format
+var containerSignatures = []struct {
+	name  string
+	match func(header []byte) bool
+}{
+	// ISO base media (MP4, MOV, M4A): a box size followed by the ftyp box type
+	{"mp4", func(h []byte) bool { return len(h) >= 8 && bytes.Equal(h[4:8], []byte("ftyp")) }},
+	// EBML header of Matroska and WebM
+	{"mkv", func(h []byte) bool { return bytes.HasPrefix(h, []byte{0x1a, 0x45, 0xdf, 0xa3}) }},
+	// MPEG-TS sync byte
+	{"ts", func(h []byte) bool { return len(h) > 0 && h[0] == 0x47 }},
+	// RIFF container of AVI
+	{"avi", func(h []byte) bool { return bytes.HasPrefix(h, []byte("RIFF")) }},
+}
+
+// ValidateInputMagicBytes checks that the file at path starts with the magic
+// bytes of a known video container, so files that are not videos are rejected
+// before FFmpeg parses them
+func ValidateInputMagicBytes(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, inputHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	header = header[:n]
+
+	for _, signature := range containerSignatures {
+		if signature.match(header) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnrecognizedInput, path)
+}
+
+// validateInputPath rejects local inputs that use .. elements, and inputs
+// under basePath that resolve outside it through symlinks. Inputs elsewhere
+// are not confined to basePath.
+func validateInputPath(inputPath, basePath string) error {
+	for _, element := range strings.Split(filepath.ToSlash(inputPath), "/") {
+		if element == ".." {
+			return fmt.Errorf("%w: %s contains ..", ErrInputOutsideBasePath, inputPath)
+		}
+	}
+
+	if basePath == "" {
+		return nil
+	}
+
+	absBase, err := filepath.Abs(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve storage base path: %w", err)
+	}
+	absInput, err := filepath.Abs(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve input path: %w", err)
+	}
+	if !withinDir(absInput, absBase) {
+		return nil
+	}
+
+	resolvedBase, err := filepath.EvalSymlinks(absBase)
+	if err != nil {
+		return fmt.Errorf("failed to resolve storage base path: %w", err)
+	}
+	resolvedInput, err := filepath.EvalSymlinks(absInput)
+	if err != nil {
+		return fmt.Errorf("failed to resolve input path: %w", err)
+	}
+	if !withinDir(resolvedInput, resolvedBase) {
+		return fmt.Errorf("%w: %s resolves to %s", ErrInputOutsideBasePath, inputPath, resolvedInput)
+	}
+
+	return nil
+}
+
+// withinDir reports whether path is dir or below it. Both must be absolute.
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
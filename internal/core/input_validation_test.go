@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateInputMagicBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []byte
+		wantErr error
+	}{
+		{name: "mp4", header: []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00")},
+		{name: "mkv", header: []byte{0x1a, 0x45, 0xdf, 0xa3, 0x9f, 0x42, 0x86, 0x81}},
+		{name: "ts", header: []byte{0x47, 0x40, 0x00, 0x10}},
+		{name: "avi", header: []byte("RIFF\x24\x00\x00\x00AVI LIST")},
+		{name: "text", header: []byte("#!/bin/sh\nrm -rf /\n"), wantErr: ErrUnrecognizedInput},
+		{name: "png", header: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), wantErr: ErrUnrecognizedInput},
+		{name: "empty", header: nil, wantErr: ErrUnrecognizedInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "input")
+			if err := os.WriteFile(path, tt.header, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateInputMagicBytes(context.Background(), path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateInputMagicBytes() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateInputMagicBytesMissingFile(t *testing.T) {
+	err := ValidateInputMagicBytes(context.Background(), filepath.Join(t.TempDir(), "missing.mp4"))
+	if err == nil || errors.Is(err, ErrUnrecognizedInput) {
+		t.Errorf("ValidateInputMagicBytes() error = %v, want an open error", err)
+	}
+}
+
+func TestValidateInputPath(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+
+	inside := filepath.Join(base, "video.mp4")
+	secret := filepath.Join(outside, "secret.mp4")
+	for _, path := range []string{inside, secret} {
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	escape := filepath.Join(base, "escape.mp4")
+	if err := os.Symlink(secret, escape); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	internal := filepath.Join(base, "link.mp4")
+	if err := os.Symlink(inside, internal); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		basePath string
+		wantErr  error
+	}{
+		{name: "inside base", path: inside, basePath: base},
+		{name: "symlink inside base", path: internal, basePath: base},
+		{name: "symlink escaping base", path: escape, basePath: base, wantErr: ErrInputOutsideBasePath},
+		{name: "dot dot", path: base + "/../" + filepath.Base(outside) + "/secret.mp4", basePath: base, wantErr: ErrInputOutsideBasePath},
+		{name: "outside base", path: secret, basePath: base},
+		{name: "no base path", path: escape},
+		{name: "dot dot without base path", path: "videos/../secret.mp4", wantErr: ErrInputOutsideBasePath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInputPath(tt.path, tt.basePath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateInputPath() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
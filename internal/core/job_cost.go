@@ -0,0 +1,85 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/billing"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// recordJobCost adds a completed job to the cost ledger. Jobs without a
+// tenant ID are not billed.
+func (w *Worker) recordJobCost(job *queue.Job) {
+	tenantID := job.Metadata[queue.MetadataTenantID]
+	if w.ledger == nil || tenantID == "" || job.StartedAt == nil || job.CompletedAt == nil {
+		return
+	}
+
+	qualityCount := 0
+	if executor := w.executor.forJob(job); !executor.audioOnly {
+		qualityCount = len(executor.enabledQualities())
+	}
+
+	entry := billing.LedgerEntry{
+		TenantID:        tenantID,
+		JobID:           job.ID,
+		CompletedAt:     *job.CompletedAt,
+		DurationSeconds: job.CompletedAt.Sub(*job.StartedAt).Seconds(),
+		QualityCount:    qualityCount,
+		StorageBytes:    jobOutputBytes(job.OutputPath),
+		ComputeMinutes:  jobComputeMinutes(job),
+	}
+
+	if err := w.ledger.Record(w.ctx, entry); err != nil {
+		w.jobLogger(job).Warn("Failed to record job cost", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+}
+
+// jobComputeMinutes returns the user and system CPU time FFmpeg used for a
+// job, in minutes
+func jobComputeMinutes(job *queue.Job) float64 {
+	var ms int64
+	for _, key := range []string{queue.MetadataFFmpegUserCPUMs, queue.MetadataFFmpegSysCPUMs} {
+		if value, err := strconv.ParseInt(job.Metadata[key], 10, 64); err == nil {
+			ms += value
+		}
+	}
+	return float64(ms) / 60000
+}
+
+// jobOutputBytes returns the size of the job output and of the files written
+// next to it under the same name, such as renditions and manifests
+func jobOutputBytes(outputPath string) int64 {
+	var total int64
+	for _, file := range jobOutputFiles(outputPath) {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// jobOutputFiles returns the job output and the regular files written next to
+// it under the same name
+func jobOutputFiles(outputPath string) []string {
+	base := filepath.Base(outputPath)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	dir := filepath.Dir(outputPath)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), stem) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// forJob returns an executor with the job's metadata overrides applied, such
+// as those set by a transcode preset, or fe itself if the job has none
+func (fe *FFmpegExecutor) forJob(job *queue.Job) *FFmpegExecutor {
+	cfg := fe.config
+	changed := false
+
+	if value := job.Metadata[queue.MetadataQualities]; value != "" {
+		cfg.Qualities = make(map[string]bool)
+		for _, quality := range strings.Split(value, ",") {
+			if quality = strings.TrimSpace(quality); quality != "" {
+				cfg.Qualities[strings.ToLower(quality)] = true
+			}
+		}
+		changed = true
+	}
+
+	if value := job.Metadata[queue.MetadataHardwareAccel]; value != "" {
+		if value == "none" {
+			value = ""
+		}
+		cfg.HardwareAccel = value
+		changed = true
+	}
+
+	if enabled, err := strconv.ParseBool(job.Metadata[queue.MetadataAudioNormalization]); err == nil {
+		cfg.AudioNormalization.Enabled = enabled
+		changed = true
+	}
+
+	switch job.Metadata[queue.MetadataOutputFormat] {
+	case config.OutputFormatFMP4:
+		cfg.FragmentedMP4.Enabled = true
+		changed = true
+	case config.OutputFormatHLS:
+		cfg.FragmentedMP4.Enabled = false
+		changed = true
+	}
+
+	// Per-quality formats choose the qualities, and MP4 or MKV replaces the
+	// job's HLS or fragmented MP4 output
+	var fileFormats map[string]string
+	if formats := job.QualityOutputFormats(); len(formats) > 0 {
+		cfg.Qualities = make(map[string]bool)
+		for quality, format := range formats {
+			quality = strings.ToLower(quality)
+			cfg.Qualities[quality] = true
+			if format != config.OutputFormatHLS {
+				if fileFormats == nil {
+					fileFormats = make(map[string]string)
+				}
+				fileFormats[quality] = format
+			}
+		}
+		if fileFormats != nil {
+			cfg.FragmentedMP4.Enabled = false
+		}
+		changed = true
+	}
+
+	lossless, _ := strconv.ParseBool(job.Metadata[queue.MetadataLossless])
+	audioOnly := job.Metadata[queue.MetadataOutputFormat] == config.OutputFormatAudio
+	if !changed && !lossless && !audioOnly {
+		return fe
+	}
+
+	jobExecutor := &FFmpegExecutor{
+		config:      cfg,
+		logDir:      fe.logDir,
+		stats:       fe.stats,
+		logger:      fe.logger,
+		breaker:     fe.breaker,
+		lossless:    lossless,
+		audioOnly:   audioOnly,
+		fileFormats: fileFormats,
+	}
+	jobExecutor.timeout.Store(fe.timeout.Load())
+
+	return jobExecutor
+}
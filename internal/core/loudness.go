@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// loudnormMeasurement is the JSON printed by the loudnorm measurement pass
+type loudnormMeasurement struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// loudnormTargets returns the loudnorm options for the configured targets
+func (fe *FFmpegExecutor) loudnormTargets() string {
+	an := fe.config.AudioNormalization
+	return fmt.Sprintf("loudnorm=I=%s:TP=%s:LRA=%s",
+		formatLoudness(an.Target), formatLoudness(an.TP), formatLoudness(an.LRA))
+}
+
+// measureLoudness runs the first loudnorm pass over the input audio and
+// stores the measured values in the job metadata
+func (fe *FFmpegExecutor) measureLoudness(ctx context.Context, job *queue.Job) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, fe.config.ExecutablePath,
+		"-hide_banner",
+		"-nostats",
+		"-i", job.LocalInputPath(),
+		"-vn",
+		"-af", fe.loudnormTargets()+":print_format=json",
+		"-f", "null",
+		"-",
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, stderr.String())
+	}
+
+	// The measurement is the last JSON object in the output
+	output := stderr.Bytes()
+	start := bytes.LastIndexByte(output, '{')
+	end := bytes.LastIndexByte(output, '}')
+	if start < 0 || end < start {
+		return fmt.Errorf("loudnorm printed no measurement")
+	}
+
+	var m loudnormMeasurement
+	if err := json.Unmarshal(output[start:end+1], &m); err != nil {
+		return fmt.Errorf("failed to parse loudnorm measurement: %w", err)
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataLoudnessInputI] = m.InputI
+	job.Metadata[queue.MetadataLoudnessInputTP] = m.InputTP
+	job.Metadata[queue.MetadataLoudnessInputLRA] = m.InputLRA
+	job.Metadata[queue.MetadataLoudnessInputThresh] = m.InputThresh
+	job.Metadata[queue.MetadataLoudnessTargetOffset] = m.TargetOffset
+
+	return nil
+}
+
+// loudnormFilter returns the audio filter for the second loudnorm pass, or ""
+// if audio normalization is disabled. Without a usable measurement, such as
+// for silent input, loudnorm falls back to single-pass dynamic normalization.
+func (fe *FFmpegExecutor) loudnormFilter(job *queue.Job) string {
+	if !fe.config.AudioNormalization.Enabled {
+		return ""
+	}
+
+	filter := fe.loudnormTargets()
+
+	measured := []struct {
+		option string
+		key    string
+	}{
+		{"measured_I", queue.MetadataLoudnessInputI},
+		{"measured_TP", queue.MetadataLoudnessInputTP},
+		{"measured_LRA", queue.MetadataLoudnessInputLRA},
+		{"measured_thresh", queue.MetadataLoudnessInputThresh},
+		{"offset", queue.MetadataLoudnessTargetOffset},
+	}
+	var options string
+	for _, m := range measured {
+		value, err := strconv.ParseFloat(job.Metadata[m.key], 64)
+		if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+			options = ""
+			break
+		}
+		options += fmt.Sprintf(":%s=%s", m.option, formatLoudness(value))
+	}
+	if options != "" {
+		filter += options + ":linear=true"
+	}
+
+	// loudnorm resamples to 192kHz internally
+	return filter + ",aresample=48000"
+}
+
+// formatLoudness formats a loudness value for a filter option
+func formatLoudness(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// OutputArtifact is a file written for a job
+type OutputArtifact struct {
+	// Quality is empty for files that hold no single quality, such as playlists
+	Quality   string `json:"quality,omitempty"`
+	Format    string `json:"format"`
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	URL       string `json:"url"`
+}
+
+// OutputManifest lists the output of a completed job. It is stored as
+// manifest.json in the job's output directory.
+type OutputManifest struct {
+	JobID     string           `json:"job_id"`
+	Source    VideoInfo        `json:"source"`
+	CreatedAt time.Time        `json:"created_at"`
+	Artifacts []OutputArtifact `json:"artifacts"`
+	// MasterPlaylistURL is empty for jobs without HLS output
+	MasterPlaylistURL string `json:"master_playlist_url,omitempty"`
+}
+
+// GenerateManifest describes the output files of a job, writes the
+// description to manifest.json in the job's output directory and stores it
+// with the worker's storage adapter. The storage path of the manifest is
+// recorded on the job.
+func (w *Worker) GenerateManifest(job *queue.Job, outputFiles []string) (*OutputManifest, error) {
+	executor := w.executor.forJob(job)
+
+	source, err := executor.Probe(w.ctx, job.LocalInputPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe input: %w", err)
+	}
+	source.Path = job.InputPath
+	if err := recordStreamMetadata(job, source); err != nil {
+		return nil, err
+	}
+
+	manifest := &OutputManifest{
+		JobID:     job.ID,
+		Source:    *source,
+		CreatedAt: time.Now().UTC(),
+		Artifacts: make([]OutputArtifact, 0, len(outputFiles)),
+	}
+
+	for _, file := range outputFiles {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		url, err := w.storage.GetURL(w.ctx, filepath.ToSlash(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get URL of %s: %w", file, err)
+		}
+
+		manifest.Artifacts = append(manifest.Artifacts, OutputArtifact{
+			Quality:   artifactQuality(file),
+			Format:    strings.TrimPrefix(filepath.Ext(file), "."),
+			Path:      filepath.ToSlash(file),
+			SizeBytes: info.Size(),
+			URL:       url,
+		})
+		if manifest.MasterPlaylistURL == "" && executor.isMasterPlaylist(file) {
+			manifest.MasterPlaylistURL = url
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(filepath.Dir(job.OutputPath), config.OutputManifestName)
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := w.uploadOutput(job, manifestPath, filepath.ToSlash(manifestPath)); err != nil {
+		return nil, fmt.Errorf("failed to store manifest: %w", err)
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataManifestPath] = filepath.ToSlash(manifestPath)
+
+	return manifest, nil
+}
+
+// writeManifest generates the manifest of a job from the files found next to
+// its output path
+func (w *Worker) writeManifest(job *queue.Job) error {
+	outputFiles := w.executor.forJob(job).outputFiles(job.OutputPath)
+
+	manifest, err := w.GenerateManifest(job, outputFiles)
+	if err != nil {
+		return err
+	}
+
+	w.jobLogger(job).Debug("Wrote output manifest",
+		zap.String("manifest_path", job.Metadata[queue.MetadataManifestPath]),
+		zap.Int("artifacts", len(manifest.Artifacts)))
+	return nil
+}
+
+// outputFiles returns the files written next to the output path under the
+// same name, and the HLS master playlists in the output directory
+func (fe *FFmpegExecutor) outputFiles(outputPath string) []string {
+	files := jobOutputFiles(outputPath)
+
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file] = true
+	}
+
+	master := fe.config.HLS.MasterPlaylistName
+	ext := filepath.Ext(master)
+	pattern := filepath.Join(filepath.Dir(outputPath), strings.TrimSuffix(master, ext)+"*"+ext)
+	matches, _ := filepath.Glob(pattern)
+	for _, file := range matches {
+		if !seen[file] && fe.isMasterPlaylist(file) {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// isMasterPlaylist reports whether file is an HLS master playlist written by
+// the executor, including the per-muxer playlists of mixed segment durations
+func (fe *FFmpegExecutor) isMasterPlaylist(file string) bool {
+	if fe.fragmentedMP4() || fe.fileFormats != nil {
+		return false
+	}
+
+	master := fe.config.HLS.MasterPlaylistName
+	ext := filepath.Ext(master)
+	name := filepath.Base(file)
+	if name == master {
+		return true
+	}
+
+	// Per-muxer playlists are suffixed with their segment duration, e.g. _6s
+	suffix, ok := strings.CutPrefix(name, strings.TrimSuffix(master, ext)+"_")
+	if !ok {
+		return false
+	}
+	seconds, ok := strings.CutSuffix(suffix, "s"+ext)
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(seconds)
+	return err == nil
+}
+
+// artifactQuality returns the quality a file was named after, e.g. 720p for
+// movie_720p.mkv, or "" when the name has no known quality suffix
+func artifactQuality(file string) string {
+	stem := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	i := strings.LastIndex(stem, "_")
+	if i < 0 {
+		return ""
+	}
+	if _, ok := config.LookupQuality(stem[i+1:]); !ok {
+		return ""
+	}
+	return stem[i+1:]
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+)
+
+// manifestProbeOutput is ffprobe output for a 1280x720 source
+const manifestProbeOutput = `{
+  "format": {"format_name": "mov,mp4", "duration": "10.0", "size": "1000"},
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720,
+     "avg_frame_rate": "30/1", "r_frame_rate": "30/1"}
+  ]
+}`
+
+// urlStorage serves files from https://cdn.example.com and records uploads
+type urlStorage struct {
+	storage.Storage
+	uploads []string
+}
+
+func (s *urlStorage) GetURL(ctx context.Context, remotePath string) (string, error) {
+	return "https://cdn.example.com" + remotePath, nil
+}
+
+func (s *urlStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	s.uploads = append(s.uploads, remotePath)
+	return nil
+}
+
+func TestWorkerWriteManifest(t *testing.T) {
+	cfg, _ := fakeFFmpeg(t, manifestProbeOutput, 0)
+	store := &urlStorage{}
+	w := NewWorker(queue.NewMemoryQueue(), store, NewFFmpegExecutor(cfg, "", nil, zap.NewNop()), metrics.NewJobStatsAggregator(), zap.NewNop())
+	t.Cleanup(w.Stop)
+
+	job := newTestJob(t)
+	dir := filepath.Dir(job.OutputPath)
+	for _, name := range []string{"output_720p.m3u8", "output_360p.m3u8", "srota.m3u8", "srota_6s.m3u8", "unrelated.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.writeManifest(job); err != nil {
+		t.Fatalf("writeManifest() error = %v", err)
+	}
+
+	manifestPath := filepath.Join(dir, config.OutputManifestName)
+	if got := job.Metadata[queue.MetadataManifestPath]; got != filepath.ToSlash(manifestPath) {
+		t.Errorf("manifest_path = %s, want %s", got, manifestPath)
+	}
+	if len(store.uploads) != 1 || store.uploads[0] != filepath.ToSlash(manifestPath) {
+		t.Errorf("uploads = %v, want the manifest stored", store.uploads)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("manifest was not written: %v", err)
+	}
+	var manifest OutputManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not JSON: %v", err)
+	}
+
+	if manifest.JobID != "job-1" || manifest.Source.Width != 1280 || manifest.Source.Path != job.InputPath {
+		t.Errorf("manifest job %s with source %+v", manifest.JobID, manifest.Source)
+	}
+	qualities := make(map[string]string)
+	for _, artifact := range manifest.Artifacts {
+		qualities[filepath.Base(artifact.Path)] = artifact.Quality
+		if artifact.SizeBytes != 4 || artifact.Format != "m3u8" || artifact.URL != "https://cdn.example.com"+artifact.Path {
+			t.Errorf("artifact = %+v", artifact)
+		}
+	}
+	want := map[string]string{"output_720p.m3u8": "720p", "output_360p.m3u8": "360p", "srota.m3u8": "", "srota_6s.m3u8": ""}
+	if len(qualities) != len(want) {
+		t.Errorf("artifacts = %v, want %v", qualities, want)
+	}
+	for name, quality := range want {
+		if got, ok := qualities[name]; !ok || got != quality {
+			t.Errorf("quality of %s = %q, want %q", name, got, quality)
+		}
+	}
+	if want := "https://cdn.example.com" + filepath.ToSlash(filepath.Join(dir, "srota.m3u8")); manifest.MasterPlaylistURL != want {
+		t.Errorf("MasterPlaylistURL = %s, want %s", manifest.MasterPlaylistURL, want)
+	}
+}
+
+func TestIsMasterPlaylist(t *testing.T) {
+	fe := NewFFmpegExecutor(config.DefaultConfig().FFmpeg, "", nil, zap.NewNop())
+
+	for file, want := range map[string]bool{
+		"out/srota.m3u8":       true,
+		"out/srota_6s.m3u8":    true,
+		"out/srota_720p.m3u8":  false,
+		"out/srota_6s.mp4":     false,
+		"out/output_720p.m3u8": false,
+	} {
+		if got := fe.isMasterPlaylist(file); got != want {
+			t.Errorf("isMasterPlaylist(%s) = %v, want %v", file, got, want)
+		}
+	}
+}
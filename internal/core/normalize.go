@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// normalizationFilters returns the filters applied to the input video before
+// it is split into output qualities, in the order they run
+func (fe *FFmpegExecutor) normalizationFilters(ctx context.Context, inputPath string) ([]string, error) {
+	var filters []string
+	norm := fe.config.Normalization
+
+	if norm.AutoRotate {
+		rotation, err := fe.probeRotation(ctx, inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input rotation: %w", err)
+		}
+		filters = append(filters, rotationFilters(rotation)...)
+	}
+
+	if norm.Deinterlace {
+		filters = append(filters, "yadif=mode=1")
+	}
+
+	if norm.DenoiseStrength > 0 {
+		filters = append(filters, "hqdn3d="+strconv.FormatFloat(norm.DenoiseStrength, 'f', -1, 64))
+	}
+
+	return filters, nil
+}
+
+// convertsToCFR reports whether the job's video is converted to the constant
+// target frame rate: always when forced, otherwise when the input was probed
+// as variable frame rate
+func (fe *FFmpegExecutor) convertsToCFR(job *queue.Job) bool {
+	if fe.audioOnly {
+		return false
+	}
+	if fe.config.ForceCFR {
+		return true
+	}
+	return fe.config.ConvertVFRtoCFR && job.Metadata[queue.MetadataVariableFrameRate] == "true"
+}
+
+// rotationFilters returns the filters that turn a video with the given
+// clockwise rotate tag upright
+func rotationFilters(rotation int) []string {
+	switch ((rotation % 360) + 360) % 360 {
+	case 90:
+		return []string{"transpose=1"}
+	case 180:
+		return []string{"hflip", "vflip"}
+	case 270:
+		return []string{"transpose=2"}
+	default:
+		return nil
+	}
+}
+
+// ffprobeRotation is the subset of ffprobe JSON output describing rotation
+type ffprobeRotation struct {
+	Streams []struct {
+		Tags struct {
+			Rotate string `json:"rotate"`
+		} `json:"tags"`
+		SideDataList []struct {
+			Rotation int `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+}
+
+// probeRotation returns the clockwise rotation of the first video stream of
+// the input. Newer FFmpeg versions report rotation as display matrix side
+// data, which is counter-clockwise, instead of a rotate tag.
+func (fe *FFmpegExecutor) probeRotation(ctx context.Context, inputPath string) (int, error) {
+	output, err := exec.CommandContext(ctx, fe.config.FFprobePath(),
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream_tags=rotate:stream_side_data=rotation",
+		"-of", "json",
+		inputPath,
+	).Output()
+	if err != nil {
+		return 0, err
+	}
+
+	var probe ffprobeRotation
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return 0, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	if len(probe.Streams) == 0 {
+		return 0, nil
+	}
+
+	stream := probe.Streams[0]
+	if stream.Tags.Rotate != "" {
+		return strconv.Atoi(stream.Tags.Rotate)
+	}
+	for _, sideData := range stream.SideDataList {
+		if sideData.Rotation != 0 {
+			return -sideData.Rotation, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// ExpandOutputDirectory returns the per-job output subdirectory from the
+// configured template, or "" when no template is configured
+func (fe *FFmpegExecutor) ExpandOutputDirectory(job *queue.Job) (string, error) {
+	if fe.config.OutputDirectoryTemplate == "" {
+		return "", nil
+	}
+
+	tmpl, err := config.ParseOutputDirectoryTemplate(fe.config.OutputDirectoryTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	created := job.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	return config.ExpandOutputDirectory(tmpl,
+		config.JobOutputDirectoryValues(job.ID, job.Metadata[queue.MetadataTenantID], created, job.Metadata))
+}
+
+// resolveOutputPath moves the job output into its templated subdirectory
+// and creates it. The directory is recorded on the job so a resubmitted job
+// is not nested a second time.
+func (fe *FFmpegExecutor) resolveOutputPath(job *queue.Job) error {
+	if job.Metadata[queue.MetadataOutputDirectory] != "" {
+		return nil
+	}
+
+	dir, err := fe.ExpandOutputDirectory(job)
+	if err != nil {
+		return err
+	}
+	if dir == "" || dir == "." {
+		return nil
+	}
+
+	job.OutputPath = filepath.Join(filepath.Dir(job.OutputPath), filepath.FromSlash(dir), filepath.Base(job.OutputPath))
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataOutputDirectory] = dir
+
+	if err := os.MkdirAll(filepath.Dir(job.OutputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+func TestResolveOutputPath(t *testing.T) {
+	cfg := config.DefaultConfig().FFmpeg
+	cfg.OutputDirectoryTemplate = "{{.TenantID}}/{{.Year}}/{{.Month}}/{{.JobID}}"
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+
+	root := t.TempDir()
+	job := &queue.Job{
+		ID:         "job-1",
+		OutputPath: filepath.Join(root, "output"),
+		CreatedAt:  time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+		Metadata:   map[string]string{queue.MetadataTenantID: "acme"},
+	}
+
+	if err := fe.resolveOutputPath(job); err != nil {
+		t.Fatalf("resolveOutputPath() error = %v", err)
+	}
+	want := filepath.Join(root, "acme", "2024", "03", "job-1", "output")
+	if job.OutputPath != want {
+		t.Errorf("OutputPath = %s, want %s", job.OutputPath, want)
+	}
+	if info, err := os.Stat(filepath.Dir(want)); err != nil || !info.IsDir() {
+		t.Errorf("output directory was not created: %v", err)
+	}
+
+	// A resubmitted job keeps its directory instead of nesting it again
+	if err := fe.resolveOutputPath(job); err != nil || job.OutputPath != want {
+		t.Errorf("second resolveOutputPath() = %s, %v; want %s", job.OutputPath, err, want)
+	}
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// VideoInfo describes a source video as reported by ffprobe
+type VideoInfo struct {
+	Path            string  `json:"path"`
+	Format          string  `json:"format"`
+	SizeBytes       int64   `json:"size_bytes"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Width           int     `json:"width,omitempty"`
+	Height          int     `json:"height,omitempty"`
+	VideoCodec      string  `json:"video_codec,omitempty"`
+	AudioCodec      string  `json:"audio_codec,omitempty"`
+	FrameRate       float64 `json:"frame_rate,omitempty"`
+	// VariableFrameRate is set when the average frame rate of the video
+	// differs from its base frame rate
+	VariableFrameRate bool `json:"variable_frame_rate,omitempty"`
+	// AudioStreams is the number of audio streams, and AudioLanguages their
+	// language tags in order, "" for untagged streams
+	AudioStreams   int      `json:"audio_streams,omitempty"`
+	AudioLanguages []string `json:"audio_languages,omitempty"`
+
+	Chapters       []Chapter       `json:"chapters,omitempty"`
+	SubtitleTracks []SubtitleTrack `json:"subtitle_tracks,omitempty"`
+	// AttachedImages names the cover art streams, by attachment file name
+	// when the container has one
+	AttachedImages []string `json:"attached_images,omitempty"`
+}
+
+// Chapter is a chapter marker of the input, with times in seconds
+type Chapter struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Title     string  `json:"title,omitempty"`
+}
+
+// SubtitleTrack is a subtitle stream of the input
+type SubtitleTrack struct {
+	// Index is the stream index in the input
+	Index    int    `json:"index"`
+	Language string `json:"language,omitempty"`
+	// Format is the subtitle codec, such as subrip, ass or mov_text
+	Format string `json:"format"`
+}
+
+// ffprobeVideoInfo is the subset of ffprobe JSON output used for VideoInfo
+type ffprobeVideoInfo struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+	} `json:"format"`
+	Streams []struct {
+		Index        int    `json:"index"`
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+		Tags struct {
+			Language string `json:"language"`
+			Filename string `json:"filename"`
+		} `json:"tags"`
+	} `json:"streams"`
+	Chapters []struct {
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+		Tags      struct {
+			Title string `json:"title"`
+		} `json:"tags"`
+	} `json:"chapters"`
+}
+
+// Probe returns the container, duration, first video and audio streams,
+// chapters, subtitle tracks and cover art of the input
+func (fe *FFmpegExecutor) Probe(ctx context.Context, inputPath string) (*VideoInfo, error) {
+	output, err := exec.CommandContext(ctx, fe.config.FFprobePath(),
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		"-of", "json",
+		inputPath,
+	).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var probe ffprobeVideoInfo
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &VideoInfo{
+		Path:   inputPath,
+		Format: probe.Format.FormatName,
+	}
+	info.SizeBytes, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	info.DurationSeconds, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 1:
+			// Cover art is stored as a single-frame video stream
+			name := stream.Tags.Filename
+			if name == "" {
+				name = fmt.Sprintf("stream %d (%s)", stream.Index, stream.CodecName)
+			}
+			info.AttachedImages = append(info.AttachedImages, name)
+		case stream.CodecType == "video" && info.VideoCodec == "":
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+			// avg_frame_rate is 0/0 for some variable frame rate inputs
+			avg, base := parseFrameRate(stream.AvgFrameRate), parseFrameRate(stream.RFrameRate)
+			info.FrameRate = avg
+			if info.FrameRate == 0 {
+				info.FrameRate = base
+			}
+			info.VariableFrameRate = avg > 0 && base > 0 && math.Abs(avg-base) > vfrTolerance
+		case stream.CodecType == "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = stream.CodecName
+			}
+			info.AudioStreams++
+			info.AudioLanguages = append(info.AudioLanguages, probedLanguage(stream.Tags.Language))
+		case stream.CodecType == "subtitle":
+			info.SubtitleTracks = append(info.SubtitleTracks, SubtitleTrack{
+				Index:    stream.Index,
+				Language: stream.Tags.Language,
+				Format:   stream.CodecName,
+			})
+		}
+	}
+
+	for _, chapter := range probe.Chapters {
+		start, _ := strconv.ParseFloat(chapter.StartTime, 64)
+		end, _ := strconv.ParseFloat(chapter.EndTime, 64)
+		info.Chapters = append(info.Chapters, Chapter{
+			StartTime: start,
+			EndTime:   end,
+			Title:     chapter.Tags.Title,
+		})
+	}
+
+	return info, nil
+}
+
+// vfrTolerance is how far, in frames per second, the average frame rate may
+// be from the base frame rate before the input counts as variable frame rate
+const vfrTolerance = 0.01
+
+// parseFrameRate parses an ffprobe frame rate such as 30000/1001, returning 0
+// when it is missing or invalid
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		fps, _ := strconv.ParseFloat(rate, 64)
+		return fps
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// probedLanguage returns the language tag of a stream if it is a two or three
+// letter code, and "" for missing, undetermined ("und") or unusual tags
+func probedLanguage(tag string) string {
+	tag = strings.ToLower(tag)
+	if len(tag) < 2 || len(tag) > 3 || tag == "und" {
+		return ""
+	}
+	for _, c := range tag {
+		if c < 'a' || c > 'z' {
+			return ""
+		}
+	}
+	return tag
+}
+
+// recordStreamMetadata stores the chapters, subtitle tracks, cover art and
+// audio stream languages of the input on the job as JSON lists, and its frame
+// rate, whether it is variable and its number of audio streams. Empty values
+// are left out.
+func recordStreamMetadata(job *queue.Job, info *VideoInfo) error {
+	values := []struct {
+		key   string
+		empty bool
+		value interface{}
+	}{
+		{queue.MetadataChapters, len(info.Chapters) == 0, info.Chapters},
+		{queue.MetadataSubtitleTracks, len(info.SubtitleTracks) == 0, info.SubtitleTracks},
+		{queue.MetadataAttachedImages, len(info.AttachedImages) == 0, info.AttachedImages},
+		{queue.MetadataAudioLanguages, info.AudioStreams == 0, info.AudioLanguages},
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	for _, v := range values {
+		if v.empty {
+			delete(job.Metadata, v.key)
+			continue
+		}
+		data, err := json.Marshal(v.value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", v.key, err)
+		}
+		job.Metadata[v.key] = string(data)
+	}
+
+	if info.FrameRate > 0 {
+		job.Metadata[queue.MetadataSourceFrameRate] = strconv.FormatFloat(info.FrameRate, 'f', -1, 64)
+	} else {
+		delete(job.Metadata, queue.MetadataSourceFrameRate)
+	}
+	if info.VariableFrameRate {
+		job.Metadata[queue.MetadataVariableFrameRate] = "true"
+	} else {
+		delete(job.Metadata, queue.MetadataVariableFrameRate)
+	}
+	if info.AudioStreams > 0 {
+		job.Metadata[queue.MetadataAudioTrackCount] = strconv.Itoa(info.AudioStreams)
+	} else {
+		delete(job.Metadata, queue.MetadataAudioTrackCount)
+	}
+	return nil
+}
+
+// chapterKeyframes returns the chapter start times recorded on the job as a
+// -force_key_frames list, or "" when keyframes are not aligned to chapters.
+// The first chapter starts on the first frame, which is always a keyframe.
+func (fe *FFmpegExecutor) chapterKeyframes(job *queue.Job) string {
+	if !fe.config.AlignKeyframesToChapters || job.Metadata[queue.MetadataChapters] == "" {
+		return ""
+	}
+
+	var chapters []Chapter
+	if err := json.Unmarshal([]byte(job.Metadata[queue.MetadataChapters]), &chapters); err != nil {
+		return ""
+	}
+
+	var times []string
+	for _, chapter := range chapters {
+		if chapter.StartTime > 0 {
+			times = append(times, strconv.FormatFloat(chapter.StartTime, 'f', 3, 64))
+		}
+	}
+	return strings.Join(times, ",")
+}
+
+// forceKeyFramesArgs returns the option forcing keyframes at times on an
+// output video stream, or "" when times is empty
+func forceKeyFramesArgs(index int, times string) string {
+	if times == "" {
+		return ""
+	}
+	return fmt.Sprintf(" -force_key_frames:v:%d %s", index, times)
+}
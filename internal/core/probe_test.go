@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// chapterProbeOutput is ffprobe output for an input with chapters, subtitle
+// tracks and cover art
+const chapterProbeOutput = `{
+  "format": {"format_name": "matroska,webm", "duration": "180.5", "size": "5000"},
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080,
+     "avg_frame_rate": "25/1", "r_frame_rate": "25/1"},
+    {"index": 1, "codec_type": "audio", "codec_name": "aac", "tags": {"language": "eng"}},
+    {"index": 2, "codec_type": "audio", "codec_name": "aac", "tags": {"language": "und"}},
+    {"index": 3, "codec_type": "subtitle", "codec_name": "subrip", "tags": {"language": "fra"}},
+    {"index": 4, "codec_type": "video", "codec_name": "mjpeg", "disposition": {"attached_pic": 1},
+     "tags": {"filename": "cover.jpg"}},
+    {"index": 5, "codec_type": "video", "codec_name": "png", "disposition": {"attached_pic": 1}}
+  ],
+  "chapters": [
+    {"start_time": "0.000000", "end_time": "60.000000", "tags": {"title": "Intro"}},
+    {"start_time": "60.000000", "end_time": "120.500000", "tags": {"title": "Talk"}},
+    {"start_time": "120.500000", "end_time": "180.500000"}
+  ]
+}`
+
+func TestProbe(t *testing.T) {
+	cfg, _ := fakeFFmpeg(t, chapterProbeOutput, 0)
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+
+	info, err := fe.Probe(context.Background(), "input.mkv")
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+
+	if info.Width != 1920 || info.VideoCodec != "h264" || info.FrameRate != 25 || info.DurationSeconds != 180.5 {
+		t.Errorf("video = %dpx %s at %v fps for %vs", info.Width, info.VideoCodec, info.FrameRate, info.DurationSeconds)
+	}
+	if info.AudioStreams != 2 || strings.Join(info.AudioLanguages, ",") != "eng," {
+		t.Errorf("audio = %d streams in %q, want eng and an untagged stream", info.AudioStreams, info.AudioLanguages)
+	}
+	if len(info.Chapters) != 3 || info.Chapters[1] != (Chapter{StartTime: 60, EndTime: 120.5, Title: "Talk"}) {
+		t.Errorf("Chapters = %+v", info.Chapters)
+	}
+	if len(info.SubtitleTracks) != 1 || info.SubtitleTracks[0] != (SubtitleTrack{Index: 3, Language: "fra", Format: "subrip"}) {
+		t.Errorf("SubtitleTracks = %+v", info.SubtitleTracks)
+	}
+	// Cover art is not mistaken for the video stream
+	if got := strings.Join(info.AttachedImages, ","); got != "cover.jpg,stream 5 (png)" {
+		t.Errorf("AttachedImages = %s", got)
+	}
+}
+
+func TestRecordStreamMetadata(t *testing.T) {
+	job := &queue.Job{Metadata: map[string]string{queue.MetadataSubtitleTracks: "stale"}}
+	info := &VideoInfo{
+		Chapters:       []Chapter{{StartTime: 0, EndTime: 10, Title: "Intro"}},
+		AttachedImages: []string{"cover.jpg"},
+		FrameRate:      25,
+		AudioStreams:   2,
+		AudioLanguages: []string{"eng", ""},
+	}
+
+	if err := recordStreamMetadata(job, info); err != nil {
+		t.Fatalf("recordStreamMetadata() error = %v", err)
+	}
+
+	var chapters []Chapter
+	if err := json.Unmarshal([]byte(job.Metadata[queue.MetadataChapters]), &chapters); err != nil || len(chapters) != 1 || chapters[0].Title != "Intro" {
+		t.Errorf("chapters = %s, want the probed chapter", job.Metadata[queue.MetadataChapters])
+	}
+	if got := job.Metadata[queue.MetadataAttachedImages]; got != `["cover.jpg"]` {
+		t.Errorf("attached_images = %s", got)
+	}
+	if _, ok := job.Metadata[queue.MetadataSubtitleTracks]; ok {
+		t.Errorf("subtitle_tracks kept without probed subtitle tracks")
+	}
+	if got := job.Metadata[queue.MetadataSourceFrameRate]; got != "25" {
+		t.Errorf("source frame rate = %s, want 25", got)
+	}
+	if job.AudioTrackCount() != 2 || job.Metadata[queue.MetadataAudioLanguages] != `["eng",""]` {
+		t.Errorf("audio = %d tracks in %s, want the probed streams", job.AudioTrackCount(), job.Metadata[queue.MetadataAudioLanguages])
+	}
+}
+
+func TestProbedLanguage(t *testing.T) {
+	for tag, want := range map[string]string{
+		"eng": "eng", "FR": "fr", "und": "", "": "", "e": "", "english": "", "en-US": "",
+	} {
+		if got := probedLanguage(tag); got != want {
+			t.Errorf("probedLanguage(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestFFmpegExecutorAlignsKeyframesToChapters(t *testing.T) {
+	cfg, argsFile := fakeFFmpeg(t, chapterProbeOutput, 0)
+	cfg.AlignKeyframesToChapters = true
+	cfg.Qualities = map[string]bool{"360p": true, "720p": true}
+
+	fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop())
+	job := newTestJob(t)
+	if err := fe.Execute(context.Background(), job, nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	command := strings.Join(readArgs(t, argsFile), " ")
+	for _, want := range []string{"-force_key_frames:v:0 60.000,120.500", "-force_key_frames:v:1 60.000,120.500"} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command does not contain %q: %s", want, command)
+		}
+	}
+	if job.Metadata[queue.MetadataChapters] == "" {
+		t.Errorf("the probed chapters were not recorded on the job")
+	}
+
+	// Without the option the chapters are not used for keyframes
+	cfg.AlignKeyframesToChapters = false
+	if fe := NewFFmpegExecutor(cfg, "", nil, zap.NewNop()); fe.chapterKeyframes(job) != "" {
+		t.Errorf("chapterKeyframes() = %q with align_keyframes_to_chapters off", fe.chapterKeyframes(job))
+	}
+}
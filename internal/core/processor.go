@@ -2,36 +2,66 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/nikhil0verma/flixsrota/internal/billing"
 	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/nikhil0verma/flixsrota/internal/recovery"
 	"go.uber.org/zap"
 )
 
 // JobProcessor manages video processing jobs
 type JobProcessor struct {
-	config   config.WorkerConfig
-	queue    queue.Queue
-	storage  storage.Storage
-	executor *FFmpegExecutor
-	logger   *zap.Logger
+	config     config.WorkerConfig
+	queue      queue.Queue
+	storage    storage.Storage
+	executor   *FFmpegExecutor
+	stats      *metrics.JobStatsAggregator
+	dispatcher Dispatcher
+	logger     *zap.Logger
 
+	// dispatchInterval is how often the queue is polled for a job
+	dispatchInterval time.Duration
+
+	// ledger records the cost of completed jobs, nil when billing is disabled
+	ledger *billing.CostLedger
+
+	// inputRoot is passed to workers to confine inputs under the local
+	// storage base path
+	inputRoot string
+
+	// uploadRetry is passed to workers to retry failed output uploads
+	uploadRetry storage.UploadRetryPolicy
+
+	// directInput is passed to workers to read uploaded inputs through
+	// pre-signed URLs
+	directInput bool
+
+	workersMu  sync.RWMutex
 	workers    []*Worker
+	paused     bool
 	workerPool chan *Worker
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 }
 
+// defaultDispatchInterval is how often the processor polls the queue
+const defaultDispatchInterval = time.Second
+
 // NewJobProcessor creates a new job processor
 func NewJobProcessor(
 	config config.WorkerConfig,
 	queue queue.Queue,
 	storage storage.Storage,
 	executor *FFmpegExecutor,
+	stats *metrics.JobStatsAggregator,
 	logger *zap.Logger,
 ) *JobProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -41,10 +71,14 @@ func NewJobProcessor(
 		queue:      queue,
 		storage:    storage,
 		executor:   executor,
+		stats:      stats,
+		dispatcher: NewDispatcher(config.DispatchAlgorithm, config.MaxWorkers),
 		logger:     logger,
 		workerPool: make(chan *Worker, config.MaxWorkers),
 		ctx:        ctx,
 		cancel:     cancel,
+
+		dispatchInterval: defaultDispatchInterval,
 	}
 }
 
@@ -52,19 +86,38 @@ func NewJobProcessor(
 func (jp *JobProcessor) Start() {
 	jp.logger.Info("Starting job processor",
 		zap.Int("min_workers", jp.config.MinWorkers),
-		zap.Int("max_workers", jp.config.MaxWorkers))
+		zap.Int("max_workers", jp.config.MaxWorkers),
+		zap.String("dispatch_algorithm", jp.config.DispatchAlgorithm))
 
 	// Start minimum number of workers
-	for i := 0; i < jp.config.MinWorkers; i++ {
-		worker := NewWorker(jp.queue, jp.storage, jp.executor, jp.logger)
-		jp.workers = append(jp.workers, worker)
-		jp.workerPool <- worker
-		go worker.Start(jp.ctx)
-	}
+	jp.ScaleUp(jp.config.MinWorkers)
 
 	// Start job processing loop
 	jp.wg.Add(1)
-	go jp.processJobs()
+	recovery.SafeGo(jp.logger, jp.processJobs)
+
+	// Replace workers that stop making progress
+	if jp.config.StuckJobTimeout > 0 {
+		watchdog := NewWorkerWatchdog(jp, time.Duration(jp.config.StuckJobTimeout)*time.Second, jp.logger)
+		jp.wg.Add(1)
+		recovery.SafeGo(jp.logger, func() {
+			defer jp.wg.Done()
+			watchdog.Run(jp.ctx)
+		})
+	}
+
+	// Requeue jobs left in the processing state by crashed workers
+	if jp.config.StaleJobCheckInterval > 0 {
+		reaper := NewStaleJobReaper(jp.queue, jp,
+			time.Duration(jp.config.StaleJobCheckInterval)*time.Second,
+			time.Duration(jp.config.MaxJobProcessingTime)*time.Second,
+			jp.logger)
+		jp.wg.Add(1)
+		recovery.SafeGo(jp.logger, func() {
+			defer jp.wg.Done()
+			reaper.Run(jp.ctx)
+		})
+	}
 }
 
 // Stop stops the job processor
@@ -73,19 +126,255 @@ func (jp *JobProcessor) Stop() {
 	jp.cancel()
 	jp.wg.Wait()
 
+	// Return jobs held by the dispatcher to the queue
+	if flusher, ok := jp.dispatcher.(DispatchFlusher); ok {
+		if err := flusher.Flush(context.Background(), jp.queue); err != nil {
+			jp.logger.Error("Failed to requeue dispatcher jobs", zap.Error(err))
+		}
+	}
+
 	// Stop all workers
+	jp.workersMu.Lock()
 	for _, worker := range jp.workers {
 		worker.Stop()
 	}
+	jp.workersMu.Unlock()
 
 	jp.logger.Info("Job processor stopped")
 }
 
+// WorkerCount returns the number of running workers
+func (jp *JobProcessor) WorkerCount() int {
+	jp.workersMu.RLock()
+	defer jp.workersMu.RUnlock()
+	return len(jp.workers)
+}
+
+// SetWorkerLimits changes the minimum and maximum number of workers at
+// runtime and scales the pool into the new range. The maximum cannot be
+// raised above the value the processor was started with.
+func (jp *JobProcessor) SetWorkerLimits(minWorkers, maxWorkers int) error {
+	jp.workersMu.Lock()
+	if maxWorkers > cap(jp.workerPool) {
+		jp.workersMu.Unlock()
+		return fmt.Errorf("max workers can only be raised up to %d without a restart", cap(jp.workerPool))
+	}
+	jp.config.MinWorkers = minWorkers
+	jp.config.MaxWorkers = maxWorkers
+	count := len(jp.workers)
+	jp.workersMu.Unlock()
+
+	jp.logger.Info("Worker limits changed",
+		zap.Int("min_workers", minWorkers),
+		zap.Int("max_workers", maxWorkers))
+
+	if count < minWorkers {
+		jp.ScaleUp(minWorkers - count)
+	} else if count > maxWorkers {
+		jp.ScaleDown(count - maxWorkers)
+	}
+
+	return nil
+}
+
+// Pause stops dispatching new jobs. Jobs already running are not interrupted.
+func (jp *JobProcessor) Pause() {
+	jp.workersMu.Lock()
+	jp.paused = true
+	jp.workersMu.Unlock()
+
+	jp.logger.Info("Job processor paused")
+}
+
+// Resume restarts job dispatch after Pause
+func (jp *JobProcessor) Resume() {
+	jp.workersMu.Lock()
+	jp.paused = false
+	jp.workersMu.Unlock()
+
+	jp.logger.Info("Job processor resumed")
+}
+
+// IsPaused reports whether job dispatch is paused
+func (jp *JobProcessor) IsPaused() bool {
+	jp.workersMu.RLock()
+	defer jp.workersMu.RUnlock()
+	return jp.paused
+}
+
+// Snapshot returns a consistent view of the jobs running on each worker,
+// the number of idle workers, the queue depth and the aggregated job stats
+func (jp *JobProcessor) Snapshot() metrics.ProcessorState {
+	var state metrics.ProcessorState
+
+	jp.workersMu.RLock()
+	state.IsPaused = jp.paused
+	for _, worker := range jp.workers {
+		if job := worker.CurrentJob(); job != nil {
+			state.ActiveJobs = append(state.ActiveJobs, job)
+		} else {
+			state.IdleWorkerCount++
+		}
+	}
+	jp.workersMu.RUnlock()
+
+	depth, err := jp.queue.GetQueueDepth(jp.ctx)
+	if err != nil {
+		jp.logger.Warn("Failed to get queue depth", zap.Error(err))
+	}
+	state.QueueDepth = depth
+	state.Stats = jp.stats.Snapshot()
+
+	return state
+}
+
+// WorkerStates returns the state of every worker in start order
+func (jp *JobProcessor) WorkerStates() []metrics.WorkerState {
+	jp.workersMu.RLock()
+	defer jp.workersMu.RUnlock()
+
+	states := make([]metrics.WorkerState, 0, len(jp.workers))
+	for i, worker := range jp.workers {
+		job := worker.CurrentJob()
+		states = append(states, metrics.WorkerState{
+			Index:  i,
+			Busy:   job != nil,
+			Job:    job,
+			Labels: worker.labels,
+		})
+	}
+	return states
+}
+
+// ActiveJob returns a copy of a job currently being executed by a worker,
+// including its latest progress
+func (jp *JobProcessor) ActiveJob(jobID string) (*queue.Job, bool) {
+	jp.workersMu.RLock()
+	defer jp.workersMu.RUnlock()
+
+	for _, worker := range jp.workers {
+		if job := worker.CurrentJob(); job != nil && job.ID == jobID {
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+// ScaleUp starts up to n additional workers without exceeding MaxWorkers.
+// It returns the number of workers started.
+func (jp *JobProcessor) ScaleUp(n int) int {
+	jp.workersMu.Lock()
+	defer jp.workersMu.Unlock()
+
+	started := 0
+	for ; started < n && len(jp.workers) < jp.config.MaxWorkers; started++ {
+		worker := NewWorker(jp.queue, jp.storage, jp.executor, jp.stats, jp.logger)
+		worker.labels = lowerKeys(jp.config.WorkerLabels)
+		worker.ledger = jp.ledger
+		worker.inputRoot = jp.inputRoot
+		worker.uploadRetry = jp.uploadRetry
+		worker.directInput = jp.directInput
+		jp.workers = append(jp.workers, worker)
+		jp.workerPool <- worker
+		recovery.SafeGo(jp.logger, func() { worker.Start(jp.ctx) })
+	}
+
+	return started
+}
+
+// ScaleDown retires up to n idle workers without going below MinWorkers.
+// Idle workers are taken out of the pool and stopped right away; busy
+// workers are never interrupted and are retired when they return to the
+// pool above MaxWorkers. It returns the number of workers retired.
+func (jp *JobProcessor) ScaleDown(n int) int {
+	jp.workersMu.RLock()
+	minWorkers := jp.config.MinWorkers
+	jp.workersMu.RUnlock()
+
+	retired := 0
+	for retired < n {
+		var w *Worker
+		select {
+		case w = <-jp.workerPool:
+		default:
+		}
+		if w == nil {
+			break
+		}
+		if !jp.retireWorker(w, minWorkers) {
+			jp.workerPool <- w
+			break
+		}
+		retired++
+	}
+
+	if retired > 0 {
+		jp.logger.Info("Scaling down workers", zap.Int("workers", retired))
+	}
+
+	return retired
+}
+
+// releaseWorker returns a worker to the pool, or retires it when there are
+// more workers than MaxWorkers, as after the limit was lowered while it was
+// busy. Recycled workers are dropped and replaced, unless the watchdog
+// already replaced them.
+func (jp *JobProcessor) releaseWorker(w *Worker) {
+	if w.isRecycled() {
+		if jp.removeWorker(w) {
+			jp.ScaleUp(1)
+		}
+		return
+	}
+
+	jp.workersMu.RLock()
+	maxWorkers := jp.config.MaxWorkers
+	jp.workersMu.RUnlock()
+	if !jp.retireWorker(w, maxWorkers) {
+		jp.workerPool <- w
+	}
+}
+
+// retireWorker stops a worker and drops it from the running workers if more
+// than keep workers remain, and reports whether it did
+func (jp *JobProcessor) retireWorker(w *Worker, keep int) bool {
+	jp.workersMu.Lock()
+	if len(jp.workers) <= keep {
+		jp.workersMu.Unlock()
+		return false
+	}
+	for i, worker := range jp.workers {
+		if worker == w {
+			jp.workers = append(jp.workers[:i], jp.workers[i+1:]...)
+			break
+		}
+	}
+	jp.workersMu.Unlock()
+
+	w.Stop()
+	return true
+}
+
+// removeWorker drops a worker from the list of running workers and reports
+// whether it was still in it
+func (jp *JobProcessor) removeWorker(w *Worker) bool {
+	jp.workersMu.Lock()
+	defer jp.workersMu.Unlock()
+
+	for i, worker := range jp.workers {
+		if worker == w {
+			jp.workers = append(jp.workers[:i], jp.workers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // processJobs continuously processes jobs from the queue
 func (jp *JobProcessor) processJobs() {
 	defer jp.wg.Done()
 
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(jp.dispatchInterval)
 	defer ticker.Stop()
 
 	for {
@@ -93,8 +382,12 @@ func (jp *JobProcessor) processJobs() {
 		case <-jp.ctx.Done():
 			return
 		case <-ticker.C:
+			if jp.IsPaused() {
+				continue
+			}
+
 			// Try to get a job from the queue
-			job, err := jp.queue.Dequeue(jp.ctx)
+			job, err := jp.dispatcher.Next(jp.ctx, jp.queue)
 			if err != nil {
 				jp.logger.Error("Failed to dequeue job", zap.Error(err))
 				continue
@@ -105,21 +398,63 @@ func (jp *JobProcessor) processJobs() {
 				continue
 			}
 
-			// Get a worker from the pool
-			select {
-			case worker := <-jp.workerPool:
+			// Get a worker whose labels satisfy the job from the pool
+			worker, available := jp.acquireWorker(job.RequiredWorkerLabels())
+			if worker != nil {
 				// Process job in worker
-				go func(w *Worker, j *queue.Job) {
+				w, j := worker, job
+				recovery.SafeGo(jp.logger, func() {
 					w.ProcessJob(j)
-					jp.workerPool <- w
-				}(worker, job)
-			default:
-				// No available workers, put job back in queue
+					jp.releaseWorker(w)
+				})
+				continue
+			}
+
+			// No suitable workers, put job back in queue
+			if available {
+				jp.logger.Debug("No worker has the required labels, requeuing job",
+					zap.String("job_id", job.ID),
+					zap.Any("required_worker_labels", job.RequiredWorkerLabels()))
+			} else {
 				jp.logger.Warn("No available workers, requeuing job", zap.String("job_id", job.ID))
-				if err := jp.queue.Enqueue(jp.ctx, job); err != nil {
-					jp.logger.Error("Failed to requeue job", zap.Error(err))
-				}
+			}
+			if err := queue.Requeue(jp.ctx, jp.queue, job); err != nil {
+				jp.logger.Error("Failed to requeue job", zap.Error(err))
 			}
 		}
 	}
 }
+
+// acquireWorker takes an idle worker with the required labels from the pool.
+// Idle workers that do not match are returned to the pool. available reports
+// whether there were idle workers at all.
+func (jp *JobProcessor) acquireWorker(required map[string]string) (worker *Worker, available bool) {
+	var skipped []*Worker
+	defer func() {
+		for _, w := range skipped {
+			jp.workerPool <- w
+		}
+	}()
+
+	for {
+		select {
+		case w := <-jp.workerPool:
+			available = true
+			if w.MatchesLabels(required) {
+				return w, true
+			}
+			skipped = append(skipped, w)
+		default:
+			return nil, available
+		}
+	}
+}
+
+// lowerKeys returns a copy of labels with lower-case keys
+func lowerKeys(labels map[string]string) map[string]string {
+	lowered := make(map[string]string, len(labels))
+	for key, value := range labels {
+		lowered[strings.ToLower(key)] = value
+	}
+	return lowered
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+)
+
+// BenchmarkJobProcessor measures end-to-end job throughput of a processor
+// running the mock FFmpeg, and the queue overhead on its own
+func BenchmarkJobProcessor(b *testing.B) {
+	b.Run("Processor", benchmarkProcessor)
+	b.Run("EnqueueDequeue", benchmarkEnqueueDequeue)
+}
+
+// benchmarkProcessor enqueues b.N jobs and waits for a processor with four
+// workers to complete them
+func benchmarkProcessor(b *testing.B) {
+	ctx := context.Background()
+	ffmpegConfig, _ := fakeFFmpeg(b, "{}", 0)
+	dir := b.TempDir()
+
+	workerConfig := config.DefaultConfig().Worker
+	workerConfig.MinWorkers = 4
+	workerConfig.MaxWorkers = 4
+
+	store, err := storage.NewLocalStorage(dir, filepath.Join(dir, "tmp"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	q := queue.NewMemoryQueue()
+	executor := NewFFmpegExecutor(ffmpegConfig, "", nil, zap.NewNop())
+	jp := NewJobProcessor(workerConfig, q, store, executor, metrics.NewJobStatsAggregator(), zap.NewNop())
+	jp.dispatchInterval = 100 * time.Microsecond
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		job := &queue.Job{
+			ID:         fmt.Sprintf("job-%d", i),
+			InputPath:  filepath.Join(dir, "input.mp4"),
+			OutputPath: filepath.Join(dir, fmt.Sprintf("output-%d", i)),
+		}
+		if err := q.Enqueue(ctx, job); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	jp.Start()
+	defer jp.Stop()
+
+	for {
+		_, completed, _ := q.ListJobs(ctx, queue.JobStatusCompleted, 1, 0)
+		_, failed, _ := q.ListJobs(ctx, queue.JobStatusFailed, 1, 0)
+		if failed > 0 {
+			jobs, _, _ := q.ListJobs(ctx, queue.JobStatusFailed, 1, 0)
+			b.Fatalf("%d jobs failed, the first with: %s", failed, jobs[0].Error)
+		}
+		if completed == b.N {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "jobs/s")
+}
+
+// benchmarkEnqueueDequeue measures one Enqueue and Dequeue of the queue,
+// without FFmpeg
+func benchmarkEnqueueDequeue(b *testing.B) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := q.Enqueue(ctx, &queue.Job{ID: "job", Priority: i % 10}); err != nil {
+			b.Fatal(err)
+		}
+		if job, err := q.Dequeue(ctx); err != nil || job == nil {
+			b.Fatalf("Dequeue() = %v, %v", job, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// newTestProcessor returns a processor on a memory queue that is not
+// dispatching jobs, with workers started workers
+func newTestProcessor(t *testing.T, workers int) (*JobProcessor, *queue.MemoryQueue) {
+	t.Helper()
+	workerConfig := config.DefaultConfig().Worker
+	workerConfig.MinWorkers = 0
+	workerConfig.MaxWorkers = workers
+
+	q := queue.NewMemoryQueue()
+	executor := NewFFmpegExecutor(config.DefaultConfig().FFmpeg, "", nil, zap.NewNop())
+	jp := NewJobProcessor(workerConfig, q, nil, executor, metrics.NewJobStatsAggregator(), zap.NewNop())
+	jp.ScaleUp(workers)
+	t.Cleanup(jp.Stop)
+	return jp, q
+}
+
+func TestJobProcessorSnapshot(t *testing.T) {
+	ctx := context.Background()
+	jp, q := newTestProcessor(t, 3)
+
+	for _, id := range []string{"queued-1", "queued-2"} {
+		if err := q.Enqueue(ctx, &queue.Job{ID: id}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	jp.workers[0].setCurrentJob(&queue.Job{ID: "running", Progress: 40, Metadata: map[string]string{"k": "v"}})
+	jp.Pause()
+
+	state := jp.Snapshot()
+	if len(state.ActiveJobs) != 1 || state.ActiveJobs[0].ID != "running" || state.ActiveJobs[0].Progress != 40 {
+		t.Errorf("ActiveJobs = %v, want the running job at 40%%", state.ActiveJobs)
+	}
+	if state.IdleWorkerCount != 2 {
+		t.Errorf("IdleWorkerCount = %d, want 2", state.IdleWorkerCount)
+	}
+	if state.QueueDepth != 2 {
+		t.Errorf("QueueDepth = %d, want 2", state.QueueDepth)
+	}
+	if !state.IsPaused {
+		t.Errorf("IsPaused = false after Pause")
+	}
+
+	// The snapshot is a copy that the worker does not see changes to
+	state.ActiveJobs[0].Metadata["k"] = "changed"
+	if job := jp.workers[0].CurrentJob(); job.Metadata["k"] != "v" {
+		t.Errorf("changing the snapshot changed the worker's job")
+	}
+}
+
+func TestProcessorStateHandler(t *testing.T) {
+	jp, _ := newTestProcessor(t, 1)
+	handler := metrics.ProcessorStateHandler(jp.Snapshot)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/processor", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", rec.Code)
+	}
+	var state metrics.ProcessorState
+	if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+		t.Fatalf("response is not a processor state: %v", err)
+	}
+	if state.IdleWorkerCount != 1 {
+		t.Errorf("idle_worker_count = %d, want 1", state.IdleWorkerCount)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/processor", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestJobProcessorScaleDown(t *testing.T) {
+	jp, _ := newTestProcessor(t, 4)
+	if err := jp.SetWorkerLimits(1, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// Idle workers leave the pool as soon as they are retired, down to the minimum
+	if n := jp.ScaleDown(5); n != 3 {
+		t.Errorf("ScaleDown() retired %d workers, want 3", n)
+	}
+	if jp.WorkerCount() != 1 || len(jp.workerPool) != 1 {
+		t.Errorf("%d workers with %d in the pool, want 1", jp.WorkerCount(), len(jp.workerPool))
+	}
+	if n := jp.ScaleDown(1); n != 0 {
+		t.Errorf("ScaleDown() at the minimum retired %d workers", n)
+	}
+}
+
+func TestJobProcessorRetiresBusyWorkersAboveMax(t *testing.T) {
+	jp, _ := newTestProcessor(t, 2)
+	first, second := <-jp.workerPool, <-jp.workerPool
+
+	// Busy workers keep running when the limit is lowered
+	if err := jp.SetWorkerLimits(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if jp.WorkerCount() != 2 {
+		t.Fatalf("%d workers, want both busy ones kept", jp.WorkerCount())
+	}
+
+	jp.releaseWorker(first)
+	if first.ctx.Err() == nil || jp.WorkerCount() != 1 {
+		t.Errorf("a worker returning above the maximum was not retired")
+	}
+	jp.releaseWorker(second)
+	if second.ctx.Err() != nil || len(jp.workerPool) != 1 {
+		t.Errorf("the last worker was not returned to the pool")
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+var durationPattern = regexp.MustCompile(`Duration:\s*(\d+):(\d+):(\d+(?:\.\d+)?)`)
+
+// FFmpegProgressParser parses the key=value output of ffmpeg -progress pipe:1
+// into progress snapshots. The input duration, needed for the percentage, is
+// read from the stderr header through StderrWriter.
+type FFmpegProgressParser struct {
+	onProgress func(queue.ProgressSnapshot)
+
+	// duration is written from the stderr copy goroutine
+	duration atomic.Int64
+}
+
+// NewFFmpegProgressParser creates a parser that calls onProgress for each progress block
+func NewFFmpegProgressParser(onProgress func(queue.ProgressSnapshot)) *FFmpegProgressParser {
+	return &FFmpegProgressParser{
+		onProgress: onProgress,
+	}
+}
+
+// Scan reads progress output until r is closed. FFmpeg writes one key=value
+// pair per line and ends each block with progress=continue or progress=end.
+func (p *FFmpegProgressParser) Scan(r io.Reader) {
+	var snapshot queue.ProgressSnapshot
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "frame":
+			snapshot.Frame, _ = strconv.ParseInt(value, 10, 64)
+		case "fps":
+			snapshot.FPS, _ = strconv.ParseFloat(value, 64)
+		case "bitrate":
+			snapshot.Bitrate = value
+		case "total_size":
+			if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+				snapshot.SizeKB = size / 1024
+			}
+		case "out_time":
+			snapshot.TimeStr = value
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+				snapshot.OutTimeUS = us
+				snapshot.Percent = p.percent(us)
+			}
+		case "speed":
+			snapshot.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+		case "progress":
+			if value == "end" {
+				snapshot.Percent = 100
+			}
+			p.onProgress(snapshot)
+			snapshot = queue.ProgressSnapshot{}
+		}
+	}
+
+	// Keep the pipe drained if the scanner gave up on an oversized line
+	io.Copy(io.Discard, r)
+}
+
+// percent converts the output time in microseconds into a percentage of the
+// input duration, or 0 while the duration is unknown
+func (p *FFmpegProgressParser) percent(outTimeUS int64) float64 {
+	duration := p.duration.Load()
+	if duration <= 0 || outTimeUS < 0 {
+		return 0
+	}
+	return min(100, float64(outTimeUS)/(time.Duration(duration).Seconds()*1e6)*100)
+}
+
+// StderrWriter returns a writer that picks the input duration out of the
+// FFmpeg stderr header
+func (p *FFmpegProgressParser) StderrWriter() io.Writer {
+	return &durationWriter{parser: p}
+}
+
+// durationWriter scans stderr lines for the input duration
+type durationWriter struct {
+	parser *FFmpegProgressParser
+	buf    []byte
+	found  bool
+}
+
+// Write implements io.Writer, splitting output on carriage returns and newlines
+func (w *durationWriter) Write(data []byte) (int, error) {
+	if w.found {
+		return len(data), nil
+	}
+
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if m := durationPattern.FindSubmatch(w.buf[:i]); m != nil {
+			w.parser.duration.Store(int64(parseTimestamp(string(m[1]), string(m[2]), string(m[3]))))
+			w.found = true
+			w.buf = nil
+			break
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// parseTimestamp converts HH, MM and SS.ss components into a duration
+func parseTimestamp(hours, minutes, seconds string) time.Duration {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.ParseFloat(seconds, 64)
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+}
+
+// progressOutput is the FFmpeg stdout writer. It feeds the progress parser
+// and copies the output to the job log, without letting a failing log file
+// interrupt progress reporting.
+type progressOutput struct {
+	progress io.Writer
+	log      io.Writer
+}
+
+// Write implements io.Writer
+func (o *progressOutput) Write(data []byte) (int, error) {
+	if o.log != nil {
+		o.log.Write(data)
+	}
+	return o.progress.Write(data)
+}
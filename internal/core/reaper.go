@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// reaperPageSize is the number of processing jobs listed per page
+const reaperPageSize = 100
+
+// StaleJobReaper puts jobs back in the queue that have been in the
+// processing state for too long. A worker that crashes mid-job, or an
+// instance that is killed, leaves its job processing forever; the reaper
+// makes it run again, counting the attempt in its retry count.
+type StaleJobReaper struct {
+	queue queue.Queue
+	// processor is consulted so jobs still running on this instance are
+	// left alone, nil when the reaper runs without one
+	processor *JobProcessor
+	interval  time.Duration
+	maxAge    time.Duration
+	logger    *zap.Logger
+}
+
+// NewStaleJobReaper creates a reaper that checks q every interval and
+// requeues jobs that started processing more than maxAge ago
+func NewStaleJobReaper(q queue.Queue, processor *JobProcessor, interval, maxAge time.Duration, logger *zap.Logger) *StaleJobReaper {
+	return &StaleJobReaper{
+		queue:     q,
+		processor: processor,
+		interval:  interval,
+		maxAge:    maxAge,
+		logger:    logger,
+	}
+}
+
+// Run reaps stale jobs every interval until ctx is cancelled
+func (r *StaleJobReaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := r.Reap(ctx, now); err != nil {
+				r.logger.Warn("Failed to reap stale jobs", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Reap requeues every processing job that started more than maxAge before
+// now and returns how many were requeued
+func (r *StaleJobReaper) Reap(ctx context.Context, now time.Time) (int, error) {
+	// Collect stale jobs first, as requeueing changes the processing listing
+	var stale []*queue.Job
+	for offset := 0; ; offset += reaperPageSize {
+		jobs, total, err := r.queue.ListJobs(ctx, queue.JobStatusProcessing, reaperPageSize, offset)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list processing jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			if job.StartedAt != nil && now.Sub(*job.StartedAt) > r.maxAge && !r.runningLocally(job.ID) {
+				stale = append(stale, job)
+			}
+		}
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+
+	reaped := 0
+	for _, job := range stale {
+		processingTime := now.Sub(*job.StartedAt)
+		if err := requeueStaleJob(job, processingTime); err != nil {
+			r.logger.Warn("Cannot requeue stale job", zap.String("job_id", job.ID), zap.Error(err))
+			continue
+		}
+		if err := queue.Requeue(ctx, r.queue, job); err != nil {
+			return reaped, fmt.Errorf("failed to requeue job %s: %w", job.ID, err)
+		}
+		reaped++
+		metrics.IncJobsReaped()
+
+		r.logger.Warn("Requeued stale processing job",
+			zap.String("job_id", job.ID),
+			zap.Duration("processing_time", processingTime),
+			zap.Int("retry_count", job.RetryCount()))
+	}
+
+	return reaped, nil
+}
+
+// runningLocally reports whether a worker of this instance is processing
+// the job, which the watchdog handles instead
+func (r *StaleJobReaper) runningLocally(jobID string) bool {
+	if r.processor == nil {
+		return false
+	}
+
+	r.processor.workersMu.RLock()
+	defer r.processor.workersMu.RUnlock()
+
+	for _, w := range r.processor.workers {
+		if job := w.CurrentJob(); job != nil && job.ID == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// requeueStaleJob resets a stale job to the queued state and increments its
+// retry count
+func requeueStaleJob(job *queue.Job, processingTime time.Duration) error {
+	reason := fmt.Sprintf("stale after processing for %s", processingTime.Round(time.Second))
+	if err := queue.DefaultStateMachine.Transition(job, queue.JobStatusQueued, reason); err != nil {
+		return err
+	}
+	count := job.RetryCount() + 1
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataRetryCount] = strconv.Itoa(count)
+	delete(job.Metadata, queue.MetadataProgress)
+
+	job.Progress = 0.0
+	job.Error = ""
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	return nil
+}
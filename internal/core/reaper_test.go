@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// startProcessing enqueues a job to an otherwise empty queue and dequeues it
+// as if a worker had picked it up at startedAt
+func startProcessing(t *testing.T, q queue.Queue, id string, startedAt time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, &queue.Job{ID: id, Progress: 40}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.Dequeue(ctx)
+	if err != nil || job == nil || job.ID != id {
+		t.Fatalf("Dequeue() = %v, %v; want job %s", job, err, id)
+	}
+	job.Status = queue.JobStatusProcessing
+	job.StartedAt = &startedAt
+	if err := q.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStaleJobReaper(t *testing.T) {
+	ctx := context.Background()
+	jp, q := newTestProcessor(t, 1)
+	now := time.Now()
+
+	// More stale jobs than fit on one page of the processing listing
+	staleJobs := reaperPageSize + 20
+	for i := 0; i < staleJobs; i++ {
+		startProcessing(t, q, fmt.Sprintf("stale-%d", i), now.Add(-3*time.Hour))
+	}
+	startProcessing(t, q, "fresh", now.Add(-10*time.Minute))
+	startProcessing(t, q, "local", now.Add(-3*time.Hour))
+	local, _ := q.GetJob(ctx, "local")
+	jp.workers[0].setCurrentJob(local)
+
+	reaper := NewStaleJobReaper(q, jp, time.Minute, 2*time.Hour, zap.NewNop())
+	reaped, err := reaper.Reap(ctx, now)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reaped != staleJobs {
+		t.Errorf("Reap() = %d, want %d", reaped, staleJobs)
+	}
+
+	job, err := q.GetJob(ctx, "stale-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != queue.JobStatusQueued || job.RetryCount() != 1 || job.Progress != 0 || job.StartedAt != nil {
+		t.Errorf("reaped job = %s, retry %d, progress %v, started %v; want it queued again with one retry",
+			job.Status, job.RetryCount(), job.Progress, job.StartedAt)
+	}
+	for _, id := range []string{"fresh", "local"} {
+		if job, _ := q.GetJob(ctx, id); job.Status != queue.JobStatusProcessing {
+			t.Errorf("job %s = %s, want it left processing", id, job.Status)
+		}
+	}
+	if depth, _ := q.GetQueueDepth(ctx); int(depth) != staleJobs {
+		t.Errorf("queue depth = %d, want the %d reaped jobs", depth, staleJobs)
+	}
+
+	// A job that goes stale again counts another retry
+	startedAt := now.Add(-3 * time.Hour)
+	job, _ = q.Dequeue(ctx)
+	job.Status = queue.JobStatusProcessing
+	job.StartedAt = &startedAt
+	if err := q.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if reaped, err := reaper.Reap(ctx, now); err != nil || reaped != 1 {
+		t.Fatalf("second Reap() = %d, %v; want 1", reaped, err)
+	}
+	if job, _ := q.GetJob(ctx, job.ID); job.RetryCount() != 2 {
+		t.Errorf("retry_count = %d after two reaps, want 2", job.RetryCount())
+	}
+}
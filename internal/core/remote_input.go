@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// isRemoteInput reports whether an input path is an HTTP(S) URL
+func isRemoteInput(inputPath string) bool {
+	return strings.HasPrefix(inputPath, "http://") || strings.HasPrefix(inputPath, "https://")
+}
+
+// downloadInputFile streams a remote input into a new file in destDir and
+// returns the file path and the hex SHA-256 of its content. The download
+// fails if the body is shorter or longer than its Content-Length.
+func downloadInputFile(ctx context.Context, inputURL, destDir string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, inputURL, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid input URL: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download input: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to download input: %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create input directory: %w", err)
+	}
+
+	// Keep the extension so FFmpeg can use it to guess the format
+	ext := ""
+	if u, err := url.Parse(inputURL); err == nil {
+		ext = path.Ext(u.Path)
+	}
+	file, err := os.CreateTemp(destDir, "input-*"+ext)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create input file: %w", err)
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && resp.ContentLength >= 0 && written != resp.ContentLength {
+		err = fmt.Errorf("received %d of %d bytes", written, resp.ContentLength)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", "", fmt.Errorf("failed to download input: %w", err)
+	}
+
+	return file.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
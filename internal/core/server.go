@@ -3,42 +3,96 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/nikhil0verma/flixsrota/internal/admin"
+	"github.com/nikhil0verma/flixsrota/internal/billing"
 	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/events"
+	"github.com/nikhil0verma/flixsrota/internal/ffmpeg"
+	flixgrpc "github.com/nikhil0verma/flixsrota/internal/grpc"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/nikhil0verma/flixsrota/internal/preflight"
+	"github.com/nikhil0verma/flixsrota/internal/recovery"
 	"go.uber.org/zap"
 	grpcstd "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 // Server represents the main Flixsrota server
 type Server struct {
-	config     *config.Config
-	logger     *zap.Logger
-	grpcServer *grpcstd.Server
-	processor  *JobProcessor
-	queue      queue.Queue
-	storage    storage.Storage
-	ctx        context.Context
-	cancel     context.CancelFunc
+	config        *config.Config
+	logger        *zap.Logger
+	grpcServer    *grpcstd.Server
+	adminServer   *grpcstd.Server
+	healthServer  *health.Server
+	metricsServer *http.Server
+	processor     *JobProcessor
+	executor      *FFmpegExecutor
+	stats         *metrics.JobStatsAggregator
+	events        events.Bus
+	queue         queue.Queue
+	storage       storage.Storage
+	storageCache  *storage.ReadThroughCache
+	ledger        *billing.CostLedger
+	ctx           context.Context
+	cancel        context.CancelFunc
+	shutdownCh    chan struct{}
+
+	// healthMu guards the latest queue and storage connection tests, the
+	// consecutive failures of each health service and the degraded state
+	healthMu       sync.RWMutex
+	healthResults  []preflight.Result
+	healthFailures map[string]int
+	degraded       bool
+
+	// logLevel, liveConfig and configWatcher support reloading the config file
+	logLevel      zap.AtomicLevel
+	liveConfig    *config.Config
+	configWatcher io.Closer
+
+	// exporters are the metric exporters flushed at shutdown, keyed by name
+	exporters map[string]metrics.Flusher
 }
 
 // NewServer creates a new Flixsrota server instance
 func NewServer(cfg *config.Config) *Server {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	logger, _ := zap.NewProduction()
+	logLevel := zap.NewAtomicLevel()
+	if err := logLevel.UnmarshalText([]byte(cfg.Logging.Level)); err != nil {
+		logLevel.SetLevel(zap.InfoLevel)
+	}
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = logLevel
+	logger, _ := zapConfig.Build()
 
 	return &Server{
-		config: cfg,
-		logger: logger,
-		ctx:    ctx,
-		cancel: cancel,
+		config:         cfg,
+		logger:         logger,
+		stats:          metrics.NewJobStatsAggregator(),
+		ctx:            ctx,
+		cancel:         cancel,
+		shutdownCh:     make(chan struct{}, 1),
+		healthFailures: make(map[string]int),
+		exporters:      make(map[string]metrics.Flusher),
+		logLevel:       logLevel,
+		liveConfig:     cfg,
 	}
 }
 
@@ -46,16 +100,33 @@ func NewServer(cfg *config.Config) *Server {
 func (s *Server) Start() error {
 	s.logger.Info("Starting Flixsrota server...")
 
+	// Report panics in background goroutines
+	reporter, err := recovery.NewCrashReporter(s.config.CrashReporter)
+	if err != nil {
+		return fmt.Errorf("failed to initialize crash reporter: %w", err)
+	}
+	recovery.SetCrashReporter(reporter, s.config.CrashReporter.RethrowPanic)
+
 	// Initialize queue
 	if err := s.initializeQueue(); err != nil {
 		return fmt.Errorf("failed to initialize queue: %w", err)
 	}
 
+	// Initialize job events
+	if err := s.initializeEvents(); err != nil {
+		return fmt.Errorf("failed to initialize job events: %w", err)
+	}
+
 	// Initialize storage
 	if err := s.initializeStorage(); err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	// Fill the storage cache with recent outputs
+	if s.storageCache != nil && s.config.Storage.Cache.WarmJobs > 0 {
+		recovery.SafeGo(s.logger, s.warmStorageCache)
+	}
+
 	// Initialize job processor
 	if err := s.initializeJobProcessor(); err != nil {
 		return fmt.Errorf("failed to initialize job processor: %w", err)
@@ -67,10 +138,63 @@ func (s *Server) Start() error {
 	}
 
 	// Start job processor
-	go s.processor.Start()
+	recovery.SafeGo(s.logger, s.processor.Start)
+
+	// Start FFmpeg log cleanup
+	if s.config.FFmpeg.CaptureLog {
+		recovery.SafeGo(s.logger, func() { s.executor.RunLogCleanup(s.ctx) })
+	}
+
+	// Start temp file cleanup
+	if s.config.Storage.Local.Cleanup.Enabled {
+		recovery.SafeGo(s.logger, s.purgeTempFiles)
+	}
 
 	// Start gRPC server
-	go s.startGRPCServer()
+	recovery.SafeGo(s.logger, func() {
+		if err := s.startGRPCServer(); err != nil {
+			s.logger.Error("gRPC server failed", zap.Error(err))
+		}
+	})
+
+	// Keep the health service up to date
+	recovery.SafeGo(s.logger, s.watchHealth)
+
+	// Start admin service
+	if s.adminServer != nil {
+		recovery.SafeGo(s.logger, s.startAdminServer)
+	}
+
+	// Start metrics endpoint
+	if s.config.Metrics.Enabled {
+		s.initializeMetricsServer()
+		recovery.SafeGo(s.logger, func() {
+			if err := s.startMetricsServer(); err != nil {
+				s.logger.Error("Metrics server failed", zap.Error(err))
+			}
+		})
+		recovery.SafeGo(s.logger, s.collectStorageMetrics)
+	}
+
+	// Start profiling endpoints
+	if s.config.Admin.EnablePprof {
+		recovery.SafeGo(s.logger, s.startPprofServer)
+	}
+
+	// Persist metrics to InfluxDB
+	if s.config.Metrics.InfluxDB.Enabled {
+		writer := metrics.NewInfluxDBWriter(s.config.Metrics.InfluxDB,
+			metrics.NewSystemMetricsCollector(s.logger),
+			s.processor.Snapshot, s.processor.WorkerStates, s.logger)
+		interval := time.Duration(s.config.Metrics.CollectInterval) * time.Second
+		recovery.SafeGo(s.logger, func() { writer.Run(s.ctx, interval) })
+		s.exporters["influxdb"] = writer
+	}
+
+	// Apply config file changes while running
+	if s.config.FilePath != "" {
+		s.watchConfig()
+	}
 
 	// Wait for shutdown signal
 	s.waitForShutdown()
@@ -85,21 +209,58 @@ func (s *Server) Stop() error {
 	// Cancel context to stop all goroutines
 	s.cancel()
 
+	// Stop watching the config file
+	if s.configWatcher != nil {
+		s.configWatcher.Close()
+	}
+
 	// Stop job processor
 	if s.processor != nil {
 		s.processor.Stop()
 	}
 
+	// Report NOT_SERVING to health checks while draining
+	if s.healthServer != nil {
+		s.healthServer.Shutdown()
+	}
+
 	// Stop gRPC server
 	if s.grpcServer != nil {
 		s.grpcServer.GracefulStop()
 	}
 
+	// Stop admin service
+	if s.adminServer != nil {
+		s.adminServer.GracefulStop()
+	}
+
+	// Write the metrics the exporters still buffer
+	if len(s.exporters) > 0 {
+		flushCtx, cancel := context.WithTimeout(context.Background(), metrics.FlushTimeout)
+		metrics.FlushAll(flushCtx, s.exporters, s.logger)
+		cancel()
+	}
+
+	// Stop metrics endpoint
+	if s.metricsServer != nil {
+		s.metricsServer.Shutdown(context.Background())
+	}
+
 	// Close queue connection
 	if s.queue != nil {
 		s.queue.Close()
 	}
 
+	// Close cost ledger
+	if s.ledger != nil {
+		s.ledger.Close()
+	}
+
+	// Close job events
+	if s.events != nil {
+		s.events.Close()
+	}
+
 	s.logger.Info("Server stopped")
 	return nil
 }
@@ -108,83 +269,321 @@ func (s *Server) Stop() error {
 func (s *Server) initializeQueue() error {
 	var err error
 
-	switch s.config.Queue.Adapter {
+	s.queue, err = NewQueue(s.ctx, s.config.Queue, s.config.MultiQueue)
+	if err != nil {
+		return fmt.Errorf("failed to initialize queue: %w", err)
+	}
+
+	// Jobs carry the trace of the request that enqueued them
+	s.queue = queue.NewTracingQueue(s.queue)
+
+	s.logger.Info("Queue initialized", zap.String("adapter", s.config.Queue.Adapter))
+	return nil
+}
+
+// NewQueue creates the queue adapter selected in the configuration. The multi
+// adapter combines the queues listed in multiQueue in priority order.
+func NewQueue(ctx context.Context, cfg config.QueueConfig, multiQueue []config.QueueConfig) (queue.Queue, error) {
+	if len(cfg.PriorityLanes) > 0 {
+		return newLaneQueue(cfg)
+	}
+	if cfg.Adapter != "multi" {
+		return newQueueAdapter(ctx, cfg)
+	}
+
+	var names []string
+	var queues []queue.Queue
+	for _, queueCfg := range multiQueue {
+		q, err := newQueueAdapter(ctx, queueCfg)
+		if err != nil {
+			for _, opened := range queues {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to initialize queue %s: %w", queueCfg.Name, err)
+		}
+		names = append(names, queueCfg.Name)
+		queues = append(queues, q)
+	}
+
+	return queue.NewMultiQueue(names, queues)
+}
+
+// newLaneQueue creates a queue per priority lane. Lanes need a backend queue
+// each, which only the memory adapter provides: the redis adapter keeps every
+// job in a single sorted set.
+func newLaneQueue(cfg config.QueueConfig) (queue.Queue, error) {
+	if cfg.Adapter != "memory" {
+		return nil, fmt.Errorf("priority lanes are not supported by the %s queue adapter yet", cfg.Adapter)
+	}
+
+	var lanes []queue.PriorityLane
+	var queues []queue.Queue
+	for name, lane := range cfg.PriorityLanes {
+		lanes = append(lanes, queue.PriorityLane{
+			Name:        name,
+			MinPriority: lane.MinPriority,
+			MaxPriority: lane.MaxPriority,
+			Allocation:  lane.WorkerAllocation,
+		})
+		queues = append(queues, queue.NewMemoryQueue())
+	}
+
+	return queue.NewLaneQueue(lanes, queues)
+}
+
+// newQueueAdapter creates a single queue adapter
+func newQueueAdapter(ctx context.Context, cfg config.QueueConfig) (queue.Queue, error) {
+	switch cfg.Adapter {
 	case "redis":
-		s.queue, err = queue.NewRedisQueue(
-			s.ctx,
-			s.config.Queue.Redis.Address,
-			s.config.Queue.Redis.Password,
-			s.config.Queue.Redis.DB,
+		return queue.NewRedisQueue(
+			ctx,
+			cfg.Redis.Address,
+			cfg.Redis.Password,
+			cfg.Redis.DB,
 		)
+	case "memory":
+		return queue.NewMemoryQueue(), nil
 	case "kafka":
 		// TODO: Implement Kafka queue
-		return fmt.Errorf("kafka queue not implemented yet")
+		return nil, fmt.Errorf("kafka queue not implemented yet")
 	case "sqs":
 		// TODO: Implement SQS queue
-		return fmt.Errorf("sqs queue not implemented yet")
+		return nil, fmt.Errorf("sqs queue not implemented yet")
 	default:
-		return fmt.Errorf("unknown queue adapter: %s", s.config.Queue.Adapter)
+		return nil, fmt.Errorf("unknown queue adapter: %s", cfg.Adapter)
 	}
+}
 
+// initializeEvents initializes the job event bus and publishes queue changes on it
+func (s *Server) initializeEvents() error {
+	var err error
+
+	s.events, err = NewEventBus(s.ctx, s.config.Queue)
 	if err != nil {
-		return fmt.Errorf("failed to initialize queue: %w", err)
+		return err
 	}
 
-	s.logger.Info("Queue initialized", zap.String("adapter", s.config.Queue.Adapter))
+	s.queue = events.NewPublishingQueue(s.queue, s.events, s.logger)
 	return nil
 }
 
+// NewEventBus creates the job event bus. Redis queues share events between
+// server instances over pub/sub; other adapters use an in-process bus.
+func NewEventBus(ctx context.Context, cfg config.QueueConfig) (events.Bus, error) {
+	if cfg.Adapter == "redis" {
+		return events.NewRedisBus(ctx, cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	}
+	return events.NewMemoryBus(), nil
+}
+
 // initializeStorage initializes the storage adapter
 func (s *Server) initializeStorage() error {
 	var err error
 
-	switch s.config.Storage.Adapter {
+	s.storage, err = NewStorage(s.config.Storage, s.logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	if quota := s.config.Storage.Quota; quota.Enabled {
+		s.storage = storage.NewQuotaEnforcingStorage(
+			s.storage,
+			quota.MaxBytesPerTenant,
+			quota.MaxFilesPerTenant,
+			newQuotaCounter(s.config.Queue),
+			s.logger,
+		)
+		s.logger.Info("Storage quotas enabled",
+			zap.Int64("max_bytes_per_tenant", quota.MaxBytesPerTenant),
+			zap.Int64("max_files_per_tenant", quota.MaxFilesPerTenant))
+	}
+
+	if cache := s.config.Storage.Cache; cache.Enabled {
+		s.storageCache, err = storage.NewReadThroughCache(
+			s.storage,
+			cache.CachePath,
+			int64(cache.MaxCacheSizeGB*(1<<30)),
+			s.logger,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to initialize storage cache: %w", err)
+		}
+		s.storage = s.storageCache
+		s.logger.Info("Storage cache enabled",
+			zap.String("cache_path", cache.CachePath),
+			zap.Float64("max_cache_size_gb", cache.MaxCacheSizeGB))
+	}
+
+	s.logger.Info("Storage initialized", zap.String("adapter", s.config.Storage.Adapter))
+	return nil
+}
+
+// newQuotaCounter keeps quota counters in Redis when the queue uses Redis,
+// and in memory otherwise
+func newQuotaCounter(cfg config.QueueConfig) storage.QuotaCounter {
+	if cfg.Adapter == "redis" {
+		return storage.NewRedisQuotaCounter(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	}
+	return storage.NewMemoryQuotaCounter()
+}
+
+// NewStorage creates the storage adapter selected in the configuration,
+// wrapped with its fallback backends if any are configured
+func NewStorage(cfg config.StorageConfig, logger *zap.Logger) (storage.Storage, error) {
+	primary, err := newStorageAdapter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Fallback) == 0 {
+		return primary, nil
+	}
+
+	var fallbacks []storage.Storage
+	for i, fallbackCfg := range cfg.Fallback {
+		fallback, err := newStorageAdapter(fallbackCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize fallback storage %d: %w", i, err)
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+
+	return storage.NewFallbackStorage(primary, fallbacks, cfg.WritableFallbacks, logger), nil
+}
+
+// newStorageAdapter creates a single storage adapter
+func newStorageAdapter(cfg config.StorageConfig) (storage.Storage, error) {
+	switch cfg.Adapter {
 	case "local":
-		s.storage, err = storage.NewLocalStorage(
-			s.config.Storage.Local.BasePath,
-			s.config.Storage.Local.TempPath,
+		return storage.NewLocalStorage(
+			cfg.Local.BasePath,
+			cfg.Local.TempPath,
 		)
 	case "s3":
 		// TODO: Implement S3 storage
-		return fmt.Errorf("s3 storage not implemented yet")
+		return nil, fmt.Errorf("s3 storage not implemented yet")
 	case "gcs":
 		// TODO: Implement GCS storage
-		return fmt.Errorf("gcs storage not implemented yet")
+		return nil, fmt.Errorf("gcs storage not implemented yet")
 	default:
-		return fmt.Errorf("unknown storage adapter: %s", s.config.Storage.Adapter)
+		return nil, fmt.Errorf("unknown storage adapter: %s", cfg.Adapter)
 	}
+}
+
+// checkFFmpegFeatures warns when the FFmpeg binary is too old for features
+// the configured output needs. Jobs using them fail at encode time.
+func (s *Server) checkFFmpegFeatures() {
+	ctx, cancel := context.WithTimeout(s.ctx, ffmpegProbeTimeout)
+	defer cancel()
 
+	info, err := ffmpeg.ProbeVersion(ctx, s.config.FFmpeg.ExecutablePath)
 	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+		s.logger.Warn("Failed to read FFmpeg version", zap.Error(err))
+		return
 	}
 
-	s.logger.Info("Storage initialized", zap.String("adapter", s.config.Storage.Adapter))
-	return nil
+	s.logger.Info("Detected FFmpeg",
+		zap.String("version", info.Version),
+		zap.Strings("libraries", info.Libraries))
+	if missing := info.MissingFeatures(ffmpeg.RequiredFeatures(s.config.FFmpeg)); len(missing) > 0 {
+		s.logger.Warn("FFmpeg version does not support features the configuration needs",
+			zap.String("version", info.Version),
+			zap.Strings("missing_features", missing))
+	}
 }
 
 // initializeJobProcessor initializes the job processor
 func (s *Server) initializeJobProcessor() error {
-	executor := NewFFmpegExecutor(s.config.FFmpeg)
+	s.executor = NewFFmpegExecutor(
+		s.config.FFmpeg,
+		ffmpeg.LogDir(s.config.Storage.Local.TempPath),
+		s.stats,
+		s.logger,
+	)
+
+	s.checkFFmpegFeatures()
+
+	// Pick the hardware encoder before any job runs
+	if s.config.FFmpeg.AutoDetectHWAccel && s.config.FFmpeg.HardwareAccel == "" {
+		backend, err := s.executor.DetectHardwareAccel()
+		if err != nil {
+			s.logger.Warn("Hardware encoder detection failed, using software encoding", zap.Error(err))
+		} else {
+			s.logger.Info("Detected hardware encoder", zap.String("hardware_accel", backend))
+			s.config.FFmpeg.HardwareAccel = backend
+			s.executor.config.HardwareAccel = backend
+		}
+	}
 
 	s.processor = NewJobProcessor(
 		s.config.Worker,
 		s.queue,
 		s.storage,
-		executor,
+		s.executor,
+		s.stats,
 		s.logger,
 	)
 
+	// Inputs under the local storage base path must stay inside it
+	if s.config.Storage.Adapter == "local" {
+		s.processor.inputRoot = s.config.Storage.Local.BasePath
+	}
+
+	// Let FFmpeg read S3 inputs itself instead of downloading them
+	s.processor.directInput = s.config.Storage.Adapter == "s3" && s.config.Storage.S3.DirectS3Mode
+
+	retry := s.config.Storage.UploadRetry
+	s.processor.uploadRetry = storage.UploadRetryPolicy{
+		MaxRetries:   retry.MaxRetries,
+		InitialDelay: time.Duration(retry.InitialDelayMs) * time.Millisecond,
+		MaxDelay:     time.Duration(retry.MaxDelayMs) * time.Millisecond,
+		Multiplier:   retry.Multiplier,
+	}
+
+	// Keep the cost ledger in the queue's Redis server
+	if s.config.Billing.Enabled {
+		redisCfg := s.config.Queue.Redis
+		s.ledger = billing.NewCostLedger(s.config.Billing, redisCfg.Address, redisCfg.Password, redisCfg.DB)
+		s.processor.ledger = s.ledger
+		s.logger.Info("Job cost ledger enabled")
+	}
+
 	s.logger.Info("Job processor initialized")
 	return nil
 }
 
 // initializeGRPCServer initializes the gRPC server
 func (s *Server) initializeGRPCServer() error {
-	s.grpcServer = grpcstd.NewServer()
+	var credsOpts []grpcstd.ServerOption
+	if s.config.GRPC.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.config.GRPC.TLSCertFile, s.config.GRPC.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load gRPC TLS credentials: %w", err)
+		}
+		credsOpts = append(credsOpts, grpcstd.Creds(creds))
+	}
+
+	opts := append([]grpcstd.ServerOption{
+		grpcstd.UnaryInterceptor(middleware.RequestIDInterceptor()),
+		grpcstd.StreamInterceptor(middleware.RequestIDStreamInterceptor()),
+	}, credsOpts...)
+
+	s.grpcServer = grpcstd.NewServer(opts...)
 
 	// TODO: Register services when protobuf is generated
 	// For now, we'll just create the server without services
 
+	// Standard health service for grpc-health-probe and Kubernetes probes
+	s.healthServer = health.NewServer()
+	healthgrpc.RegisterHealthServer(s.grpcServer, s.healthServer)
+
+	// The admin service is only exposed when it is protected by an API key
+	if s.config.GRPC.AdminAPIKey != "" {
+		s.adminServer = flixgrpc.NewAdminServer(s.config, s.processor, s.logLevel, s.requestShutdown, s.logger, credsOpts...)
+	} else {
+		s.logger.Info("Admin service disabled, set grpc.admin_api_key to enable it")
+	}
+
 	return nil
 }
 
@@ -212,13 +611,246 @@ func (s *Server) startGRPCServer() error {
 	return nil
 }
 
+// startAdminServer starts the admin gRPC service on its own port
+func (s *Server) startAdminServer() {
+	address := fmt.Sprintf("%s:%d", s.config.GRPC.Address, s.config.GRPC.AdminPort)
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		s.logger.Error("Failed to listen for admin service", zap.String("address", address), zap.Error(err))
+		return
+	}
+
+	s.logger.Info("Admin service starting", zap.String("address", address))
+
+	if err := s.adminServer.Serve(lis); err != nil {
+		s.logger.Error("Admin service stopped", zap.Error(err))
+	}
+}
+
+// requestShutdown asks waitForShutdown to stop the server
+func (s *Server) requestShutdown() {
+	select {
+	case s.shutdownCh <- struct{}{}:
+	default:
+	}
+}
+
+// initializeMetricsServer initializes the HTTP endpoints served on the metrics port
+func (s *Server) initializeMetricsServer() {
+	mux := http.NewServeMux()
+	mux.Handle(s.config.Metrics.Path, metrics.Handler())
+	mux.Handle("/v1/processor/state", metrics.ProcessorStateHandler(s.processor.Snapshot))
+	mux.Handle("/v1/storage/tree", storage.TreeHandler(s.storage))
+	mux.Handle(jobsAPIPath, s.jobsHandler())
+	mux.Handle("/readyz", http.HandlerFunc(s.serveReadiness))
+
+	if err := metrics.RegisterProcessor(s.processor.Snapshot); err != nil {
+		s.logger.Warn("Failed to register processor metrics", zap.Error(err))
+	}
+
+	s.metricsServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.config.Metrics.Port),
+		Handler: mux,
+	}
+}
+
+// jobsHandler serves the job endpoints, which share the /v1/jobs/ prefix:
+// the progress endpoints, and the job copy endpoint when an admin API key is
+// configured
+func (s *Server) jobsHandler() http.Handler {
+	progress := NewJobProgressHandler(s.queue, s.events, s.config.Metrics.MaxWebSocketConns, s.logger)
+	if s.config.GRPC.AdminAPIKey == "" {
+		return progress
+	}
+
+	copyJob := admin.RequireToken(s.config.GRPC.AdminAPIKey, http.HandlerFunc(s.serveJobCopy))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/copy") {
+			copyJob.ServeHTTP(w, r)
+			return
+		}
+		progress.ServeHTTP(w, r)
+	})
+}
+
+// collectStorageMetrics measures the storage backend every collect interval
+// and publishes the result as Prometheus gauges
+func (s *Server) collectStorageMetrics() {
+	ticker := time.NewTicker(time.Duration(s.config.Metrics.CollectInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		m, err := storage.CollectMetrics(s.ctx, s.storage)
+		if err != nil {
+			s.logger.Warn("Failed to collect storage metrics", zap.Error(err))
+		} else {
+			metrics.SetStorageMetrics(m.TotalBytes, m.UsedBytes, m.FileCount)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// warmCachePageSize is how many completed jobs are listed at a time when
+// warming the storage cache
+const warmCachePageSize = 100
+
+// warmStorageCache caches the outputs of the most recently completed jobs so
+// the first reads after a restart do not all go to the storage backend
+func (s *Server) warmStorageCache() {
+	var completed []*queue.Job
+	for offset := 0; ; offset += warmCachePageSize {
+		jobs, total, err := s.queue.ListJobs(s.ctx, queue.JobStatusCompleted, warmCachePageSize, offset)
+		if err != nil {
+			s.logger.Warn("Failed to list completed jobs for the storage cache", zap.Error(err))
+			return
+		}
+		completed = append(completed, jobs...)
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		return completedAt(completed[i]).After(completedAt(completed[j]))
+	})
+	if len(completed) > s.config.Storage.Cache.WarmJobs {
+		completed = completed[:s.config.Storage.Cache.WarmJobs]
+	}
+
+	paths := make([]string, 0, len(completed))
+	for _, job := range completed {
+		if job.OutputPath != "" {
+			paths = append(paths, job.OutputPath)
+		}
+	}
+
+	warmed, err := s.storageCache.Warm(s.ctx, paths)
+	if err != nil && s.ctx.Err() == nil {
+		s.logger.Warn("Failed to warm storage cache", zap.Error(err))
+	}
+	s.logger.Info("Storage cache warmed", zap.Int("files", warmed), zap.Int("jobs", len(completed)))
+}
+
+// completedAt returns when a job completed, or the zero time if unknown
+func completedAt(job *queue.Job) time.Time {
+	if job.CompletedAt == nil {
+		return time.Time{}
+	}
+	return *job.CompletedAt
+}
+
+// purgeTempFiles deletes old temporary files left behind by killed jobs
+// every cleanup interval
+func (s *Server) purgeTempFiles() {
+	cleanup := s.config.Storage.Local.Cleanup
+	maxAge := time.Duration(cleanup.MaxAgeHours) * time.Hour
+
+	ticker := time.NewTicker(time.Duration(cleanup.IntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := storage.PurgeTempFiles(s.ctx, s.storage, maxAge)
+		if err != nil && s.ctx.Err() == nil {
+			s.logger.Warn("Failed to purge temporary files", zap.Error(err))
+		}
+		if deleted > 0 {
+			s.logger.Debug("Purged temporary files",
+				zap.Int("deleted", deleted),
+				zap.Duration("max_age", maxAge))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startMetricsServer starts the Prometheus metrics HTTP endpoint
+func (s *Server) startMetricsServer() error {
+	s.logger.Info("Metrics server starting",
+		zap.Int("port", s.config.Metrics.Port),
+		zap.String("path", s.config.Metrics.Path))
+
+	if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+
+	return nil
+}
+
+// startPprofServer serves the profiling endpoints until the server stops
+func (s *Server) startPprofServer() {
+	if err := admin.ListenAndServeAdmin(s.ctx, s.config.Admin, s.logger); err != nil {
+		s.logger.Error("Admin server failed", zap.Error(err))
+	}
+}
+
+// watchConfig starts reloading the config file when it changes
+func (s *Server) watchConfig() {
+	watcher, err := config.Watch(s.config.FilePath, s.applyConfig)
+	if err != nil {
+		s.logger.Warn("Failed to watch config file", zap.String("path", s.config.FilePath), zap.Error(err))
+		return
+	}
+
+	s.configWatcher = watcher
+	s.logger.Info("Watching config file for changes", zap.String("path", s.config.FilePath))
+}
+
+// applyConfig applies the runtime-mutable fields of a reloaded config.
+// Other changed fields are logged and take effect after a restart.
+func (s *Server) applyConfig(updated *config.Config) {
+	workersChanged := false
+
+	for _, field := range config.ChangedFields(s.liveConfig, updated) {
+		if !config.IsMutableField(field) {
+			s.logger.Warn("Config change requires a restart", zap.String("field", field))
+			continue
+		}
+
+		switch field {
+		case "logging.level":
+			if err := s.logLevel.UnmarshalText([]byte(updated.Logging.Level)); err != nil {
+				s.logger.Warn("Invalid log level", zap.String("level", updated.Logging.Level))
+				continue
+			}
+		case "worker.min_workers", "worker.max_workers":
+			workersChanged = true
+		case "ffmpeg.timeout":
+			s.executor.SetTimeout(updated.FFmpeg.Timeout)
+		}
+		s.logger.Info("Config change applied", zap.String("field", field))
+	}
+
+	if workersChanged {
+		if err := s.processor.SetWorkerLimits(updated.Worker.MinWorkers, updated.Worker.MaxWorkers); err != nil {
+			s.logger.Warn("Failed to change worker limits", zap.Error(err))
+		}
+	}
+
+	s.liveConfig = updated
+}
+
 // waitForShutdown waits for shutdown signals
 func (s *Server) waitForShutdown() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigChan
-	s.logger.Info("Received shutdown signal")
+	select {
+	case <-sigChan:
+		s.logger.Info("Received shutdown signal")
+	case <-s.shutdownCh:
+		s.logger.Info("Received shutdown request from admin service")
+	}
 
 	if err := s.Stop(); err != nil {
 		s.logger.Error("Error during shutdown", zap.Error(err))
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/nikhil0verma/flixsrota/internal/recovery"
+	"go.uber.org/zap"
+)
+
+// ErrJobNotCompleted is returned when outputs are copied of a job that has
+// not completed
+var ErrJobNotCompleted = errors.New("job has not completed")
+
+// StorageManager copies the outputs of completed jobs between storage
+// backends
+type StorageManager struct {
+	queue       queue.Queue
+	storage     storage.Storage
+	concurrency int
+	logger      *zap.Logger
+}
+
+// NewStorageManager creates a manager for the jobs of q whose outputs are in
+// st, copying up to concurrency files at a time
+func NewStorageManager(q queue.Queue, st storage.Storage, concurrency int, logger *zap.Logger) *StorageManager {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &StorageManager{queue: q, storage: st, concurrency: concurrency, logger: logger}
+}
+
+// CopyJobOutputs copies every artifact in the output manifest of a completed
+// job to dst below destinationPrefix, keeping their paths relative to the
+// manifest. A manifest describing the copies is stored next to them and
+// returned.
+func (m *StorageManager) CopyJobOutputs(ctx context.Context, jobID, destinationPrefix string, dst storage.Storage) (*OutputManifest, error) {
+	job, err := m.queue.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Status != queue.JobStatusCompleted {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotCompleted, jobID, job.Status)
+	}
+	manifestPath := job.Metadata[queue.MetadataManifestPath]
+	if manifestPath == "" {
+		return nil, fmt.Errorf("job %s has no output manifest", jobID)
+	}
+
+	workDir, err := os.MkdirTemp(storage.TempDir(m.storage), "copy-"+jobID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	source, err := m.readManifest(ctx, manifestPath, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceDir := path.Dir(manifestPath)
+	copied := &OutputManifest{
+		JobID:     source.JobID,
+		Source:    source.Source,
+		CreatedAt: time.Now().UTC(),
+		Artifacts: make([]OutputArtifact, len(source.Artifacts)),
+	}
+
+	// The first failed copy cancels the others
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, m.concurrency)
+	for i, artifact := range source.Artifacts {
+		i, artifact := i, artifact
+		wg.Add(1)
+		slots <- struct{}{}
+		recovery.SafeGo(m.logger, func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			dstPath := path.Join(destinationPrefix, relativeArtifactPath(sourceDir, artifact.Path))
+			url, err := m.copyFile(copyCtx, artifact.Path, dstPath, dst, workDir)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to copy %s: %w", artifact.Path, err)
+					cancel()
+				}
+				return
+			}
+
+			if source.MasterPlaylistURL != "" && artifact.URL == source.MasterPlaylistURL {
+				copied.MasterPlaylistURL = url
+			}
+			artifact.Path = dstPath
+			artifact.URL = url
+			copied.Artifacts[i] = artifact
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := m.writeManifest(ctx, copied, path.Join(destinationPrefix, config.OutputManifestName), dst, workDir); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Copied job outputs",
+		zap.String("job_id", jobID),
+		zap.String("destination", destinationPrefix),
+		zap.Int("artifacts", len(copied.Artifacts)))
+	return copied, nil
+}
+
+// readManifest downloads and parses the output manifest at manifestPath
+func (m *StorageManager) readManifest(ctx context.Context, manifestPath, workDir string) (*OutputManifest, error) {
+	localPath := filepath.Join(workDir, config.OutputManifestName)
+	if err := m.storage.Download(ctx, manifestPath, localPath); err != nil {
+		return nil, fmt.Errorf("failed to download manifest: %w", err)
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest OutputManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest stores the manifest of the copied artifacts at remotePath
+func (m *StorageManager) writeManifest(ctx context.Context, manifest *OutputManifest, remotePath string, dst storage.Storage, workDir string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	localPath := filepath.Join(workDir, "copied-"+config.OutputManifestName)
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := dst.Upload(ctx, localPath, remotePath); err != nil {
+		return fmt.Errorf("failed to store manifest: %w", err)
+	}
+	return nil
+}
+
+// copyFile downloads srcPath to a temp file, uploads it to dst and returns
+// its URL there
+func (m *StorageManager) copyFile(ctx context.Context, srcPath, dstPath string, dst storage.Storage, workDir string) (string, error) {
+	tmp, err := os.CreateTemp(workDir, "artifact-*"+path.Ext(srcPath))
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := m.storage.Download(ctx, srcPath, tmp.Name()); err != nil {
+		return "", err
+	}
+	if err := dst.Upload(ctx, tmp.Name(), dstPath); err != nil {
+		return "", err
+	}
+	return dst.GetURL(ctx, dstPath)
+}
+
+// relativeArtifactPath returns the path of an artifact relative to the
+// directory of its manifest, or its base name when it is stored elsewhere
+func relativeArtifactPath(manifestDir, artifactPath string) string {
+	if rel, ok := strings.CutPrefix(artifactPath, manifestDir+"/"); ok {
+		return rel
+	}
+	return path.Base(artifactPath)
+}
+
+// ParseCopyDestination parses a copy destination of the form
+// <bucket>:<region>/<prefix> into the S3 settings of the destination and the
+// prefix. Credentials and encryption settings are taken from base.
+func ParseCopyDestination(destination string, base config.S3StorageConfig) (config.StorageConfig, string, error) {
+	bucket, rest, ok := strings.Cut(destination, ":")
+	if !ok || bucket == "" {
+		return config.StorageConfig{}, "", fmt.Errorf("invalid destination %q: expected <bucket>:<region>/<prefix>", destination)
+	}
+	region, prefix, _ := strings.Cut(rest, "/")
+	if region == "" {
+		return config.StorageConfig{}, "", fmt.Errorf("invalid destination %q: missing region", destination)
+	}
+
+	s3 := base
+	s3.Bucket = bucket
+	s3.Region = region
+	s3.DirectS3Mode = false
+	return config.StorageConfig{Adapter: "s3", S3: s3}, strings.Trim(prefix, "/"), nil
+}
+
+// jobsAPIPath is the prefix of the job endpoints of the metrics server
+const jobsAPIPath = "/v1/jobs/"
+
+// serveJobCopy handles POST /v1/jobs/{id}/copy?destination=<bucket>:<region>/<prefix>
+// and responds with the manifest of the copied outputs
+func (s *Server) serveJobCopy(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, jobsAPIPath), "/copy")
+	if !ok || jobID == "" || strings.Contains(jobID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dstConfig, prefix, err := ParseCopyDestination(r.URL.Query().Get("destination"), s.config.Storage.S3)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dst, err := NewStorage(dstConfig, s.logger)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to open destination: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	manager := NewStorageManager(s.queue, s.storage, s.config.Storage.CopyConcurrency, s.logger)
+	manifest, err := manager.CopyJobOutputs(r.Context(), jobID, prefix, dst)
+	switch {
+	case errors.Is(err, ErrJobNotCompleted):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		s.logger.Warn("Failed to copy job outputs", zap.String("job_id", jobID), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+)
+
+// memoryStorage keeps files in memory and serves them below baseURL
+type memoryStorage struct {
+	storage.Storage
+	baseURL string
+	tempDir string
+	failOn  string
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// newMemoryStorage returns an empty memory storage
+func newMemoryStorage(t *testing.T, baseURL string) *memoryStorage {
+	return &memoryStorage{baseURL: baseURL, tempDir: t.TempDir(), files: make(map[string][]byte)}
+}
+
+func (s *memoryStorage) TempDir() string { return s.tempDir }
+
+func (s *memoryStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	if remotePath == s.failOn {
+		return errors.New("bucket is full")
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[remotePath] = data
+	return nil
+}
+
+func (s *memoryStorage) Download(ctx context.Context, remotePath, localPath string) error {
+	s.mu.Lock()
+	data, ok := s.files[remotePath]
+	s.mu.Unlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+	return os.WriteFile(localPath, data, 0644)
+}
+
+func (s *memoryStorage) GetURL(ctx context.Context, remotePath string) (string, error) {
+	return s.baseURL + remotePath, nil
+}
+
+// newCopyTest returns a manager for a completed job whose HLS outputs and
+// manifest are stored in the returned source storage
+func newCopyTest(t *testing.T) (*StorageManager, *queue.MemoryQueue, *memoryStorage) {
+	t.Helper()
+	ctx := context.Background()
+	src := newMemoryStorage(t, "https://src.example.com/")
+	manifest := OutputManifest{
+		JobID: "job-1",
+		Artifacts: []OutputArtifact{
+			{Format: "hls", Path: "out/job-1/srota.m3u8", URL: "https://src.example.com/out/job-1/srota.m3u8"},
+			{Quality: "720p", Format: "hls", Path: "out/job-1/720p/index.m3u8"},
+			{Quality: "720p", Format: "ts", Path: "out/job-1/720p/segment0.ts"},
+			{Format: "jpg", Path: "thumbnails/job-1.jpg"},
+		},
+		MasterPlaylistURL: "https://src.example.com/out/job-1/srota.m3u8",
+	}
+	for _, artifact := range manifest.Artifacts {
+		src.files[artifact.Path] = []byte("data of " + artifact.Path)
+	}
+	data, _ := json.Marshal(manifest)
+	src.files["out/job-1/"+config.OutputManifestName] = data
+
+	q := queue.NewMemoryQueue()
+	if err := q.Enqueue(ctx, &queue.Job{ID: "job-1"}); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := q.Dequeue(ctx)
+	job.Status = queue.JobStatusCompleted
+	job.Metadata[queue.MetadataManifestPath] = "out/job-1/" + config.OutputManifestName
+	if err := q.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStorageManager(q, src, 2, zap.NewNop()), q, src
+}
+
+func TestStorageManagerCopyJobOutputs(t *testing.T) {
+	m, _, _ := newCopyTest(t)
+	dst := newMemoryStorage(t, "https://dst.example.com/")
+
+	copied, err := m.CopyJobOutputs(context.Background(), "job-1", "archive/2024", dst)
+	if err != nil {
+		t.Fatalf("CopyJobOutputs() error = %v", err)
+	}
+
+	// Paths stay relative to the manifest, and files stored elsewhere keep
+	// their base name
+	for _, want := range []string{"srota.m3u8", "720p/index.m3u8", "720p/segment0.ts", "job-1.jpg"} {
+		data, ok := dst.files["archive/2024/"+want]
+		if !ok {
+			t.Errorf("%s was not copied", want)
+			continue
+		}
+		if !strings.HasSuffix(string(data), want) {
+			t.Errorf("%s = %q, want the source file", want, data)
+		}
+	}
+	if copied.MasterPlaylistURL != "https://dst.example.com/archive/2024/srota.m3u8" {
+		t.Errorf("MasterPlaylistURL = %s, want the copy's URL", copied.MasterPlaylistURL)
+	}
+	if copied.Artifacts[1].Quality != "720p" || copied.Artifacts[1].URL != "https://dst.example.com/archive/2024/720p/index.m3u8" {
+		t.Errorf("artifact = %+v, want the copy's path and URL", copied.Artifacts[1])
+	}
+
+	var stored OutputManifest
+	if err := json.Unmarshal(dst.files["archive/2024/"+config.OutputManifestName], &stored); err != nil || len(stored.Artifacts) != 4 {
+		t.Errorf("stored manifest = %+v, %v; want the copied artifacts", stored, err)
+	}
+}
+
+func TestStorageManagerCopyJobOutputsErrors(t *testing.T) {
+	ctx := context.Background()
+	m, q, _ := newCopyTest(t)
+
+	dst := newMemoryStorage(t, "https://dst.example.com/")
+	dst.failOn = "archive/720p/segment0.ts"
+	if _, err := m.CopyJobOutputs(ctx, "job-1", "archive", dst); err == nil || !strings.Contains(err.Error(), "failed to copy out/job-1/720p/segment0.ts: bucket is full") {
+		t.Errorf("CopyJobOutputs() error = %v, want the failed copy", err)
+	}
+	if _, ok := dst.files["archive/"+config.OutputManifestName]; ok {
+		t.Errorf("manifest was stored for a failed copy")
+	}
+
+	if err := q.Enqueue(ctx, &queue.Job{ID: "job-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.CopyJobOutputs(ctx, "job-2", "archive", dst); !errors.Is(err, ErrJobNotCompleted) {
+		t.Errorf("CopyJobOutputs() of a queued job error = %v, want ErrJobNotCompleted", err)
+	}
+}
+
+func TestParseCopyDestination(t *testing.T) {
+	base := config.S3StorageConfig{Bucket: "outputs", Region: "us-east-1", AccessKeyID: "id", DirectS3Mode: true}
+
+	cfg, prefix, err := ParseCopyDestination("archive:eu-west-1/customers/acme/", base)
+	if err != nil {
+		t.Fatalf("ParseCopyDestination() error = %v", err)
+	}
+	if cfg.Adapter != "s3" || cfg.S3.Bucket != "archive" || cfg.S3.Region != "eu-west-1" || prefix != "customers/acme" {
+		t.Errorf("ParseCopyDestination() = %s %s/%s %q", cfg.Adapter, cfg.S3.Bucket, cfg.S3.Region, prefix)
+	}
+	if cfg.S3.AccessKeyID != "id" || cfg.S3.DirectS3Mode {
+		t.Errorf("S3 settings = %+v, want the base credentials without direct mode", cfg.S3)
+	}
+
+	for _, destination := range []string{"", "archive", ":eu-west-1/x", "archive:/x"} {
+		if _, _, err := ParseCopyDestination(destination, base); err == nil {
+			t.Errorf("ParseCopyDestination(%q) succeeded", destination)
+		}
+	}
+}
+
+func TestServeJobCopy(t *testing.T) {
+	m, q, src := newCopyTest(t)
+	s := &Server{config: config.DefaultConfig(), queue: q, storage: src, logger: m.logger}
+
+	tests := []struct {
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{http.MethodPost, "/v1/jobs/job-1", http.StatusNotFound},
+		{http.MethodPost, "/v1/jobs/a/b/copy", http.StatusNotFound},
+		{http.MethodGet, "/v1/jobs/job-1/copy?destination=archive:eu-west-1", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/v1/jobs/job-1/copy?destination=archive", http.StatusBadRequest},
+		// No S3 adapter exists to open the destination with
+		{http.MethodPost, "/v1/jobs/job-1/copy?destination=archive:eu-west-1/x", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.serveJobCopy(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestJobsHandlerRoutes(t *testing.T) {
+	_, q, src := newCopyTest(t)
+	cfg := config.DefaultConfig()
+	cfg.GRPC.AdminAPIKey = "secret"
+	s := &Server{config: cfg, queue: q, storage: src, logger: zap.NewNop()}
+	handler := s.jobsHandler()
+
+	tests := []struct {
+		method     string
+		target     string
+		token      string
+		wantStatus int
+	}{
+		{http.MethodPost, "/v1/jobs/job-1/copy?destination=archive", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/jobs/job-1/copy?destination=archive", "secret", http.StatusBadRequest},
+		// Progress endpoints stay open alongside the admin endpoint
+		{http.MethodGet, "/v1/jobs/missing/progress", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/jobs/job-1/progress", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+)
+
+// uploadOutput uploads a job output file, retrying transient failures with
+// the worker's retry policy, and records the retries on the job
+func (w *Worker) uploadOutput(job *queue.Job, localPath, remotePath string) error {
+	logger := w.jobLogger(job)
+
+	retries, err := storage.UploadWithRetry(w.ctx, w.storage, localPath, remotePath, w.uploadRetry,
+		func(retry int, delay time.Duration, err error) {
+			logger.Warn("Output upload failed, retrying",
+				zap.String("remote_path", remotePath),
+				zap.Int("retry", retry),
+				zap.Duration("delay", delay),
+				zap.Error(err))
+		})
+
+	if retries > 0 {
+		if job.Metadata == nil {
+			job.Metadata = make(map[string]string)
+		}
+		previous, _ := strconv.Atoi(job.Metadata[queue.MetadataUploadRetries])
+		job.Metadata[queue.MetadataUploadRetries] = strconv.Itoa(previous + retries)
+	}
+
+	return err
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+)
+
+// timeoutError is a network timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+// failingUploadStorage fails its first failures uploads with a timeout
+type failingUploadStorage struct {
+	storage.Storage
+	failures int
+}
+
+func (s *failingUploadStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	if s.failures > 0 {
+		s.failures--
+		return timeoutError{}
+	}
+	return nil
+}
+
+func TestWorkerUploadOutputRecordsRetries(t *testing.T) {
+	jp, _ := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	w.storage = &failingUploadStorage{failures: 2}
+	w.uploadRetry = storage.UploadRetryPolicy{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2}
+
+	job := &queue.Job{ID: "job-1", Metadata: map[string]string{queue.MetadataUploadRetries: "1"}}
+	if err := w.uploadOutput(job, "local", "remote"); err != nil {
+		t.Fatalf("uploadOutput() error = %v", err)
+	}
+	if got := job.Metadata[queue.MetadataUploadRetries]; got != "3" {
+		t.Errorf("upload_retries = %s, want the 2 retries added to the earlier 1", got)
+	}
+
+	w.storage = &failingUploadStorage{failures: 10}
+	if err := w.uploadOutput(job, "local", "remote"); !errors.As(err, new(timeoutError)) {
+		t.Errorf("uploadOutput() error = %v, want the timeout after the last retry", err)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// watchdogInterval is how often the watchdog checks worker activity
+const watchdogInterval = 30 * time.Second
+
+// Error codes recorded on jobs failed by worker recovery
+const (
+	ErrorCodeWorkerTimeout = "ErrWorkerTimeout"
+	ErrorCodeWorkerPanic   = "ErrWorkerPanic"
+)
+
+// WorkerWatchdog replaces workers whose job has not made progress within a
+// timeout, such as when FFmpeg hangs on a frozen pipe. Without it a stuck
+// worker never returns to the pool and the processor slowly loses capacity.
+type WorkerWatchdog struct {
+	processor *JobProcessor
+	timeout   time.Duration
+	logger    *zap.Logger
+}
+
+// NewWorkerWatchdog creates a watchdog for the workers of a processor
+func NewWorkerWatchdog(processor *JobProcessor, timeout time.Duration, logger *zap.Logger) *WorkerWatchdog {
+	return &WorkerWatchdog{
+		processor: processor,
+		timeout:   timeout,
+		logger:    logger,
+	}
+}
+
+// Run checks the workers every watchdogInterval until ctx is cancelled
+func (wd *WorkerWatchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			wd.Check(now)
+		}
+	}
+}
+
+// Check recycles every busy worker that has been inactive for longer than
+// the timeout at now and returns how many were recycled
+func (wd *WorkerWatchdog) Check(now time.Time) int {
+	wd.processor.workersMu.RLock()
+	workers := append([]*Worker(nil), wd.processor.workers...)
+	wd.processor.workersMu.RUnlock()
+
+	recycled := 0
+	for _, w := range workers {
+		if w.IsIdle() {
+			continue
+		}
+		inactive := now.Sub(w.LastActivityAt())
+		if inactive <= wd.timeout {
+			continue
+		}
+
+		reason := fmt.Sprintf("worker timed out after %s without progress", inactive.Truncate(time.Second))
+		if wd.processor.recycleWorker(w, ErrorCodeWorkerTimeout, reason) {
+			recycled++
+		}
+	}
+	return recycled
+}
+
+// recycleWorker abandons a busy worker: its FFmpeg process group is killed,
+// its job is marked failed with code, and a new worker takes its place. It
+// returns false if the worker was idle or already recycled.
+func (jp *JobProcessor) recycleWorker(w *Worker, code, reason string) bool {
+	job := w.CurrentJob()
+	if !w.recycle() {
+		return false
+	}
+
+	jp.removeWorker(w)
+	jp.ScaleUp(1)
+
+	if job != nil {
+		w.jobLogger(job).Warn("Recycled stuck worker",
+			zap.String("error_code", code),
+			zap.String("reason", reason))
+		failRecycledJob(jp.ctx, jp.queue, jp.stats, w.jobLogger(job), job, code, reason)
+	}
+	return true
+}
+
+// recoverPanic fails the job of a worker that panicked and recycles the
+// worker, which the processor replaces once the job returns. It must be
+// deferred while the worker is still busy.
+func (w *Worker) recoverPanic(job *queue.Job) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	logger := w.jobLogger(job)
+	logger.Error("Worker panicked", zap.Any("panic", r), zap.Stack("stack"))
+
+	if !w.recycle() {
+		return
+	}
+	failRecycledJob(context.Background(), w.queue, w.stats, logger, job, ErrorCodeWorkerPanic, fmt.Sprintf("worker panicked: %v", r))
+}
+
+// failRecycledJob marks the job of a recycled worker failed with an error code
+func failRecycledJob(ctx context.Context, q queue.Queue, stats *metrics.JobStatsAggregator, logger *zap.Logger, job *queue.Job, code, reason string) {
+	if err := queue.DefaultStateMachine.Transition(job, queue.JobStatusFailed, reason); err != nil {
+		logger.Error("Cannot fail job of recycled worker", zap.Error(err))
+		return
+	}
+	job.Error = reason
+	now := time.Now()
+	job.CompletedAt = &now
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataErrorCode] = code
+	stats.OnJobFailed()
+
+	if err := q.UpdateJob(ctx, job); err != nil {
+		logger.Error("Failed to update job of recycled worker", zap.Error(err))
+	}
+}
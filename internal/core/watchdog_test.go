@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// panickingQueue panics when a job is updated to processing
+type panickingQueue struct {
+	queue.Queue
+}
+
+func (q panickingQueue) UpdateJob(ctx context.Context, job *queue.Job) error {
+	if job.Status == queue.JobStatusProcessing {
+		panic("update failed")
+	}
+	return q.Queue.UpdateJob(ctx, job)
+}
+
+// startTestJob enqueues a job and takes a worker from the pool to process it
+func startTestJob(t *testing.T, jp *JobProcessor, q queue.Queue) *Worker {
+	t.Helper()
+	ctx := context.Background()
+	if err := q.Enqueue(ctx, &queue.Job{ID: "stuck"}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.Dequeue(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("Dequeue() = %v, %v", job, err)
+	}
+	job.Status = queue.JobStatusProcessing
+	if err := q.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	w := <-jp.workerPool
+	w.setBusy(true)
+	w.setCurrentJob(job)
+	return w
+}
+
+func TestWorkerWatchdogRecyclesStuckWorker(t *testing.T) {
+	ctx := context.Background()
+	jp, q := newTestProcessor(t, 2)
+	stuck := startTestJob(t, jp, q)
+
+	watchdog := NewWorkerWatchdog(jp, time.Minute, zap.NewNop())
+	if n := watchdog.Check(time.Now()); n != 0 {
+		t.Fatalf("Check() recycled %d workers before the timeout", n)
+	}
+	if n := watchdog.Check(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("Check() recycled %d workers, want the stuck one", n)
+	}
+
+	if stuck.ctx.Err() == nil {
+		t.Errorf("the recycled worker's context was not cancelled")
+	}
+	jp.workersMu.RLock()
+	workers := append([]*Worker(nil), jp.workers...)
+	jp.workersMu.RUnlock()
+	if len(workers) != 2 {
+		t.Errorf("%d workers after recycling, want the stuck one replaced", len(workers))
+	}
+	for _, w := range workers {
+		if w == stuck {
+			t.Errorf("the recycled worker is still running")
+		}
+	}
+
+	job, err := q.GetJob(ctx, "stuck")
+	if err != nil || job.Status != queue.JobStatusFailed || job.Metadata[queue.MetadataErrorCode] != ErrorCodeWorkerTimeout {
+		t.Errorf("job = %v, %v; want it failed with %s", job, err, ErrorCodeWorkerTimeout)
+	}
+
+	// A recycled worker returning from its job is not put back in the pool
+	stuck.setBusy(false)
+	jp.releaseWorker(stuck)
+	if got := len(jp.workerPool); got != 2 {
+		t.Errorf("%d workers in the pool, want the 2 running ones", got)
+	}
+}
+
+func TestWorkerRecoversPanic(t *testing.T) {
+	ctx := context.Background()
+	jp, memory := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	w.queue = panickingQueue{memory}
+
+	if err := memory.Enqueue(ctx, &queue.Job{ID: "panics"}); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := memory.Dequeue(ctx)
+
+	w.ProcessJob(job)
+
+	if !w.isRecycled() {
+		t.Errorf("the worker that panicked was not recycled")
+	}
+	stored, err := memory.GetJob(ctx, "panics")
+	if err != nil || stored.Status != queue.JobStatusFailed || stored.Metadata[queue.MetadataErrorCode] != ErrorCodeWorkerPanic {
+		t.Errorf("job = %v, %v; want it failed with %s", stored, err, ErrorCodeWorkerPanic)
+	}
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/events"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// JobProgressPath is the route prefix of the job progress endpoints, served
+// as GET /v1/jobs/{id}/ws and GET /v1/jobs/{id}/progress
+const JobProgressPath = "/v1/jobs/"
+
+// JobProgressHandler streams the state of a job over a WebSocket as JSON
+// text frames until the job finishes, and returns the latest FFmpeg progress
+// snapshot of a job as JSON
+type JobProgressHandler struct {
+	queue  queue.Queue
+	bus    events.Bus
+	conns  chan struct{}
+	logger *zap.Logger
+}
+
+// NewJobProgressHandler creates a handler allowing at most maxConns
+// concurrent connections, or unlimited connections if maxConns is 0
+func NewJobProgressHandler(q queue.Queue, bus events.Bus, maxConns int, logger *zap.Logger) *JobProgressHandler {
+	h := &JobProgressHandler{
+		queue:  q,
+		bus:    bus,
+		logger: logger,
+	}
+	if maxConns > 0 {
+		h.conns = make(chan struct{}, maxConns)
+	}
+	return h
+}
+
+// ServeHTTP sends the current job state, then forwards every job event and
+// closes the connection normally once the job reaches a terminal state
+func (h *JobProgressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if jobID, ok := parseJobPath(r.URL.Path, "/progress"); ok {
+		h.serveSnapshot(w, r, jobID)
+		return
+	}
+
+	jobID, ok := parseJobPath(r.URL.Path, "/ws")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.conns != nil {
+		select {
+		case h.conns <- struct{}{}:
+			defer func() { <-h.conns }()
+		default:
+			http.Error(w, "too many WebSocket connections", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	job, err := h.queue.GetJob(ctx, jobID)
+	if err != nil {
+		h.logger.Error("Failed to get job", zap.String("job_id", jobID), zap.Error(err))
+		http.Error(w, "failed to get job", http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		http.Error(w, "job not found: "+jobID, http.StatusNotFound)
+		return
+	}
+
+	// Subscribe before sending the current state, so no change is missed in between
+	jobEvents, unsubscribe, err := h.bus.Subscribe(ctx, events.Filter{JobID: jobID})
+	if err != nil {
+		h.logger.Error("Failed to subscribe to job events", zap.String("job_id", jobID), zap.Error(err))
+		http.Error(w, "failed to subscribe to job events", http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribe()
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		h.logger.Debug("Failed to accept WebSocket connection", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+	defer conn.Close(websocket.StatusInternalError, "")
+
+	// The client sends nothing, so reading only watches for its close frame
+	ctx = conn.CloseRead(ctx)
+
+	event := events.NewJobEvent(job)
+	for {
+		if err := wsjson.Write(ctx, conn, event); err != nil {
+			h.logger.Debug("Failed to send job event", zap.String("job_id", jobID), zap.Error(err))
+			return
+		}
+		if isTerminalStatus(event.Status) {
+			conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok = <-jobEvents:
+			if !ok {
+				conn.Close(websocket.StatusGoingAway, "job event subscription closed")
+				return
+			}
+		}
+	}
+}
+
+// serveSnapshot responds with the latest progress snapshot of a job
+func (h *JobProgressHandler) serveSnapshot(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	job, err := h.queue.GetJob(r.Context(), jobID)
+	if err != nil {
+		h.logger.Error("Failed to get job", zap.String("job_id", jobID), zap.Error(err))
+		http.Error(w, "failed to get job", http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		http.Error(w, "job not found: "+jobID, http.StatusNotFound)
+		return
+	}
+	snapshot, ok := job.LatestProgress()
+	if !ok {
+		http.Error(w, "no progress reported for job: "+jobID, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		h.logger.Debug("Failed to send progress snapshot", zap.String("job_id", jobID), zap.Error(err))
+	}
+}
+
+// parseJobPath extracts the job ID from /v1/jobs/{id}<suffix>
+func parseJobPath(path, suffix string) (string, bool) {
+	jobID, ok := strings.CutSuffix(strings.TrimPrefix(path, JobProgressPath), suffix)
+	if !ok || jobID == "" || strings.Contains(jobID, "/") {
+		return "", false
+	}
+	return jobID, true
+}
+
+// isTerminalStatus reports whether a job in this status will not change again
+func isTerminalStatus(status queue.JobStatus) bool {
+	switch status {
+	case queue.JobStatusCompleted, queue.JobStatusFailed, queue.JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/events"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// jobQueue serves GetJob from a fixed set of jobs
+type jobQueue struct {
+	queue.Queue
+	jobs map[string]*queue.Job
+}
+
+func (q *jobQueue) GetJob(ctx context.Context, jobID string) (*queue.Job, error) {
+	return q.jobs[jobID], nil
+}
+
+func newProgressServer(t *testing.T, maxConns int, jobs ...*queue.Job) (*httptest.Server, *events.MemoryBus) {
+	t.Helper()
+
+	q := &jobQueue{jobs: make(map[string]*queue.Job)}
+	for _, job := range jobs {
+		q.jobs[job.ID] = job
+	}
+	bus := events.NewMemoryBus()
+	t.Cleanup(func() { bus.Close() })
+
+	mux := http.NewServeMux()
+	mux.Handle(JobProgressPath, NewJobProgressHandler(q, bus, maxConns, zap.NewNop()))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, bus
+}
+
+func dialProgress(t *testing.T, server *httptest.Server, jobID string) *websocket.Conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, server.URL+JobProgressPath+jobID+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return conn
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) events.JobEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var event events.JobEvent
+	if err := wsjson.Read(ctx, conn, &event); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return event
+}
+
+func TestJobProgressHandlerStreamsUntilTerminal(t *testing.T) {
+	server, bus := newProgressServer(t, 0, &queue.Job{ID: "job-1", Status: queue.JobStatusProcessing, Progress: 10})
+	conn := dialProgress(t, server, "job-1")
+
+	if event := readEvent(t, conn); event.Status != queue.JobStatusProcessing || event.Progress != 10 {
+		t.Fatalf("first frame = %+v, want the current job state", event)
+	}
+
+	ctx := context.Background()
+	bus.Publish(ctx, events.JobEvent{JobID: "job-2", Status: queue.JobStatusProcessing, Progress: 99})
+	bus.Publish(ctx, events.JobEvent{JobID: "job-1", Status: queue.JobStatusProcessing, Progress: 50})
+	bus.Publish(ctx, events.JobEvent{JobID: "job-1", Status: queue.JobStatusCompleted, Progress: 100})
+
+	if event := readEvent(t, conn); event.JobID != "job-1" || event.Progress != 50 {
+		t.Fatalf("progress frame = %+v, want job-1 at 50", event)
+	}
+	if event := readEvent(t, conn); event.Status != queue.JobStatusCompleted {
+		t.Fatalf("final frame = %+v, want completed", event)
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, _, err := conn.Read(readCtx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusNormalClosure {
+		t.Errorf("close status = %v (%v), want %v", status, err, websocket.StatusNormalClosure)
+	}
+}
+
+func TestJobProgressHandlerFinishedJob(t *testing.T) {
+	server, _ := newProgressServer(t, 0, &queue.Job{ID: "job-1", Status: queue.JobStatusFailed, Error: "boom"})
+	conn := dialProgress(t, server, "job-1")
+
+	if event := readEvent(t, conn); event.Status != queue.JobStatusFailed || event.Error != "boom" {
+		t.Fatalf("frame = %+v, want the failed job", event)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _, err := conn.Read(ctx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusNormalClosure {
+		t.Errorf("close status = %v (%v), want %v", status, err, websocket.StatusNormalClosure)
+	}
+}
+
+func TestJobProgressHandlerErrors(t *testing.T) {
+	server, _ := newProgressServer(t, 0, &queue.Job{ID: "job-1", Status: queue.JobStatusQueued})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{name: "unknown job", method: http.MethodGet, path: "/v1/jobs/missing/ws", want: http.StatusNotFound},
+		{name: "no job ID", method: http.MethodGet, path: "/v1/jobs//ws", want: http.StatusNotFound},
+		{name: "other route", method: http.MethodGet, path: "/v1/jobs/job-1", want: http.StatusNotFound},
+		{name: "nested path", method: http.MethodGet, path: "/v1/jobs/a/b/ws", want: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPost, path: "/v1/jobs/job-1/ws", want: http.StatusMethodNotAllowed},
+		{name: "not a WebSocket", method: http.MethodGet, path: "/v1/jobs/job-1/ws", want: http.StatusUpgradeRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobProgressHandlerMaxConns(t *testing.T) {
+	server, _ := newProgressServer(t, 1, &queue.Job{ID: "job-1", Status: queue.JobStatusQueued})
+	conn := dialProgress(t, server, "job-1")
+	readEvent(t, conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, resp, err := websocket.Dial(ctx, server.URL+JobProgressPath+"job-1/ws", nil)
+	if err == nil {
+		t.Fatal("Dial() error = nil, want the connection limit to be enforced")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Dial() response = %v, error = %v, want status 503", resp, err)
+	}
+
+	// Closing the first connection frees its slot
+	conn.Close(websocket.StatusNormalClosure, "")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		second, _, err := websocket.Dial(ctx, server.URL+JobProgressPath+"job-1/ws", nil)
+		if err == nil {
+			second.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+		if !strings.Contains(err.Error(), "503") || time.Now().After(deadline) {
+			t.Fatalf("Dial() after close error = %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobProgressHandlerSnapshot(t *testing.T) {
+	running := &queue.Job{ID: "running", Status: queue.JobStatusProcessing, Metadata: map[string]string{
+		queue.MetadataProgress: `{"frame":250,"fps":48.5,"speed":1.9,"percent":42}`,
+	}}
+	server, _ := newProgressServer(t, 0, running, &queue.Job{ID: "queued", Status: queue.JobStatusQueued})
+
+	resp, err := http.Get(server.URL + "/v1/jobs/running/progress")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	var snapshot queue.ProgressSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("decoding the snapshot: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || snapshot.Frame != 250 || snapshot.FPS != 48.5 || snapshot.Percent != 42 {
+		t.Errorf("snapshot = %d %+v, want the stored progress", resp.StatusCode, snapshot)
+	}
+
+	for path, want := range map[string]int{
+		"/v1/jobs/queued/progress":  http.StatusNotFound,
+		"/v1/jobs/missing/progress": http.StatusNotFound,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestParseJobPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		suffix string
+		wantID string
+		wantOK bool
+	}{
+		{path: "/v1/jobs/job-1/ws", suffix: "/ws", wantID: "job-1", wantOK: true},
+		{path: "/v1/jobs/job-1/progress", suffix: "/progress", wantID: "job-1", wantOK: true},
+		{path: "/v1/jobs/job-1/progress", suffix: "/ws", wantOK: false},
+		{path: "/v1/jobs/job-1", suffix: "/ws", wantOK: false},
+		{path: "/v1/jobs//ws", suffix: "/ws", wantOK: false},
+		{path: "/v1/jobs/a/b/ws", suffix: "/ws", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		id, ok := parseJobPath(tt.path, tt.suffix)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("parseJobPath(%q, %q) = %q, %v, want %q, %v", tt.path, tt.suffix, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
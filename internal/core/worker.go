@@ -2,32 +2,84 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/nikhil0verma/flixsrota/internal/billing"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// progressUpdateInterval limits how often progress is written back to the queue
+const progressUpdateInterval = 5 * time.Second
+
+// tracerName is the instrumentation name of the worker spans
+const tracerName = "github.com/nikhil0verma/flixsrota/internal/core"
+
+// minDirectInputTTL is the shortest validity of a pre-signed input URL
+const minDirectInputTTL = time.Hour
+
 // Worker processes individual video processing jobs
 type Worker struct {
 	queue    queue.Queue
 	storage  storage.Storage
 	executor *FFmpegExecutor
+	stats    *metrics.JobStatsAggregator
 	logger   *zap.Logger
 
+	// labels describe the machine the worker runs on
+	labels map[string]string
+
+	// ledger records the cost of completed jobs, nil when billing is disabled
+	ledger *billing.CostLedger
+
+	// inputRoot is the local storage base path that inputs below it must not
+	// escape, empty for other storage adapters
+	inputRoot string
+
+	// uploadRetry controls the retries of failed output uploads
+	uploadRetry storage.UploadRetryPolicy
+
+	// directInput passes uploaded inputs to FFmpeg as pre-signed URLs
+	// instead of downloading them first
+	directInput bool
+
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// busy, current, lastActivity and recycled are guarded by mu. current is
+	// a copy of the running job that is refreshed on progress so it can be
+	// read without racing the worker. recycled is set once the watchdog has
+	// given up on the worker.
+	mu           sync.Mutex
+	busy         bool
+	current      *queue.Job
+	lastActivity time.Time
+	recycled     bool
 }
 
 // NewWorker creates a new worker
-func NewWorker(queue queue.Queue, storage storage.Storage, executor *FFmpegExecutor, logger *zap.Logger) *Worker {
+func NewWorker(queue queue.Queue, storage storage.Storage, executor *FFmpegExecutor, stats *metrics.JobStatsAggregator, logger *zap.Logger) *Worker {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Worker{
 		queue:    queue,
 		storage:  storage,
 		executor: executor,
+		stats:    stats,
 		logger:   logger,
 		ctx:      ctx,
 		cancel:   cancel,
@@ -46,60 +98,348 @@ func (w *Worker) Stop() {
 	w.cancel()
 }
 
+// IsIdle reports whether the worker is not currently executing a job
+func (w *Worker) IsIdle() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.busy
+}
+
+// setBusy marks the worker as executing or idle
+func (w *Worker) setBusy(busy bool) {
+	w.mu.Lock()
+	w.busy = busy
+	if !busy {
+		w.current = nil
+	}
+	w.mu.Unlock()
+}
+
+// MatchesLabels reports whether the worker has every required label with the
+// required value. Keys are compared case-insensitively because the config
+// loader lower-cases them.
+func (w *Worker) MatchesLabels(required map[string]string) bool {
+	for key, value := range required {
+		if actual, ok := w.labels[strings.ToLower(key)]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrentJob returns a copy of the job being executed, or nil when idle
+func (w *Worker) CurrentJob() *queue.Job {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		return nil
+	}
+	return w.current.Clone()
+}
+
+// setCurrentJob records a copy of the job being executed and counts as
+// activity for the watchdog
+func (w *Worker) setCurrentJob(job *queue.Job) {
+	clone := job.Clone()
+
+	w.mu.Lock()
+	w.current = clone
+	w.lastActivity = time.Now()
+	w.mu.Unlock()
+}
+
+// LastActivityAt returns when the worker last started a job or reported
+// progress on it
+func (w *Worker) LastActivityAt() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastActivity
+}
+
+// recycle marks a busy worker as abandoned and cancels its context, which
+// kills a running FFmpeg process group. It returns false if the worker is
+// idle or was already recycled.
+func (w *Worker) recycle() bool {
+	w.mu.Lock()
+	if !w.busy || w.recycled {
+		w.mu.Unlock()
+		return false
+	}
+	w.recycled = true
+	w.mu.Unlock()
+
+	w.cancel()
+	return true
+}
+
+// isRecycled reports whether the worker has been recycled. A recycled worker
+// no longer updates its job, which has already been failed.
+func (w *Worker) isRecycled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.recycled
+}
+
+// jobLogger returns a logger annotated with the job and originating request IDs
+func (w *Worker) jobLogger(job *queue.Job) *zap.Logger {
+	return w.logger.With(
+		zap.String("job_id", job.ID),
+		middleware.RequestIDField(job.Metadata[queue.MetadataRequestID]))
+}
+
 // ProcessJob processes a video processing job
 func (w *Worker) ProcessJob(job *queue.Job) {
-	w.logger.Info("Processing job",
-		zap.String("job_id", job.ID),
+	w.setBusy(true)
+	defer w.setBusy(false)
+	defer w.recoverPanic(job)
+
+	logger := w.jobLogger(job)
+
+	logger.Info("Processing job",
 		zap.String("input_path", job.InputPath),
 		zap.String("output_path", job.OutputPath))
 
 	// Update job status to processing
-	job.Status = queue.JobStatusProcessing
+	if err := queue.DefaultStateMachine.Transition(job, queue.JobStatusProcessing, "picked up by worker"); err != nil {
+		logger.Error("Cannot process job", zap.Error(err))
+		return
+	}
 	now := time.Now()
 	job.StartedAt = &now
 	job.Progress = 0.0
+	w.setCurrentJob(job)
 
 	if err := w.queue.UpdateJob(w.ctx, job); err != nil {
-		w.logger.Error("Failed to update job status", zap.Error(err))
+		logger.Error("Failed to update job status", zap.Error(err))
 		return
 	}
 
-	// Execute FFmpeg command
-	err := w.executor.Execute(w.ctx, job)
+	w.stats.OnJobStarted()
+	defer w.stats.OnJobFinished()
+
+	// Continue the trace of the request that enqueued the job
+	ctx, span := otel.Tracer(tracerName).Start(queue.ExtractTraceContext(w.ctx, job), "job.process",
+		trace.WithAttributes(attribute.String("job.id", job.ID)))
+	defer span.End()
+
+	// Download a remote input, check it, execute FFmpeg command, then describe
+	// the output in the job manifest
+	err := w.fetchInput(job)
 	if err != nil {
-		w.logger.Error("Failed to execute FFmpeg",
-			zap.String("job_id", job.ID),
-			zap.Error(err))
+		logger.Error("Failed to download input", zap.Error(err))
+	} else if err = w.validateInput(job); err != nil {
+		logger.Error("Rejected input", zap.Error(err))
+	} else if err = w.executor.Execute(ctx, job, w.progressReporter(job)); err != nil {
+		logger.Error("Failed to execute FFmpeg", zap.Error(err))
+	} else if err = w.writeManifest(job); err != nil {
+		logger.Error("Failed to write output manifest", zap.Error(err))
+	}
+	w.releaseInput(job)
+
+	// The watchdog has already failed the job of a recycled worker
+	if w.isRecycled() {
+		logger.Warn("Abandoning job of recycled worker", zap.NamedError("job_error", err))
+		return
+	}
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 
 		// Update job status to failed
-		job.Status = queue.JobStatusFailed
+		if transitionErr := queue.DefaultStateMachine.Transition(job, queue.JobStatusFailed, err.Error()); transitionErr != nil {
+			logger.Error("Cannot fail job", zap.Error(transitionErr))
+			return
+		}
 		job.Error = err.Error()
 		now := time.Now()
 		job.CompletedAt = &now
+		w.stats.OnJobFailed()
 
 		if updateErr := w.queue.UpdateJob(w.ctx, job); updateErr != nil {
-			w.logger.Error("Failed to update failed job", zap.Error(updateErr))
+			logger.Error("Failed to update failed job", zap.Error(updateErr))
 		}
 		return
 	}
 
 	// Update job status to completed
-	job.Status = queue.JobStatusCompleted
+	if err := queue.DefaultStateMachine.Transition(job, queue.JobStatusCompleted, "finished"); err != nil {
+		logger.Error("Cannot complete job", zap.Error(err))
+		return
+	}
 	job.Progress = 100.0
 	now = time.Now()
 	job.CompletedAt = &now
+	w.stats.OnJobCompleted(now.Sub(*job.StartedAt))
 
 	if err := w.queue.UpdateJob(w.ctx, job); err != nil {
-		w.logger.Error("Failed to update completed job", zap.Error(err))
+		logger.Error("Failed to update completed job", zap.Error(err))
 		return
 	}
 
 	// Acknowledge job completion
 	if err := w.queue.Acknowledge(w.ctx, job.ID); err != nil {
-		w.logger.Error("Failed to acknowledge job", zap.Error(err))
+		logger.Error("Failed to acknowledge job", zap.Error(err))
 	}
 
-	w.logger.Info("Job completed successfully",
-		zap.String("job_id", job.ID),
+	w.recordJobCost(job)
+
+	logger.Info("Job completed successfully",
 		zap.String("output_path", job.OutputPath))
 }
+
+// fetchInput downloads an HTTP(S) input to the storage temp directory and
+// records the copy and its checksum on the job. Inputs are passed to FFmpeg
+// unchanged when remote input is allowed.
+func (w *Worker) fetchInput(job *queue.Job) error {
+	// Only a copy made here is read and later deleted, never a path the job
+	// came with
+	delete(job.Metadata, queue.MetadataLocalInputPath)
+	delete(job.Metadata, queue.MetadataInputChecksum)
+
+	if job.Metadata[queue.MetadataUploadID] != "" {
+		return w.fetchUpload(job)
+	}
+	if w.executor.config.AllowRemoteInput || !isRemoteInput(job.InputPath) {
+		return nil
+	}
+
+	destDir := filepath.Join(storage.TempDir(w.storage), "inputs")
+	localPath, checksum, err := downloadInputFile(w.ctx, job.InputPath, destDir)
+	if err != nil {
+		return err
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataLocalInputPath] = localPath
+	job.Metadata[queue.MetadataInputChecksum] = checksum
+
+	w.jobLogger(job).Info("Downloaded remote input",
+		zap.String("local_path", localPath),
+		zap.String("input_checksum", checksum))
+	return nil
+}
+
+// fetchUpload downloads an input the client uploaded directly to storage to
+// the storage temp directory and records the copy on the job. In direct
+// input mode a pre-signed URL of the upload is recorded instead, which
+// FFmpeg reads from.
+func (w *Worker) fetchUpload(job *queue.Job) error {
+	if w.directInput {
+		return w.presignUpload(job)
+	}
+
+	destDir := filepath.Join(storage.TempDir(w.storage), "inputs")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create input directory: %w", err)
+	}
+	localPath := filepath.Join(destDir, "upload-"+job.Metadata[queue.MetadataUploadID])
+
+	if err := w.storage.Download(w.ctx, job.InputPath, localPath); err != nil {
+		return fmt.Errorf("failed to download uploaded input: %w", err)
+	}
+	job.Metadata[queue.MetadataLocalInputPath] = localPath
+
+	w.jobLogger(job).Info("Downloaded uploaded input",
+		zap.String("upload_id", job.Metadata[queue.MetadataUploadID]),
+		zap.String("local_path", localPath))
+	return nil
+}
+
+// presignUpload records a pre-signed GET URL of an uploaded input as the
+// job's input. The URL stays valid for the FFmpeg timeout, so it does not
+// expire while the job runs.
+func (w *Worker) presignUpload(job *queue.Job) error {
+	ttl := time.Duration(w.executor.config.Timeout) * time.Second
+	if ttl < minDirectInputTTL {
+		ttl = minDirectInputTTL
+	}
+
+	inputURL, err := storage.PresignGetURL(w.ctx, w.storage, job.InputPath, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to pre-sign uploaded input: %w", err)
+	}
+	job.Metadata[queue.MetadataLocalInputPath] = inputURL
+
+	w.jobLogger(job).Info("Reading uploaded input through a pre-signed URL",
+		zap.String("upload_id", job.Metadata[queue.MetadataUploadID]),
+		zap.Duration("ttl", ttl))
+	return nil
+}
+
+// validateInput checks that the local file handed to FFmpeg, the input or
+// its downloaded copy, stays inside the storage base path and looks like a
+// video file. Remote inputs passed to FFmpeg directly are not checked.
+func (w *Worker) validateInput(job *queue.Job) error {
+	inputPath := job.LocalInputPath()
+	if isRemoteInput(inputPath) {
+		return nil
+	}
+
+	if err := validateInputPath(inputPath, w.inputRoot); err != nil {
+		return err
+	}
+
+	if !w.executor.config.ValidateInput {
+		return nil
+	}
+	return ValidateInputMagicBytes(w.ctx, inputPath)
+}
+
+// releaseInput deletes the downloaded copy of a remote input once the job has
+// finished with it
+func (w *Worker) releaseInput(job *queue.Job) {
+	localPath := job.Metadata[queue.MetadataLocalInputPath]
+	if localPath == "" {
+		return
+	}
+
+	// Pre-signed inputs were never downloaded
+	if !isRemoteInput(localPath) {
+		if err := os.Remove(localPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			w.jobLogger(job).Warn("Failed to delete downloaded input", zap.String("local_path", localPath), zap.Error(err))
+		}
+	}
+	delete(job.Metadata, queue.MetadataLocalInputPath)
+}
+
+// progressReporter returns a callback that records FFmpeg progress on the job
+func (w *Worker) progressReporter(job *queue.Job) func(queue.ProgressSnapshot) {
+	var lastUpdate time.Time
+	logger := w.jobLogger(job)
+
+	return func(snapshot queue.ProgressSnapshot) {
+		logger.Debug("FFmpeg progress",
+			zap.Int64("frame", snapshot.Frame),
+			zap.Float64("fps", snapshot.FPS),
+			zap.String("bitrate", snapshot.Bitrate),
+			zap.Int64("size_kb", snapshot.SizeKB),
+			zap.String("time", snapshot.TimeStr),
+			zap.Float64("speed", snapshot.Speed),
+			zap.Float64("percent", snapshot.Percent))
+
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return
+		}
+		if job.Metadata == nil {
+			job.Metadata = make(map[string]string)
+		}
+		job.Metadata[queue.MetadataProgress] = string(data)
+		job.Progress = snapshot.Percent
+		w.setCurrentJob(job)
+
+		if time.Since(lastUpdate) < progressUpdateInterval {
+			return
+		}
+		lastUpdate = time.Now()
+
+		// Only the snapshots written back to the queue are logged as events
+		job.AppendJobEvent(queue.JobEventProgress, string(data))
+		if err := w.queue.UpdateJob(w.ctx, job); err != nil {
+			logger.Warn("Failed to update job progress", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+)
+
+// uploadStorage downloads uploaded inputs to its temp directory
+type uploadStorage struct {
+	storage.Storage
+	tempDir string
+}
+
+func (s *uploadStorage) TempDir() string { return s.tempDir }
+
+func (s *uploadStorage) Download(ctx context.Context, remotePath, localPath string) error {
+	return os.WriteFile(localPath, []byte("video from "+remotePath), 0644)
+}
+
+func TestWorkerFetchUpload(t *testing.T) {
+	jp, _ := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	store := &uploadStorage{tempDir: t.TempDir()}
+	w.storage = store
+
+	job := &queue.Job{InputPath: "uploads/abc", Metadata: map[string]string{queue.MetadataUploadID: "abc"}}
+	if err := w.fetchInput(job); err != nil {
+		t.Fatalf("fetchInput() error = %v", err)
+	}
+	localPath := job.Metadata[queue.MetadataLocalInputPath]
+	if localPath != filepath.Join(store.tempDir, "inputs", "upload-abc") {
+		t.Errorf("local input = %s, want it in the storage temp directory", localPath)
+	}
+	if data, err := os.ReadFile(localPath); err != nil || string(data) != "video from uploads/abc" {
+		t.Errorf("downloaded input = %q, %v", data, err)
+	}
+}
+
+func TestWorkerIgnoresLocalInputPathFromJob(t *testing.T) {
+	jp, _ := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	base := t.TempDir()
+	w.inputRoot = base
+
+	victim := filepath.Join(t.TempDir(), "victim")
+	if err := os.WriteFile(victim, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(base, "in.mp4")
+	job := &queue.Job{InputPath: input, Metadata: map[string]string{queue.MetadataLocalInputPath: victim}}
+
+	if err := w.fetchInput(job); err != nil {
+		t.Fatalf("fetchInput() error = %v", err)
+	}
+	if got := job.LocalInputPath(); got != input {
+		t.Errorf("input = %s, want %s", got, input)
+	}
+	w.releaseInput(job)
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("releaseInput() deleted a path the job came with: %v", err)
+	}
+
+	// The path handed to FFmpeg is confined to the base path even when it is
+	// not the job input
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(victim, link); err != nil {
+		t.Fatal(err)
+	}
+	job.Metadata[queue.MetadataLocalInputPath] = link
+	if err := w.validateInput(job); !errors.Is(err, ErrInputOutsideBasePath) {
+		t.Errorf("validateInput() error = %v, want ErrInputOutsideBasePath", err)
+	}
+}
+
+// presigningUploadStorage issues download URLs for uploads, recording the
+// requested validity
+type presigningUploadStorage struct {
+	uploadStorage
+	ttl time.Duration
+}
+
+func (s *presigningUploadStorage) PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	s.ttl = ttl
+	return "https://bucket.example.com/" + remotePath + "?X-Amz-Signature=abc", nil
+}
+
+func TestWorkerFetchUploadDirectInput(t *testing.T) {
+	jp, _ := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	w.directInput = true
+	store := &presigningUploadStorage{uploadStorage: uploadStorage{tempDir: t.TempDir()}}
+	w.storage = store
+
+	job := &queue.Job{InputPath: "uploads/abc", Metadata: map[string]string{queue.MetadataUploadID: "abc"}}
+	if err := w.fetchInput(job); err != nil {
+		t.Fatalf("fetchInput() error = %v", err)
+	}
+	if got := job.LocalInputPath(); got != "https://bucket.example.com/uploads/abc?X-Amz-Signature=abc" {
+		t.Errorf("input = %s, want the pre-signed URL", got)
+	}
+	if store.ttl != minDirectInputTTL {
+		t.Errorf("URL valid for %v, want at least %v", store.ttl, minDirectInputTTL)
+	}
+	if entries, _ := os.ReadDir(store.tempDir); len(entries) != 0 {
+		t.Errorf("input was downloaded in direct input mode")
+	}
+	// The URL is not deleted like a downloaded copy
+	w.releaseInput(job)
+
+	// The URL outlives a long FFmpeg timeout
+	w.executor.config.Timeout = 3 * 3600
+	if err := w.fetchInput(job); err != nil || store.ttl != 3*time.Hour {
+		t.Errorf("fetchInput() = %v with a %v URL, want one valid for the 3h timeout", err, store.ttl)
+	}
+
+	// Backends that cannot pre-sign fail the job instead of downloading
+	w.storage = &store.uploadStorage
+	if err := w.fetchInput(job); !errors.Is(err, storage.ErrPresignNotSupported) {
+		t.Errorf("fetchInput() error = %v, want ErrPresignNotSupported", err)
+	}
+}
+
+func TestWorkerSkipsJobThatIsNotQueued(t *testing.T) {
+	jp, q := newTestProcessor(t, 1)
+	w := jp.workers[0]
+
+	job := &queue.Job{ID: "job-1", Status: queue.JobStatusCancelled}
+	w.ProcessJob(job)
+	if job.Status != queue.JobStatusCancelled || job.StartedAt != nil {
+		t.Errorf("ProcessJob() moved a cancelled job to %s", job.Status)
+	}
+	if stored, _ := q.GetJob(context.Background(), "job-1"); stored != nil {
+		t.Errorf("ProcessJob() stored the job it refused")
+	}
+}
+
+// panickingUploadStorage panics while downloading an uploaded input
+type panickingUploadStorage struct {
+	uploadStorage
+}
+
+func (s *panickingUploadStorage) Download(ctx context.Context, remotePath, localPath string) error {
+	panic("download failed")
+}
+
+// cancellingQueue cancels the job of ID once a worker has started it
+type cancellingQueue struct {
+	queue.Queue
+	id string
+}
+
+func (q cancellingQueue) UpdateJob(ctx context.Context, job *queue.Job) error {
+	err := q.Queue.UpdateJob(ctx, job)
+	if job.ID == q.id && job.Status == queue.JobStatusProcessing {
+		job.Status = queue.JobStatusCancelled
+	}
+	return err
+}
+
+func TestWorkerFinishesJobStats(t *testing.T) {
+	ctx := context.Background()
+	jp, memory := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	w.queue = cancellingQueue{Queue: memory, id: "cancelled"}
+	w.storage = &panickingUploadStorage{uploadStorage{tempDir: t.TempDir()}}
+
+	// Jobs leave the processing count however their worker returns
+	jobs := []*queue.Job{
+		{ID: "fails", InputPath: "missing.mp4"},
+		{ID: "cancelled", InputPath: "missing.mp4"},
+		{ID: "panics", InputPath: "uploads/abc", Metadata: map[string]string{queue.MetadataUploadID: "abc"}},
+	}
+	for _, job := range jobs {
+		if err := memory.Enqueue(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		dequeued, _ := memory.Dequeue(ctx)
+		w.ProcessJob(dequeued)
+	}
+
+	stats := jp.stats.Snapshot()
+	if stats.ProcessingJobs != 0 || stats.FailedJobs != 2 {
+		t.Errorf("stats = %d processing, %d failed; want no job processing and 2 failed", stats.ProcessingJobs, stats.FailedJobs)
+	}
+}
+
+func TestWorkerProgressReporter(t *testing.T) {
+	ctx := context.Background()
+	jp, q := newTestProcessor(t, 1)
+	w := jp.workers[0]
+	job := &queue.Job{ID: "job-1"}
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first of two quick snapshots is written back as an event
+	report := w.progressReporter(job)
+	report(queue.ProgressSnapshot{Frame: 100, FPS: 50, Speed: 2, Percent: 10})
+	report(queue.ProgressSnapshot{Frame: 200, FPS: 50, Speed: 2, Percent: 20})
+
+	if snapshot, ok := job.LatestProgress(); !ok || snapshot.Frame != 200 || job.Progress != 20 {
+		t.Errorf("latest progress = %+v, %v; want frame 200", snapshot, ok)
+	}
+	stored, err := q.GetJob(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := stored.JobEvents()
+	if len(events) != 1 || events[0].Type != queue.JobEventProgress || events[0].Detail != `{"frame":100,"fps":50,"bitrate":"","size_kb":0,"time_str":"","speed":2,"percent":10}` {
+		t.Errorf("stored events = %+v, want the first progress snapshot", events)
+	}
+}
@@ -0,0 +1,309 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/nikhil0verma/flixsrota/internal/preflight"
+)
+
+// LogTailLines is how many lines of the log file a report includes
+const LogTailLines = 100
+
+// logTailBytes bounds how much of the end of the log file is read
+const logTailBytes = 1 << 20
+
+// metricsTimeout bounds the request for the metrics snapshot
+const metricsTimeout = 5 * time.Second
+
+// Report is everything collected for a troubleshooting request
+type Report struct {
+	GeneratedAt    time.Time `json:"generated_at"`
+	Version        string    `json:"version"`
+	GoVersion      string    `json:"go_version"`
+	OS             string    `json:"os"`
+	Arch           string    `json:"arch"`
+	ConfigFile     string    `json:"config_file"`
+	FFmpegVersion  string    `json:"ffmpeg_version"`
+	FFprobeVersion string    `json:"ffprobe_version"`
+	Checks         []Check   `json:"checks"`
+
+	// Config is the loaded configuration as YAML with secrets redacted
+	Config string `json:"config"`
+	// Metrics is the Prometheus exposition of the running server
+	Metrics string `json:"metrics,omitempty"`
+	// LogTail holds the last lines of the log file
+	LogTail []string `json:"log_tail,omitempty"`
+}
+
+// Check is the outcome of one diagnostic
+type Check struct {
+	Name      string `json:"name"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Passed reports whether every check passed
+func (r *Report) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Collect gathers a report for cfg. q and st are checked unless queueErr or
+// storageErr report that they could not be created.
+func Collect(ctx context.Context, cfg *config.Config, version string, q queue.Queue, queueErr error, st storage.Storage, storageErr error) *Report {
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Version:     version,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		ConfigFile:  cfg.FilePath,
+	}
+
+	if redacted, err := config.RedactedYAML(cfg); err != nil {
+		report.Checks = append(report.Checks, Check{Name: "config", Message: err.Error()})
+	} else {
+		report.Config = string(redacted)
+		report.Checks = append(report.Checks, Check{Name: "config", Passed: true, Message: "configuration loaded"})
+	}
+
+	// Dependency checks run in parallel like the preflight checks
+	var checks []preflight.Check
+	if queueErr == nil {
+		checks = append(checks, preflight.QueueCheck(q))
+	} else {
+		checks = append(checks, preflight.FailedCheck("queue", queueErr))
+	}
+	if storageErr == nil {
+		checks = append(checks, preflight.StorageCheck(st))
+	} else {
+		checks = append(checks, preflight.FailedCheck("storage", storageErr))
+	}
+	checks = append(checks,
+		versionCheck("ffmpeg", cfg.FFmpeg.ExecutablePath, &report.FFmpegVersion),
+		versionCheck("ffprobe", cfg.FFmpeg.FFprobePath(), &report.FFprobeVersion),
+		metricsCheck(cfg.Metrics, &report.Metrics),
+		logCheck(cfg.Logging.OutputPath, &report.LogTail),
+	)
+
+	for _, result := range preflight.Run(ctx, checks) {
+		report.Checks = append(report.Checks, Check{
+			Name:      result.Name,
+			Passed:    result.Passed,
+			Message:   result.Message,
+			LatencyMs: result.Duration.Milliseconds(),
+		})
+	}
+
+	return report
+}
+
+// versionCheck records the first line of `<executable> -version`
+func versionCheck(name, executablePath string, version *string) preflight.Check {
+	return preflight.Check{
+		Name: name,
+		Run: func(ctx context.Context) (string, error) {
+			output, err := exec.CommandContext(ctx, executablePath, "-version").Output()
+			if err != nil {
+				return "", fmt.Errorf("%s not found or not executable: %w", executablePath, err)
+			}
+			*version, _, _ = strings.Cut(strings.TrimSpace(string(output)), "\n")
+			return *version, nil
+		},
+	}
+}
+
+// metricsCheck records the metrics exposed by a server running on this host
+func metricsCheck(cfg config.MetricsConfig, snapshot *string) preflight.Check {
+	return preflight.Check{
+		Name: "metrics",
+		Run: func(ctx context.Context) (string, error) {
+			if !cfg.Enabled {
+				return "metrics disabled", nil
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, metricsTimeout)
+			defer cancel()
+
+			url := fmt.Sprintf("http://localhost:%d%s", cfg.Port, cfg.Path)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return "", err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("failed to fetch metrics from %s: %w", url, err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("failed to fetch metrics from %s: %s", url, resp.Status)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return "", fmt.Errorf("failed to read metrics: %w", err)
+			}
+			*snapshot = string(body)
+			return fmt.Sprintf("fetched %d bytes from %s", len(body), url), nil
+		},
+	}
+}
+
+// logCheck records the last lines of the log file
+func logCheck(path string, tail *[]string) preflight.Check {
+	return preflight.Check{
+		Name: "log",
+		Run: func(ctx context.Context) (string, error) {
+			if path == "" || path == "stdout" || path == "stderr" {
+				return "logging to the console, no log file", nil
+			}
+
+			lines, err := tailFile(path, LogTailLines)
+			if err != nil {
+				return "", fmt.Errorf("failed to read log file: %w", err)
+			}
+			*tail = lines
+			return fmt.Sprintf("read %d lines from %s", len(lines), path), nil
+		},
+	}
+}
+
+// tailFile returns up to n last lines of a file, reading at most
+// logTailBytes from its end
+func tailFile(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - logTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		// The first line was cut by the offset
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	return lines, nil
+}
+
+// Summary renders the report as a human-readable summary
+func (r *Report) Summary() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, "🩺 Flixsrota Diagnostics")
+	fmt.Fprintln(&b, "=======================")
+	fmt.Fprintf(&b, "Version:     %s\n", r.Version)
+	fmt.Fprintf(&b, "Go:          %s\n", r.GoVersion)
+	fmt.Fprintf(&b, "Platform:    %s/%s\n", r.OS, r.Arch)
+	configFile := r.ConfigFile
+	if configFile == "" {
+		configFile = "(defaults)"
+	}
+	fmt.Fprintf(&b, "Config file: %s\n", configFile)
+	fmt.Fprintln(&b)
+
+	for _, check := range r.Checks {
+		marker := "✅"
+		if !check.Passed {
+			marker = "❌"
+		}
+		fmt.Fprintf(&b, "%s %-10s %s (%dms)\n", marker, check.Name, check.Message, check.LatencyMs)
+	}
+
+	if len(r.LogTail) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "Last %d log lines:\n", len(r.LogTail))
+		for _, line := range r.LogTail {
+			fmt.Fprintln(&b, "  "+line)
+		}
+	}
+
+	return b.String()
+}
+
+// zipFile is a file added to the diagnostics archive
+type zipFile struct {
+	name string
+	data []byte
+}
+
+// WriteZip packages the report, its summary, the redacted configuration, the
+// metrics snapshot and the log tail as separate files in a zip archive
+func (r *Report) WriteZip(w io.Writer) error {
+	reportJSON, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	files := []zipFile{
+		{"report.json", reportJSON},
+		{"summary.txt", []byte(r.Summary())},
+		{"config.yaml", []byte(r.Config)},
+	}
+	if r.Metrics != "" {
+		files = append(files, zipFile{"metrics.txt", []byte(r.Metrics)})
+	}
+	if len(r.LogTail) > 0 {
+		files = append(files, zipFile{"log_tail.log", []byte(strings.Join(r.LogTail, "\n") + "\n")})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: r.GeneratedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.name, err)
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// ZipName returns the archive name for a report generated at t
+func ZipName(t time.Time) string {
+	return fmt.Sprintf("flixsrota-diagnostics-%s.zip", t.UTC().Format("20060102-150405"))
+}
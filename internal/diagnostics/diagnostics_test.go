@@ -0,0 +1,140 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// testConfig returns a config whose FFmpeg and FFprobe print a version, that
+// logs to a file with logLines lines and reads metrics from server
+func testConfig(t *testing.T, logLines int, server *httptest.Server) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"ffmpeg", "ffprobe"} {
+		script := fmt.Sprintf("#!/bin/sh\necho '%s version 6.1'\necho 'built with gcc'\n", name)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var log strings.Builder
+	for i := 1; i <= logLines; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+	}
+	logPath := filepath.Join(dir, "flixsrota.log")
+	if err := os.WriteFile(logPath, []byte(log.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.FFmpeg.ExecutablePath = filepath.Join(dir, "ffmpeg")
+	cfg.Logging.OutputPath = logPath
+	cfg.Queue.Redis.Password = "hunter2"
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Port, _ = strconv.Atoi(port)
+	cfg.Metrics.Path = "/metrics"
+	return cfg
+}
+
+func TestCollect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "flixsrota_jobs_total 3")
+	}))
+	defer server.Close()
+	cfg := testConfig(t, LogTailLines+20, server)
+
+	report := Collect(context.Background(), cfg, "1.2.3", queue.NewMemoryQueue(), nil, nil, errors.New("bucket missing"))
+
+	if report.Version != "1.2.3" || report.FFmpegVersion != "ffmpeg version 6.1" || report.FFprobeVersion != "ffprobe version 6.1" {
+		t.Errorf("versions = %s, %q, %q", report.Version, report.FFmpegVersion, report.FFprobeVersion)
+	}
+	if strings.Contains(report.Config, "hunter2") || !strings.Contains(report.Config, config.RedactedValue) {
+		t.Errorf("Config is not redacted:\n%s", report.Config)
+	}
+	if !strings.Contains(report.Metrics, "flixsrota_jobs_total 3") {
+		t.Errorf("Metrics = %q, want the server's metrics", report.Metrics)
+	}
+	if len(report.LogTail) != LogTailLines || report.LogTail[LogTailLines-1] != fmt.Sprintf("line %d", LogTailLines+20) {
+		t.Errorf("LogTail has %d lines, want the last %d", len(report.LogTail), LogTailLines)
+	}
+
+	passed := make(map[string]bool)
+	for _, check := range report.Checks {
+		passed[check.Name] = check.Passed
+	}
+	for name, want := range map[string]bool{"config": true, "queue": true, "storage": false, "ffmpeg": true, "ffprobe": true, "metrics": true, "log": true} {
+		if got, ok := passed[name]; !ok || got != want {
+			t.Errorf("check %s passed = %v (reported %v), want %v", name, got, ok, want)
+		}
+	}
+	if report.Passed() {
+		t.Errorf("Passed() = true with the storage check failing")
+	}
+	if summary := report.Summary(); !strings.Contains(summary, "❌ storage") || !strings.Contains(summary, "✅ queue") {
+		t.Errorf("Summary() does not mark the checks:\n%s", summary)
+	}
+}
+
+func TestReportWriteZip(t *testing.T) {
+	report := &Report{
+		GeneratedAt: time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC),
+		Config:      "grpc:\n  port: 50051\n",
+		Metrics:     "flixsrota_jobs_total 3\n",
+		LogTail:     []string{"first", "second"},
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteZip(&buf); err != nil {
+		t.Fatalf("WriteZip() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("WriteZip() did not write a zip archive: %v", err)
+	}
+
+	var names []string
+	for _, file := range zr.File {
+		names = append(names, file.Name)
+	}
+	if got := strings.Join(names, " "); got != "report.json summary.txt config.yaml metrics.txt log_tail.log" {
+		t.Errorf("archive files = %s", got)
+	}
+
+	if got := ZipName(report.GeneratedAt); got != "flixsrota-diagnostics-20240307-120000.zip" {
+		t.Errorf("ZipName() = %s", got)
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lines, err := tailFile(path, 2); err != nil || strings.Join(lines, " ") != "b c" {
+		t.Errorf("tailFile() = %v, %v; want [b c]", lines, err)
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lines, err := tailFile(path, 2); err != nil || lines != nil {
+		t.Errorf("tailFile() of an empty file = %v, %v; want no lines", lines, err)
+	}
+}
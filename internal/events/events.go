@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// Channel naming for job events
+const (
+	// ChannelPrefix prefixes the per-job event channel
+	ChannelPrefix = "flixsrota:events:"
+
+	// ChannelPattern matches the event channels of all jobs
+	ChannelPattern = ChannelPrefix + "*"
+)
+
+// subscriberBuffer is the number of events buffered per subscriber
+const subscriberBuffer = 64
+
+// JobEvent describes a job lifecycle change
+type JobEvent struct {
+	JobID     string          `json:"job_id"`
+	GroupID   string          `json:"group_id,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Status    queue.JobStatus `json:"status"`
+	Progress  float64         `json:"progress"`
+	Error     string          `json:"error,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// NewJobEvent creates an event from the current state of a job
+func NewJobEvent(job *queue.Job) JobEvent {
+	return JobEvent{
+		JobID:     job.ID,
+		GroupID:   job.Metadata[queue.MetadataGroupID],
+		RequestID: job.Metadata[queue.MetadataRequestID],
+		Status:    job.Status,
+		Progress:  job.Progress,
+		Error:     job.Error,
+		Timestamp: time.Now(),
+	}
+}
+
+// Filter selects the events delivered to a subscriber; empty fields match everything
+type Filter struct {
+	JobID   string
+	GroupID string
+	Status  queue.JobStatus
+}
+
+// Matches reports whether an event satisfies the filter
+func (f Filter) Matches(event JobEvent) bool {
+	if f.JobID != "" && event.JobID != f.JobID {
+		return false
+	}
+	if f.GroupID != "" && event.GroupID != f.GroupID {
+		return false
+	}
+	if f.Status != "" && event.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// Channel returns the event channel of a job
+func Channel(jobID string) string {
+	return ChannelPrefix + jobID
+}
+
+// Bus publishes job events and delivers them to subscribers
+type Bus interface {
+	// Publish sends an event to all matching subscribers
+	Publish(ctx context.Context, event JobEvent) error
+
+	// Subscribe returns a channel of events matching the filter and a function
+	// that ends the subscription. The channel is closed once unsubscribed.
+	Subscribe(ctx context.Context, filter Filter) (<-chan JobEvent, func(), error)
+
+	// Close releases the bus resources
+	Close() error
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// memorySubscriber is a single subscription to a MemoryBus
+type memorySubscriber struct {
+	filter Filter
+	events chan JobEvent
+}
+
+// MemoryBus delivers job events to subscribers within the current process.
+// Events are dropped for subscribers that are not keeping up.
+type MemoryBus struct {
+	mu          sync.RWMutex
+	subscribers map[*memorySubscriber]struct{}
+}
+
+// NewMemoryBus creates an in-process event bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscribers: make(map[*memorySubscriber]struct{}),
+	}
+}
+
+// Publish sends an event to all matching subscribers
+func (b *MemoryBus) Publish(ctx context.Context, event JobEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber for events matching the filter
+func (b *MemoryBus) Subscribe(ctx context.Context, filter Filter) (<-chan JobEvent, func(), error) {
+	sub := &memorySubscriber{
+		filter: filter,
+		events: make(chan JobEvent, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// The channel is closed by whoever removes the subscriber
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+
+	return sub.events, unsubscribe, nil
+}
+
+// Close ends all subscriptions
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+
+	return nil
+}
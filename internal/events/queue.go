@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// PublishingQueue wraps a queue and publishes a job event for every state
+// change made through it
+type PublishingQueue struct {
+	queue.Queue
+
+	bus    Bus
+	logger *zap.Logger
+}
+
+// NewPublishingQueue wraps a queue so job changes are published on the bus
+func NewPublishingQueue(q queue.Queue, bus Bus, logger *zap.Logger) *PublishingQueue {
+	return &PublishingQueue{
+		Queue:  q,
+		bus:    bus,
+		logger: logger,
+	}
+}
+
+// Enqueue adds a job to the queue and publishes its queued event
+func (q *PublishingQueue) Enqueue(ctx context.Context, job *queue.Job) error {
+	if err := q.Queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	q.publish(ctx, job)
+	return nil
+}
+
+// Requeue puts a stored job back in the wrapped queue and publishes its
+// queued event
+func (q *PublishingQueue) Requeue(ctx context.Context, job *queue.Job) error {
+	if err := queue.Requeue(ctx, q.Queue, job); err != nil {
+		return err
+	}
+
+	q.publish(ctx, job)
+	return nil
+}
+
+// BulkEnqueue adds jobs to the wrapped queue and publishes their queued
+// events once all of them are enqueued
+func (q *PublishingQueue) BulkEnqueue(ctx context.Context, jobs []*queue.Job) error {
+	if err := queue.BulkEnqueue(ctx, q.Queue, jobs); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		q.publish(ctx, job)
+	}
+	return nil
+}
+
+// UpdateJob updates a job and publishes its new state
+func (q *PublishingQueue) UpdateJob(ctx context.Context, job *queue.Job) error {
+	if err := q.Queue.UpdateJob(ctx, job); err != nil {
+		return err
+	}
+
+	q.publish(ctx, job)
+	return nil
+}
+
+// CancelJob cancels a job and publishes its cancelled state
+func (q *PublishingQueue) CancelJob(ctx context.Context, jobID string) error {
+	if err := q.Queue.CancelJob(ctx, jobID); err != nil {
+		return err
+	}
+
+	// The stored job is cancelled now; publish that state even if it cannot
+	// be read back
+	job, err := q.Queue.GetJob(ctx, jobID)
+	if err != nil || job == nil {
+		job = &queue.Job{ID: jobID, Status: queue.JobStatusCancelled}
+	}
+
+	q.publish(ctx, job)
+	return nil
+}
+
+// ResubmitFailedJobs resubmits failed jobs through the wrapper so each
+// requeued job is published
+func (q *PublishingQueue) ResubmitFailedJobs(ctx context.Context, filter queue.ResubmitFilter) (int, error) {
+	if r, ok := q.Queue.(queue.Resubmitter); ok {
+		return r.ResubmitFailedJobs(ctx, filter)
+	}
+	return queue.ResubmitByListing(ctx, q, filter)
+}
+
+// GetJobs loads several jobs from the wrapped queue, in one batch if it supports it
+func (q *PublishingQueue) GetJobs(ctx context.Context, jobIDs []string) (map[string]*queue.Job, error) {
+	return queue.GetJobs(ctx, q.Queue, jobIDs)
+}
+
+// GetJobsByTimeRange looks up jobs by creation time in the wrapped queue
+func (q *PublishingQueue) GetJobsByTimeRange(ctx context.Context, from, to time.Time, status queue.JobStatus, limit int) ([]*queue.Job, error) {
+	return queue.GetJobsByTimeRange(ctx, q.Queue, from, to, status, limit)
+}
+
+// TestConnection probes the wrapped queue
+func (q *PublishingQueue) TestConnection(ctx context.Context) error {
+	return queue.TestConnection(ctx, q.Queue)
+}
+
+// publish sends the job's current state on the bus
+func (q *PublishingQueue) publish(ctx context.Context, job *queue.Job) {
+	if err := q.bus.Publish(ctx, NewJobEvent(job)); err != nil {
+		q.logger.Warn("Failed to publish job event", zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBus distributes job events over Redis pub/sub so that subscribers
+// connected to any server instance receive them
+type RedisBus struct {
+	client *redis.Client
+}
+
+// NewRedisBus creates a Redis backed event bus
+func NewRedisBus(ctx context.Context, address, password string, db int) (*RedisBus, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+		DB:       db,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisBus{client: client}, nil
+}
+
+// Publish sends an event on the job's channel
+func (b *RedisBus) Publish(ctx context.Context, event JobEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := b.client.Publish(ctx, Channel(event.JobID), data).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe listens on a single job channel when the filter names a job,
+// and on all event channels otherwise
+func (b *RedisBus) Subscribe(ctx context.Context, filter Filter) (<-chan JobEvent, func(), error) {
+	var pubsub *redis.PubSub
+	if filter.JobID != "" {
+		pubsub = b.client.Subscribe(ctx, Channel(filter.JobID))
+	} else {
+		pubsub = b.client.PSubscribe(ctx, ChannelPattern)
+	}
+
+	// Wait for the subscription to be confirmed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	events := make(chan JobEvent, subscriberBuffer)
+	go func() {
+		defer close(events)
+
+		for msg := range pubsub.Channel() {
+			var event JobEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+			if !filter.Matches(event) {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, func() { pubsub.Close() }, nil
+}
+
+// Close closes the Redis connection
+func (b *RedisBus) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,20 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// LogDir returns the directory under the temp path that holds FFmpeg job logs
+func LogDir(tempPath string) string {
+	return filepath.Join(tempPath, "logs")
+}
+
+// JobLogPath returns the FFmpeg log file of a job in logDir. Job IDs that are
+// not a plain file name are rejected so the path stays inside logDir.
+func JobLogPath(logDir, jobID string) (string, error) {
+	if jobID == "" || jobID == "." || jobID == ".." || filepath.Base(jobID) != jobID {
+		return "", fmt.Errorf("invalid job ID for log file: %q", jobID)
+	}
+	return filepath.Join(logDir, jobID+"_ffmpeg.log"), nil
+}
@@ -0,0 +1,28 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJobLogPath(t *testing.T) {
+	logDir := filepath.Join("tmp", "logs")
+	tests := []struct {
+		jobID   string
+		want    string
+		wantErr bool
+	}{
+		{jobID: "job-1", want: filepath.Join(logDir, "job-1_ffmpeg.log")},
+		{jobID: "", wantErr: true},
+		{jobID: "..", wantErr: true},
+		{jobID: "../secrets", wantErr: true},
+		{jobID: "a/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := JobLogPath(logDir, tt.jobID)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("JobLogPath(%q) = %q, %v; want %q, error %v", tt.jobID, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
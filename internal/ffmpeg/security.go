@@ -0,0 +1,139 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsafeArgs is returned for user-supplied FFmpeg arguments that could
+// run commands, read files or reach the network
+var ErrUnsafeArgs = errors.New("unsafe ffmpeg arguments")
+
+// allowedFlags are the output options jobs may add, without their stream
+// specifier: -b:v is checked as -b. Inputs (-i), muxers (-f), filter
+// scripts and anything writing side files are left out.
+var allowedFlags = map[string]bool{
+	"-c": true, "-codec": true, "-vcodec": true, "-acodec": true,
+	"-b": true, "-maxrate": true, "-minrate": true, "-bufsize": true,
+	"-crf": true, "-qp": true, "-q": true, "-qscale": true,
+	"-preset": true, "-tune": true, "-profile": true, "-level": true,
+	"-x264-params": true, "-x265-params": true,
+	"-pix_fmt": true, "-r": true, "-g": true, "-keyint_min": true,
+	"-sc_threshold": true, "-bf": true, "-refs": true, "-force_key_frames": true,
+	"-s": true, "-aspect": true, "-vf": true, "-af": true, "-filter": true,
+	"-ar": true, "-ac": true, "-an": true, "-vn": true, "-sn": true, "-dn": true,
+	"-ss": true, "-t": true, "-to": true, "-threads": true,
+	"-map": true, "-metadata": true, "-movflags": true, "-tag": true,
+	"-color_primaries": true, "-color_trc": true, "-colorspace": true, "-color_range": true,
+	"-fps_mode": true, "-vsync": true,
+}
+
+// shellMetacharacters are rejected anywhere in the arguments. FFmpeg is not
+// run through a shell, but arguments containing them are never legitimate.
+const shellMetacharacters = ";|&$`<>\n\r\x00"
+
+// unsafePatterns match argument values that make FFmpeg open other files or
+// network resources: protocol URLs and filters or options taking a file name
+var unsafePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(pipe|data|crypto|file|fd|unix|concat|concatf|subfile|tee|cache|async|http|https|tcp|udp|tls|rtmp|rtmps|rtp|rtsp|srt|ftp|sftp|gopher|smb)\s*:`),
+	regexp.MustCompile(`(?i)(^|[,;\[\]'"\s])\s*(movie|amovie|sendcmd|asendcmd|subtitles|ass|lut1d|lut3d|haldclutsrc|ladspa|lv2|frei0r|frei0r_src)\s*(=|,|$)`),
+	regexp.MustCompile(`(?i)\b(textfile|fontfile|filename|file|stats_file|passlogfile)\s*=`),
+}
+
+// ValidateArgs checks user-supplied FFmpeg arguments, given as a shell-like
+// string, before they are accepted for a job. Every flag must be an allowed
+// output option, and no argument may contain shell metacharacters, protocol
+// URLs or filters reading files.
+func ValidateArgs(args string) error {
+	if strings.ContainsAny(args, shellMetacharacters) {
+		return fmt.Errorf("%w: shell metacharacters are not allowed", ErrUnsafeArgs)
+	}
+
+	tokens, err := SplitArgs(args)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsafeArgs, err)
+	}
+
+	for _, token := range tokens {
+		if isFlag(token) {
+			name, _, _ := strings.Cut(token, ":")
+			if !allowedFlags[name] {
+				return fmt.Errorf("%w: option %s is not allowed", ErrUnsafeArgs, token)
+			}
+			continue
+		}
+		for _, pattern := range unsafePatterns {
+			if pattern.MatchString(token) {
+				return fmt.Errorf("%w: value %q is not allowed", ErrUnsafeArgs, token)
+			}
+		}
+	}
+	return nil
+}
+
+// isFlag reports whether a token is an option name rather than a value such
+// as -1
+func isFlag(token string) bool {
+	return len(token) > 1 && token[0] == '-' && (token[1] < '0' || token[1] > '9') && token[1] != '.'
+}
+
+// SplitArgs splits a string into arguments the way a POSIX shell does for
+// plain words: on whitespace, with single quotes, double quotes and
+// backslash escapes. Expansions are not performed.
+func SplitArgs(args string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inToken := false
+
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		case c == '\\':
+			if i+1 >= len(args) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			current.WriteByte(args[i])
+			inToken = true
+		case c == '\'':
+			end := strings.IndexByte(args[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			current.WriteString(args[i+1 : i+1+end])
+			i += end + 1
+			inToken = true
+		case c == '"':
+			closed := false
+			for i++; i < len(args); i++ {
+				if args[i] == '"' {
+					closed = true
+					break
+				}
+				if args[i] == '\\' && i+1 < len(args) && strings.IndexByte(`"\`, args[i+1]) >= 0 {
+					i++
+				}
+				current.WriteByte(args[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+			inToken = true
+		default:
+			current.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,79 @@
+package ffmpeg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{name: "empty", args: ""},
+		{name: "rate control", args: "-crf 23 -preset slow -maxrate:v 5M -bufsize 10M"},
+		{name: "stream specifiers", args: "-c:v libx264 -b:a:0 192k -ac 2"},
+		{name: "negative value", args: "-ss -1 -sc_threshold 0"},
+		{name: "quoted filter", args: `-vf "scale=1280:-2,unsharp=5:5:1.0"`},
+		{name: "x264 params", args: "-x264-params 'keyint=48:min-keyint=48'"},
+
+		{name: "input", args: "-i /etc/passwd", wantErr: "option -i is not allowed"},
+		{name: "muxer", args: "-f lavfi", wantErr: "option -f is not allowed"},
+		{name: "filter script", args: "-filter_script:v filters.txt", wantErr: "option -filter_script:v is not allowed"},
+		{name: "command substitution", args: "-crf $(id)", wantErr: "shell metacharacters"},
+		{name: "command separator", args: "-crf 23; rm -rf /", wantErr: "shell metacharacters"},
+		{name: "newline", args: "-crf 23\n-i x", wantErr: "shell metacharacters"},
+		{name: "pipe protocol", args: "-metadata title=pipe:0", wantErr: `value "title=pipe:0"`},
+		{name: "http URL", args: "-vf HTTP://example.com/x", wantErr: "is not allowed"},
+		{name: "movie filter", args: "-vf movie=/etc/passwd", wantErr: "is not allowed"},
+		{name: "chained subtitles filter", args: "-vf scale=640:-2,subtitles=/etc/shadow", wantErr: "is not allowed"},
+		{name: "quoted drawtext font", args: `-vf "drawtext=fontfile=/etc/passwd:text=x"`, wantErr: "is not allowed"},
+		{name: "two-pass log", args: "-x264-params stats_file=/tmp/x", wantErr: "is not allowed"},
+		{name: "unterminated quote", args: `-vf "scale=640:-2`, wantErr: "unterminated double quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArgs(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateArgs(%q) error = %v", tt.args, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsafeArgs) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateArgs(%q) error = %v, want ErrUnsafeArgs containing %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    []string
+		wantErr bool
+	}{
+		{args: "  -crf\t23  ", want: []string{"-crf", "23"}},
+		{args: `-metadata 'title=My Film'`, want: []string{"-metadata", "title=My Film"}},
+		{args: `-metadata "title=\"Quoted\" \n"`, want: []string{"-metadata", `title="Quoted" \n`}},
+		{args: `-metadata title=My\ Film`, want: []string{"-metadata", "title=My Film"}},
+		{args: `-metadata a'b c'd`, want: []string{"-metadata", "ab cd"}},
+		{args: `''`, want: []string{""}},
+		{args: `-metadata 'open`, wantErr: true},
+		{args: `-crf 23\`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitArgs(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// VersionCacheTTL is how long a probed version is reused before ffmpeg
+// -version is run again
+const VersionCacheTTL = time.Hour
+
+// Features of the compatibility matrix
+const (
+	FeatureHLSIndependentSegments = "hls_flags independent_segments"
+	FeatureHLSFMP4Segments        = "hls_segment_type fmp4"
+	FeatureHLSVarStreamMap        = "hls var_stream_map"
+	FeatureLoudnorm               = "loudnorm filter"
+	FeatureDASHMuxer              = "dash muxer"
+)
+
+// minVersion is the first FFmpeg release supporting a feature
+type minVersion struct {
+	major, minor int
+}
+
+// compatibility is the FFmpeg release each feature first appeared in
+var compatibility = map[string]minVersion{
+	FeatureHLSIndependentSegments: {3, 4},
+	FeatureHLSFMP4Segments:        {3, 4},
+	FeatureHLSVarStreamMap:        {4, 0},
+	FeatureLoudnorm:               {3, 1},
+	FeatureDASHMuxer:              {3, 0},
+}
+
+// VersionInfo describes an FFmpeg build as reported by ffmpeg -version
+type VersionInfo struct {
+	// Version is the version string, e.g. "6.1.1-3ubuntu5" or "N-112233-gabcdef"
+	Version string
+	// Major and Minor are parsed from Version, both 0 for development builds
+	Major int
+	Minor int
+	// Configuration lists the build configuration flags
+	Configuration []string
+	// Libraries lists the external libraries the build was configured with,
+	// e.g. libx264, taken from the --enable-lib flags
+	Libraries []string
+}
+
+// ParseVersion parses the output of ffmpeg -version
+func ParseVersion(output string) (*VersionInfo, error) {
+	info := &VersionInfo{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "ffmpeg version "):
+			// ffmpeg version <version> Copyright (c) ...
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				info.Version = fields[2]
+			}
+		case strings.HasPrefix(line, "configuration:"):
+			info.Configuration = strings.Fields(strings.TrimPrefix(line, "configuration:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if info.Version == "" {
+		return nil, fmt.Errorf("no version line in ffmpeg -version output")
+	}
+
+	info.Major, info.Minor = parseVersionNumber(info.Version)
+	for _, flag := range info.Configuration {
+		if library, ok := strings.CutPrefix(flag, "--enable-lib"); ok {
+			info.Libraries = append(info.Libraries, "lib"+library)
+		}
+	}
+	sort.Strings(info.Libraries)
+
+	return info, nil
+}
+
+// parseVersionNumber returns the major and minor number of a release version
+// such as 4.4.2-0ubuntu0.22.04.1 or n6.1, and 0, 0 for development builds
+func parseVersionNumber(version string) (int, int) {
+	version = strings.TrimPrefix(version, "n")
+	end := strings.IndexFunc(version, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end >= 0 {
+		version = version[:end]
+	}
+
+	parts := strings.Split(version, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0
+	}
+	minor := 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major, minor
+}
+
+// CheckFeatureSupport reports whether the build supports a feature of the
+// compatibility matrix. Development builds, whose release cannot be told
+// from the version, are assumed to support every feature. Unknown features
+// are not supported.
+func (v *VersionInfo) CheckFeatureSupport(feature string) bool {
+	required, ok := compatibility[feature]
+	if !ok {
+		return false
+	}
+	if v.Major == 0 {
+		return true
+	}
+	return v.Major > required.major || (v.Major == required.major && v.Minor >= required.minor)
+}
+
+// Features reports the support of every feature of the compatibility matrix
+func (v *VersionInfo) Features() map[string]bool {
+	features := make(map[string]bool, len(compatibility))
+	for feature := range compatibility {
+		features[feature] = v.CheckFeatureSupport(feature)
+	}
+	return features
+}
+
+// MissingFeatures returns the features the build does not support
+func (v *VersionInfo) MissingFeatures(features []string) []string {
+	var missing []string
+	for _, feature := range features {
+		if !v.CheckFeatureSupport(feature) {
+			missing = append(missing, feature)
+		}
+	}
+	return missing
+}
+
+// RequiredFeatures returns the features the configured output needs
+func RequiredFeatures(cfg config.FFmpegConfig) []string {
+	features := []string{FeatureHLSIndependentSegments, FeatureHLSVarStreamMap}
+	if cfg.VideoCodec != "" && cfg.VideoCodec != "h264" {
+		// Only H.264 is carried in MPEG-TS segments
+		features = append(features, FeatureHLSFMP4Segments)
+	}
+	if cfg.FragmentedMP4.Enabled {
+		features = append(features, FeatureDASHMuxer)
+	}
+	if cfg.AudioNormalization.Enabled {
+		features = append(features, FeatureLoudnorm)
+	}
+	return features
+}
+
+// ProbeVersion runs ffmpeg -version and parses its output
+func ProbeVersion(ctx context.Context, executablePath string) (*VersionInfo, error) {
+	output, err := exec.CommandContext(ctx, executablePath, "-version").Output()
+	if err != nil {
+		return nil, err
+	}
+	return ParseVersion(string(output))
+}
+
+// VersionCache probes the FFmpeg version at most once per VersionCacheTTL
+type VersionCache struct {
+	executablePath string
+
+	mu        sync.Mutex
+	info      *VersionInfo
+	expiresAt time.Time
+}
+
+// NewVersionCache creates a cache for the FFmpeg binary at executablePath
+func NewVersionCache(executablePath string) *VersionCache {
+	return &VersionCache{executablePath: executablePath}
+}
+
+// Get returns the cached version, probing the binary when it has expired.
+// Failed probes are not cached.
+func (c *VersionCache) Get(ctx context.Context) (*VersionInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.info != nil && time.Now().Before(c.expiresAt) {
+		return c.info, nil
+	}
+
+	info, err := ProbeVersion(ctx, c.executablePath)
+	if err != nil {
+		return nil, err
+	}
+	c.info = info
+	c.expiresAt = time.Now().Add(VersionCacheTTL)
+	return info, nil
+}
@@ -0,0 +1,151 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// releaseOutput is ffmpeg -version output of a distribution build
+const releaseOutput = `ffmpeg version 4.4.2-0ubuntu0.22.04.1 Copyright (c) 2000-2021 the FFmpeg developers
+built with gcc 11 (Ubuntu 11.2.0-19ubuntu1)
+configuration: --prefix=/usr --enable-gpl --enable-libx264 --enable-libmp3lame --enable-libdav1d
+libavutil      56. 70.100 / 56. 70.100
+`
+
+// fakeFFmpeg writes an ffmpeg script printing output to -version, and
+// counting its runs in the returned file
+func fakeFFmpeg(t *testing.T, output string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	runsFile := filepath.Join(dir, "runs")
+	script := "#!/bin/sh\necho run >> '" + runsFile + "'\ncat <<'EOF'\n" + output + "EOF\n"
+	path := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, runsFile
+}
+
+func TestParseVersion(t *testing.T) {
+	info, err := ParseVersion(releaseOutput)
+	if err != nil {
+		t.Fatalf("ParseVersion() error = %v", err)
+	}
+	if info.Version != "4.4.2-0ubuntu0.22.04.1" || info.Major != 4 || info.Minor != 4 {
+		t.Errorf("version = %s (%d.%d), want 4.4.2-0ubuntu0.22.04.1 (4.4)", info.Version, info.Major, info.Minor)
+	}
+	if len(info.Configuration) != 5 {
+		t.Errorf("Configuration = %v, want 5 flags", info.Configuration)
+	}
+	if got := strings.Join(info.Libraries, ","); got != "libdav1d,libmp3lame,libx264" {
+		t.Errorf("Libraries = %s, want libdav1d,libmp3lame,libx264", got)
+	}
+
+	if _, err := ParseVersion("not ffmpeg\n"); err == nil {
+		t.Errorf("ParseVersion() of output without a version line succeeded")
+	}
+}
+
+func TestParseVersionNumber(t *testing.T) {
+	tests := []struct {
+		version      string
+		major, minor int
+	}{
+		{"6.1.1-3ubuntu5", 6, 1},
+		{"n6.1", 6, 1},
+		{"7.0", 7, 0},
+		{"5", 5, 0},
+		{"N-112233-gabcdef", 0, 0},
+		{"git-2024-01-01", 0, 0},
+	}
+	for _, tt := range tests {
+		if major, minor := parseVersionNumber(tt.version); major != tt.major || minor != tt.minor {
+			t.Errorf("parseVersionNumber(%q) = %d.%d, want %d.%d", tt.version, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+func TestCheckFeatureSupport(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    VersionInfo
+		feature string
+		want    bool
+	}{
+		{"newer major", VersionInfo{Major: 6, Minor: 0}, FeatureHLSVarStreamMap, true},
+		{"same release", VersionInfo{Major: 3, Minor: 4}, FeatureHLSIndependentSegments, true},
+		{"older minor", VersionInfo{Major: 3, Minor: 3}, FeatureHLSIndependentSegments, false},
+		{"older major", VersionInfo{Major: 3, Minor: 4}, FeatureHLSVarStreamMap, false},
+		{"development build", VersionInfo{Version: "N-112233-gabcdef"}, FeatureHLSVarStreamMap, true},
+		{"unknown feature", VersionInfo{Major: 6}, "teleport", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.CheckFeatureSupport(tt.feature); got != tt.want {
+				t.Errorf("CheckFeatureSupport(%q) = %v, want %v", tt.feature, got, tt.want)
+			}
+		})
+	}
+
+	info := &VersionInfo{Major: 3, Minor: 2}
+	if features := info.Features(); len(features) != len(compatibility) || !features[FeatureLoudnorm] || features[FeatureHLSFMP4Segments] {
+		t.Errorf("Features() = %v", features)
+	}
+}
+
+func TestRequiredFeatures(t *testing.T) {
+	cfg := config.DefaultConfig().FFmpeg
+	cfg.VideoCodec = "h265"
+	cfg.AudioNormalization.Enabled = true
+
+	required := RequiredFeatures(cfg)
+	for _, want := range []string{FeatureHLSFMP4Segments, FeatureLoudnorm} {
+		found := false
+		for _, feature := range required {
+			found = found || feature == want
+		}
+		if !found {
+			t.Errorf("RequiredFeatures() = %v, want it to contain %s", required, want)
+		}
+	}
+
+	info := &VersionInfo{Major: 3, Minor: 2}
+	if missing := info.MissingFeatures(required); strings.Join(missing, ",") != FeatureHLSIndependentSegments+","+FeatureHLSVarStreamMap+","+FeatureHLSFMP4Segments {
+		t.Errorf("MissingFeatures() = %v", missing)
+	}
+}
+
+func TestVersionCache(t *testing.T) {
+	path, runsFile := fakeFFmpeg(t, releaseOutput)
+	cache := NewVersionCache(path)
+
+	for i := 0; i < 3; i++ {
+		info, err := cache.Get(context.Background())
+		if err != nil || info.Major != 4 {
+			t.Fatalf("Get() = %v, %v", info, err)
+		}
+	}
+	if runs, _ := os.ReadFile(runsFile); strings.Count(string(runs), "run") != 1 {
+		t.Errorf("ffmpeg ran %d times, want the version cached after one run", strings.Count(string(runs), "run"))
+	}
+
+	// An expired version is probed again
+	cache.expiresAt = cache.expiresAt.Add(-VersionCacheTTL)
+	if _, err := cache.Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if runs, _ := os.ReadFile(runsFile); strings.Count(string(runs), "run") != 2 {
+		t.Errorf("ffmpeg ran %d times, want an expired version probed again", strings.Count(string(runs), "run"))
+	}
+
+	// Failed probes are not cached
+	failing := NewVersionCache(filepath.Join(t.TempDir(), "missing"))
+	if _, err := failing.Get(context.Background()); err == nil || failing.info != nil {
+		t.Errorf("Get() of a missing binary = %v, want an uncached error", err)
+	}
+}
@@ -0,0 +1,244 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// drainPollInterval is how often DrainAndShutdown checks for running jobs
+const drainPollInterval = 500 * time.Millisecond
+
+// AdminServer implements the admin service
+type AdminServer struct {
+	config    *config.Config
+	processor interface{} // JobProcessor interface
+	logLevel  zap.AtomicLevel
+	shutdown  func()
+	logger    *zap.Logger
+	startedAt time.Time
+}
+
+// NewAdminServer creates the admin gRPC server. Every call must carry the
+// admin API key. shutdown is called once DrainAndShutdown has drained the
+// processor and must not block.
+func NewAdminServer(cfg *config.Config, processor interface{}, logLevel zap.AtomicLevel, shutdown func(), logger *zap.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	s := &AdminServer{
+		config:    cfg,
+		processor: processor,
+		logLevel:  logLevel,
+		shutdown:  shutdown,
+		logger:    logger,
+		startedAt: time.Now(),
+	}
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			middleware.RequestIDInterceptor(),
+			middleware.APIKeyInterceptor(cfg.GRPC.AdminAPIKey),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.RequestIDStreamInterceptor(),
+			middleware.APIKeyStreamInterceptor(cfg.GRPC.AdminAPIKey),
+		),
+	)
+	grpcServer := grpc.NewServer(opts...)
+
+	pb.RegisterAdminServiceServer(grpcServer, s)
+
+	return grpcServer
+}
+
+// GetServerInfo returns the version, uptime and runtime details of the server
+func (s *AdminServer) GetServerInfo(ctx context.Context, req *pb.GetServerInfoRequest) (*pb.GetServerInfoResponse, error) {
+	hostname, _ := os.Hostname()
+
+	response := &pb.GetServerInfoResponse{
+		Version:        Version,
+		GoVersion:      runtime.Version(),
+		Hostname:       hostname,
+		StartedAt:      timestamppb.New(s.startedAt),
+		UptimeSeconds:  int64(time.Since(s.startedAt).Seconds()),
+		Goroutines:     int32(runtime.NumGoroutine()),
+		NumCpu:         int32(runtime.NumCPU()),
+		QueueAdapter:   s.config.Queue.Adapter,
+		StorageAdapter: s.config.Storage.Adapter,
+		RequestId:      middleware.RequestIDFromContext(ctx),
+	}
+	if p, ok := s.processor.(interface{ IsPaused() bool }); ok {
+		response.IsPaused = p.IsPaused()
+	}
+
+	return response, nil
+}
+
+// GetAllWorkerStats returns the state of every worker
+func (s *AdminServer) GetAllWorkerStats(ctx context.Context, req *pb.GetAllWorkerStatsRequest) (*pb.GetAllWorkerStatsResponse, error) {
+	p, ok := s.processor.(interface{ WorkerStates() []metrics.WorkerState })
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "worker stats are not available")
+	}
+
+	states := p.WorkerStates()
+	workers := make([]*pb.WorkerStats, 0, len(states))
+	for _, state := range states {
+		worker := &pb.WorkerStats{
+			Index:  int32(state.Index),
+			Busy:   state.Busy,
+			Labels: state.Labels,
+		}
+		if job := state.Job; job != nil {
+			worker.JobId = job.ID
+			worker.Progress = float32(job.Progress)
+			if job.StartedAt != nil {
+				worker.JobStartedAt = timestamppb.New(*job.StartedAt)
+			}
+		}
+		workers = append(workers, worker)
+	}
+
+	return &pb.GetAllWorkerStatsResponse{
+		Workers:   workers,
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// PauseProcessor stops dispatching new jobs
+func (s *AdminServer) PauseProcessor(ctx context.Context, req *pb.PauseProcessorRequest) (*pb.PauseProcessorResponse, error) {
+	p, ok := s.processor.(interface{ Pause() })
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "processor cannot be paused")
+	}
+
+	p.Pause()
+	s.logger.Info("Job processor paused through admin API", middleware.RequestIDField(middleware.RequestIDFromContext(ctx)))
+
+	return &pb.PauseProcessorResponse{
+		IsPaused:  true,
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// ResumeProcessor resumes dispatching jobs
+func (s *AdminServer) ResumeProcessor(ctx context.Context, req *pb.ResumeProcessorRequest) (*pb.ResumeProcessorResponse, error) {
+	p, ok := s.processor.(interface{ Resume() })
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "processor cannot be resumed")
+	}
+
+	p.Resume()
+	s.logger.Info("Job processor resumed through admin API", middleware.RequestIDField(middleware.RequestIDFromContext(ctx)))
+
+	return &pb.ResumeProcessorResponse{
+		IsPaused:  false,
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// TriggerGC runs the garbage collector and reports the heap size before and after
+func (s *AdminServer) TriggerGC(ctx context.Context, req *pb.TriggerGCRequest) (*pb.TriggerGCResponse, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	s.logger.Info("Garbage collection triggered through admin API",
+		zap.Uint64("heap_alloc_before", before.HeapAlloc),
+		zap.Uint64("heap_alloc_after", after.HeapAlloc))
+
+	return &pb.TriggerGCResponse{
+		HeapAllocBefore: before.HeapAlloc,
+		HeapAllocAfter:  after.HeapAlloc,
+		RequestId:       middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// SetLogLevel changes the log level until the next restart or config reload
+func (s *AdminServer) SetLogLevel(ctx context.Context, req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	previous := s.logLevel.Level()
+
+	var level zapcore.Level
+	switch req.Level {
+	case "debug", "info", "warn", "error":
+		level.UnmarshalText([]byte(req.Level))
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid log level %q (supported: debug, info, warn, error)", req.Level)
+	}
+
+	s.logLevel.SetLevel(level)
+	s.logger.Info("Log level changed through admin API",
+		zap.String("previous_level", previous.String()),
+		zap.String("level", req.Level))
+
+	return &pb.SetLogLevelResponse{
+		PreviousLevel: previous.String(),
+		Level:         req.Level,
+		RequestId:     middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// DrainAndShutdown pauses the processor, waits up to the timeout for running
+// jobs to finish and then shuts the server down, whether or not they finished
+func (s *AdminServer) DrainAndShutdown(ctx context.Context, req *pb.DrainAndShutdownRequest) (*pb.DrainAndShutdownResponse, error) {
+	if req.TimeoutSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "timeout_seconds cannot be negative")
+	}
+
+	p, ok := s.processor.(interface {
+		Pause()
+		Snapshot() metrics.ProcessorState
+	})
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "processor cannot be drained")
+	}
+
+	s.logger.Info("Draining job processor through admin API", zap.Int32("timeout_seconds", req.TimeoutSeconds))
+	p.Pause()
+
+	deadline := time.NewTimer(time.Duration(req.TimeoutSeconds) * time.Second)
+	defer deadline.Stop()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	// Running jobs are not interrupted; the shutdown stops whatever remains
+	var remaining []string
+drain:
+	for {
+		remaining = remaining[:0]
+		for _, job := range p.Snapshot().ActiveJobs {
+			remaining = append(remaining, job.ID)
+		}
+		if len(remaining) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-deadline.C:
+			break drain
+		case <-ticker.C:
+		}
+	}
+
+	s.logger.Info("Shutting down through admin API", zap.Strings("remaining_job_ids", remaining))
+	s.shutdown()
+
+	return &pb.DrainAndShutdownResponse{
+		Drained:         len(remaining) == 0,
+		RemainingJobIds: remaining,
+		RequestId:       middleware.RequestIDFromContext(ctx),
+	}, nil
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/nikhil0verma/flixsrota/internal/billing"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetBillingSummary totals the cost ledger of a tenant over a time range
+func (s *Server) GetBillingSummary(ctx context.Context, req *pb.GetBillingSummaryRequest) (*pb.GetBillingSummaryResponse, error) {
+	if s.ledger == nil {
+		return nil, status.Error(codes.FailedPrecondition, "billing is not enabled")
+	}
+	if req.TenantId == "" {
+		return nil, status.Error(codes.InvalidArgument, "tenant_id is required")
+	}
+	if req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to are required")
+	}
+
+	from, to := req.From.AsTime(), req.To.AsTime()
+	if !to.After(from) {
+		return nil, status.Error(codes.InvalidArgument, "to must be after from")
+	}
+
+	entries, err := s.ledger.GetTenantLedger(ctx, req.TenantId, from, to)
+	if err != nil {
+		s.logger.Error("Failed to read cost ledger",
+			zap.String("tenant_id", req.TenantId),
+			middleware.RequestIDField(middleware.RequestIDFromContext(ctx)),
+			zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to read cost ledger: %v", err)
+	}
+
+	summary := billing.Summarize(entries)
+	return &pb.GetBillingSummaryResponse{
+		TenantId:       req.TenantId,
+		TotalCost:      summary.TotalCost,
+		JobCount:       int32(summary.JobCount),
+		ComputeMinutes: summary.ComputeMinutes,
+		StorageBytes:   summary.StorageBytes,
+		RequestId:      middleware.RequestIDFromContext(ctx),
+	}, nil
+}
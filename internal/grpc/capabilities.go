@@ -0,0 +1,136 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// Version is the server version reported by GetCapabilities
+var Version = "dev"
+
+// capabilitiesTTL is how long the capabilities response is cached, since
+// probing FFmpeg for encoders and muxers is slow
+const capabilitiesTTL = 5 * time.Minute
+
+// capabilitiesProbeTimeout bounds each FFmpeg listing command
+const capabilitiesProbeTimeout = 30 * time.Second
+
+// capabilitiesCache holds the last capabilities response
+type capabilitiesCache struct {
+	mu        sync.Mutex
+	response  *pb.CapabilitiesResponse
+	expiresAt time.Time
+}
+
+// GetCapabilities reports the adapters, video codecs, muxers and features
+// available on the server
+func (s *Server) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest) (*pb.CapabilitiesResponse, error) {
+	s.capabilities.mu.Lock()
+	defer s.capabilities.mu.Unlock()
+
+	if s.capabilities.response == nil || time.Now().After(s.capabilities.expiresAt) {
+		// Probe independently of the request so a cancelled client does not
+		// leave an empty response cached
+		probeCtx, cancel := context.WithTimeout(context.Background(), capabilitiesProbeTimeout)
+		s.capabilities.response = s.probeCapabilities(probeCtx)
+		cancel()
+		s.capabilities.expiresAt = time.Now().Add(capabilitiesTTL)
+	}
+
+	// The cached slices and map are never modified, so they can be shared
+	cached := s.capabilities.response
+	return &pb.CapabilitiesResponse{
+		Version:        cached.Version,
+		QueueAdapter:   cached.QueueAdapter,
+		StorageAdapter: cached.StorageAdapter,
+		VideoCodecs:    cached.VideoCodecs,
+		Muxers:         cached.Muxers,
+		Features:       cached.Features,
+		RequestId:      middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// probeCapabilities builds the capabilities response from the configuration
+// and the FFmpeg binary
+func (s *Server) probeCapabilities(ctx context.Context) *pb.CapabilitiesResponse {
+	response := &pb.CapabilitiesResponse{
+		Version:        Version,
+		QueueAdapter:   s.config.Queue.Adapter,
+		StorageAdapter: s.config.Storage.Adapter,
+		Features: map[string]bool{
+			"tls":               s.config.GRPC.TLSCertFile != "",
+			"reflection":        s.config.GRPC.EnableReflection,
+			"metrics":           s.config.Metrics.Enabled,
+			"storage_quotas":    s.config.Storage.Quota.Enabled,
+			"queue_compression": s.config.Queue.CompressionEnabled,
+			"streaming_input":   s.config.Storage.UseStreamingInput,
+			"hardware_accel":    s.config.FFmpeg.HardwareAccel != "",
+		},
+	}
+
+	encoders, err := ffmpegList(ctx, s.config.FFmpeg.ExecutablePath, "-encoders")
+	if err != nil {
+		s.logger.Warn("Failed to list FFmpeg encoders", zap.Error(err))
+	}
+	for _, encoder := range encoders {
+		// Video encoders are flagged with V in the first column
+		if strings.HasPrefix(encoder.flags, "V") {
+			response.VideoCodecs = append(response.VideoCodecs, encoder.name)
+		}
+	}
+
+	muxers, err := ffmpegList(ctx, s.config.FFmpeg.ExecutablePath, "-muxers")
+	if err != nil {
+		s.logger.Warn("Failed to list FFmpeg muxers", zap.Error(err))
+	}
+	for _, muxer := range muxers {
+		response.Muxers = append(response.Muxers, muxer.name)
+	}
+
+	sort.Strings(response.VideoCodecs)
+	sort.Strings(response.Muxers)
+	return response
+}
+
+// ffmpegListEntry is a single row of an FFmpeg component listing
+type ffmpegListEntry struct {
+	flags string
+	name  string
+}
+
+// ffmpegList runs FFmpeg with a listing option such as -encoders or -muxers
+// and parses the rows following the "--" separator line
+func ffmpegList(ctx context.Context, executablePath, option string) ([]ffmpegListEntry, error) {
+	output, err := exec.CommandContext(ctx, executablePath, "-hide_banner", option).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ffmpegListEntry
+	started := false
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !started {
+			started = strings.HasPrefix(line, "--")
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		entries = append(entries, ffmpegListEntry{flags: fields[0], name: fields[1]})
+	}
+
+	return entries, scanner.Err()
+}
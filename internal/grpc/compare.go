@@ -0,0 +1,187 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CompareJobs probes the outputs of two jobs and returns how they differ.
+// SSIM and PSNR are computed when quality metrics are enabled, with job A
+// as the reference.
+func (s *Server) CompareJobs(ctx context.Context, req *pb.CompareJobsRequest) (*pb.CompareJobsResponse, error) {
+	jobA, err := s.comparedJob(ctx, req.JobIdA)
+	if err != nil {
+		return nil, err
+	}
+	jobB, err := s.comparedJob(ctx, req.JobIdB)
+	if err != nil {
+		return nil, err
+	}
+
+	infoA, err := s.probeOutput(ctx, jobA)
+	if err != nil {
+		return nil, err
+	}
+	infoB, err := s.probeOutput(ctx, jobB)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.CompareJobsResponse{
+		A:                          infoA,
+		B:                          infoB,
+		DurationDifferenceSeconds:  infoB.DurationSeconds - infoA.DurationSeconds,
+		VideoStreamCountDifference: infoB.VideoStreamCount - infoA.VideoStreamCount,
+		AudioStreamCountDifference: infoB.AudioStreamCount - infoA.AudioStreamCount,
+		CodecMatches:               infoA.VideoCodec == infoB.VideoCodec,
+		ResolutionMatches:          infoA.Width == infoB.Width && infoA.Height == infoB.Height,
+		RequestId:                  middleware.RequestIDFromContext(ctx),
+	}
+	if infoA.BitRate > 0 {
+		response.BitRateDifferencePercent = float64(infoB.BitRate-infoA.BitRate) * 100 / float64(infoA.BitRate)
+	}
+
+	if s.config.FFmpeg.EnableQualityMetrics {
+		ssim, psnr, err := s.qualityMetrics(ctx, jobA.OutputPath, jobB.OutputPath, infoA)
+		if err != nil {
+			s.logger.Warn("Failed to compute quality metrics",
+				zap.String("job_id_a", jobA.ID),
+				zap.String("job_id_b", jobB.ID),
+				zap.Error(err))
+		} else {
+			response.QualityMetricsComputed = true
+			response.Ssim = ssim
+			response.Psnr = psnr
+		}
+	}
+
+	return response, nil
+}
+
+// comparedJob loads a job to compare and checks that it has finished
+func (s *Server) comparedJob(ctx context.Context, jobID string) (*queue.Job, error) {
+	job, err := s.queue.GetJob(ctx, jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job", zap.String("job_id", jobID), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to get job: %v", err)
+	}
+	if job == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found: %s", jobID)
+	}
+	if job.Status != queue.JobStatusCompleted {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s has not completed", jobID)
+	}
+	return job, nil
+}
+
+// ffprobeOutput is the subset of ffprobe JSON output used for comparisons
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int32  `json:"width"`
+		Height    int32  `json:"height"`
+	} `json:"streams"`
+}
+
+// probeOutput describes a job output file using ffprobe
+func (s *Server) probeOutput(ctx context.Context, job *queue.Job) (*pb.MediaInfo, error) {
+	output, err := exec.CommandContext(ctx, s.config.FFmpeg.FFprobePath(),
+		"-v", "error",
+		"-show_entries", "format=duration,bit_rate:stream=codec_type,codec_name,width,height",
+		"-of", "json",
+		job.OutputPath,
+	).Output()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to probe output of job %s: %v", job.ID, err)
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse ffprobe output for job %s: %v", job.ID, err)
+	}
+
+	info := &pb.MediaInfo{JobId: job.ID}
+	info.DurationSeconds, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			info.VideoStreamCount++
+			// Report the first, highest-priority video stream
+			if info.VideoCodec == "" {
+				info.VideoCodec = stream.CodecName
+				info.Width = stream.Width
+				info.Height = stream.Height
+			}
+		case "audio":
+			info.AudioStreamCount++
+		}
+	}
+
+	return info, nil
+}
+
+// Patterns for the summary lines printed by the ssim and psnr filters
+var (
+	ssimPattern = regexp.MustCompile(`SSIM .*All:([0-9.]+)`)
+	psnrPattern = regexp.MustCompile(`PSNR .*average:(\S+)`)
+)
+
+// qualityMetrics computes the SSIM and PSNR of distorted against reference.
+// The distorted video is scaled to the reference resolution first.
+func (s *Server) qualityMetrics(ctx context.Context, reference, distorted string, referenceInfo *pb.MediaInfo) (float64, float64, error) {
+	scale := ""
+	if referenceInfo.Width > 0 && referenceInfo.Height > 0 {
+		scale = fmt.Sprintf("scale=%d:%d,", referenceInfo.Width, referenceInfo.Height)
+	}
+	filter := fmt.Sprintf("[0:v]split[ref0][ref1];[1:v]%ssplit[dist0][dist1];[dist0][ref0]ssim;[dist1][ref1]psnr", scale)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.config.FFmpeg.ExecutablePath,
+		"-hide_banner",
+		"-i", reference,
+		"-i", distorted,
+		"-lavfi", filter,
+		"-f", "null", "-",
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, 0, fmt.Errorf("ffmpeg failed: %w", err)
+	}
+
+	ssimMatch := ssimPattern.FindSubmatch(stderr.Bytes())
+	psnrMatch := psnrPattern.FindSubmatch(stderr.Bytes())
+	if ssimMatch == nil || psnrMatch == nil {
+		return 0, 0, fmt.Errorf("quality metrics not found in FFmpeg output")
+	}
+
+	ssim, err := strconv.ParseFloat(string(ssimMatch[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid SSIM value: %w", err)
+	}
+	// Identical inputs report a PSNR of "inf", which ParseFloat accepts
+	psnr, err := strconv.ParseFloat(string(psnrMatch[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid PSNR value: %w", err)
+	}
+
+	return ssim, psnr, nil
+}
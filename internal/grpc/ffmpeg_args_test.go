@@ -0,0 +1,25 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProcessVideoRejectsUnsafeFFmpegArgs(t *testing.T) {
+	s := &Server{logger: zap.NewNop()}
+
+	_, err := s.ProcessVideo(context.Background(), &pb.ProcessVideoRequest{
+		InputPath:  "in.mp4",
+		OutputPath: "out",
+		FfmpegArgs: "-vf movie=/etc/passwd",
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "invalid ffmpeg_args") {
+		t.Errorf("ProcessVideo() error = %v, want InvalidArgument for ffmpeg_args", err)
+	}
+}
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ffmpegVersionTimeout bounds the ffmpeg -version probe
+const ffmpegVersionTimeout = 10 * time.Second
+
+// GetFFmpegVersion reports the version, build configuration and enabled
+// libraries of the FFmpeg binary, and which features of the compatibility
+// matrix it supports. The version is probed at most once an hour.
+func (s *Server) GetFFmpegVersion(ctx context.Context, req *pb.GetFFmpegVersionRequest) (*pb.FFmpegVersionResponse, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, ffmpegVersionTimeout)
+	defer cancel()
+
+	info, err := s.ffmpegVersion.Get(probeCtx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to read FFmpeg version: %v", err)
+	}
+
+	return &pb.FFmpegVersionResponse{
+		Version:       info.Version,
+		Configuration: info.Configuration,
+		Libraries:     info.Libraries,
+		Features:      info.Features(),
+		RequestId:     middleware.RequestIDFromContext(ctx),
+	}, nil
+}
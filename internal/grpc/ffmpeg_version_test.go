@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/ffmpeg"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetFFmpegVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\necho 'ffmpeg version 3.2.18 Copyright (c) 2000-2022 the FFmpeg developers'\necho 'configuration: --enable-gpl --enable-libx264'\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{ffmpegVersion: ffmpeg.NewVersionCache(path)}
+
+	resp, err := s.GetFFmpegVersion(context.Background(), &pb.GetFFmpegVersionRequest{})
+	if err != nil {
+		t.Fatalf("GetFFmpegVersion() error = %v", err)
+	}
+	if resp.Version != "3.2.18" || len(resp.Libraries) != 1 || resp.Libraries[0] != "libx264" {
+		t.Errorf("GetFFmpegVersion() = %s with %v, want 3.2.18 with libx264", resp.Version, resp.Libraries)
+	}
+	if !resp.Features[ffmpeg.FeatureLoudnorm] || resp.Features[ffmpeg.FeatureHLSVarStreamMap] {
+		t.Errorf("Features = %v, want loudnorm but not var_stream_map supported by 3.2", resp.Features)
+	}
+
+	s = &Server{ffmpegVersion: ffmpeg.NewVersionCache(filepath.Join(t.TempDir(), "missing"))}
+	if _, err := s.GetFFmpegVersion(context.Background(), &pb.GetFFmpegVersionRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("GetFFmpegVersion() without a binary error = %v, want Unavailable", err)
+	}
+}
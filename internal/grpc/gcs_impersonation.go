@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// assignGCSServiceAccount records the service account the job's GCS storage
+// operations impersonate when per-tenant impersonation is enabled. The
+// account comes from the configured tenant map; a client may not choose a
+// different one.
+func (s *Server) assignGCSServiceAccount(job *queue.Job) error {
+	adapter := job.StorageAdapter
+	if adapter == "" {
+		adapter = s.config.Storage.Adapter
+	}
+	gcs := s.config.Storage.GCS
+	if adapter != "gcs" || !gcs.ImpersonateByTenant {
+		return nil
+	}
+
+	account, err := gcs.ServiceAccountForTenant(job.Metadata[queue.MetadataTenantID])
+	if err != nil {
+		return status.Errorf(codes.PermissionDenied, "%v", err)
+	}
+	if requested, ok := job.Metadata[queue.MetadataGCSServiceAccount]; ok && requested != account {
+		return status.Errorf(codes.PermissionDenied, "gcs_service_account %s is not allowed for this tenant", requested)
+	}
+
+	job.Metadata[queue.MetadataGCSServiceAccount] = account
+	return nil
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAssignGCSServiceAccount(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Storage.Adapter = "gcs"
+	cfg.Storage.GCS.ImpersonateByTenant = true
+	cfg.Storage.GCS.TenantImpersonationMap = map[string]string{
+		"acme":  "acme@project.iam.gserviceaccount.com",
+		"other": "other@project.iam.gserviceaccount.com",
+	}
+	s := &Server{config: cfg}
+
+	job := &queue.Job{Metadata: map[string]string{queue.MetadataTenantID: "acme"}}
+	if err := s.assignGCSServiceAccount(job); err != nil {
+		t.Fatalf("assignGCSServiceAccount() error = %v", err)
+	}
+	if got := job.Metadata[queue.MetadataGCSServiceAccount]; got != "acme@project.iam.gserviceaccount.com" {
+		t.Errorf("gcs_service_account = %s, want the tenant's account", got)
+	}
+
+	for name, metadata := range map[string]map[string]string{
+		"unmapped tenant": {queue.MetadataTenantID: "unknown"},
+		"no tenant":       {},
+		"other account": {
+			queue.MetadataTenantID:          "acme",
+			queue.MetadataGCSServiceAccount: "other@project.iam.gserviceaccount.com",
+		},
+	} {
+		job := &queue.Job{Metadata: metadata}
+		if err := s.assignGCSServiceAccount(job); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: assignGCSServiceAccount() error = %v, want PermissionDenied", name, err)
+		}
+	}
+
+	// Jobs stored elsewhere are left alone
+	job = &queue.Job{StorageAdapter: "local", Metadata: map[string]string{}}
+	if err := s.assignGCSServiceAccount(job); err != nil || job.Metadata[queue.MetadataGCSServiceAccount] != "" {
+		t.Errorf("assignGCSServiceAccount() of a local job = %v with account %q", err, job.Metadata[queue.MetadataGCSServiceAccount])
+	}
+}
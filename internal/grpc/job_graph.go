@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetJobGraph returns the dependency graph below a job. Cycles are reported
+// in the response rather than as an error so the graph can be inspected.
+func (s *Server) GetJobGraph(ctx context.Context, req *pb.GetJobGraphRequest) (*pb.GetJobGraphResponse, error) {
+	if req.JobId == "" {
+		return nil, status.Error(codes.InvalidArgument, "job_id is required")
+	}
+
+	root, err := s.queue.GetJob(ctx, req.JobId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get job: %v", err)
+	}
+	if root == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found: %s", req.JobId)
+	}
+
+	graph, err := queue.BuildJobGraph(ctx, s.queue, req.JobId)
+	var cycleErr *queue.GraphCycleError
+	if err != nil && !errors.As(err, &cycleErr) {
+		s.logger.Error("Failed to build job graph",
+			zap.String("job_id", req.JobId),
+			middleware.RequestIDField(middleware.RequestIDFromContext(ctx)),
+			zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to build job graph: %v", err)
+	}
+
+	response := &pb.GetJobGraphResponse{
+		Truncated: graph.Truncated,
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}
+	if cycleErr != nil {
+		response.Cycle = cycleErr.Cycle
+	}
+	response.Dot = graph.DOT(response.Cycle)
+
+	for _, node := range graph.Nodes {
+		response.Nodes = append(response.Nodes, &pb.JobNode{
+			JobId:     node.ID,
+			Status:    convertJobStatus(node.Status),
+			DependsOn: node.DependsOn,
+			Depth:     int32(node.Depth),
+			Missing:   node.Missing,
+		})
+	}
+
+	return response, nil
+}
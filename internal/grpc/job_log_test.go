@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/ffmpeg"
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jobLogStream records the chunks sent by GetJobLog
+type jobLogStream struct {
+	grpc.ServerStream
+	chunks []*pb.GetJobLogResponse
+}
+
+func (s *jobLogStream) Context() context.Context { return context.Background() }
+
+func (s *jobLogStream) Send(resp *pb.GetJobLogResponse) error {
+	resp.Chunk = append([]byte(nil), resp.Chunk...)
+	s.chunks = append(s.chunks, resp)
+	return nil
+}
+
+func TestGetJobLog(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Storage.Local.TempPath = t.TempDir()
+	q := queue.NewMemoryQueue()
+	s := &Server{config: cfg, queue: q, logger: zap.NewNop()}
+
+	// The log is found by job ID, not by a path recorded in the metadata
+	job := &queue.Job{ID: "job-1", Metadata: map[string]string{queue.MetadataFFmpegLogPath: "/etc/passwd"}}
+	if err := q.Enqueue(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	logDir := ffmpeg.LogDir(cfg.Storage.Local.TempPath)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Repeat("frame=1 fps=25\n", downloadChunkSize/10)
+	if err := os.WriteFile(filepath.Join(logDir, "job-1_ffmpeg.log"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := &jobLogStream{}
+	if err := s.GetJobLog(&pb.GetJobLogRequest{JobId: "job-1"}, stream); err != nil {
+		t.Fatalf("GetJobLog() error = %v", err)
+	}
+	var received bytes.Buffer
+	for _, chunk := range stream.chunks {
+		if chunk.Offset != int64(received.Len()) {
+			t.Errorf("chunk offset = %d, want %d", chunk.Offset, received.Len())
+		}
+		received.Write(chunk.Chunk)
+	}
+	if len(stream.chunks) < 2 || received.String() != content {
+		t.Errorf("received %d bytes in %d chunks, want the %d byte log in several", received.Len(), len(stream.chunks), len(content))
+	}
+
+	err := s.GetJobLog(&pb.GetJobLogRequest{JobId: "missing"}, &jobLogStream{})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetJobLog() of an unknown job error = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultProgressInterval is used when a request has no interval
+	defaultProgressInterval = 500 * time.Millisecond
+	// minProgressInterval bounds how often progress frames are sent
+	minProgressInterval = 50 * time.Millisecond
+)
+
+// StreamJobProgress sends progress frames for a job at the interval chosen by
+// the client. A new request on the stream switches to another job or
+// interval. The stream ends after the frame reporting a finished job.
+func (s *Server) StreamJobProgress(stream pb.VideoProcessor_StreamJobProgressServer) error {
+	ctx := stream.Context()
+
+	requests := make(chan *pb.StreamJobProgressRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Wait for the first request before polling anything
+	var req *pb.StreamJobProgressRequest
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-recvErr:
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case req = <-requests:
+	}
+
+	for {
+		if req.JobId == "" {
+			return status.Error(codes.InvalidArgument, "job_id is required")
+		}
+
+		interval := time.Duration(req.IntervalMs) * time.Millisecond
+		if interval <= 0 {
+			interval = defaultProgressInterval
+		}
+		interval = max(interval, minProgressInterval)
+
+		next, err := s.streamProgress(stream, req.JobId, interval, requests, recvErr)
+		if err != nil || next == nil {
+			return err
+		}
+		req = next
+	}
+}
+
+// streamProgress sends frames for one job until it finishes, the client sends
+// a new request, which is returned, or the stream ends
+func (s *Server) streamProgress(stream pb.VideoProcessor_StreamJobProgressServer, jobID string, interval time.Duration,
+	requests <-chan *pb.StreamJobProgressRequest, recvErr <-chan error) (*pb.StreamJobProgressRequest, error) {
+	ctx := stream.Context()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := s.progressJob(stream, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := stream.Send(jobProgressResponse(job)); err != nil {
+			s.logger.Error("Failed to send job progress", zap.String("job_id", jobID), zap.Error(err))
+			return nil, err
+		}
+
+		switch job.Status {
+		case queue.JobStatusCompleted, queue.JobStatusFailed, queue.JobStatusCancelled:
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-recvErr:
+			// A client that closed its side still receives frames until the job finishes
+			if !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			recvErr = nil
+		case req := <-requests:
+			return req, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// progressJob returns the latest state of a job, preferring the copy held by
+// the worker running it since the queue is only updated periodically
+func (s *Server) progressJob(stream pb.VideoProcessor_StreamJobProgressServer, jobID string) (*queue.Job, error) {
+	if p, ok := s.processor.(interface {
+		ActiveJob(jobID string) (*queue.Job, bool)
+	}); ok {
+		if job, ok := p.ActiveJob(jobID); ok {
+			return job, nil
+		}
+	}
+
+	job, err := s.queue.GetJob(stream.Context(), jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job", zap.String("job_id", jobID), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to get job: %v", err)
+	}
+	if job == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found: %s", jobID)
+	}
+	return job, nil
+}
+
+// jobProgressResponse converts the latest progress of a job into a frame
+func jobProgressResponse(job *queue.Job) *pb.StreamJobProgressResponse {
+	response := &pb.StreamJobProgressResponse{
+		JobId:     job.ID,
+		Status:    convertJobStatus(job.Status),
+		Percent:   float32(job.Progress),
+		Timestamp: timestamppb.Now(),
+	}
+
+	if progress, ok := job.LatestProgress(); ok {
+		response.Frame = progress.Frame
+		response.Fps = float32(progress.FPS)
+		response.BitrateKbps = float32(parseBitrateKbps(progress.Bitrate))
+		response.OutTimeUs = progress.OutTimeUS
+		response.Speed = float32(progress.Speed)
+	}
+	if job.Status == queue.JobStatusCompleted {
+		response.Percent = 100
+	}
+
+	return response
+}
+
+// parseBitrateKbps parses an FFmpeg bitrate such as "1523.4kbits/s", returning
+// 0 for N/A
+func parseBitrateKbps(bitrate string) float64 {
+	kbps, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(bitrate), "kbits/s"), 64)
+	if err != nil {
+		return 0
+	}
+	return kbps
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// manifestPath returns the storage path the worker writes the output
+// manifest of an enqueued job to: manifest.json next to the output, inside
+// the templated output directory when one is configured
+func (s *Server) manifestPath(job *queue.Job) (string, error) {
+	dir := filepath.ToSlash(filepath.Dir(job.OutputPath))
+
+	if text := s.config.FFmpeg.OutputDirectoryTemplate; text != "" {
+		tmpl, err := config.ParseOutputDirectoryTemplate(text)
+		if err != nil {
+			return "", err
+		}
+
+		created := job.CreatedAt
+		if created.IsZero() {
+			created = time.Now()
+		}
+		subdir, err := config.ExpandOutputDirectory(tmpl,
+			config.JobOutputDirectoryValues(job.ID, job.Metadata[queue.MetadataTenantID], created, job.Metadata))
+		if err != nil {
+			return "", err
+		}
+		dir = path.Join(dir, subdir)
+	}
+
+	return path.Join(dir, config.OutputManifestName), nil
+}
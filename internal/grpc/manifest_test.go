@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+func TestManifestPath(t *testing.T) {
+	cfg := config.DefaultConfig()
+	s := &Server{config: cfg}
+	job := &queue.Job{
+		ID:         "job-1",
+		OutputPath: "videos/out/output.m3u8",
+		CreatedAt:  time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+		Metadata:   map[string]string{queue.MetadataTenantID: "acme"},
+	}
+
+	if got, err := s.manifestPath(job); err != nil || got != "videos/out/manifest.json" {
+		t.Errorf("manifestPath() = %s, %v; want videos/out/manifest.json", got, err)
+	}
+
+	cfg.FFmpeg.OutputDirectoryTemplate = "{{.TenantID}}/{{.Year}}/{{.JobID}}"
+	if got, err := s.manifestPath(job); err != nil || got != "videos/out/acme/2024/job-1/manifest.json" {
+		t.Errorf("manifestPath() with a template = %s, %v; want it inside the templated directory", got, err)
+	}
+}
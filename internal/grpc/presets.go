@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+)
+
+// ListPresets returns the configured and built-in transcode presets
+func (s *Server) ListPresets(ctx context.Context, req *pb.ListPresetsRequest) (*pb.ListPresetsResponse, error) {
+	response := &pb.ListPresetsResponse{
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}
+
+	for _, name := range s.config.FFmpeg.PresetNames() {
+		preset, _ := s.config.FFmpeg.Preset(name)
+		_, configured := s.config.FFmpeg.Presets[name]
+
+		response.Presets = append(response.Presets, &pb.TranscodePreset{
+			Name:               name,
+			Description:        preset.Description,
+			Qualities:          preset.Qualities,
+			VideoCodec:         preset.VideoCodec,
+			OutputFormat:       preset.OutputFormat,
+			TwoPass:            preset.TwoPass,
+			Lossless:           preset.Lossless,
+			HardwareAccel:      preset.HardwareAccel,
+			AudioNormalization: preset.AudioNormalization,
+			Builtin:            !configured,
+		})
+	}
+
+	return response, nil
+}
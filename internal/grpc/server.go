@@ -2,44 +2,79 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
+	"os"
+	"path"
+	"sync/atomic"
 	"time"
 
+	"github.com/nikhil0verma/flixsrota/internal/billing"
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/events"
+	"github.com/nikhil0verma/flixsrota/internal/ffmpeg"
 	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
 	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"github.com/nikhil0verma/flixsrota/internal/pipeline"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
 	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/nikhil0verma/flixsrota/internal/preflight"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Server represents the gRPC server
 type Server struct {
+	config     *config.Config
 	queue      queue.Queue
 	storage    storage.Storage
 	processor  interface{} // JobProcessor interface
 	logger     *zap.Logger
 	grpcServer *grpc.Server
 	metrics    *metrics.SystemMetricsCollector
+	stats      *metrics.JobStatsAggregator
+	events     events.Bus
+
+	// ledger is nil when billing is disabled
+	ledger *billing.CostLedger
+
+	subscribers   atomic.Int64
+	capabilities  capabilitiesCache
+	ffmpegVersion *ffmpeg.VersionCache
 }
 
 // NewServer creates a new gRPC server
-func NewServer(queue queue.Queue, storage storage.Storage, processor interface{}, logger *zap.Logger) *grpc.Server {
+func NewServer(cfg *config.Config, queue queue.Queue, storage storage.Storage, processor interface{}, stats *metrics.JobStatsAggregator, events events.Bus, logger *zap.Logger) *grpc.Server {
 	s := &Server{
+		config:    cfg,
 		queue:     queue,
 		storage:   storage,
 		processor: processor,
 		logger:    logger,
 		metrics:   metrics.NewSystemMetricsCollector(logger),
+		stats:     stats,
+		events:    events,
+
+		ffmpegVersion: ffmpeg.NewVersionCache(cfg.FFmpeg.ExecutablePath),
+	}
+	if cfg.Billing.Enabled {
+		s.ledger = billing.NewCostLedger(cfg.Billing, cfg.Queue.Redis.Address, cfg.Queue.Redis.Password, cfg.Queue.Redis.DB)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(middleware.RequestIDInterceptor()),
+		grpc.StreamInterceptor(middleware.RequestIDStreamInterceptor()),
+	)
 
 	// Register services (these will be implemented when protobuf is generated)
 	// pb.RegisterVideoProcessorServer(grpcServer, s)
 	// pb.RegisterSystemMetricsServer(grpcServer, s)
+	// pb.RegisterJobEventsServer(grpcServer, s)
 
 	s.grpcServer = grpcServer
 	return grpcServer
@@ -57,12 +92,27 @@ func (s *Server) GracefulStop() {
 
 // ProcessVideo handles video processing requests
 func (s *Server) ProcessVideo(ctx context.Context, req *pb.ProcessVideoRequest) (*pb.ProcessVideoResponse, error) {
-	s.logger.Info("Processing video request",
+	requestID := middleware.RequestIDFromContext(ctx)
+	logger := s.logger.With(middleware.RequestIDField(requestID))
+
+	logger.Info("Processing video request",
 		zap.String("input_path", req.InputPath),
 		zap.String("output_path", req.OutputPath))
 
+	// Reject extra FFmpeg arguments that could read files or run commands
+	if err := ffmpeg.ValidateArgs(req.FfmpegArgs); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid ffmpeg_args: %v", err)
+	}
+
+	// Metadata the server writes, such as the local input path, cannot come
+	// from the client
+	if stripped := queue.StripReservedMetadata(req.Metadata); len(stripped) > 0 {
+		logger.Warn("Ignoring reserved job metadata", zap.Strings("keys", stripped))
+	}
+
 	// Create job
 	job := &queue.Job{
+		ID:             queue.NewJobID(s.queue),
 		InputPath:      req.InputPath,
 		OutputPath:     req.OutputPath,
 		FFmpegArgs:     req.FfmpegArgs,
@@ -72,16 +122,90 @@ func (s *Server) ProcessVideo(ctx context.Context, req *pb.ProcessVideoRequest)
 		QueueAdapter:   req.QueueAdapter,
 	}
 
+	// Carry the request ID through the job lifecycle
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[queue.MetadataRequestID] = requestID
+
+	// Read the input from a direct upload to storage
+	if req.UploadId != "" {
+		if err := useUpload(job, req.UploadId); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fill in the settings of the requested preset
+	if req.PresetName != "" {
+		preset, ok := s.config.FFmpeg.Preset(req.PresetName)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown preset: %s", req.PresetName)
+		}
+		pipeline.ApplyPreset(job, req.PresetName, preset)
+	}
+
+	// Storage operations of GCS jobs run as the tenant's service account
+	if err := s.assignGCSServiceAccount(job); err != nil {
+		return nil, err
+	}
+
+	// Record the requested audio tracks
+	tracks := make([]queue.AudioTrackConfig, 0, len(req.AudioTracks))
+	for _, track := range req.AudioTracks {
+		if err := config.ValidateAudioTrack(track.Codec, int(track.Channels)); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid audio track: %v", err)
+		}
+		tracks = append(tracks, queue.AudioTrackConfig{
+			Codec:    track.Codec,
+			Bitrate:  track.Bitrate,
+			Channels: int(track.Channels),
+			Language: track.Language,
+		})
+	}
+	if err := job.SetAudioTracks(tracks); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid audio tracks: %v", err)
+	}
+	if err := job.SetAudioLanguageMap(req.AudioLanguageMap); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid audio language map: %v", err)
+	}
+
+	// Record the requested container of each quality
+	if err := config.ValidateQualityOutputFormats(req.QualityOutputFormats); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid quality output formats: %v", err)
+	}
+	if err := job.SetQualityOutputFormats(req.QualityOutputFormats); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid quality output formats: %v", err)
+	}
+
+	// Restrict the job to workers with the required labels
+	if err := job.SetRequiredWorkerLabels(req.RequiredWorkerLabels); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid required worker labels: %v", err)
+	}
+
 	// Enqueue job
 	if err := s.queue.Enqueue(ctx, job); err != nil {
-		s.logger.Error("Failed to enqueue job", zap.Error(err))
+		logger.Error("Failed to enqueue job", zap.Error(err))
+		if errors.Is(err, queue.ErrJobAlreadyExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "failed to enqueue job: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to enqueue job: %v", err)
 	}
 
+	s.stats.OnJobEnqueued()
+
+	logger.Info("Job queued", zap.String("job_id", job.ID))
+
+	manifestPath, err := s.manifestPath(job)
+	if err != nil {
+		logger.Warn("Failed to resolve manifest path", zap.String("job_id", job.ID), zap.Error(err))
+	}
+
 	return &pb.ProcessVideoResponse{
-		JobId:   job.ID,
-		Status:  pb.JobStatus_JOB_STATUS_QUEUED,
-		Message: "Job queued successfully",
+		JobId:        job.ID,
+		Status:       pb.JobStatus_JOB_STATUS_QUEUED,
+		Message:      "Job queued successfully",
+		RequestId:    requestID,
+		ManifestPath: manifestPath,
 	}, nil
 }
 
@@ -97,22 +221,63 @@ func (s *Server) GetJobStatus(ctx context.Context, req *pb.GetJobStatusRequest)
 		return nil, status.Errorf(codes.NotFound, "job not found: %s", req.JobId)
 	}
 
+	return jobStatusResponse(job, middleware.RequestIDFromContext(ctx)), nil
+}
+
+// maxBatchJobStatus is the maximum number of jobs in a BatchGetJobStatus request
+const maxBatchJobStatus = 100
+
+// BatchGetJobStatus retrieves the status of several jobs in one call. IDs
+// that are not found are listed in the response instead of failing it.
+func (s *Server) BatchGetJobStatus(ctx context.Context, req *pb.BatchGetJobStatusRequest) (*pb.BatchGetJobStatusResponse, error) {
+	if len(req.JobIds) > maxBatchJobStatus {
+		return nil, status.Errorf(codes.InvalidArgument, "too many job IDs: %d (max %d)", len(req.JobIds), maxBatchJobStatus)
+	}
+
+	jobs, err := queue.GetJobs(ctx, s.queue, req.JobIds)
+	if err != nil {
+		s.logger.Error("Failed to get jobs", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to get jobs: %v", err)
+	}
+
+	requestID := middleware.RequestIDFromContext(ctx)
+	response := &pb.BatchGetJobStatusResponse{RequestId: requestID}
+	for _, jobID := range req.JobIds {
+		job, ok := jobs[jobID]
+		if !ok {
+			response.NotFound = append(response.NotFound, jobID)
+			continue
+		}
+		response.Jobs = append(response.Jobs, jobStatusResponse(job, requestID))
+	}
+
+	return response, nil
+}
+
+// jobStatusResponse converts a job to its status response
+func jobStatusResponse(job *queue.Job, requestID string) *pb.GetJobStatusResponse {
 	response := &pb.GetJobStatusResponse{
-		JobId:      job.ID,
-		Status:     convertJobStatus(job.Status),
-		Progress:   float32(job.Progress),
-		OutputPath: job.OutputPath,
-		Metadata:   job.Metadata,
+		JobId:        job.ID,
+		Status:       convertJobStatus(job.Status),
+		Progress:     float32(job.Progress),
+		OutputPath:   job.OutputPath,
+		ErrorMessage: job.Error,
+		Metadata:     job.Metadata,
+		RequestId:    requestID,
 	}
 
 	if job.StartedAt != nil {
-		response.StartedAt = job.StartedAt
+		response.StartedAt = timestamppb.New(*job.StartedAt)
 	}
 	if job.CompletedAt != nil {
-		response.CompletedAt = job.CompletedAt
+		response.CompletedAt = timestamppb.New(*job.CompletedAt)
+	}
+	if progress, ok := job.LatestProgress(); ok {
+		response.CurrentFps = float32(progress.FPS)
+		response.CurrentSpeed = float32(progress.Speed)
 	}
 
-	return response, nil
+	return response
 }
 
 // CancelJob cancels a running job
@@ -123,9 +288,12 @@ func (s *Server) CancelJob(ctx context.Context, req *pb.CancelJobRequest) (*pb.C
 		return nil, status.Errorf(codes.Internal, "failed to cancel job: %v", err)
 	}
 
+	s.stats.OnJobCancelled()
+
 	return &pb.CancelJobResponse{
-		Success: true,
-		Message: "Job cancelled successfully",
+		Success:   true,
+		Message:   "Job cancelled successfully",
+		RequestId: middleware.RequestIDFromContext(ctx),
 	}, nil
 }
 
@@ -161,9 +329,244 @@ func (s *Server) ListJobs(ctx context.Context, req *pb.ListJobsRequest) (*pb.Lis
 	return &pb.ListJobsResponse{
 		Jobs:       jobInfos,
 		TotalCount: int32(total),
+		RequestId:  middleware.RequestIDFromContext(ctx),
 	}, nil
 }
 
+// GetJobLog streams the captured FFmpeg log of a job in chunks. The log is
+// looked up by job ID in the log directory rather than by a path stored in
+// the job metadata.
+func (s *Server) GetJobLog(req *pb.GetJobLogRequest, stream pb.VideoProcessor_GetJobLogServer) error {
+	ctx := stream.Context()
+
+	job, err := s.queue.GetJob(ctx, req.JobId)
+	if err != nil {
+		s.logger.Error("Failed to get job", zap.String("job_id", req.JobId), zap.Error(err))
+		return status.Errorf(codes.Internal, "failed to get job: %v", err)
+	}
+
+	if job == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", req.JobId)
+	}
+
+	logPath, err := ffmpeg.JobLogPath(ffmpeg.LogDir(s.config.Storage.Local.TempPath), job.ID)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return status.Errorf(codes.NotFound, "no FFmpeg log available for job: %s", req.JobId)
+		}
+		s.logger.Error("Failed to open job log", zap.String("job_id", req.JobId), zap.Error(err))
+		return status.Errorf(codes.Internal, "failed to open job log: %v", err)
+	}
+	defer file.Close()
+
+	requestID := middleware.RequestIDFromContext(ctx)
+	buf := make([]byte, downloadChunkSize)
+	var offset int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.GetJobLogResponse{
+				JobId:     job.ID,
+				LogPath:   logPath,
+				Chunk:     buf[:n],
+				RequestId: requestID,
+				Offset:    offset,
+			}); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			s.logger.Error("Failed to read job log", zap.String("job_id", req.JobId), zap.Error(readErr))
+			return status.Errorf(codes.Internal, "failed to read job log: %v", readErr)
+		}
+	}
+}
+
+// Preflight checks that all configured dependencies are reachable
+func (s *Server) Preflight(ctx context.Context, req *pb.PreflightRequest) (*pb.PreflightResponse, error) {
+	results := preflight.Run(ctx, []preflight.Check{
+		preflight.QueueCheck(s.queue),
+		preflight.StorageCheck(s.storage),
+		preflight.FFmpegCheck(s.config.FFmpeg.ExecutablePath),
+		preflight.DiskSpaceCheck(s.config.Storage.Local.TempPath, preflight.MinFreeDiskBytes),
+	})
+
+	response := &pb.PreflightResponse{
+		Passed:    preflight.Passed(results),
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}
+	for _, result := range results {
+		response.Checks = append(response.Checks, &pb.PreflightCheck{
+			Name:       result.Name,
+			Passed:     result.Passed,
+			Message:    result.Message,
+			DurationMs: result.Duration.Milliseconds(),
+		})
+	}
+
+	return response, nil
+}
+
+// ResubmitFailedJobs requeues the failed jobs matching the request criteria
+func (s *Server) ResubmitFailedJobs(ctx context.Context, req *pb.ResubmitRequest) (*pb.ResubmitResponse, error) {
+	filter := queue.ResubmitFilter{
+		TagFilter:       req.TagFilter,
+		ResetRetryCount: req.ResetRetryCount,
+	}
+	if req.OlderThan != nil {
+		filter.OlderThan = req.OlderThan.AsTime()
+	}
+	if req.NewerThan != nil {
+		filter.NewerThan = req.NewerThan.AsTime()
+	}
+
+	count, err := queue.ResubmitFailedJobs(ctx, s.queue, filter)
+	for i := 0; i < count; i++ {
+		s.stats.OnJobEnqueued()
+	}
+	if err != nil {
+		s.logger.Error("Failed to resubmit jobs", zap.Int("resubmitted", count), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to resubmit jobs after %d resubmitted: %v", count, err)
+	}
+
+	s.logger.Info("Resubmitted failed jobs", zap.Int("count", count))
+
+	return &pb.ResubmitResponse{
+		ResubmittedCount: int32(count),
+		RequestId:        middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// downloadChunkSize is the size of the chunks streamed by DownloadFile and
+// GetJobLog
+const downloadChunkSize = 64 * 1024
+
+// DownloadFile streams a job output file from storage in chunks
+func (s *Server) DownloadFile(req *pb.DownloadFileRequest, stream pb.VideoProcessor_DownloadFileServer) error {
+	ctx := stream.Context()
+
+	job, err := s.queue.GetJob(ctx, req.JobId)
+	if err != nil {
+		s.logger.Error("Failed to get job", zap.String("job_id", req.JobId), zap.Error(err))
+		return status.Errorf(codes.Internal, "failed to get job: %v", err)
+	}
+
+	if job == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", req.JobId)
+	}
+
+	if job.Status != queue.JobStatusCompleted {
+		return status.Errorf(codes.FailedPrecondition, "job %s is not completed", req.JobId)
+	}
+
+	// Resolve the file inside the job output directory
+	remotePath := job.OutputPath
+	if req.Path != "" {
+		remotePath = path.Join(path.Dir(job.OutputPath), path.Clean("/"+req.Path))
+	}
+
+	reader, totalSize, err := storage.Open(ctx, s.storage, remotePath, s.config.Storage.Local.TempPath)
+	if err != nil {
+		s.logger.Error("Failed to open output file",
+			zap.String("job_id", req.JobId),
+			zap.String("path", remotePath),
+			zap.Error(err))
+		return status.Errorf(codes.Internal, "failed to open output file: %v", err)
+	}
+	defer reader.Close()
+
+	buf := make([]byte, downloadChunkSize)
+	var offset int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		n, readErr := reader.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.DownloadFileResponse{
+				Chunk:     buf[:n],
+				Offset:    offset,
+				TotalSize: totalSize,
+			}); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			s.logger.Error("Failed to read output file", zap.String("path", remotePath), zap.Error(readErr))
+			return status.Errorf(codes.Internal, "failed to read output file: %v", readErr)
+		}
+	}
+}
+
+// SubscribeJobEvents streams job lifecycle events matching the request filters
+func (s *Server) SubscribeJobEvents(req *pb.SubscribeRequest, stream pb.JobEvents_SubscribeJobEventsServer) error {
+	count := s.subscribers.Add(1)
+	defer s.subscribers.Add(-1)
+
+	if max := s.config.GRPC.MaxSubscribers; max > 0 && count > int64(max) {
+		return status.Errorf(codes.ResourceExhausted, "too many event subscribers (max %d)", max)
+	}
+
+	filter := events.Filter{
+		JobID:   req.JobId,
+		GroupID: req.GroupId,
+	}
+	if req.StatusFilter != pb.JobStatus_JOB_STATUS_UNSPECIFIED {
+		filter.Status = convertPBJobStatus(req.StatusFilter)
+	}
+
+	ctx := stream.Context()
+	jobEvents, unsubscribe, err := s.events.Subscribe(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to subscribe to job events", zap.Error(err))
+		return status.Errorf(codes.Internal, "failed to subscribe to job events: %v", err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-jobEvents:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "job event subscription closed")
+			}
+
+			if err := stream.Send(&pb.JobEvent{
+				JobId:        event.JobID,
+				GroupId:      event.GroupID,
+				Status:       convertJobStatus(event.Status),
+				Progress:     float32(event.Progress),
+				ErrorMessage: event.Error,
+				Timestamp:    timestamppb.New(event.Timestamp),
+				RequestId:    event.RequestID,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // GetMetrics returns system metrics
 func (s *Server) GetMetrics(ctx context.Context, req *pb.GetMetricsRequest) (*pb.GetMetricsResponse, error) {
 	// Get queue metrics
@@ -187,6 +590,9 @@ func (s *Server) GetMetrics(ctx context.Context, req *pb.GetMetricsRequest) (*pb
 		}
 	}
 
+	// Get job statistics
+	jobStats := s.stats.Snapshot()
+
 	// Create metrics response
 	response := &pb.GetMetricsResponse{
 		SystemMetrics: &pb.SystemMetrics{
@@ -201,18 +607,35 @@ func (s *Server) GetMetrics(ctx context.Context, req *pb.GetMetricsRequest) (*pb
 			MaxWorkerCount:       int32(systemMetrics.MaxWorkerCount),
 		},
 		JobMetrics: &pb.JobMetrics{
-			// TODO: Implement job metrics collection
-			TotalJobs:      0,
-			QueuedJobs:     int32(queueDepth),
-			ProcessingJobs: 0,
-			CompletedJobs:  0,
-			FailedJobs:     0,
-			CancelledJobs:  0,
+			TotalJobs:                    int32(jobStats.TotalJobs),
+			QueuedJobs:                   int32(queueDepth),
+			ProcessingJobs:               int32(jobStats.ProcessingJobs),
+			CompletedJobs:                int32(jobStats.CompletedJobs),
+			FailedJobs:                   int32(jobStats.FailedJobs),
+			CancelledJobs:                int32(jobStats.CancelledJobs),
+			AverageProcessingTimeSeconds: jobStats.AverageProcessingTime.Seconds(),
 		},
 		QueueMetrics: &pb.QueueMetrics{
 			QueueDepth: int32(queueDepth),
 			// TODO: Implement queue throughput metrics
 		},
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}
+
+	// Include processor state when the processor supports introspection
+	if p, ok := s.processor.(interface{ Snapshot() metrics.ProcessorState }); ok {
+		state := p.Snapshot()
+		activeJobIDs := make([]string, 0, len(state.ActiveJobs))
+		for _, job := range state.ActiveJobs {
+			activeJobIDs = append(activeJobIDs, job.ID)
+		}
+
+		response.ProcessorState = &pb.ProcessorState{
+			ActiveJobIds:    activeJobIDs,
+			IdleWorkerCount: int32(state.IdleWorkerCount),
+			QueueDepth:      int32(state.QueueDepth),
+			IsPaused:        state.IsPaused,
+		}
 	}
 
 	return response, nil
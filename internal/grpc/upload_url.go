@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"path"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/middleware"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Lifetime of pre-signed upload URLs
+const (
+	defaultUploadURLTTL = 15 * time.Minute
+	// maxUploadURLTTL is the longest lifetime S3 and GCS V4 signatures allow
+	maxUploadURLTTL = 7 * 24 * time.Hour
+)
+
+// uploadsPrefix is the storage directory direct uploads are written to
+const uploadsPrefix = "uploads"
+
+// uploadIDBytes is the number of random bytes in an upload ID
+const uploadIDBytes = 16
+
+// CreateUploadURL returns a pre-signed URL the client uploads an input file
+// to, bypassing this server, and the upload ID to pass to ProcessVideo
+func (s *Server) CreateUploadURL(ctx context.Context, req *pb.CreateUploadURLRequest) (*pb.CreateUploadURLResponse, error) {
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	switch {
+	case ttl < 0 || ttl > maxUploadURLTTL:
+		return nil, status.Errorf(codes.InvalidArgument, "ttl_seconds must be between 0 and %d", int(maxUploadURLTTL.Seconds()))
+	case ttl == 0:
+		ttl = defaultUploadURLTTL
+	}
+
+	id := make([]byte, uploadIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate upload ID: %v", err)
+	}
+	uploadID := hex.EncodeToString(id)
+
+	url, err := storage.PresignPutURL(ctx, s.storage, uploadPath(uploadID), ttl)
+	if errors.Is(err, storage.ErrPresignNotSupported) {
+		return nil, status.Errorf(codes.Unimplemented, "%v", err)
+	}
+	if err != nil {
+		s.logger.Error("Failed to create upload URL", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to create upload URL: %v", err)
+	}
+
+	return &pb.CreateUploadURLResponse{
+		UploadUrl: url,
+		UploadId:  uploadID,
+		ExpiresAt: timestamppb.New(time.Now().Add(ttl)),
+		RequestId: middleware.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// useUpload makes the file uploaded under uploadID the input of a job. The
+// worker downloads it from storage before processing.
+func useUpload(job *queue.Job, uploadID string) error {
+	if job.InputPath != "" {
+		return status.Errorf(codes.InvalidArgument, "input_path and upload_id are mutually exclusive")
+	}
+	if !validUploadID(uploadID) {
+		return status.Errorf(codes.InvalidArgument, "invalid upload_id: %s", uploadID)
+	}
+
+	job.InputPath = uploadPath(uploadID)
+	job.Metadata[queue.MetadataUploadID] = uploadID
+	return nil
+}
+
+// uploadPath returns the storage path of a direct upload
+func uploadPath(uploadID string) string {
+	return path.Join(uploadsPrefix, uploadID)
+}
+
+// validUploadID reports whether id has the form of a generated upload ID, so
+// it cannot point outside the uploads directory
+func validUploadID(id string) bool {
+	if len(id) != 2*uploadIDBytes {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
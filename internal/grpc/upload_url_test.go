@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// presigningStorage issues upload URLs valid for ttl
+type presigningStorage struct {
+	storage.Storage
+	ttl time.Duration
+}
+
+func (s *presigningStorage) PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	s.ttl = ttl
+	return "https://bucket.example.com/" + remotePath, nil
+}
+
+func TestCreateUploadURL(t *testing.T) {
+	ctx := context.Background()
+	store := &presigningStorage{}
+	s := &Server{storage: store, logger: zap.NewNop()}
+
+	resp, err := s.CreateUploadURL(ctx, &pb.CreateUploadURLRequest{})
+	if err != nil {
+		t.Fatalf("CreateUploadURL() error = %v", err)
+	}
+	if !validUploadID(resp.UploadId) || resp.UploadUrl != "https://bucket.example.com/uploads/"+resp.UploadId {
+		t.Errorf("CreateUploadURL() = %s for upload %s", resp.UploadUrl, resp.UploadId)
+	}
+	if store.ttl != defaultUploadURLTTL {
+		t.Errorf("URL lifetime = %s, want the default %s", store.ttl, defaultUploadURLTTL)
+	}
+
+	if _, err := s.CreateUploadURL(ctx, &pb.CreateUploadURLRequest{TtlSeconds: 3600}); err != nil || store.ttl != time.Hour {
+		t.Errorf("CreateUploadURL() with a TTL = %v with lifetime %s, want 1h", err, store.ttl)
+	}
+	if _, err := s.CreateUploadURL(ctx, &pb.CreateUploadURLRequest{TtlSeconds: 8 * 24 * 3600}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateUploadURL() with an 8 day TTL error = %v, want InvalidArgument", err)
+	}
+
+	s.storage = storage.Storage(nil)
+	if _, err := s.CreateUploadURL(ctx, &pb.CreateUploadURLRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("CreateUploadURL() without pre-signing storage error = %v, want Unimplemented", err)
+	}
+}
+
+func TestUseUpload(t *testing.T) {
+	uploadID := strings.Repeat("ab", uploadIDBytes)
+
+	job := &queue.Job{Metadata: map[string]string{}}
+	if err := useUpload(job, uploadID); err != nil {
+		t.Fatalf("useUpload() error = %v", err)
+	}
+	if job.InputPath != "uploads/"+uploadID || job.Metadata[queue.MetadataUploadID] != uploadID {
+		t.Errorf("useUpload() set input %s and upload_id %s", job.InputPath, job.Metadata[queue.MetadataUploadID])
+	}
+
+	for name, tt := range map[string]struct {
+		inputPath string
+		uploadID  string
+	}{
+		"with input path": {inputPath: "in.mp4", uploadID: uploadID},
+		"traversal":       {uploadID: "../../etc/passwd"},
+		"short":           {uploadID: "abcd"},
+		"not hex":         {uploadID: strings.Repeat("zz", uploadIDBytes)},
+	} {
+		job := &queue.Job{InputPath: tt.inputPath, Metadata: map[string]string{}}
+		if err := useUpload(job, tt.uploadID); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: useUpload() error = %v, want InvalidArgument", name, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"go.uber.org/zap"
+)
+
+// MetadataResource is the job metadata key holding the namespace/name of the
+// FlixsrotaJob a job was submitted for
+const MetadataResource = "k8s_resource"
+
+// Controller intervals
+const (
+	// DefaultPollInterval is how often the status of running jobs is refreshed
+	DefaultPollInterval = 10 * time.Second
+	// rewatchDelay is the pause before listing again after a failed watch
+	rewatchDelay = 5 * time.Second
+)
+
+// Controller submits FlixsrotaJob resources to a Flixsrota server with the
+// ProcessVideo RPC and mirrors the job progress into their status
+type Controller struct {
+	client       *Client
+	processor    pb.VideoProcessorClient
+	namespace    string
+	pollInterval time.Duration
+	logger       *zap.Logger
+
+	// jobs holds the latest version of every resource, keyed by namespace/name
+	jobs map[string]*FlixsrotaJob
+}
+
+// NewController creates a controller for the FlixsrotaJob resources in
+// namespace, or in all namespaces when namespace is empty
+func NewController(client *Client, processor pb.VideoProcessorClient, namespace string, pollInterval time.Duration, logger *zap.Logger) *Controller {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Controller{
+		client:       client,
+		processor:    processor,
+		namespace:    namespace,
+		pollInterval: pollInterval,
+		logger:       logger,
+		jobs:         make(map[string]*FlixsrotaJob),
+	}
+}
+
+// Run watches FlixsrotaJob resources and reconciles them until ctx is
+// cancelled. The resources are listed again whenever the watch ends.
+func (c *Controller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		resourceVersion, err := c.resync(ctx)
+		if err != nil {
+			c.logger.Warn("Failed to list FlixsrotaJob resources", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(rewatchDelay):
+				continue
+			}
+		}
+
+		events := make(chan watchEvent)
+		watchErr := make(chan error, 1)
+		watchCtx, cancelWatch := context.WithCancel(ctx)
+		go func() {
+			watchErr <- c.client.Watch(watchCtx, c.namespace, resourceVersion, events)
+		}()
+
+		if err := c.handleEvents(ctx, events, watchErr, ticker.C); err != nil {
+			c.logger.Debug("FlixsrotaJob watch ended", zap.Error(err))
+		}
+		cancelWatch()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// handleEvents reconciles resources as watch events arrive and refreshes
+// running jobs on every tick. It returns when the watch ends.
+func (c *Controller) handleEvents(ctx context.Context, events <-chan watchEvent, watchErr <-chan error, ticks <-chan time.Time) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-watchErr:
+			return err
+		case event := <-events:
+			switch event.Type {
+			case "ADDED", "MODIFIED":
+				job := event.Object
+				c.jobs[resourceKey(&job)] = &job
+				c.reconcile(ctx, &job)
+			case "DELETED":
+				delete(c.jobs, resourceKey(&event.Object))
+			case "ERROR":
+				// Usually an expired resource version, fixed by listing again
+				return nil
+			}
+		case <-ticks:
+			for _, job := range c.jobs {
+				if !job.Status.terminal() {
+					c.reconcile(ctx, job)
+				}
+			}
+		}
+	}
+}
+
+// resync lists every resource, reconciles it and returns the resource
+// version to watch from
+func (c *Controller) resync(ctx context.Context) (string, error) {
+	items, resourceVersion, err := c.client.List(ctx, c.namespace)
+	if err != nil {
+		return "", err
+	}
+
+	c.jobs = make(map[string]*FlixsrotaJob, len(items))
+	for i := range items {
+		job := &items[i]
+		c.jobs[resourceKey(job)] = job
+		c.reconcile(ctx, job)
+	}
+	return resourceVersion, nil
+}
+
+// reconcile submits a new resource or refreshes the status of a running one
+func (c *Controller) reconcile(ctx context.Context, job *FlixsrotaJob) {
+	if job.Status.terminal() {
+		return
+	}
+
+	logger := c.logger.With(zap.String("resource", resourceKey(job)))
+	previous := job.Status
+
+	if job.Status.JobID == "" {
+		c.submit(ctx, job)
+	} else {
+		c.refresh(ctx, job)
+	}
+
+	if job.Status == previous {
+		return
+	}
+	if err := c.client.UpdateStatus(ctx, job); err != nil {
+		logger.Warn("Failed to update FlixsrotaJob status", zap.Error(err))
+		return
+	}
+	logger.Info("Updated FlixsrotaJob status",
+		zap.String("job_id", job.Status.JobID),
+		zap.String("phase", job.Status.Phase))
+}
+
+// submit sends the resource to the server with ProcessVideo
+func (c *Controller) submit(ctx context.Context, job *FlixsrotaJob) {
+	metadata := make(map[string]string, len(job.Spec.Metadata)+1)
+	for key, value := range job.Spec.Metadata {
+		metadata[key] = value
+	}
+	metadata[MetadataResource] = resourceKey(job)
+
+	resp, err := c.processor.ProcessVideo(ctx, &pb.ProcessVideoRequest{
+		InputPath:            job.Spec.InputPath,
+		OutputPath:           job.Spec.OutputPath,
+		FfmpegArgs:           job.Spec.FFmpegArgs,
+		Priority:             job.Spec.Priority,
+		Metadata:             metadata,
+		StorageAdapter:       job.Spec.StorageAdapter,
+		QueueAdapter:         job.Spec.QueueAdapter,
+		PresetName:           job.Spec.PresetName,
+		RequiredWorkerLabels: job.Spec.RequiredWorkerLabels,
+		QualityOutputFormats: job.Spec.QualityOutputFormats,
+	})
+	if err != nil {
+		job.Status.Phase = PhaseFailed
+		job.Status.Error = err.Error()
+		return
+	}
+
+	job.Status.JobID = resp.JobId
+	job.Status.Phase = phase(resp.Status)
+	job.Status.OutputPath = job.Spec.OutputPath
+	job.Status.ManifestPath = resp.ManifestPath
+}
+
+// refresh copies the server's view of the job into the resource status
+func (c *Controller) refresh(ctx context.Context, job *FlixsrotaJob) {
+	resp, err := c.processor.GetJobStatus(ctx, &pb.GetJobStatusRequest{JobId: job.Status.JobID})
+	if err != nil {
+		c.logger.Debug("Failed to get job status",
+			zap.String("resource", resourceKey(job)),
+			zap.String("job_id", job.Status.JobID),
+			zap.Error(err))
+		return
+	}
+
+	job.Status.Phase = phase(resp.Status)
+	job.Status.Progress = float64(resp.Progress)
+	job.Status.Error = resp.ErrorMessage
+	if resp.OutputPath != "" {
+		job.Status.OutputPath = resp.OutputPath
+	}
+	if resp.StartedAt != nil {
+		job.Status.StartedAt = resp.StartedAt.AsTime().UTC().Format(time.RFC3339)
+	}
+	if resp.CompletedAt != nil {
+		job.Status.CompletedAt = resp.CompletedAt.AsTime().UTC().Format(time.RFC3339)
+	}
+}
+
+// phase converts a job status to a resource phase, e.g. JOB_STATUS_QUEUED to Queued
+func phase(status pb.JobStatus) string {
+	switch status {
+	case pb.JobStatus_JOB_STATUS_QUEUED:
+		return PhaseQueued
+	case pb.JobStatus_JOB_STATUS_PROCESSING:
+		return PhaseProcessing
+	case pb.JobStatus_JOB_STATUS_COMPLETED:
+		return PhaseCompleted
+	case pb.JobStatus_JOB_STATUS_FAILED:
+		return PhaseFailed
+	case pb.JobStatus_JOB_STATUS_CANCELLED:
+		return PhaseCancelled
+	default:
+		return PhasePending
+	}
+}
+
+// resourceKey identifies a resource as namespace/name
+func resourceKey(job *FlixsrotaJob) string {
+	return strings.Join([]string{job.Metadata.Namespace, job.Metadata.Name}, "/")
+}
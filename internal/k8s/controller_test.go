@@ -0,0 +1,253 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/nikhil0verma/flixsrota/internal/grpc/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeAPIServer serves FlixsrotaJob resources in the default namespace
+type fakeAPIServer struct {
+	mu       sync.Mutex
+	items    []FlixsrotaJob
+	events   []watchEvent
+	statuses map[string]FlixsrotaJobStatus
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collection := resourcePath("default")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == collection && r.URL.Query().Get("watch") == "true":
+		if r.URL.Query().Get("resourceVersion") != "42" {
+			http.Error(w, "watch from the wrong version", http.StatusGone)
+			return
+		}
+		for _, event := range s.events {
+			json.NewEncoder(w).Encode(event)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == collection:
+		list := flixsrotaJobList{Items: s.items}
+		list.Metadata.ResourceVersion = "42"
+		json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPatch && strings.HasSuffix(r.URL.Path, "/status"):
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			http.Error(w, "unsupported patch", http.StatusUnsupportedMediaType)
+			return
+		}
+		var patch struct {
+			Status FlixsrotaJobStatus `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, collection+"/"), "/status")
+		s.statuses[name] = patch.Status
+		w.Write([]byte("{}"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// status returns the last status patched for a resource
+func (s *fakeAPIServer) status(name string) FlixsrotaJobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statuses[name]
+}
+
+// newTestClient returns a client of a fake API server
+func newTestClient(t *testing.T, api *fakeAPIServer) *Client {
+	t.Helper()
+	api.statuses = make(map[string]FlixsrotaJobStatus)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return &Client{baseURL: server.URL, token: "test-token", httpClient: server.Client()}
+}
+
+// fakeProcessor accepts every job as job-<n> and reports jobs with status
+type fakeProcessor struct {
+	pb.VideoProcessorClient
+	requests  []*pb.ProcessVideoRequest
+	submitErr error
+	status    *pb.GetJobStatusResponse
+}
+
+func (p *fakeProcessor) ProcessVideo(ctx context.Context, in *pb.ProcessVideoRequest, opts ...grpc.CallOption) (*pb.ProcessVideoResponse, error) {
+	if p.submitErr != nil {
+		return nil, p.submitErr
+	}
+	p.requests = append(p.requests, in)
+	return &pb.ProcessVideoResponse{
+		JobId:        "job-" + in.Metadata[MetadataResource],
+		Status:       pb.JobStatus_JOB_STATUS_QUEUED,
+		ManifestPath: "out/manifest.json",
+	}, nil
+}
+
+func (p *fakeProcessor) GetJobStatus(ctx context.Context, in *pb.GetJobStatusRequest, opts ...grpc.CallOption) (*pb.GetJobStatusResponse, error) {
+	return p.status, nil
+}
+
+func testResource(name string) FlixsrotaJob {
+	return FlixsrotaJob{
+		Metadata: ObjectMeta{Name: name, Namespace: "default"},
+		Spec:     FlixsrotaJobSpec{InputPath: "in.mp4", OutputPath: "out/" + name, Priority: 5},
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPIServer{
+		items:  []FlixsrotaJob{testResource("a")},
+		events: []watchEvent{{Type: "ADDED", Object: testResource("b")}, {Type: "DELETED", Object: testResource("a")}},
+	}
+	client := newTestClient(t, api)
+
+	items, resourceVersion, err := client.List(ctx, "default")
+	if err != nil || len(items) != 1 || items[0].Metadata.Name != "a" || resourceVersion != "42" {
+		t.Fatalf("List() = %v, %s, %v", items, resourceVersion, err)
+	}
+
+	events := make(chan watchEvent, 2)
+	if err := client.Watch(ctx, "default", resourceVersion, events); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if first, second := <-events, <-events; first.Type != "ADDED" || first.Object.Metadata.Name != "b" || second.Type != "DELETED" {
+		t.Errorf("Watch() events = %v, %v", first, second)
+	}
+
+	job := testResource("a")
+	job.Status = FlixsrotaJobStatus{JobID: "job-1", Phase: PhaseQueued}
+	if err := client.UpdateStatus(ctx, &job); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	if got := api.status("a"); got != job.Status {
+		t.Errorf("patched status = %+v, want %+v", got, job.Status)
+	}
+
+	client.token = "wrong"
+	if _, _, err := client.List(ctx, "default"); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("List() with a wrong token error = %v, want the server's message", err)
+	}
+}
+
+func TestControllerReconcile(t *testing.T) {
+	ctx := context.Background()
+	done := testResource("done")
+	done.Status = FlixsrotaJobStatus{JobID: "job-old", Phase: PhaseCompleted}
+	api := &fakeAPIServer{items: []FlixsrotaJob{testResource("a"), done}}
+	processor := &fakeProcessor{}
+	c := NewController(newTestClient(t, api), processor, "default", time.Minute, zap.NewNop())
+
+	// New resources are submitted, finished ones are left alone
+	if _, err := c.resync(ctx); err != nil {
+		t.Fatalf("resync() error = %v", err)
+	}
+	if len(processor.requests) != 1 {
+		t.Fatalf("submitted %d jobs, want only the new resource", len(processor.requests))
+	}
+	if req := processor.requests[0]; req.InputPath != "in.mp4" || req.Priority != 5 || req.Metadata[MetadataResource] != "default/a" {
+		t.Errorf("ProcessVideo request = %+v", req)
+	}
+	want := FlixsrotaJobStatus{JobID: "job-default/a", Phase: PhaseQueued, OutputPath: "out/a", ManifestPath: "out/manifest.json"}
+	if got := api.status("a"); got != want {
+		t.Errorf("status after submitting = %+v, want %+v", got, want)
+	}
+
+	// Running jobs are refreshed on every tick, and watched resources are
+	// submitted as they are added
+	processor.status = &pb.GetJobStatusResponse{Status: pb.JobStatus_JOB_STATUS_COMPLETED, Progress: 100, OutputPath: "out/a.m3u8"}
+	events := make(chan watchEvent)
+	watchErr := make(chan error, 1)
+	ticks := make(chan time.Time)
+	result := make(chan error)
+	go func() { result <- c.handleEvents(ctx, events, watchErr, ticks) }()
+
+	ticks <- time.Now()
+	events <- watchEvent{Type: "ADDED", Object: testResource("b")}
+	events <- watchEvent{Type: "DELETED", Object: done}
+	watchErr <- errors.New("watch closed")
+	if err := <-result; err == nil {
+		t.Errorf("handleEvents() returned no error when the watch failed")
+	}
+
+	if got := api.status("a"); got.Phase != PhaseCompleted || got.Progress != 100 || got.OutputPath != "out/a.m3u8" {
+		t.Errorf("status after refreshing = %+v, want the completed job", got)
+	}
+	if got := api.status("b"); got.JobID != "job-default/b" {
+		t.Errorf("status of the added resource = %+v, want it submitted", got)
+	}
+	if _, ok := c.jobs["default/done"]; ok {
+		t.Errorf("the deleted resource is still tracked")
+	}
+}
+
+func TestControllerSubmitFailure(t *testing.T) {
+	api := &fakeAPIServer{items: []FlixsrotaJob{testResource("a")}}
+	processor := &fakeProcessor{submitErr: errors.New("invalid input")}
+	c := NewController(newTestClient(t, api), processor, "default", time.Minute, zap.NewNop())
+
+	if _, err := c.resync(context.Background()); err != nil {
+		t.Fatalf("resync() error = %v", err)
+	}
+	if got := api.status("a"); got.Phase != PhaseFailed || got.Error != "invalid input" {
+		t.Errorf("status = %+v, want the submission failure", got)
+	}
+}
+
+func TestGenerateCRD(t *testing.T) {
+	data, err := GenerateCRD()
+	if err != nil {
+		t.Fatalf("GenerateCRD() error = %v", err)
+	}
+
+	var crd struct {
+		Kind     string `yaml:"kind"`
+		Metadata struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Group string `yaml:"group"`
+			Names struct {
+				Kind string `yaml:"kind"`
+			} `yaml:"names"`
+			Versions []struct {
+				Name         string                 `yaml:"name"`
+				Subresources map[string]interface{} `yaml:"subresources"`
+			} `yaml:"versions"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		t.Fatalf("GenerateCRD() is not YAML: %v", err)
+	}
+
+	if crd.Kind != "CustomResourceDefinition" || crd.Metadata.Name != "flixsrotajobs.flixsrota.io" || crd.Spec.Names.Kind != Kind {
+		t.Errorf("CRD = %+v", crd)
+	}
+	if len(crd.Spec.Versions) != 1 || crd.Spec.Versions[0].Name != Version {
+		t.Fatalf("versions = %+v, want %s", crd.Spec.Versions, Version)
+	}
+	if _, ok := crd.Spec.Versions[0].Subresources["status"]; !ok {
+		t.Errorf("the status subresource is not enabled")
+	}
+}
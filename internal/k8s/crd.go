@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FlixsrotaJob resource names
+const (
+	Group    = "flixsrota.io"
+	Version  = "v1alpha1"
+	Kind     = "FlixsrotaJob"
+	ListKind = "FlixsrotaJobList"
+	Plural   = "flixsrotajobs"
+	Singular = "flixsrotajob"
+)
+
+// Phases reported in the status of a FlixsrotaJob
+const (
+	PhasePending    = "Pending"
+	PhaseQueued     = "Queued"
+	PhaseProcessing = "Processing"
+	PhaseCompleted  = "Completed"
+	PhaseFailed     = "Failed"
+	PhaseCancelled  = "Cancelled"
+)
+
+// schema is a node of an OpenAPI v3 schema
+type schema map[string]interface{}
+
+func stringProp(description string) schema {
+	return schema{"type": "string", "description": description}
+}
+
+func stringMapProp(description string) schema {
+	return schema{
+		"type":                 "object",
+		"description":          description,
+		"additionalProperties": schema{"type": "string"},
+	}
+}
+
+// GenerateCRD returns the CustomResourceDefinition of the FlixsrotaJob
+// resource as YAML. The spec mirrors the fields of a queued job and the
+// status subresource reports its progress.
+func GenerateCRD() ([]byte, error) {
+	spec := schema{
+		"type":     "object",
+		"required": []string{"inputPath", "outputPath"},
+		"properties": schema{
+			"inputPath":      stringProp("Input file path or HTTP(S) URL"),
+			"outputPath":     stringProp("Output path of the job"),
+			"ffmpegArgs":     stringProp("Additional FFmpeg arguments"),
+			"priority":       schema{"type": "integer", "description": "Job priority, higher runs first"},
+			"metadata":       stringMapProp("Job metadata, e.g. tenant_id or video_codec"),
+			"storageAdapter": stringProp("Storage adapter to use instead of the server default"),
+			"queueAdapter":   stringProp("Queue adapter to use instead of the server default"),
+			"presetName":     stringProp("Transcode preset filling in codec, qualities and audio settings"),
+			"requiredWorkerLabels": stringMapProp(
+				"Labels a worker must have to run the job"),
+			"qualityOutputFormats": stringMapProp(
+				"Output container per quality, e.g. 720p: mkv"),
+		},
+	}
+
+	status := schema{
+		"type": "object",
+		"properties": schema{
+			"jobId": stringProp("ID of the job on the Flixsrota server"),
+			"phase": schema{
+				"type": "string",
+				"enum": []string{PhasePending, PhaseQueued, PhaseProcessing, PhaseCompleted, PhaseFailed, PhaseCancelled},
+			},
+			"progress":     schema{"type": "number", "description": "Progress in percent"},
+			"outputPath":   stringProp("Output path of the job"),
+			"manifestPath": stringProp("Storage path of the output manifest"),
+			"error":        stringProp("Error of a failed job"),
+			"startedAt":    schema{"type": "string", "format": "date-time"},
+			"completedAt":  schema{"type": "string", "format": "date-time"},
+		},
+	}
+
+	crd := schema{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   schema{"name": Plural + "." + Group},
+		"spec": schema{
+			"group": Group,
+			"scope": "Namespaced",
+			"names": schema{
+				"kind":       Kind,
+				"listKind":   ListKind,
+				"plural":     Plural,
+				"singular":   Singular,
+				"shortNames": []string{"fj"},
+			},
+			"versions": []schema{{
+				"name":    Version,
+				"served":  true,
+				"storage": true,
+				"schema": schema{
+					"openAPIV3Schema": schema{
+						"type": "object",
+						"properties": schema{
+							"spec":   spec,
+							"status": status,
+						},
+					},
+				},
+				"subresources": schema{"status": schema{}},
+				"additionalPrinterColumns": []schema{
+					{"name": "Phase", "type": "string", "jsonPath": ".status.phase"},
+					{"name": "Progress", "type": "number", "jsonPath": ".status.progress"},
+					{"name": "Job ID", "type": "string", "jsonPath": ".status.jobId"},
+					{"name": "Age", "type": "date", "jsonPath": ".metadata.creationTimestamp"},
+				},
+			}},
+		},
+	}
+
+	out, err := yaml.Marshal(crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package k8s
+
+// FlixsrotaJob is a video processing job submitted as a Kubernetes resource
+type FlixsrotaJob struct {
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Metadata   ObjectMeta         `json:"metadata"`
+	Spec       FlixsrotaJobSpec   `json:"spec"`
+	Status     FlixsrotaJobStatus `json:"status,omitempty"`
+}
+
+// ObjectMeta is the subset of Kubernetes object metadata the controller uses
+type ObjectMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	UID             string `json:"uid,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// FlixsrotaJobSpec mirrors the fields of a ProcessVideo request
+type FlixsrotaJobSpec struct {
+	InputPath            string            `json:"inputPath"`
+	OutputPath           string            `json:"outputPath"`
+	FFmpegArgs           string            `json:"ffmpegArgs,omitempty"`
+	Priority             int32             `json:"priority,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	StorageAdapter       string            `json:"storageAdapter,omitempty"`
+	QueueAdapter         string            `json:"queueAdapter,omitempty"`
+	PresetName           string            `json:"presetName,omitempty"`
+	RequiredWorkerLabels map[string]string `json:"requiredWorkerLabels,omitempty"`
+	QualityOutputFormats map[string]string `json:"qualityOutputFormats,omitempty"`
+}
+
+// FlixsrotaJobStatus is written by the controller to the status subresource
+type FlixsrotaJobStatus struct {
+	JobID        string  `json:"jobId,omitempty"`
+	Phase        string  `json:"phase,omitempty"`
+	Progress     float64 `json:"progress,omitempty"`
+	OutputPath   string  `json:"outputPath,omitempty"`
+	ManifestPath string  `json:"manifestPath,omitempty"`
+	Error        string  `json:"error,omitempty"`
+	StartedAt    string  `json:"startedAt,omitempty"`
+	CompletedAt  string  `json:"completedAt,omitempty"`
+}
+
+// terminal reports whether the job has finished and needs no more updates
+func (s FlixsrotaJobStatus) terminal() bool {
+	return s.Phase == PhaseCompleted || s.Phase == PhaseFailed || s.Phase == PhaseCancelled
+}
+
+// flixsrotaJobList is the response of a list request
+type flixsrotaJobList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []FlixsrotaJob `json:"items"`
+}
+
+// watchEvent is one line of a watch response
+type watchEvent struct {
+	Type   string       `json:"type"`
+	Object FlixsrotaJob `json:"object"`
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// FlushTimeout bounds the flush of all metric exporters at shutdown
+const FlushTimeout = 10 * time.Second
+
+// slowFlushThreshold is the flush duration of one exporter above which a
+// warning is logged
+const slowFlushThreshold = 5 * time.Second
+
+// Flusher is a metric exporter that buffers points and writes the buffered
+// ones when flushed
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Duration of metric exporter flushes
+var metricsFlushDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "flixsrota",
+	Name:      "metrics_flush_duration_seconds",
+	Help:      "Duration of metric exporter flushes in seconds by exporter.",
+	Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10},
+}, []string{"exporter"})
+
+// FlushAll flushes the exporters, keyed by name, one after another until
+// ctx is done. Failures are logged, and each flush is measured.
+func FlushAll(ctx context.Context, exporters map[string]Flusher, logger *zap.Logger) {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		start := time.Now()
+		err := exporters[name].Flush(ctx)
+		elapsed := time.Since(start)
+		metricsFlushDurationSeconds.WithLabelValues(name).Observe(elapsed.Seconds())
+
+		if err != nil {
+			logger.Warn("Failed to flush metrics", zap.String("exporter", name), zap.Error(err))
+		}
+		if elapsed > slowFlushThreshold {
+			logger.Warn("Slow metrics flush",
+				zap.String("exporter", name),
+				zap.Duration("duration", elapsed))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingFlusher appends its name to flushed when flushed and returns err
+type recordingFlusher struct {
+	name    string
+	flushed *[]string
+	err     error
+}
+
+func (f recordingFlusher) Flush(ctx context.Context) error {
+	*f.flushed = append(*f.flushed, f.name)
+	return f.err
+}
+
+func TestFlushAll(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	var flushed []string
+	exporters := map[string]Flusher{
+		"pushgateway": recordingFlusher{name: "pushgateway", flushed: &flushed},
+		"influxdb":    recordingFlusher{name: "influxdb", flushed: &flushed, err: errors.New("influxdb unreachable")},
+	}
+
+	FlushAll(context.Background(), exporters, zap.New(core))
+
+	// Exporters are flushed in name order, and a failure does not stop the rest
+	if len(flushed) != 2 || flushed[0] != "influxdb" || flushed[1] != "pushgateway" {
+		t.Errorf("flushed = %v, want influxdb then pushgateway", flushed)
+	}
+	failures := logs.FilterMessage("Failed to flush metrics").All()
+	if len(failures) != 1 || failures[0].ContextMap()["exporter"] != "influxdb" {
+		t.Errorf("failure logs = %v, want the influxdb failure", failures)
+	}
+	if n := testutil.CollectAndCount(metricsFlushDurationSeconds); n < 2 {
+		t.Errorf("flush duration has %d series, want one per exporter", n)
+	}
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"go.uber.org/zap"
+)
+
+// influxWriteTimeout bounds a single write request
+const influxWriteTimeout = 30 * time.Second
+
+// tagEscaper escapes tag keys and values in the line protocol
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// InfluxDBWriter periodically writes system, job and worker metrics to an
+// InfluxDB 2.x bucket using the line protocol. Points are buffered and
+// written in batches every flush interval; points of failed writes are kept
+// for the next flush, up to the retry buffer.
+type InfluxDBWriter struct {
+	cfg       config.InfluxDBConfig
+	collector *SystemMetricsCollector
+	snapshot  func() ProcessorState
+	workers   func() []WorkerState
+	logger    *zap.Logger
+	client    *http.Client
+	writeURL  string
+	host      string
+
+	mu      sync.Mutex
+	pending []string
+}
+
+// NewInfluxDBWriter creates a writer for the processor state returned by
+// snapshot and workers
+func NewInfluxDBWriter(cfg config.InfluxDBConfig, collector *SystemMetricsCollector, snapshot func() ProcessorState, workers func() []WorkerState, logger *zap.Logger) *InfluxDBWriter {
+	params := url.Values{}
+	params.Set("org", cfg.Org)
+	params.Set("bucket", cfg.Bucket)
+	params.Set("precision", "ns")
+	host, _ := os.Hostname()
+
+	return &InfluxDBWriter{
+		cfg:       cfg,
+		collector: collector,
+		snapshot:  snapshot,
+		workers:   workers,
+		logger:    logger,
+		client:    &http.Client{Timeout: influxWriteTimeout},
+		writeURL:  strings.TrimRight(cfg.URL, "/") + "/api/v2/write?" + params.Encode(),
+		host:      host,
+	}
+}
+
+// Run collects metrics every collectInterval and writes them every flush
+// interval until ctx is done. The points buffered at that time are written
+// by a final Flush.
+func (w *InfluxDBWriter) Run(ctx context.Context, collectInterval time.Duration) {
+	collectTicker := time.NewTicker(collectInterval)
+	defer collectTicker.Stop()
+	flushTicker := time.NewTicker(time.Duration(w.cfg.FlushInterval) * time.Second)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-collectTicker.C:
+			w.Collect()
+		case <-flushTicker.C:
+			if err := w.Flush(ctx); err != nil {
+				w.logger.Warn("Failed to write metrics to InfluxDB", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Collect buffers one point per measurement for the current time
+func (w *InfluxDBWriter) Collect() {
+	now := time.Now()
+	var lines []string
+
+	if system, err := w.collector.CollectMetrics(); err != nil {
+		w.logger.Warn("Failed to collect system metrics for InfluxDB", zap.Error(err))
+	} else {
+		lines = append(lines, w.line("flixsrota_system", nil, map[string]string{
+			"cpu_usage_percent":      formatFloat(system.CPUUsagePercent),
+			"memory_usage_percent":   formatFloat(system.MemoryUsagePercent),
+			"disk_usage_percent":     formatFloat(system.DiskUsagePercent),
+			"available_memory_bytes": formatUint(system.AvailableMemoryBytes),
+			"available_disk_bytes":   formatUint(system.AvailableDiskBytes),
+			"goroutines":             formatInt(int64(system.Goroutines)),
+			"heap_alloc_bytes":       formatUint(system.HeapAllocBytes),
+			"heap_sys_bytes":         formatUint(system.HeapSysBytes),
+		}, now))
+	}
+
+	state := w.snapshot()
+	lines = append(lines, w.line("flixsrota_jobs", nil, map[string]string{
+		"queue_depth":                formatInt(int64(state.QueueDepth)),
+		"active_jobs":                formatInt(int64(len(state.ActiveJobs))),
+		"idle_workers":               formatInt(int64(state.IdleWorkerCount)),
+		"paused":                     strconv.FormatBool(state.IsPaused),
+		"total":                      formatInt(state.Stats.TotalJobs),
+		"processing":                 formatInt(state.Stats.ProcessingJobs),
+		"completed":                  formatInt(state.Stats.CompletedJobs),
+		"failed":                     formatInt(state.Stats.FailedJobs),
+		"cancelled":                  formatInt(state.Stats.CancelledJobs),
+		"average_processing_seconds": formatFloat(state.Stats.AverageProcessingTime.Seconds()),
+	}, now))
+
+	for _, worker := range w.workers() {
+		fields := map[string]string{"busy": strconv.FormatBool(worker.Busy)}
+		if worker.Job != nil {
+			fields["job_progress"] = formatFloat(worker.Job.Progress)
+		}
+		lines = append(lines, w.line("flixsrota_worker", map[string]string{
+			"worker": strconv.Itoa(worker.Index),
+		}, fields, now))
+	}
+
+	w.mu.Lock()
+	w.pending = append(w.pending, lines...)
+	w.mu.Unlock()
+}
+
+// Flush writes the buffered points in batches of the configured size. When a
+// write fails, its points and the unwritten ones stay buffered, dropping the
+// oldest beyond the retry buffer.
+func (w *InfluxDBWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	lines := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for len(lines) > 0 {
+		n := min(len(lines), w.cfg.BatchSize)
+		if err := w.write(ctx, lines[:n]); err != nil {
+			w.requeue(lines)
+			return fmt.Errorf("%d points not written: %w", len(lines), err)
+		}
+		lines = lines[n:]
+	}
+	return nil
+}
+
+// requeue puts unwritten points back in front of the ones collected meanwhile
+func (w *InfluxDBWriter) requeue(lines []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(append([]string(nil), lines...), w.pending...)
+	if dropped := len(w.pending) - w.cfg.RetryBuffer; dropped > 0 {
+		w.pending = w.pending[dropped:]
+		w.logger.Warn("Dropped metrics beyond the InfluxDB retry buffer", zap.Int("points", dropped))
+	}
+}
+
+// write posts one batch of points
+func (w *InfluxDBWriter) write(ctx context.Context, lines []string) error {
+	body := strings.Join(lines, "\n")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.writeURL, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.cfg.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influxdb write failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// line formats one point in the line protocol, tagged with the host
+func (w *InfluxDBWriter) line(measurement string, tags, fields map[string]string, at time.Time) string {
+	var b strings.Builder
+	b.WriteString(measurement)
+	if w.host != "" {
+		b.WriteString(",host=" + tagEscaper.Replace(w.host))
+	}
+	for key, value := range tags {
+		b.WriteString("," + tagEscaper.Replace(key) + "=" + tagEscaper.Replace(value))
+	}
+
+	sep := " "
+	for key, value := range fields {
+		b.WriteString(sep + tagEscaper.Replace(key) + "=" + value)
+		sep = ","
+	}
+	b.WriteString(" " + strconv.FormatInt(at.UnixNano(), 10))
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10) + "i"
+}
+
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10) + "i"
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"go.uber.org/zap"
+)
+
+// influxServer records the write requests it receives and answers them with
+// status
+type influxServer struct {
+	mu       sync.Mutex
+	status   int
+	batches  [][]string
+	requests []*http.Request
+}
+
+func (s *influxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	if s.status != http.StatusNoContent {
+		w.WriteHeader(s.status)
+		io.WriteString(w, "bucket not found")
+		return
+	}
+	s.batches = append(s.batches, strings.Split(string(body), "\n"))
+	w.WriteHeader(s.status)
+}
+
+// newTestInfluxDBWriter returns a writer posting to a test server, with two
+// workers of which the first is busy
+func newTestInfluxDBWriter(t *testing.T, cfg config.InfluxDBConfig) (*InfluxDBWriter, *influxServer) {
+	t.Helper()
+	server := &influxServer{status: http.StatusNoContent}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	cfg.URL = ts.URL + "/"
+	cfg.Org = "media"
+	cfg.Bucket = "flixsrota"
+	snapshot := func() ProcessorState { return ProcessorState{QueueDepth: 7, IdleWorkerCount: 1} }
+	workers := func() []WorkerState {
+		return []WorkerState{{Index: 0, Busy: true, Job: &queue.Job{ID: "job-1", Progress: 42.5}}, {Index: 1}}
+	}
+	w := NewInfluxDBWriter(cfg, NewSystemMetricsCollector(zap.NewNop()), snapshot, workers, zap.NewNop())
+	w.host = "encoder 1"
+	return w, server
+}
+
+// measurementLines returns the buffered lines of a measurement
+func measurementLines(lines []string, measurement string) []string {
+	var matched []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, measurement+",") {
+			matched = append(matched, line)
+		}
+	}
+	return matched
+}
+
+func TestInfluxDBWriterFlush(t *testing.T) {
+	w, server := newTestInfluxDBWriter(t, config.InfluxDBConfig{Token: "secret-token", BatchSize: 2, RetryBuffer: 100})
+	w.Collect()
+	pending := len(w.pending)
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("%d points still buffered after a successful flush", len(w.pending))
+	}
+
+	var lines []string
+	for _, batch := range server.batches {
+		if len(batch) > 2 {
+			t.Errorf("batch of %d points, want at most 2", len(batch))
+		}
+		lines = append(lines, batch...)
+	}
+	if len(lines) != pending {
+		t.Errorf("%d points written, want the %d collected", len(lines), pending)
+	}
+
+	jobs := measurementLines(lines, "flixsrota_jobs")
+	if len(jobs) != 1 || !strings.Contains(jobs[0], `flixsrota_jobs,host=encoder\ 1 `) || !strings.Contains(jobs[0], "queue_depth=7i") {
+		t.Errorf("jobs points = %v, want one with the host tag and queue depth", jobs)
+	}
+	workers := measurementLines(lines, "flixsrota_worker")
+	if len(workers) != 2 || !strings.Contains(strings.Join(workers, "\n"), "job_progress=42.5") {
+		t.Errorf("worker points = %v, want one per worker with the busy one's progress", workers)
+	}
+
+	req := server.requests[0]
+	if req.URL.Path != "/api/v2/write" || req.URL.Query().Get("org") != "media" || req.URL.Query().Get("bucket") != "flixsrota" || req.URL.Query().Get("precision") != "ns" {
+		t.Errorf("write URL = %s", req.URL)
+	}
+	if got := req.Header.Get("Authorization"); got != "Token secret-token" {
+		t.Errorf("Authorization = %q, want the token", got)
+	}
+}
+
+func TestInfluxDBWriterRetryBuffer(t *testing.T) {
+	w, server := newTestInfluxDBWriter(t, config.InfluxDBConfig{BatchSize: 100, RetryBuffer: 100})
+	server.status = http.StatusNotFound
+
+	w.Collect()
+	collected := len(w.pending)
+	err := w.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status 404: bucket not found") {
+		t.Fatalf("Flush() error = %v, want the rejected write", err)
+	}
+	if len(w.pending) != collected {
+		t.Errorf("%d points buffered after a failed write, want the %d collected", len(w.pending), collected)
+	}
+	if server.requests[0].Header.Get("Authorization") != "" {
+		t.Errorf("Authorization sent without a token")
+	}
+
+	// Beyond the retry buffer the oldest points are dropped
+	w.cfg.RetryBuffer = collected
+	w.Collect()
+	newest := append([]string(nil), w.pending[collected:]...)
+	w.Flush(context.Background())
+	if len(w.pending) != collected || w.pending[0] != newest[0] {
+		t.Errorf("buffer = %d points, want only the %d newest", len(w.pending), collected)
+	}
+
+	// The buffered points are written once InfluxDB accepts them again
+	server.status = http.StatusNoContent
+	if err := w.Flush(context.Background()); err != nil || len(w.pending) != 0 {
+		t.Errorf("Flush() = %v with %d points left, want the buffer written", err, len(w.pending))
+	}
+}
+
+func TestInfluxDBWriterLine(t *testing.T) {
+	w := &InfluxDBWriter{host: "a,b=c"}
+	at := time.Unix(0, 1700000000000000000)
+
+	got := w.line("flixsrota_worker", map[string]string{"worker": "3"}, map[string]string{"busy": "true"}, at)
+	want := `flixsrota_worker,host=a\,b\=c,worker=3 busy=true 1700000000000000000`
+	if got != want {
+		t.Errorf("line() = %s, want %s", got, want)
+	}
+}
+
+func TestInfluxDBWriterRunLeavesFinalFlush(t *testing.T) {
+	w, server := newTestInfluxDBWriter(t, config.InfluxDBConfig{FlushInterval: 3600, BatchSize: 100, RetryBuffer: 100})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		w.mu.Lock()
+		collected := len(w.pending)
+		w.mu.Unlock()
+		if collected > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// The points are written by FlushAll at shutdown, not by Run
+	if len(server.requests) != 0 {
+		t.Errorf("Run() wrote %d requests, want none before the final flush", len(server.requests))
+	}
+	FlushAll(context.Background(), map[string]Flusher{"influxdb": w}, zap.NewNop())
+	if len(server.batches) == 0 || len(w.pending) != 0 {
+		t.Errorf("FlushAll() wrote %d batches with %d points left, want the buffer written", len(server.batches), len(w.pending))
+	}
+}
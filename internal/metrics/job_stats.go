@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bucketCount is the number of one-minute buckets kept for windowed stats (1 hour)
+const bucketCount = 60
+
+// Stats windows reported by JobStatsAggregator
+var statsWindows = []struct {
+	name    string
+	minutes int64
+}{
+	{"1m", 1},
+	{"5m", 5},
+	{"15m", 15},
+	{"1h", 60},
+}
+
+// statsBucket holds the job counters for a single minute
+type statsBucket struct {
+	minute        atomic.Int64
+	enqueued      atomic.Int64
+	started       atomic.Int64
+	completed     atomic.Int64
+	failed        atomic.Int64
+	durationNanos atomic.Int64
+}
+
+// reset clears the bucket counters
+func (b *statsBucket) reset() {
+	b.enqueued.Store(0)
+	b.started.Store(0)
+	b.completed.Store(0)
+	b.failed.Store(0)
+	b.durationNanos.Store(0)
+}
+
+// WindowStats contains job counts for a single time window
+type WindowStats struct {
+	Enqueued              int64         `json:"enqueued"`
+	Started               int64         `json:"started"`
+	Completed             int64         `json:"completed"`
+	Failed                int64         `json:"failed"`
+	AverageProcessingTime time.Duration `json:"average_processing_time"`
+}
+
+// JobStats is a point-in-time view of the aggregated job statistics
+type JobStats struct {
+	TotalJobs             int64                  `json:"total_jobs"`
+	ProcessingJobs        int64                  `json:"processing_jobs"`
+	CompletedJobs         int64                  `json:"completed_jobs"`
+	FailedJobs            int64                  `json:"failed_jobs"`
+	CancelledJobs         int64                  `json:"cancelled_jobs"`
+	AverageProcessingTime time.Duration          `json:"average_processing_time"`
+	FFmpegUsage           ResourceUsage          `json:"ffmpeg_usage"`
+	Windows               map[string]WindowStats `json:"windows"`
+}
+
+// ResourceUsage is the CPU time and peak memory used by an FFmpeg process.
+// In JobStats it holds the running average across jobs.
+type ResourceUsage struct {
+	UserCPUTime   time.Duration `json:"user_cpu_time"`
+	SystemCPUTime time.Duration `json:"system_cpu_time"`
+	MaxRSSKB      int64         `json:"max_rss_kb"`
+}
+
+// JobStatsAggregator maintains lifetime and sliding-window job counters
+type JobStatsAggregator struct {
+	buckets [bucketCount]statsBucket
+	// recycleMu serializes recycling a bucket for a new minute
+	recycleMu sync.Mutex
+
+	totalEnqueued      atomic.Int64
+	totalCompleted     atomic.Int64
+	totalFailed        atomic.Int64
+	totalCancelled     atomic.Int64
+	processing         atomic.Int64
+	totalDurationNanos atomic.Int64
+
+	usageSamples        atomic.Int64
+	totalUserCPUNanos   atomic.Int64
+	totalSystemCPUNanos atomic.Int64
+	totalMaxRSSKB       atomic.Int64
+
+	now func() time.Time
+}
+
+// NewJobStatsAggregator creates a new job statistics aggregator
+func NewJobStatsAggregator() *JobStatsAggregator {
+	return &JobStatsAggregator{
+		now: time.Now,
+	}
+}
+
+// OnJobEnqueued records a job being added to the queue
+func (a *JobStatsAggregator) OnJobEnqueued() {
+	a.totalEnqueued.Add(1)
+	a.currentBucket().enqueued.Add(1)
+	jobEventsTotal.WithLabelValues("enqueued").Inc()
+}
+
+// OnJobStarted records a worker starting a job. It must be paired with a
+// deferred OnJobFinished, which ends the job however it returns.
+func (a *JobStatsAggregator) OnJobStarted() {
+	a.processing.Add(1)
+	a.currentBucket().started.Add(1)
+	jobEventsTotal.WithLabelValues("started").Inc()
+	jobsProcessing.Inc()
+}
+
+// OnJobFinished records a worker no longer processing a job
+func (a *JobStatsAggregator) OnJobFinished() {
+	a.processing.Add(-1)
+	jobsProcessing.Dec()
+}
+
+// OnJobCompleted records a job finishing successfully after the given duration
+func (a *JobStatsAggregator) OnJobCompleted(duration time.Duration) {
+	a.totalCompleted.Add(1)
+	a.totalDurationNanos.Add(int64(duration))
+
+	b := a.currentBucket()
+	b.completed.Add(1)
+	b.durationNanos.Add(int64(duration))
+
+	jobEventsTotal.WithLabelValues("completed").Inc()
+	jobDurationSeconds.WithLabelValues("completed").Observe(duration.Seconds())
+}
+
+// OnJobFailed records a job that failed during processing
+func (a *JobStatsAggregator) OnJobFailed() {
+	a.totalFailed.Add(1)
+	a.currentBucket().failed.Add(1)
+	jobEventsTotal.WithLabelValues("failed").Inc()
+}
+
+// OnJobCancelled records a job being cancelled
+func (a *JobStatsAggregator) OnJobCancelled() {
+	a.totalCancelled.Add(1)
+	jobEventsTotal.WithLabelValues("cancelled").Inc()
+}
+
+// OnFFmpegResourceUsage records the resources used by the FFmpeg process of a
+// job producing the given output qualities with codec
+func (a *JobStatsAggregator) OnFFmpegResourceUsage(quality, codec string, usage ResourceUsage) {
+	a.usageSamples.Add(1)
+	a.totalUserCPUNanos.Add(int64(usage.UserCPUTime))
+	a.totalSystemCPUNanos.Add(int64(usage.SystemCPUTime))
+	a.totalMaxRSSKB.Add(usage.MaxRSSKB)
+
+	ffmpegUserCPUSeconds.WithLabelValues(quality, codec).Observe(usage.UserCPUTime.Seconds())
+	ffmpegSystemCPUSeconds.WithLabelValues(quality, codec).Observe(usage.SystemCPUTime.Seconds())
+	if usage.MaxRSSKB > 0 {
+		ffmpegMaxRSSBytes.WithLabelValues(quality, codec).Observe(float64(usage.MaxRSSKB * 1024))
+	}
+}
+
+// Snapshot returns the current lifetime totals and windowed counters
+func (a *JobStatsAggregator) Snapshot() JobStats {
+	stats := JobStats{
+		TotalJobs:      a.totalEnqueued.Load(),
+		ProcessingJobs: a.processing.Load(),
+		CompletedJobs:  a.totalCompleted.Load(),
+		FailedJobs:     a.totalFailed.Load(),
+		CancelledJobs:  a.totalCancelled.Load(),
+		Windows:        make(map[string]WindowStats, len(statsWindows)),
+	}
+
+	if stats.CompletedJobs > 0 {
+		stats.AverageProcessingTime = time.Duration(a.totalDurationNanos.Load() / stats.CompletedJobs)
+	}
+
+	if samples := a.usageSamples.Load(); samples > 0 {
+		stats.FFmpegUsage = ResourceUsage{
+			UserCPUTime:   time.Duration(a.totalUserCPUNanos.Load() / samples),
+			SystemCPUTime: time.Duration(a.totalSystemCPUNanos.Load() / samples),
+			MaxRSSKB:      a.totalMaxRSSKB.Load() / samples,
+		}
+	}
+
+	for _, w := range statsWindows {
+		stats.Windows[w.name] = a.window(w.minutes)
+	}
+
+	return stats
+}
+
+// window sums the buckets covering the last n minutes
+func (a *JobStatsAggregator) window(minutes int64) WindowStats {
+	var ws WindowStats
+	var durationNanos int64
+
+	current := a.now().Unix() / 60
+	for i := range a.buckets {
+		b := &a.buckets[i]
+		minute := b.minute.Load()
+		if minute <= current-minutes || minute > current {
+			continue
+		}
+		ws.Enqueued += b.enqueued.Load()
+		ws.Started += b.started.Load()
+		ws.Completed += b.completed.Load()
+		ws.Failed += b.failed.Load()
+		durationNanos += b.durationNanos.Load()
+	}
+
+	if ws.Completed > 0 {
+		ws.AverageProcessingTime = time.Duration(durationNanos / ws.Completed)
+	}
+
+	return ws
+}
+
+// currentBucket returns the bucket for the current minute, recycling stale
+// buckets. A bucket is reset before its new minute is published, so counts
+// added once the minute is visible are never cleared.
+func (a *JobStatsAggregator) currentBucket() *statsBucket {
+	minute := a.now().Unix() / 60
+	b := &a.buckets[minute%bucketCount]
+
+	if b.minute.Load() != minute {
+		a.recycleMu.Lock()
+		if b.minute.Load() != minute {
+			b.reset()
+			b.minute.Store(minute)
+		}
+		a.recycleMu.Unlock()
+	}
+
+	return b
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJobStatsAggregatorRecyclesBuckets(t *testing.T) {
+	start := time.Unix(0, 0)
+	now := start
+	a := NewJobStatsAggregator()
+	a.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		a.OnJobEnqueued()
+	}
+	if got := a.Snapshot().Windows["1m"].Enqueued; got != 3 {
+		t.Fatalf("1m enqueued = %d, want 3", got)
+	}
+
+	// An hour later the same bucket is recycled while jobs are being counted
+	now = start.Add(time.Hour)
+	const jobs = 1000
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.OnJobEnqueued()
+		}()
+	}
+	wg.Wait()
+
+	stats := a.Snapshot()
+	if got := stats.Windows["1m"].Enqueued; got != jobs {
+		t.Errorf("1m enqueued = %d, want %d", got, jobs)
+	}
+	if stats.TotalJobs != jobs+3 {
+		t.Errorf("total jobs = %d, want %d", stats.TotalJobs, jobs+3)
+	}
+}
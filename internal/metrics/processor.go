@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProcessorState is a consistent point-in-time view of the job processor
+type ProcessorState struct {
+	ActiveJobs      []*queue.Job `json:"active_jobs"`
+	IdleWorkerCount int          `json:"idle_worker_count"`
+	QueueDepth      int          `json:"queue_depth"`
+	IsPaused        bool         `json:"is_paused"`
+	Stats           JobStats     `json:"stats"`
+}
+
+// WorkerState is a point-in-time view of one worker
+type WorkerState struct {
+	Index  int               `json:"index"`
+	Busy   bool              `json:"busy"`
+	Job    *queue.Job        `json:"job,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Processor state metric descriptions
+var (
+	activeJobProgressDesc = prometheus.NewDesc(
+		"flixsrota_active_job_progress",
+		"Progress percentage of each job currently being processed.",
+		[]string{"job_id"}, nil)
+
+	idleWorkersDesc = prometheus.NewDesc(
+		"flixsrota_idle_workers",
+		"Number of workers waiting for a job.",
+		nil, nil)
+
+	processorPausedDesc = prometheus.NewDesc(
+		"flixsrota_processor_paused",
+		"Whether job dispatch is paused (1) or running (0).",
+		nil, nil)
+)
+
+// processorCollector exports processor state snapshots on each scrape
+type processorCollector struct {
+	snapshot func() ProcessorState
+}
+
+// RegisterProcessor registers a Prometheus collector reporting the state
+// returned by snapshot, including active job IDs as labels
+func RegisterProcessor(snapshot func() ProcessorState) error {
+	return prometheus.Register(&processorCollector{snapshot: snapshot})
+}
+
+// ProcessorStateHandler returns an HTTP handler serving processor state as JSON
+func ProcessorStateHandler(snapshot func() ProcessorState) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snapshot()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Describe sends the collector's metric descriptions
+func (c *processorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeJobProgressDesc
+	ch <- idleWorkersDesc
+	ch <- processorPausedDesc
+}
+
+// Collect sends the metrics of a fresh processor snapshot
+func (c *processorCollector) Collect(ch chan<- prometheus.Metric) {
+	state := c.snapshot()
+
+	for _, job := range state.ActiveJobs {
+		ch <- prometheus.MustNewConstMetric(activeJobProgressDesc, prometheus.GaugeValue, job.Progress, job.ID)
+	}
+
+	ch <- prometheus.MustNewConstMetric(idleWorkersDesc, prometheus.GaugeValue, float64(state.IdleWorkerCount))
+
+	paused := 0.0
+	if state.IsPaused {
+		paused = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(processorPausedDesc, prometheus.GaugeValue, paused)
+}
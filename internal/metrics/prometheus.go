@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus collectors exposed on the metrics endpoint
+var (
+	jobEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flixsrota",
+		Name:      "job_events_total",
+		Help:      "Total number of job lifecycle events by type.",
+	}, []string{"event"})
+
+	jobsProcessing = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "jobs_processing",
+		Help:      "Number of jobs currently being processed.",
+	})
+
+	jobDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flixsrota",
+		Name:      "job_duration_seconds",
+		Help:      "Job processing duration in seconds by outcome.",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"outcome"})
+
+	ffmpegUserCPUSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flixsrota",
+		Name:      "ffmpeg_user_cpu_seconds",
+		Help:      "User CPU time used by FFmpeg per job by output qualities and codec.",
+		Buckets:   prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"quality", "codec"})
+
+	ffmpegSystemCPUSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flixsrota",
+		Name:      "ffmpeg_system_cpu_seconds",
+		Help:      "System CPU time used by FFmpeg per job by output qualities and codec.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"quality", "codec"})
+
+	ffmpegMaxRSSBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flixsrota",
+		Name:      "ffmpeg_max_rss_bytes",
+		Help:      "Peak resident set size of FFmpeg per job by output qualities and codec.",
+		Buckets:   prometheus.ExponentialBuckets(64<<20, 2, 8),
+	}, []string{"quality", "codec"})
+)
+
+// Storage gauges updated by the storage metrics collection loop
+var (
+	storageTotalBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "storage_total_bytes",
+		Help:      "Capacity of the storage backend in bytes, 0 if unknown.",
+	})
+
+	storageUsedBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "storage_used_bytes",
+		Help:      "Bytes stored in the storage backend.",
+	})
+
+	storageFileCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "storage_file_count",
+		Help:      "Number of files stored in the storage backend.",
+	})
+)
+
+// SetStorageMetrics records the latest storage backend measurement
+func SetStorageMetrics(totalBytes, usedBytes, fileCount int64) {
+	storageTotalBytes.Set(float64(totalBytes))
+	storageUsedBytes.Set(float64(usedBytes))
+	storageFileCount.Set(float64(fileCount))
+}
+
+// Bytes freed by purging old temporary files
+var tempBytesFreedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "flixsrota",
+	Name:      "storage_temp_bytes_freed_total",
+	Help:      "Total bytes freed by purging old temporary files.",
+})
+
+// AddTempBytesFreed records bytes freed by deleting a temporary file
+func AddTempBytesFreed(bytes int64) {
+	tempBytesFreedTotal.Add(float64(bytes))
+}
+
+// Storage cache lookups
+var (
+	storageCacheHitsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "flixsrota",
+		Name:      "storage_cache_hits_total",
+		Help:      "Downloads served from the storage cache.",
+	})
+
+	storageCacheMissesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "flixsrota",
+		Name:      "storage_cache_misses_total",
+		Help:      "Downloads fetched from the storage backend because they were not cached.",
+	})
+)
+
+// RecordStorageCacheLookup counts a storage cache hit or miss
+func RecordStorageCacheLookup(hit bool) {
+	if hit {
+		storageCacheHitsTotal.Inc()
+	} else {
+		storageCacheMissesTotal.Inc()
+	}
+}
+
+// FFmpeg circuit breaker state: 0 closed, 1 half-open, 2 open
+var ffmpegCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "flixsrota",
+	Name:      "ffmpeg_circuit_breaker_state",
+	Help:      "State of the FFmpeg circuit breaker (0 closed, 1 half-open, 2 open).",
+})
+
+// SetFFmpegCircuitBreakerState records the FFmpeg circuit breaker state
+func SetFFmpegCircuitBreakerState(state int) {
+	ffmpegCircuitBreakerState.Set(float64(state))
+}
+
+// gRPC health check status per service: 1 serving, 0 not serving
+var healthStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "flixsrota",
+	Name:      "health_status",
+	Help:      "gRPC health check status of each service (1 serving, 0 not serving).",
+}, []string{"service"})
+
+// SetHealthStatus records whether a gRPC health check service is serving
+func SetHealthStatus(service string, serving bool) {
+	value := 0.0
+	if serving {
+		value = 1
+	}
+	healthStatus.WithLabelValues(service).Set(value)
+}
+
+// Queue and storage connection tests per component: result and latency
+var (
+	connectionTestUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "connection_test_up",
+		Help:      "Result of the last connection test of each component (1 passed, 0 failed).",
+	}, []string{"component"})
+
+	connectionTestLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flixsrota",
+		Name:      "connection_test_latency_seconds",
+		Help:      "Duration of the last connection test of each component.",
+	}, []string{"component"})
+)
+
+// SetConnectionTest records the result and latency of a connection test
+func SetConnectionTest(component string, passed bool, latency time.Duration) {
+	value := 0.0
+	if passed {
+		value = 1
+	}
+	connectionTestUp.WithLabelValues(component).Set(value)
+	connectionTestLatency.WithLabelValues(component).Set(latency.Seconds())
+}
+
+// Server degraded state: 1 when a dependency failed several checks in a row
+var serverDegraded = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "flixsrota",
+	Name:      "degraded",
+	Help:      "Whether the queue or storage failed several consecutive connection tests (1 degraded, 0 healthy).",
+})
+
+// SetDegraded records whether the server is degraded
+func SetDegraded(degraded bool) {
+	value := 0.0
+	if degraded {
+		value = 1
+	}
+	serverDegraded.Set(value)
+}
+
+// Processing jobs requeued by the stale job reaper
+var reaperJobsReapedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "flixsrota",
+	Name:      "reaper_jobs_reaped_total",
+	Help:      "Total number of jobs stuck in the processing state that were put back in the queue.",
+})
+
+// IncJobsReaped counts a job requeued by the stale job reaper
+func IncJobsReaped() {
+	reaperJobsReapedTotal.Inc()
+}
+
+// Handler returns the HTTP handler serving Prometheus metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
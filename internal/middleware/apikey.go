@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyHeader is the gRPC metadata key carrying an API key. A bearer token
+// in the authorization header is accepted as well.
+const APIKeyHeader = "x-api-key"
+
+// APIKeyInterceptor rejects calls that do not carry the given API key
+func APIKeyInterceptor(key string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkAPIKey(ctx, key); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// APIKeyStreamInterceptor is the streaming counterpart of APIKeyInterceptor
+func APIKeyStreamInterceptor(key string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkAPIKey(ss.Context(), key); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkAPIKey compares the API key in the incoming metadata in constant time
+func checkAPIKey(ctx context.Context, key string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	provided := ""
+	if values := md.Get(APIKeyHeader); len(values) > 0 {
+		provided = values[0]
+	} else if values := md.Get("authorization"); len(values) > 0 {
+		provided, _ = strings.CutPrefix(values[0], "Bearer ")
+	}
+
+	if provided == "" {
+		return status.Error(codes.Unauthenticated, "missing API key")
+	}
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	return nil
+}
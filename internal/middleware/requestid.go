@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the gRPC metadata key carrying the request ID
+const RequestIDHeader = "x-request-id"
+
+// requestIDKey is the context key for the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in the context, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestIDField returns a log field for the request ID
+func RequestIDField(requestID string) zap.Field {
+	return zap.String("request_id", requestID)
+}
+
+// RequestIDInterceptor extracts the X-Request-ID from incoming metadata, or
+// generates one, stores it in the handler context and echoes it in the
+// response header
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = requestIDContext(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// RequestIDStreamInterceptor is the streaming counterpart of RequestIDInterceptor
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &requestIDStream{
+			ServerStream: ss,
+			ctx:          requestIDContext(ss.Context()),
+		})
+	}
+}
+
+// requestIDStream overrides the context of a server stream
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the request ID
+func (s *requestIDStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestIDContext resolves the request ID for an incoming call
+func requestIDContext(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	return WithRequestID(ctx, requestID)
+}
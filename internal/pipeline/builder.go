@@ -0,0 +1,217 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// Pipeline step kinds, recorded in each job's pipeline_step metadata
+const (
+	StepTranscode     = "transcode"
+	StepWatermark     = "watermark"
+	StepThumbnail     = "thumbnail"
+	StepUpload        = "upload"
+	StepNotifyWebhook = "notify_webhook"
+)
+
+// Metadata keys describing pipeline jobs
+const (
+	// MetadataStep is the kind of step the job performs
+	MetadataStep = "pipeline_step"
+
+	// MetadataStepIndex is the position of the job in its pipeline, from 0
+	MetadataStepIndex = "pipeline_step_index"
+
+	// MetadataWatermark is the JSON-encoded WatermarkConfig of a watermark step
+	MetadataWatermark = "watermark"
+
+	// MetadataThumbnail is the JSON-encoded ThumbnailConfig of a thumbnail step
+	MetadataThumbnail = "thumbnail"
+
+	// MetadataWebhookURL is the URL a notify_webhook step posts to
+	MetadataWebhookURL = "webhook_url"
+)
+
+// Builder assembles a chain of jobs that run one after another, each
+// depending on the previous one. Methods record the first error, which Build
+// returns.
+type Builder struct {
+	ffmpeg config.FFmpegConfig
+	input  string
+	steps  []step
+	err    error
+}
+
+// step is one job of the pipeline before IDs and paths are assigned
+type step struct {
+	kind     string
+	metadata map[string]string
+
+	// preset is the transcode preset, destination the upload target
+	preset      string
+	destination string
+}
+
+// NewBuilder returns an empty pipeline builder using the built-in transcode
+// presets
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Presets resolves Transcode presets with the configured presets as well as
+// the built-in ones
+func (b *Builder) Presets(cfg config.FFmpegConfig) *Builder {
+	b.ffmpeg = cfg
+	return b
+}
+
+// Input sets the file the pipeline starts from
+func (b *Builder) Input(path string) *Builder {
+	if path == "" {
+		b.fail(fmt.Errorf("input path is required"))
+	}
+	b.input = path
+	return b
+}
+
+// Transcode adds a job encoding the current output with a transcode preset
+func (b *Builder) Transcode(preset string) *Builder {
+	if _, ok := b.ffmpeg.Preset(preset); !ok {
+		b.fail(fmt.Errorf("unknown preset: %s", preset))
+	}
+	return b.add(step{kind: StepTranscode, preset: preset})
+}
+
+// Watermark adds a job overlaying an image on the current output
+func (b *Builder) Watermark(cfg WatermarkConfig) *Builder {
+	if err := cfg.Validate(); err != nil {
+		b.fail(err)
+	}
+	data, err := encode(cfg)
+	if err != nil {
+		b.fail(err)
+	}
+	return b.add(step{kind: StepWatermark, metadata: map[string]string{MetadataWatermark: data}})
+}
+
+// Thumbnail adds a job extracting thumbnails from the current output. Later
+// steps keep working on the video, not the thumbnails.
+func (b *Builder) Thumbnail(cfg ThumbnailConfig) *Builder {
+	if err := cfg.Validate(); err != nil {
+		b.fail(err)
+	}
+	data, err := encode(cfg)
+	if err != nil {
+		b.fail(err)
+	}
+	return b.add(step{kind: StepThumbnail, metadata: map[string]string{MetadataThumbnail: data}})
+}
+
+// Upload adds a job copying the current output to a storage destination
+func (b *Builder) Upload(destination string) *Builder {
+	if destination == "" {
+		b.fail(fmt.Errorf("upload destination is required"))
+	}
+	return b.add(step{kind: StepUpload, destination: destination})
+}
+
+// NotifyWebhook adds a job posting the pipeline result to an HTTP(S) URL
+func (b *Builder) NotifyWebhook(webhookURL string) *Builder {
+	if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		b.fail(fmt.Errorf("invalid webhook URL: %q", webhookURL))
+	}
+	return b.add(step{kind: StepNotifyWebhook, metadata: map[string]string{MetadataWebhookURL: webhookURL}})
+}
+
+// Build returns the pipeline jobs in execution order. All jobs share a new
+// group ID, and each job depends on the one before it.
+func (b *Builder) Build() ([]*queue.Job, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.input == "" {
+		return nil, fmt.Errorf("input path is required")
+	}
+	if len(b.steps) == 0 {
+		return nil, fmt.Errorf("pipeline has no steps")
+	}
+
+	groupID := uuid.New().String()
+	current := b.input
+	stem := strings.TrimSuffix(filepath.Base(b.input), filepath.Ext(b.input))
+	dir := filepath.Dir(b.input)
+
+	jobs := make([]*queue.Job, 0, len(b.steps))
+	for i, s := range b.steps {
+		job := &queue.Job{
+			ID:        uuid.New().String(),
+			InputPath: current,
+			Metadata: map[string]string{
+				queue.MetadataGroupID: groupID,
+				MetadataStep:          s.kind,
+				MetadataStepIndex:     strconv.Itoa(i),
+			},
+		}
+		for key, value := range s.metadata {
+			job.Metadata[key] = value
+		}
+		if i > 0 {
+			job.SetDependsOn([]string{jobs[i-1].ID})
+		}
+
+		switch s.kind {
+		case StepTranscode:
+			preset, _ := b.ffmpeg.Preset(s.preset)
+			ApplyPreset(job, s.preset, preset)
+			job.OutputPath = filepath.Join(dir, fmt.Sprintf("%s_%s.m3u8", stem, s.preset))
+			current = job.OutputPath
+		case StepWatermark:
+			job.OutputPath = filepath.Join(dir, fmt.Sprintf("%s_watermarked%s", stem, filepath.Ext(current)))
+			current = job.OutputPath
+		case StepThumbnail:
+			job.OutputPath = filepath.Join(dir, stem+"_thumbnails")
+		case StepUpload:
+			job.OutputPath = s.destination
+			current = s.destination
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Submit builds the pipeline and enqueues its jobs together, returning the
+// group ID shared by the jobs
+func (b *Builder) Submit(ctx context.Context, q queue.Queue) (string, error) {
+	jobs, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+
+	if err := queue.BulkEnqueue(ctx, q, jobs); err != nil {
+		return "", err
+	}
+	return jobs[0].Metadata[queue.MetadataGroupID], nil
+}
+
+// add appends a step
+func (b *Builder) add(s step) *Builder {
+	b.steps = append(b.steps, s)
+	return b
+}
+
+// fail records err unless an earlier error was recorded
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+func TestBuilderBuild(t *testing.T) {
+	input := filepath.Join("videos", "talk.mp4")
+	watermark := WatermarkConfig{ImagePath: "logo.png", Position: "top-right", Opacity: 0.5, Margin: 10}
+
+	jobs, err := NewBuilder().
+		Input(input).
+		Transcode("web-hd").
+		Watermark(watermark).
+		Thumbnail(ThumbnailConfig{IntervalSeconds: 10, Width: 320, Format: "jpg"}).
+		Upload("s3://bucket/talk").
+		NotifyWebhook("https://example.com/hook").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	wantSteps := []string{StepTranscode, StepWatermark, StepThumbnail, StepUpload, StepNotifyWebhook}
+	if len(jobs) != len(wantSteps) {
+		t.Fatalf("Build() returned %d jobs, want %d", len(jobs), len(wantSteps))
+	}
+	groupID := jobs[0].Metadata[queue.MetadataGroupID]
+	for i, job := range jobs {
+		if job.Metadata[MetadataStep] != wantSteps[i] {
+			t.Errorf("job %d step = %s, want %s", i, job.Metadata[MetadataStep], wantSteps[i])
+		}
+		if groupID == "" || job.Metadata[queue.MetadataGroupID] != groupID {
+			t.Errorf("job %d group = %q, want the shared group %q", i, job.Metadata[queue.MetadataGroupID], groupID)
+		}
+		deps := job.DependsOn()
+		if i == 0 && len(deps) != 0 || i > 0 && (len(deps) != 1 || deps[0] != jobs[i-1].ID) {
+			t.Errorf("job %d depends on %v, want only the job before it", i, deps)
+		}
+	}
+
+	transcoded := filepath.Join("videos", "talk_web-hd.m3u8")
+	if jobs[0].InputPath != input || jobs[0].OutputPath != transcoded || jobs[0].Metadata[queue.MetadataPreset] != "web-hd" {
+		t.Errorf("transcode job = %s -> %s with preset %q", jobs[0].InputPath, jobs[0].OutputPath, jobs[0].Metadata[queue.MetadataPreset])
+	}
+	if jobs[1].InputPath != transcoded || jobs[1].OutputPath != filepath.Join("videos", "talk_watermarked.m3u8") {
+		t.Errorf("watermark job = %s -> %s", jobs[1].InputPath, jobs[1].OutputPath)
+	}
+	var decoded WatermarkConfig
+	if err := json.Unmarshal([]byte(jobs[1].Metadata[MetadataWatermark]), &decoded); err != nil || decoded != watermark {
+		t.Errorf("watermark metadata = %s, want %+v", jobs[1].Metadata[MetadataWatermark], watermark)
+	}
+	// The upload copies the watermarked video, not the thumbnails
+	if jobs[3].InputPath != jobs[1].OutputPath || jobs[3].OutputPath != "s3://bucket/talk" {
+		t.Errorf("upload job = %s -> %s, want the watermarked video uploaded", jobs[3].InputPath, jobs[3].OutputPath)
+	}
+	if jobs[4].Metadata[MetadataWebhookURL] != "https://example.com/hook" {
+		t.Errorf("webhook URL = %q", jobs[4].Metadata[MetadataWebhookURL])
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{name: "no input", builder: NewBuilder().Transcode("web-hd"), wantErr: "input path is required"},
+		{name: "no steps", builder: NewBuilder().Input("in.mp4"), wantErr: "no steps"},
+		{name: "unknown preset", builder: NewBuilder().Input("in.mp4").Transcode("unknown"), wantErr: "unknown preset"},
+		{
+			name:    "invalid watermark",
+			builder: NewBuilder().Input("in.mp4").Watermark(WatermarkConfig{ImagePath: "logo.png", Position: "middle", Opacity: 1}),
+			wantErr: "unknown watermark position",
+		},
+		{
+			name:    "invalid thumbnail",
+			builder: NewBuilder().Input("in.mp4").Thumbnail(ThumbnailConfig{IntervalSeconds: 10, Width: 320, Format: "gif"}),
+			wantErr: "unknown thumbnail format",
+		},
+		{name: "invalid webhook", builder: NewBuilder().Input("in.mp4").NotifyWebhook("ftp://example.com"), wantErr: "invalid webhook URL"},
+		{
+			name:    "first error",
+			builder: NewBuilder().Input("in.mp4").Transcode("unknown").Upload(""),
+			wantErr: "unknown preset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuilderSubmit(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+
+	groupID, err := NewBuilder().Input("in.mp4").Transcode("mobile").Upload("out").Submit(ctx, q)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	jobs, _, err := q.ListJobs(ctx, queue.JobStatusQueued, 10, 0)
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("ListJobs() = %d jobs, %v; want 2", len(jobs), err)
+	}
+	for _, job := range jobs {
+		if job.Metadata[queue.MetadataGroupID] != groupID {
+			t.Errorf("job %s group = %q, want %q", job.ID, job.Metadata[queue.MetadataGroupID], groupID)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// ApplyPreset records the preset settings in the job metadata. Metadata
+// already set on the job takes precedence over the preset.
+func ApplyPreset(job *queue.Job, name string, preset config.TranscodePreset) {
+	settings := map[string]string{
+		queue.MetadataPreset:        name,
+		queue.MetadataVideoCodec:    preset.VideoCodec,
+		queue.MetadataQualities:     strings.Join(preset.Qualities, ","),
+		queue.MetadataOutputFormat:  preset.OutputFormat,
+		queue.MetadataHardwareAccel: preset.HardwareAccel,
+	}
+	if preset.Lossless {
+		settings[queue.MetadataLossless] = "true"
+	}
+	if preset.AudioNormalization {
+		settings[queue.MetadataAudioNormalization] = "true"
+	}
+
+	for key, value := range settings {
+		if _, set := job.Metadata[key]; set || value == "" {
+			continue
+		}
+		job.Metadata[key] = value
+	}
+}
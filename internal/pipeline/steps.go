@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Watermark positions
+var watermarkPositions = map[string]bool{
+	"top-left":     true,
+	"top-right":    true,
+	"bottom-left":  true,
+	"bottom-right": true,
+	"center":       true,
+}
+
+// WatermarkConfig describes an image overlaid on the video
+type WatermarkConfig struct {
+	ImagePath string  `json:"image_path"`
+	Position  string  `json:"position"` // top-left, top-right, bottom-left, bottom-right or center
+	Opacity   float64 `json:"opacity"`  // 0 (invisible) to 1 (opaque)
+	Margin    int     `json:"margin"`   // distance from the edges in pixels
+}
+
+// Validate checks the watermark settings
+func (c WatermarkConfig) Validate() error {
+	if c.ImagePath == "" {
+		return fmt.Errorf("watermark image path is required")
+	}
+	if !watermarkPositions[c.Position] {
+		return fmt.Errorf("unknown watermark position: %q", c.Position)
+	}
+	if c.Opacity <= 0 || c.Opacity > 1 {
+		return fmt.Errorf("watermark opacity must be greater than 0 and at most 1")
+	}
+	if c.Margin < 0 {
+		return fmt.Errorf("watermark margin cannot be negative")
+	}
+	return nil
+}
+
+// Thumbnail image formats
+var thumbnailFormats = map[string]bool{"jpg": true, "png": true, "webp": true}
+
+// ThumbnailConfig describes the thumbnails extracted from the video
+type ThumbnailConfig struct {
+	IntervalSeconds int    `json:"interval_seconds"` // one thumbnail every interval
+	Width           int    `json:"width"`            // height follows the aspect ratio
+	Format          string `json:"format"`           // jpg, png or webp
+}
+
+// Validate checks the thumbnail settings
+func (c ThumbnailConfig) Validate() error {
+	if c.IntervalSeconds <= 0 {
+		return fmt.Errorf("thumbnail interval must be positive")
+	}
+	if c.Width <= 0 {
+		return fmt.Errorf("thumbnail width must be positive")
+	}
+	if !thumbnailFormats[c.Format] {
+		return fmt.Errorf("unknown thumbnail format: %q", c.Format)
+	}
+	return nil
+}
+
+// encode returns the JSON encoding of step settings for job metadata
+func encode(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
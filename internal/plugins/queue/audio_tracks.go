@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// MetadataAudioTracks is the JSON-encoded list of audio tracks to encode
+	// for the job
+	MetadataAudioTracks = "audio_tracks"
+
+	// MetadataAudioLanguageMap is the JSON-encoded map from input audio
+	// stream index to the ISO 639-1 language of that stream
+	MetadataAudioLanguageMap = "audio_language_map"
+)
+
+// AudioTrackConfig is one audio track of the job output
+type AudioTrackConfig struct {
+	Codec    string `json:"codec"`
+	Bitrate  string `json:"bitrate"`
+	Channels int    `json:"channels"`
+	Language string `json:"language,omitempty"`
+	// Stream is the index of the input audio stream the track is encoded
+	// from, the first one by default
+	Stream int `json:"stream,omitempty"`
+}
+
+// AudioTracks returns the audio tracks requested for the job, or nil if the
+// default track should be used
+func (j *Job) AudioTracks() []AudioTrackConfig {
+	data, ok := j.Metadata[MetadataAudioTracks]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var tracks []AudioTrackConfig
+	if err := json.Unmarshal([]byte(data), &tracks); err != nil {
+		return nil
+	}
+	return tracks
+}
+
+// SetAudioTracks records the audio tracks to encode for the job
+func (j *Job) SetAudioTracks(tracks []AudioTrackConfig) error {
+	if len(tracks) == 0 {
+		delete(j.Metadata, MetadataAudioTracks)
+		return nil
+	}
+
+	data, err := json.Marshal(tracks)
+	if err != nil {
+		return err
+	}
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]string)
+	}
+	j.Metadata[MetadataAudioTracks] = string(data)
+	return nil
+}
+
+// AudioLanguageMap returns the languages set for the input audio streams,
+// keyed by stream index, or nil if none were set
+func (j *Job) AudioLanguageMap() map[string]string {
+	data, ok := j.Metadata[MetadataAudioLanguageMap]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var languages map[string]string
+	if err := json.Unmarshal([]byte(data), &languages); err != nil {
+		return nil
+	}
+	return languages
+}
+
+// SetAudioLanguageMap records the languages of the input audio streams. Keys
+// are audio stream indexes ("0", "1", ...) and values ISO 639-1 codes.
+func (j *Job) SetAudioLanguageMap(languages map[string]string) error {
+	if len(languages) == 0 {
+		delete(j.Metadata, MetadataAudioLanguageMap)
+		return nil
+	}
+
+	for stream, language := range languages {
+		if n, err := strconv.Atoi(stream); err != nil || n < 0 {
+			return fmt.Errorf("invalid audio stream index %q", stream)
+		}
+		if !validLanguageCode(language) {
+			return fmt.Errorf("invalid language %q for audio stream %s", language, stream)
+		}
+	}
+
+	data, err := json.Marshal(languages)
+	if err != nil {
+		return err
+	}
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]string)
+	}
+	j.Metadata[MetadataAudioLanguageMap] = string(data)
+	return nil
+}
+
+// AudioTrackCount returns the number of audio streams probed from the input,
+// or 0 if the input was not probed
+func (j *Job) AudioTrackCount() int {
+	count, _ := strconv.Atoi(j.Metadata[MetadataAudioTrackCount])
+	return count
+}
+
+// validLanguageCode reports whether code is a two or three letter lowercase
+// language code, which is also safe to use in HLS file names
+func validLanguageCode(code string) bool {
+	if len(code) < 2 || len(code) > 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
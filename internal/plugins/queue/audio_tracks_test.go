@@ -0,0 +1,60 @@
+package queue
+
+import "testing"
+
+func TestJobAudioTracks(t *testing.T) {
+	job := &Job{}
+	if tracks := job.AudioTracks(); tracks != nil {
+		t.Errorf("AudioTracks() of a new job = %v, want nil", tracks)
+	}
+
+	want := []AudioTrackConfig{
+		{Codec: "aac", Bitrate: "128k", Channels: 2, Language: "en"},
+		{Codec: "ac3", Bitrate: "384k", Channels: 6, Stream: 1},
+	}
+	if err := job.SetAudioTracks(want); err != nil {
+		t.Fatalf("SetAudioTracks() error = %v", err)
+	}
+	got := job.AudioTracks()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("AudioTracks() = %v, want %v", got, want)
+	}
+
+	if err := job.SetAudioTracks(nil); err != nil {
+		t.Fatalf("SetAudioTracks(nil) error = %v", err)
+	}
+	if _, ok := job.Metadata[MetadataAudioTracks]; ok {
+		t.Errorf("SetAudioTracks(nil) kept the audio tracks")
+	}
+
+	job.Metadata[MetadataAudioTracks] = "not json"
+	if tracks := job.AudioTracks(); tracks != nil {
+		t.Errorf("AudioTracks() of invalid metadata = %v, want nil", tracks)
+	}
+}
+
+func TestJobSetAudioLanguageMap(t *testing.T) {
+	tests := []struct {
+		languages map[string]string
+		wantErr   bool
+	}{
+		{languages: map[string]string{"0": "en", "1": "fra"}},
+		{languages: map[string]string{"-1": "en"}, wantErr: true},
+		{languages: map[string]string{"first": "en"}, wantErr: true},
+		{languages: map[string]string{"0": "EN"}, wantErr: true},
+		{languages: map[string]string{"0": "english"}, wantErr: true},
+		{languages: map[string]string{"0": "e/"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		job := &Job{}
+		err := job.SetAudioLanguageMap(tt.languages)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetAudioLanguageMap(%v) error = %v, want error %v", tt.languages, err, tt.wantErr)
+			continue
+		}
+		if err == nil && job.AudioLanguageMap()["0"] != tt.languages["0"] {
+			t.Errorf("AudioLanguageMap() = %v, want %v", job.AudioLanguageMap(), tt.languages)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package queue
+
+import "context"
+
+// BatchGetter is implemented by queues that can load several jobs in a
+// single round trip, e.g. with a Redis pipeline
+type BatchGetter interface {
+	// GetJobs returns the jobs found for the given IDs, keyed by ID.
+	// Missing jobs are left out of the result.
+	GetJobs(ctx context.Context, jobIDs []string) (map[string]*Job, error)
+}
+
+// GetJobs loads several jobs from q. Queues implementing BatchGetter are
+// read in one batch; otherwise each job is fetched with GetJob.
+func GetJobs(ctx context.Context, q Queue, jobIDs []string) (map[string]*Job, error) {
+	if getter, ok := q.(BatchGetter); ok {
+		return getter.GetJobs(ctx, jobIDs)
+	}
+
+	jobs := make(map[string]*Job, len(jobIDs))
+	for _, jobID := range jobIDs {
+		job, err := q.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			jobs[jobID] = job
+		}
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BulkEnqueuer is implemented by queues that can enqueue several jobs
+// atomically, so either all of them are queued or none are
+type BulkEnqueuer interface {
+	BulkEnqueue(ctx context.Context, jobs []*Job) error
+}
+
+// BulkEnqueue enqueues several jobs, atomically if the queue supports it.
+// Other queues get the jobs one at a time, and the jobs already enqueued are
+// cancelled when a later one fails.
+func BulkEnqueue(ctx context.Context, q Queue, jobs []*Job) error {
+	if enqueuer, ok := q.(BulkEnqueuer); ok {
+		return enqueuer.BulkEnqueue(ctx, jobs)
+	}
+
+	for i, job := range jobs {
+		if err := q.Enqueue(ctx, job); err != nil {
+			for _, enqueued := range jobs[:i] {
+				q.CancelJob(ctx, enqueued.ID)
+			}
+			return fmt.Errorf("failed to enqueue job %s: %w", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// BulkEnqueue adds all jobs to the queue, or none if any ID is already taken
+func (q *MemoryQueue) BulkEnqueue(ctx context.Context, jobs []*Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if _, ok := q.jobs[job.ID]; ok || ids[job.ID] {
+			return fmt.Errorf("%w: %s", ErrJobAlreadyExists, job.ID)
+		}
+		ids[job.ID] = true
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		q.push(job, now)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// rejectingQueue fails to enqueue the job with the ID reject
+type rejectingQueue struct {
+	Queue
+	reject string
+}
+
+func (q rejectingQueue) Enqueue(ctx context.Context, job *Job) error {
+	if job.ID == q.reject {
+		return errors.New("queue full")
+	}
+	return q.Queue.Enqueue(ctx, job)
+}
+
+func TestBulkEnqueueCancelsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryQueue()
+	q := rejectingQueue{Queue: memory, reject: "c"}
+
+	if err := BulkEnqueue(ctx, q, []*Job{{ID: "a"}, {ID: "b"}, {ID: "c"}}); err == nil {
+		t.Fatalf("BulkEnqueue() succeeded with a rejected job")
+	}
+	for _, id := range []string{"a", "b"} {
+		job, err := memory.GetJob(ctx, id)
+		if err != nil || job.Status != JobStatusCancelled {
+			t.Errorf("job %s = %v, %v; want it cancelled", id, job, err)
+		}
+	}
+}
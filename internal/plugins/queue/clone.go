@@ -0,0 +1,23 @@
+package queue
+
+// Clone returns a copy of the job that shares no mutable state with the original
+func (j *Job) Clone() *Job {
+	clone := *j
+
+	if j.Metadata != nil {
+		clone.Metadata = make(map[string]string, len(j.Metadata))
+		for k, v := range j.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	if j.StartedAt != nil {
+		startedAt := *j.StartedAt
+		clone.StartedAt = &startedAt
+	}
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+
+	return &clone
+}
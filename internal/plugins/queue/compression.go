@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Supported job payload compression algorithms
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// contentEncodingPrefix marks a compressed job payload. It is followed by the
+// algorithm name and a newline; plain JSON payloads always start with '{'.
+const contentEncodingPrefix = "Content-Encoding: "
+
+// EncodeJob marshals a job for storage, compressing it with algorithm.
+// An empty algorithm stores plain JSON.
+func EncodeJob(job *Job, algorithm string) ([]byte, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if algorithm == "" {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(contentEncodingPrefix + algorithm + "\n")
+
+	switch algorithm {
+	case CompressionGzip:
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to compress job: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress job: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm: %s", algorithm)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeJob unmarshals a job written by EncodeJob, decompressing it when the
+// payload carries a content encoding prefix
+func DecodeJob(data []byte) (*Job, error) {
+	if bytes.HasPrefix(data, []byte(contentEncodingPrefix)) {
+		header, body, ok := bytes.Cut(data, []byte("\n"))
+		if !ok {
+			return nil, fmt.Errorf("malformed job payload encoding header")
+		}
+
+		algorithm := string(header[len(contentEncodingPrefix):])
+		switch algorithm {
+		case CompressionGzip:
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress job: %w", err)
+			}
+			defer zr.Close()
+
+			if data, err = io.ReadAll(zr); err != nil {
+				return nil, fmt.Errorf("failed to decompress job: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported compression algorithm: %s", algorithm)
+		}
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// largeJob returns a job with many metadata keys and long FFmpeg arguments,
+// like the jobs compression is meant for
+func largeJob() *Job {
+	metadata := make(map[string]string, 200)
+	for i := 0; i < 200; i++ {
+		metadata[fmt.Sprintf("tenant_label_%03d", i)] = fmt.Sprintf("value-%03d-%s", i, strings.Repeat("x", 40))
+	}
+
+	var args []string
+	for i := 0; i < 50; i++ {
+		args = append(args, fmt.Sprintf("-metadata:s:v:%d title=Rendition %d -b:v:%d %dk", i, i, i, 500+i*100))
+	}
+
+	return &Job{
+		ID:         "large",
+		InputPath:  "tenants/a/uploads/input.mov",
+		OutputPath: "tenants/a/outputs/large",
+		FFmpegArgs: strings.Join(args, " "),
+		Priority:   5,
+		Metadata:   metadata,
+		Status:     JobStatusQueued,
+		CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestEncodeJobRoundTrip(t *testing.T) {
+	job := largeJob()
+
+	for _, algorithm := range []string{"", CompressionGzip} {
+		t.Run("algorithm="+algorithm, func(t *testing.T) {
+			data, err := EncodeJob(job, algorithm)
+			if err != nil {
+				t.Fatalf("EncodeJob() error = %v", err)
+			}
+			compressed := bytes.HasPrefix(data, []byte(contentEncodingPrefix))
+			if compressed != (algorithm != "") {
+				t.Errorf("EncodeJob() compressed = %v, want %v", compressed, algorithm != "")
+			}
+
+			decoded, err := DecodeJob(data)
+			if err != nil {
+				t.Fatalf("DecodeJob() error = %v", err)
+			}
+			if decoded.FFmpegArgs != job.FFmpegArgs || len(decoded.Metadata) != len(job.Metadata) {
+				t.Errorf("DecodeJob() did not restore the job")
+			}
+		})
+	}
+}
+
+func TestEncodeJobErrors(t *testing.T) {
+	if _, err := EncodeJob(largeJob(), CompressionZstd); err == nil {
+		t.Errorf("EncodeJob() with zstd succeeded, want an unsupported algorithm error")
+	}
+	if _, err := DecodeJob([]byte(contentEncodingPrefix + "brotli\nxxx")); err == nil {
+		t.Errorf("DecodeJob() with an unknown encoding succeeded")
+	}
+	if _, err := DecodeJob([]byte(contentEncodingPrefix + "gzip")); err == nil {
+		t.Errorf("DecodeJob() without a header newline succeeded")
+	}
+}
+
+// BenchmarkEncodeJobSize reports the encoded size of a large job with and
+// without compression
+func BenchmarkEncodeJobSize(b *testing.B) {
+	job := largeJob()
+
+	for _, algorithm := range []string{"none", CompressionGzip} {
+		b.Run(algorithm, func(b *testing.B) {
+			encoding := algorithm
+			if encoding == "none" {
+				encoding = ""
+			}
+
+			var size int
+			for i := 0; i < b.N; i++ {
+				data, err := EncodeJob(job, encoding)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "bytes/job")
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ConnectionTester is implemented by queues with a lightweight liveness
+// probe, such as a Redis PING
+type ConnectionTester interface {
+	// TestConnection returns an error if the queue backend is unreachable
+	TestConnection(ctx context.Context) error
+}
+
+// TestConnection probes a queue backend. Queues without a probe of their own
+// are tested by reading the queue depth.
+func TestConnection(ctx context.Context, q Queue) error {
+	if tester, ok := q.(ConnectionTester); ok {
+		return tester.TestConnection(ctx)
+	}
+	_, err := q.GetQueueDepth(ctx)
+	return err
+}
+
+// TestConnection probes every queue and fails if any is unreachable
+func (mq *MultiQueue) TestConnection(ctx context.Context) error {
+	for i, q := range mq.queues {
+		if err := TestConnection(ctx, q); err != nil {
+			return fmt.Errorf("queue %s: %w", mq.names[i], err)
+		}
+	}
+	return nil
+}
+
+// pingRedis probes a Redis server with PING
+func pingRedis(ctx context.Context, client redis.Cmdable) error {
+	return client.Ping(ctx).Err()
+}
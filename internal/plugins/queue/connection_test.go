@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// unreachableQueue fails every depth query, like a queue whose backend is down
+type unreachableQueue struct {
+	Queue
+}
+
+func (unreachableQueue) GetQueueDepth(ctx context.Context) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+// probingQueue has a probe of its own
+type probingQueue struct {
+	Queue
+	err error
+}
+
+func (q probingQueue) TestConnection(ctx context.Context) error {
+	return q.err
+}
+
+func TestTestConnection(t *testing.T) {
+	ctx := context.Background()
+	down := unreachableQueue{NewMemoryQueue()}
+	refused := errors.New("PING refused")
+
+	healthy, err := NewMultiQueue([]string{"a", "b"}, []Queue{NewMemoryQueue(), probingQueue{Queue: NewMemoryQueue()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, err := NewMultiQueue([]string{"a", "b"}, []Queue{NewMemoryQueue(), down})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		queue   Queue
+		wantErr string
+	}{
+		{name: "memory", queue: NewMemoryQueue()},
+		{name: "probe", queue: probingQueue{Queue: down}},
+		{name: "failing probe", queue: probingQueue{Queue: NewMemoryQueue(), err: refused}, wantErr: "PING refused"},
+		// Queues without a probe are tested by reading the queue depth
+		{name: "depth", queue: down, wantErr: "connection refused"},
+		{name: "multi", queue: healthy},
+		{name: "multi with a queue down", queue: broken, wantErr: "queue b"},
+	}
+
+	for _, tt := range tests {
+		err := TestConnection(ctx, tt.queue)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: TestConnection() error = %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: TestConnection() error = %v, want one containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPingRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	if err := pingRedis(context.Background(), client); err != nil {
+		t.Errorf("pingRedis() error = %v", err)
+	}
+	server.Close()
+	if err := pingRedis(context.Background(), client); err == nil {
+		t.Errorf("pingRedis() with Redis down succeeded")
+	}
+}
@@ -0,0 +1,30 @@
+package queue
+
+import "strings"
+
+// MetadataDependsOn is the comma-separated list of job IDs the job depends on
+const MetadataDependsOn = "depends_on"
+
+// DependsOn returns the IDs of the jobs the job depends on
+func (j *Job) DependsOn() []string {
+	var ids []string
+	for _, id := range strings.Split(j.Metadata[MetadataDependsOn], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SetDependsOn records the IDs of the jobs the job depends on
+func (j *Job) SetDependsOn(jobIDs []string) {
+	if len(jobIDs) == 0 {
+		delete(j.Metadata, MetadataDependsOn)
+		return
+	}
+
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]string)
+	}
+	j.Metadata[MetadataDependsOn] = strings.Join(jobIDs, ",")
+}
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaxGraphDepth is how many dependency levels below the root job a graph
+// follows
+const MaxGraphDepth = 10
+
+// JobNode is a job in a dependency graph
+type JobNode struct {
+	ID        string
+	Status    JobStatus
+	DependsOn []string
+
+	// Depth is the shortest number of dependency links from the root job
+	Depth int
+
+	// Missing is set for dependencies that are not in the queue
+	Missing bool
+}
+
+// JobGraph is the dependency graph below a root job
+type JobGraph struct {
+	RootID string
+
+	// Nodes are ordered by depth, the root job first
+	Nodes []JobNode
+
+	// Truncated is set when dependencies deeper than MaxGraphDepth were not
+	// followed
+	Truncated bool
+}
+
+// GraphCycleError reports a dependency cycle. Cycle lists the job IDs along
+// the cycle, starting and ending with the same job.
+type GraphCycleError struct {
+	Cycle []string
+}
+
+// Error implements the error interface
+func (e *GraphCycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// BuildJobGraph loads the root job and the jobs it depends on, following
+// dependencies up to MaxGraphDepth levels. A cycle is reported as a
+// *GraphCycleError together with the graph, so it can still be rendered.
+func BuildJobGraph(ctx context.Context, q Queue, rootID string) (*JobGraph, error) {
+	graph := &JobGraph{RootID: rootID}
+	seen := map[string]bool{rootID: true}
+	level := []string{rootID}
+
+	for depth := 0; len(level) > 0; depth++ {
+		jobs, err := GetJobs(ctx, q, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jobs: %w", err)
+		}
+
+		var next []string
+		for _, id := range level {
+			job, ok := jobs[id]
+			if !ok {
+				if depth == 0 {
+					return nil, fmt.Errorf("job not found: %s", rootID)
+				}
+				graph.Nodes = append(graph.Nodes, JobNode{ID: id, Depth: depth, Missing: true})
+				continue
+			}
+
+			node := JobNode{ID: id, Status: job.Status, DependsOn: job.DependsOn(), Depth: depth}
+			graph.Nodes = append(graph.Nodes, node)
+
+			for _, dep := range node.DependsOn {
+				if seen[dep] {
+					continue
+				}
+				if depth == MaxGraphDepth {
+					graph.Truncated = true
+					continue
+				}
+				seen[dep] = true
+				next = append(next, dep)
+			}
+		}
+		level = next
+	}
+
+	if cycle := graph.findCycle(); cycle != nil {
+		return graph, &GraphCycleError{Cycle: cycle}
+	}
+	return graph, nil
+}
+
+// findCycle returns the first dependency cycle found from the root job, or
+// nil if the graph has none
+func (g *JobGraph) findCycle() []string {
+	deps := make(map[string][]string, len(g.Nodes))
+	for _, node := range g.Nodes {
+		deps[node.ID] = node.DependsOn
+	}
+
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int, len(g.Nodes))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = onPath
+		path = append(path, id)
+		for _, dep := range deps[id] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case onPath:
+				for i, pathID := range path {
+					if pathID == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	return visit(g.RootID)
+}
+
+// DOT renders the graph in the Graphviz DOT language with an edge from each
+// dependency to the job that waits for it, so edges follow execution order.
+// Edges along cycle are drawn in red.
+func (g *JobGraph) DOT(cycle []string) string {
+	cycleEdges := make(map[[2]string]bool)
+	for i := 1; i < len(cycle); i++ {
+		cycleEdges[[2]string{cycle[i-1], cycle[i]}] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph jobs {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		status := string(node.Status)
+		style := ""
+		switch {
+		case node.Missing:
+			status = "missing"
+			style = ", style=dashed"
+		case node.ID == g.RootID:
+			style = ", style=bold"
+		}
+		fmt.Fprintf(&b, "  \"%s\" [label=\"%s\\n%s\"%s];\n", dotEscape(node.ID), dotEscape(node.ID), dotEscape(status), style)
+	}
+
+	for _, node := range g.Nodes {
+		for _, dep := range node.DependsOn {
+			attrs := ""
+			if cycleEdges[[2]string{node.ID, dep}] {
+				attrs = " [color=red]"
+			}
+			fmt.Fprintf(&b, "  \"%s\" -> \"%s\"%s;\n", dotEscape(dep), dotEscape(node.ID), attrs)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotEscape escapes a value for a double-quoted DOT string
+func dotEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
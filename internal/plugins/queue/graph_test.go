@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// enqueueGraph enqueues a job for each key of deps, depending on its value
+func enqueueGraph(t *testing.T, q Queue, deps map[string][]string) {
+	t.Helper()
+	for id, dependsOn := range deps {
+		job := &Job{ID: id}
+		job.SetDependsOn(dependsOn)
+		if err := q.Enqueue(context.Background(), job); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+}
+
+func TestJobDependsOn(t *testing.T) {
+	job := &Job{}
+	job.SetDependsOn([]string{"a", "b"})
+	if got := job.DependsOn(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("DependsOn() = %v, want [a b]", got)
+	}
+
+	job.Metadata[MetadataDependsOn] = " a, ,b ,"
+	if got := job.DependsOn(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("DependsOn() of a loosely formatted list = %v, want [a b]", got)
+	}
+
+	job.SetDependsOn(nil)
+	if _, ok := job.Metadata[MetadataDependsOn]; ok {
+		t.Errorf("SetDependsOn(nil) kept the depends_on metadata")
+	}
+}
+
+func TestBuildJobGraph(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	enqueueGraph(t, q, map[string][]string{
+		"root":   {"encode", "thumbs"},
+		"encode": {"upload", "gone"},
+		"thumbs": {"upload"},
+		"upload": nil,
+	})
+
+	graph, err := BuildJobGraph(ctx, q, "root")
+	if err != nil {
+		t.Fatalf("BuildJobGraph() error = %v", err)
+	}
+
+	depths := make(map[string]int)
+	missing := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		depths[node.ID] = node.Depth
+		missing[node.ID] = node.Missing
+	}
+	want := map[string]int{"root": 0, "encode": 1, "thumbs": 1, "upload": 2, "gone": 2}
+	if len(depths) != len(want) {
+		t.Errorf("nodes = %v, want %v", depths, want)
+	}
+	for id, depth := range want {
+		if got, ok := depths[id]; !ok || got != depth {
+			t.Errorf("depth of %s = %d, want %d", id, got, depth)
+		}
+	}
+	if !missing["gone"] || missing["upload"] {
+		t.Errorf("missing = %v, want only gone missing", missing)
+	}
+	if graph.Nodes[0].ID != "root" || graph.Truncated {
+		t.Errorf("first node = %s, truncated = %v; want root and not truncated", graph.Nodes[0].ID, graph.Truncated)
+	}
+
+	dot := graph.DOT(nil)
+	for _, line := range []string{`"upload" -> "encode";`, `"encode" -> "root";`, `"gone" [label="gone\nmissing", style=dashed];`} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT() has no line %s:\n%s", line, dot)
+		}
+	}
+
+	if _, err := BuildJobGraph(ctx, q, "unknown"); err == nil {
+		t.Errorf("BuildJobGraph() of an unknown job succeeded")
+	}
+}
+
+func TestBuildJobGraphCycle(t *testing.T) {
+	q := NewMemoryQueue()
+	enqueueGraph(t, q, map[string][]string{
+		"root": {"a"},
+		"a":    {"b"},
+		"b":    {"a"},
+	})
+
+	graph, err := BuildJobGraph(context.Background(), q, "root")
+	var cycleErr *GraphCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("BuildJobGraph() error = %v, want a cycle", err)
+	}
+	if got := strings.Join(cycleErr.Cycle, " "); got != "a b a" {
+		t.Errorf("Cycle = %s, want a b a", got)
+	}
+	if graph == nil || len(graph.Nodes) != 3 {
+		t.Fatalf("graph = %v, want the three jobs returned with the cycle", graph)
+	}
+
+	dot := graph.DOT(cycleErr.Cycle)
+	if !strings.Contains(dot, `"b" -> "a" [color=red];`) || !strings.Contains(dot, `"a" -> "b" [color=red];`) {
+		t.Errorf("DOT() does not draw the cycle in red:\n%s", dot)
+	}
+	if strings.Contains(dot, `"a" -> "root" [color=red]`) {
+		t.Errorf("DOT() draws an edge outside the cycle in red:\n%s", dot)
+	}
+}
+
+func TestBuildJobGraphTruncated(t *testing.T) {
+	deps := make(map[string][]string)
+	for i := 0; i <= MaxGraphDepth+2; i++ {
+		deps[fmt.Sprintf("job-%d", i)] = []string{fmt.Sprintf("job-%d", i+1)}
+	}
+	q := NewMemoryQueue()
+	enqueueGraph(t, q, deps)
+
+	graph, err := BuildJobGraph(context.Background(), q, "job-0")
+	if err != nil {
+		t.Fatalf("BuildJobGraph() error = %v", err)
+	}
+	if !graph.Truncated || len(graph.Nodes) != MaxGraphDepth+1 {
+		t.Errorf("Truncated = %v with %d nodes, want a truncated graph of %d nodes", graph.Truncated, len(graph.Nodes), MaxGraphDepth+1)
+	}
+}
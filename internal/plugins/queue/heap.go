@@ -0,0 +1,44 @@
+package queue
+
+// jobHeap orders queued jobs by priority, highest first, and then by creation
+// time, oldest first. It implements container/heap.Interface.
+type jobHeap []*Job
+
+// Len implements sort.Interface
+func (h jobHeap) Len() int { return len(h) }
+
+// Less implements sort.Interface
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].CreatedAt.Before(h[j].CreatedAt)
+}
+
+// Swap implements sort.Interface
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Push implements heap.Interface
+func (h *jobHeap) Push(x any) {
+	*h = append(*h, x.(*Job))
+}
+
+// Pop implements heap.Interface
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return job
+}
+
+// indexOf returns the position of a job in the heap, or -1
+func (h jobHeap) indexOf(jobID string) int {
+	for i, job := range h {
+		if job.ID == jobID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobAlreadyExists is returned by Enqueue when a job with the same ID is
+// already stored in the queue
+var ErrJobAlreadyExists = errors.New("job already exists")
+
+// IDGenerator generates IDs for new jobs
+type IDGenerator interface {
+	NewID() string
+}
+
+// NewJobID returns an ID for a new job of q, from q's generator when it
+// implements IDGenerator and a UUID v4 otherwise
+func NewJobID(q Queue) string {
+	if generator, ok := q.(IDGenerator); ok {
+		return generator.NewID()
+	}
+	return UUIDGenerator{}.NewID()
+}
+
+// UUIDGenerator generates random UUID v4 job IDs. It is the default generator.
+type UUIDGenerator struct{}
+
+// NewID returns a new UUID v4
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// SequentialIDGenerator generates deterministic job IDs (job-1, job-2, ...)
+// for tests. It is safe for concurrent use.
+type SequentialIDGenerator struct {
+	next atomic.Int64
+}
+
+// NewID returns the next sequential job ID
+func (g *SequentialIDGenerator) NewID() string {
+	return "job-" + strconv.FormatInt(g.next.Add(1), 10)
+}
+
+// prefixedIDGenerator generates UUID v4 job IDs with a fixed prefix
+type prefixedIDGenerator struct {
+	prefix string
+}
+
+// PrefixedIDGenerator returns a generator of UUID v4 job IDs prefixed with
+// prefix, e.g. "<tenant>-<uuid>" for multi-tenant deployments
+func PrefixedIDGenerator(prefix string) IDGenerator {
+	return prefixedIDGenerator{prefix: prefix}
+}
+
+// NewID returns a new prefixed UUID v4
+func (g prefixedIDGenerator) NewID() string {
+	return g.prefix + "-" + uuid.New().String()
+}
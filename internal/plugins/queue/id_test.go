@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSequentialIDGenerator(t *testing.T) {
+	var g SequentialIDGenerator
+	for _, want := range []string{"job-1", "job-2", "job-3"} {
+		if got := g.NewID(); got != want {
+			t.Errorf("NewID() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSequentialIDGeneratorConcurrent(t *testing.T) {
+	var g SequentialIDGenerator
+	const n = 100
+
+	var wg sync.WaitGroup
+	ids := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids <- g.NewID()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("NewID() returned %q twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestPrefixedIDGenerator(t *testing.T) {
+	g := PrefixedIDGenerator("tenant-a")
+	first, second := g.NewID(), g.NewID()
+
+	if !strings.HasPrefix(first, "tenant-a-") {
+		t.Errorf("NewID() = %q, want prefix tenant-a-", first)
+	}
+	if first == second {
+		t.Errorf("NewID() returned %q twice", first)
+	}
+}
+
+func TestNewJobID(t *testing.T) {
+	q := NewMemoryQueueWithIDs(&SequentialIDGenerator{})
+	if got := NewJobID(q); got != "job-1" {
+		t.Errorf("NewJobID() = %q, want the queue's generator to give job-1", got)
+	}
+
+	// Queues without a generator get UUIDs
+	if got := NewJobID(&MultiQueue{}); len(got) != 36 {
+		t.Errorf("NewJobID() = %q, want a UUID", got)
+	}
+}
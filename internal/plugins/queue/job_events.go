@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// maxJobEvents is the number of events kept in a job's event log; older ones
+// are dropped first
+const maxJobEvents = 50
+
+// Job event types
+const (
+	// JobEventProgress carries a JSON-encoded ProgressSnapshot as detail
+	JobEventProgress = "progress"
+)
+
+// JobLogEvent is one entry of a job's event log
+type JobLogEvent struct {
+	Type   string    `json:"type"`
+	Detail string    `json:"detail,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// AppendJobEvent records an event in the job's event log
+func (j *Job) AppendJobEvent(eventType, detail string) {
+	entries := append(j.JobEvents(), JobLogEvent{
+		Type:   eventType,
+		Detail: detail,
+		At:     time.Now(),
+	})
+	if len(entries) > maxJobEvents {
+		entries = entries[len(entries)-maxJobEvents:]
+	}
+
+	if data, err := json.Marshal(entries); err == nil {
+		if j.Metadata == nil {
+			j.Metadata = make(map[string]string)
+		}
+		j.Metadata[MetadataJobEvents] = string(data)
+	}
+}
+
+// JobEvents returns the event log of the job, oldest first
+func (j *Job) JobEvents() []JobLogEvent {
+	data, ok := j.Metadata[MetadataJobEvents]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var entries []JobLogEvent
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil
+	}
+	return entries
+}
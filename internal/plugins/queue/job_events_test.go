@@ -0,0 +1,29 @@
+package queue
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestJobAppendJobEvent(t *testing.T) {
+	job := &Job{ID: "job-1"}
+	if events := job.JobEvents(); events != nil {
+		t.Fatalf("JobEvents() of a new job = %v, want none", events)
+	}
+
+	for i := 0; i <= maxJobEvents; i++ {
+		job.AppendJobEvent(JobEventProgress, `{"frame":`+strconv.Itoa(i)+`}`)
+	}
+
+	events := job.JobEvents()
+	if len(events) != maxJobEvents {
+		t.Fatalf("JobEvents() has %d entries, want %d", len(events), maxJobEvents)
+	}
+	// The oldest entries are dropped
+	if first := events[0]; first.Type != JobEventProgress || first.Detail != `{"frame":1}` || first.At.IsZero() {
+		t.Errorf("first event = %+v, want the second progress event", first)
+	}
+	if last := events[len(events)-1]; last.Detail != `{"frame":50}` {
+		t.Errorf("last event = %+v, want the latest progress event", last)
+	}
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PriorityLane is a range of job priorities served by its own queue
+type PriorityLane struct {
+	Name        string
+	MinPriority int
+	MaxPriority int
+	// Allocation is the lane's share of dequeues, in percent
+	Allocation int
+}
+
+// LaneQueue splits jobs by priority into lanes, each backed by its own
+// queue, and dequeues from the lanes in proportion to their allocation, so
+// a full lane of bulk jobs cannot starve the urgent ones. A lane without
+// jobs gives its turn to the others.
+type LaneQueue struct {
+	*MultiQueue
+
+	lanes []PriorityLane
+
+	mu sync.Mutex
+	// credit is the smooth weighted round-robin state of each lane
+	credit []int
+}
+
+// NewLaneQueue creates a lane queue from lanes and their queues. Lanes are
+// ordered by their minimum priority.
+func NewLaneQueue(lanes []PriorityLane, queues []Queue) (*LaneQueue, error) {
+	if len(lanes) != len(queues) {
+		return nil, fmt.Errorf("lane queue has %d lanes for %d queues", len(lanes), len(queues))
+	}
+
+	order := make([]int, len(lanes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return lanes[order[a]].MinPriority < lanes[order[b]].MinPriority
+	})
+
+	sortedLanes := make([]PriorityLane, len(lanes))
+	sortedQueues := make([]Queue, len(queues))
+	names := make([]string, len(lanes))
+	for i, index := range order {
+		sortedLanes[i] = lanes[index]
+		sortedQueues[i] = queues[index]
+		names[i] = lanes[index].Name
+	}
+
+	mq, err := NewMultiQueue(names, sortedQueues)
+	if err != nil {
+		return nil, err
+	}
+	return &LaneQueue{
+		MultiQueue: mq,
+		lanes:      sortedLanes,
+		credit:     make([]int, len(lanes)),
+	}, nil
+}
+
+// Enqueue adds a job to the lane of its priority: the lane with the highest
+// minimum priority not above it, or the lowest lane
+func (lq *LaneQueue) Enqueue(ctx context.Context, job *Job) error {
+	index := lq.route(job.Priority)
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[MetadataPriorityLane] = lq.lanes[index].Name
+
+	if err := lq.queues[index].Enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	lq.setOwner(job.ID, index)
+	return nil
+}
+
+// Dequeue returns a job from the lane whose turn it is, falling back to the
+// other lanes by their accumulated turns when it has none
+func (lq *LaneQueue) Dequeue(ctx context.Context) (*Job, error) {
+	var errs []error
+	for _, i := range lq.turns() {
+		job, err := lq.queues[i].Dequeue(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lane %s: %w", lq.lanes[i].Name, err))
+			continue
+		}
+		if job != nil {
+			lq.setOwner(job.ID, i)
+			return job, nil
+		}
+	}
+
+	if len(errs) == len(lq.queues) {
+		return nil, errors.Join(errs...)
+	}
+	return nil, nil
+}
+
+// turns advances the smooth weighted round-robin by one dequeue and returns
+// the lanes in the order they are tried: the selected lane first, then the
+// others by their credit
+func (lq *LaneQueue) turns() []int {
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+
+	total := 0
+	for i, lane := range lq.lanes {
+		lq.credit[i] += lane.Allocation
+		total += lane.Allocation
+	}
+
+	order := make([]int, len(lq.lanes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return lq.credit[order[a]] > lq.credit[order[b]]
+	})
+
+	lq.credit[order[0]] -= total
+	return order
+}
+
+// route returns the index of the lane for a priority
+func (lq *LaneQueue) route(priority int) int {
+	index := 0
+	for i, lane := range lq.lanes {
+		if lane.MinPriority <= priority {
+			index = i
+		}
+	}
+	return index
+}
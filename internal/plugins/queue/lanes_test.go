@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// testLanes are an urgent lane with 70% of the dequeues and a bulk lane
+// with 30%
+var testLanes = []PriorityLane{
+	{Name: "bulk", MinPriority: 0, MaxPriority: 9, Allocation: 30},
+	{Name: "urgent", MinPriority: 10, MaxPriority: 100, Allocation: 70},
+}
+
+func TestLaneQueueRouting(t *testing.T) {
+	ctx := context.Background()
+	lq, err := NewLaneQueue(testLanes, []Queue{NewMemoryQueue(), NewMemoryQueue()})
+	if err != nil {
+		t.Fatalf("NewLaneQueue() error = %v", err)
+	}
+
+	tests := []struct {
+		priority int
+		want     string
+	}{
+		{priority: 50, want: "urgent"},
+		{priority: 10, want: "urgent"},
+		{priority: 9, want: "bulk"},
+		{priority: -5, want: "bulk"},
+		{priority: 500, want: "urgent"},
+	}
+	for _, tt := range tests {
+		job := &Job{Priority: tt.priority}
+		if err := lq.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		if job.ID == "" {
+			t.Errorf("Enqueue() did not assign an ID")
+		}
+		if got := job.Metadata[MetadataPriorityLane]; got != tt.want {
+			t.Errorf("priority %d went to lane %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestLaneQueueAllocation(t *testing.T) {
+	ctx := context.Background()
+	lq, err := NewLaneQueue(testLanes, []Queue{NewMemoryQueue(), NewMemoryQueue()})
+	if err != nil {
+		t.Fatalf("NewLaneQueue() error = %v", err)
+	}
+
+	// More bulk jobs than urgent ones, with bulk enqueued first
+	for i := 0; i < 20; i++ {
+		if err := lq.Enqueue(ctx, &Job{ID: fmt.Sprintf("bulk-%d", i), Priority: 5}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	for i := 0; i < 7; i++ {
+		if err := lq.Enqueue(ctx, &Job{ID: fmt.Sprintf("urgent-%d", i), Priority: 50}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		job, err := lq.Dequeue(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("Dequeue() = %v, %v; want a job", job, err)
+		}
+		counts[job.Metadata[MetadataPriorityLane]]++
+	}
+	if counts["urgent"] != 7 || counts["bulk"] != 3 {
+		t.Errorf("10 dequeues took %v, want 7 urgent and 3 bulk", counts)
+	}
+
+	// With the urgent lane empty, bulk gets every turn
+	for i := 0; i < 5; i++ {
+		job, err := lq.Dequeue(ctx)
+		if err != nil || job == nil || job.Metadata[MetadataPriorityLane] != "bulk" {
+			t.Fatalf("Dequeue() = %v, %v; want a bulk job", job, err)
+		}
+	}
+}
+
+func TestLaneQueueRequeue(t *testing.T) {
+	ctx := context.Background()
+	lq, err := NewLaneQueue(testLanes, []Queue{NewMemoryQueue(), NewMemoryQueue()})
+	if err != nil {
+		t.Fatalf("NewLaneQueue() error = %v", err)
+	}
+
+	if err := lq.Enqueue(ctx, &Job{ID: "a", Priority: 50}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	job, err := lq.Dequeue(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("Dequeue() = %v, %v; want job a", job, err)
+	}
+	if err := Requeue(ctx, lq, job); err != nil {
+		t.Fatalf("Requeue() error = %v", err)
+	}
+	if depth, _ := lq.GetQueueDepth(ctx); depth != 1 {
+		t.Errorf("GetQueueDepth() = %d after requeue, want 1", depth)
+	}
+}
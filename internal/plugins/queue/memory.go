@@ -0,0 +1,222 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is an in-process queue for development and single-node setups.
+// Queued jobs are kept in a heap ordered by priority and then creation time.
+// Jobs are lost when the process exits.
+type MemoryQueue struct {
+	mu     sync.Mutex
+	queued jobHeap
+	jobs   map[string]*Job
+	ids    IDGenerator
+}
+
+// NewMemoryQueue creates an empty in-memory queue that assigns UUID v4 IDs
+func NewMemoryQueue() *MemoryQueue {
+	return NewMemoryQueueWithIDs(UUIDGenerator{})
+}
+
+// NewMemoryQueueWithIDs creates an empty in-memory queue that assigns IDs
+// from ids to jobs enqueued without one
+func NewMemoryQueueWithIDs(ids IDGenerator) *MemoryQueue {
+	return &MemoryQueue{
+		jobs: make(map[string]*Job),
+		ids:  ids,
+	}
+}
+
+// NewID returns an ID from the queue's generator
+func (q *MemoryQueue) NewID() string {
+	return q.ids.NewID()
+}
+
+// Enqueue adds a job to the queue, assigning it an ID if it has none. A job
+// whose ID is already stored, whatever its status, is rejected; jobs taken
+// by Dequeue go back with Requeue.
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job.ID == "" {
+		job.ID = q.ids.NewID()
+	}
+	if _, ok := q.jobs[job.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrJobAlreadyExists, job.ID)
+	}
+
+	q.push(job, time.Now())
+	return nil
+}
+
+// Requeue replaces the stored state of a job, such as one taken by Dequeue
+// or a failed one being resubmitted, and puts it back in the queue. A job
+// that is still queued is rejected.
+func (q *MemoryQueue) Requeue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.jobs[job.ID]; !ok {
+		return fmt.Errorf("job not found: %s", job.ID)
+	}
+	if q.queued.indexOf(job.ID) >= 0 {
+		return fmt.Errorf("%w: %s", ErrJobAlreadyExists, job.ID)
+	}
+
+	q.push(job, time.Now())
+	return nil
+}
+
+// push stores a copy of the job in the queued state and adds it to the heap.
+// The caller must hold q.mu.
+func (q *MemoryQueue) push(job *Job, now time.Time) {
+	stored := job.Clone()
+	DefaultStateMachine.Initialize(stored, "enqueued")
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = now
+	}
+
+	q.jobs[stored.ID] = stored
+	heap.Push(&q.queued, stored)
+}
+
+// Dequeue removes and returns the highest priority job, or nil if the queue
+// is empty
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued.Len() == 0 {
+		return nil, nil
+	}
+	return heap.Pop(&q.queued).(*Job).Clone(), nil
+}
+
+// Len returns the number of queued jobs
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queued.Len()
+}
+
+// Peek returns the job Dequeue would return next without removing it, or nil
+// if the queue is empty
+func (q *MemoryQueue) Peek() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued.Len() == 0 {
+		return nil
+	}
+	return q.queued[0].Clone()
+}
+
+// GetJob returns a copy of a job, or nil if it does not exist
+func (q *MemoryQueue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return nil, nil
+	}
+	return job.Clone(), nil
+}
+
+// UpdateJob replaces the stored state of a job
+func (q *MemoryQueue) UpdateJob(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, ok := q.jobs[job.ID]
+	if !ok {
+		return fmt.Errorf("job not found: %s", job.ID)
+	}
+
+	// Update in place so a queued job keeps its position in the heap
+	*stored = *job.Clone()
+	if i := q.queued.indexOf(job.ID); i >= 0 {
+		heap.Fix(&q.queued, i)
+	}
+	return nil
+}
+
+// Acknowledge confirms a job has been handled. The job stays available to
+// GetJob and ListJobs.
+func (q *MemoryQueue) Acknowledge(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.jobs[jobID]; !ok {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+	return nil
+}
+
+// CancelJob removes a queued job from the queue and marks it cancelled.
+// Completed, failed and cancelled jobs cannot be cancelled.
+func (q *MemoryQueue) CancelJob(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if err := DefaultStateMachine.Transition(job, JobStatusCancelled, "cancelled"); err != nil {
+		return err
+	}
+	if i := q.queued.indexOf(jobID); i >= 0 {
+		heap.Remove(&q.queued, i)
+	}
+	now := time.Now()
+	job.CompletedAt = &now
+	return nil
+}
+
+// ListJobs returns jobs with the given status, or all jobs for an empty
+// status, ordered by creation time
+func (q *MemoryQueue) ListJobs(ctx context.Context, status JobStatus, limit, offset int) ([]*Job, int, error) {
+	q.mu.Lock()
+	var matched []*Job
+	for _, job := range q.jobs {
+		if status == "" || job.Status == status {
+			matched = append(matched, job.Clone())
+		}
+	}
+	q.mu.Unlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return nil, total, nil
+	}
+	matched = matched[offset:]
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	return matched, total, nil
+}
+
+// GetQueueDepth returns the number of queued jobs
+func (q *MemoryQueue) GetQueueDepth(ctx context.Context) (int, error) {
+	return q.Len(), nil
+}
+
+// Close releases nothing; the jobs are kept until the queue is garbage collected
+func (q *MemoryQueue) Close() error {
+	return nil
+}
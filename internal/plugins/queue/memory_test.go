@@ -0,0 +1,253 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+func TestMemoryQueueAssignsIDs(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueueWithIDs(&SequentialIDGenerator{})
+
+	for _, want := range []string{"job-1", "job-2"} {
+		job := &Job{InputPath: "in.mp4"}
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		if job.ID != want {
+			t.Errorf("Enqueue() assigned ID %q, want %q", job.ID, want)
+		}
+		stored, err := q.GetJob(ctx, want)
+		if err != nil || stored == nil {
+			t.Fatalf("GetJob(%q) = %v, %v; want the job", want, stored, err)
+		}
+	}
+
+	if got := q.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestMemoryQueueKeepsGivenIDs(t *testing.T) {
+	q := NewMemoryQueueWithIDs(&SequentialIDGenerator{})
+
+	job := &Job{ID: "custom"}
+	if err := q.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if job.ID != "custom" {
+		t.Errorf("Enqueue() changed ID to %q", job.ID)
+	}
+}
+
+func TestMemoryQueueRequeue(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	job := &Job{ID: "a", Priority: 5}
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := q.Enqueue(ctx, &Job{ID: "a"}); !errors.Is(err, ErrJobAlreadyExists) {
+		t.Fatalf("Enqueue() of a queued job error = %v, want ErrJobAlreadyExists", err)
+	}
+
+	dequeued, err := q.Dequeue(ctx)
+	if err != nil || dequeued == nil {
+		t.Fatalf("Dequeue() = %v, %v; want the job", dequeued, err)
+	}
+
+	// The ID stays taken while the job is processing
+	if err := q.Enqueue(ctx, &Job{ID: "a"}); !errors.Is(err, ErrJobAlreadyExists) {
+		t.Fatalf("Enqueue() of a dequeued job error = %v, want ErrJobAlreadyExists", err)
+	}
+
+	// A dequeued job is put back, e.g. when no worker can take it
+	if err := Requeue(ctx, q, dequeued); err != nil {
+		t.Fatalf("Requeue() error = %v", err)
+	}
+	if err := Requeue(ctx, q, dequeued); !errors.Is(err, ErrJobAlreadyExists) {
+		t.Fatalf("Requeue() of a queued job error = %v, want ErrJobAlreadyExists", err)
+	}
+	if got := q.Len(); got != 1 {
+		t.Fatalf("Len() = %d after requeue, want 1", got)
+	}
+
+	again, err := q.Dequeue(ctx)
+	if err != nil || again == nil || again.ID != "a" {
+		t.Fatalf("Dequeue() = %v, %v; want job a", again, err)
+	}
+	if !again.CreatedAt.Equal(dequeued.CreatedAt) {
+		t.Errorf("requeued job CreatedAt = %v, want %v", again.CreatedAt, dequeued.CreatedAt)
+	}
+}
+
+func TestMemoryQueueRejectsStoredIDs(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	for _, status := range []JobStatus{JobStatusCompleted, JobStatusFailed, JobStatusCancelled} {
+		id := string(status)
+		if err := q.Enqueue(ctx, &Job{ID: id}); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+		job, _ := q.Dequeue(ctx)
+		job.Status = status
+		if err := q.UpdateJob(ctx, job); err != nil {
+			t.Fatalf("UpdateJob(%s) error = %v", id, err)
+		}
+
+		if err := q.Enqueue(ctx, &Job{ID: id, InputPath: "other.mp4"}); !errors.Is(err, ErrJobAlreadyExists) {
+			t.Errorf("Enqueue() of a %s job error = %v, want ErrJobAlreadyExists", status, err)
+		}
+		stored, _ := q.GetJob(ctx, id)
+		if stored == nil || stored.Status != status || stored.InputPath != "" {
+			t.Errorf("GetJob(%s) = %+v, want the %s job unchanged", id, stored, status)
+		}
+	}
+
+	if err := q.Requeue(ctx, &Job{ID: "missing"}); err == nil {
+		t.Error("Requeue() of an unknown job error = nil, want an error")
+	}
+}
+
+func TestMemoryQueueOrder(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	base := time.Now()
+
+	jobs := []*Job{
+		{ID: "low-old", Priority: 1, CreatedAt: base},
+		{ID: "high-new", Priority: 9, CreatedAt: base.Add(2 * time.Second)},
+		{ID: "high-old", Priority: 9, CreatedAt: base.Add(time.Second)},
+		{ID: "mid", Priority: 5, CreatedAt: base},
+	}
+	for _, job := range jobs {
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", job.ID, err)
+		}
+	}
+
+	if peek := q.Peek(); peek == nil || peek.ID != "high-old" {
+		t.Fatalf("Peek() = %v, want high-old", peek)
+	}
+	for _, want := range []string{"high-old", "high-new", "mid", "low-old"} {
+		job, err := q.Dequeue(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("Dequeue() = %v, %v; want %s", job, err, want)
+		}
+		if job.ID != want {
+			t.Errorf("Dequeue() = %s, want %s", job.ID, want)
+		}
+	}
+	if job, _ := q.Dequeue(ctx); job != nil {
+		t.Errorf("Dequeue() of an empty queue = %s, want nil", job.ID)
+	}
+}
+
+// TestMemoryQueueHeapProperties runs random sequences of Enqueue, Dequeue
+// and CancelJob against a model of the queued jobs and checks the heap
+// invariant, Len and Peek after every operation
+func TestMemoryQueueHeapProperties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		q := NewMemoryQueue()
+		base := time.Now()
+		queued := make(map[string]*Job)
+		var ids []string
+
+		t.Repeat(map[string]func(*rapid.T){
+			"enqueue": func(t *rapid.T) {
+				job := &Job{
+					ID:        fmt.Sprintf("job-%d", len(ids)),
+					Priority:  rapid.IntRange(-3, 3).Draw(t, "priority"),
+					CreatedAt: base.Add(time.Duration(rapid.IntRange(0, 20).Draw(t, "created")) * time.Second),
+				}
+				if err := q.Enqueue(ctx, job); err != nil {
+					t.Fatalf("Enqueue() error = %v", err)
+				}
+				ids = append(ids, job.ID)
+				queued[job.ID] = job
+			},
+			"dequeue": func(t *rapid.T) {
+				job, err := q.Dequeue(ctx)
+				if err != nil {
+					t.Fatalf("Dequeue() error = %v", err)
+				}
+				if len(queued) == 0 {
+					if job != nil {
+						t.Fatalf("Dequeue() of an empty queue = %s", job.ID)
+					}
+					return
+				}
+				if job == nil {
+					t.Fatalf("Dequeue() = nil with %d jobs queued", len(queued))
+				}
+				for _, other := range queued {
+					if before(other, job) {
+						t.Fatalf("Dequeue() = %s (priority %d) while %s (priority %d) was queued",
+							job.ID, job.Priority, other.ID, other.Priority)
+					}
+				}
+				delete(queued, job.ID)
+			},
+			"cancel": func(t *rapid.T) {
+				if len(ids) == 0 {
+					t.Skip("no jobs")
+				}
+				id := rapid.SampledFrom(ids).Draw(t, "id")
+				if err := q.CancelJob(ctx, id); err == nil {
+					delete(queued, id)
+				} else if _, ok := queued[id]; ok {
+					t.Fatalf("CancelJob(%s) of a queued job error = %v", id, err)
+				}
+			},
+			"": func(t *rapid.T) {
+				checkHeap(t, q, queued)
+			},
+		})
+	})
+}
+
+// before reports whether a is dequeued before b
+func before(a, b *Job) bool {
+	return jobHeap{a, b}.Less(0, 1)
+}
+
+// checkHeap verifies the heap invariant of q and that it holds the queued
+// jobs of the model
+func checkHeap(t *rapid.T, q *MemoryQueue, queued map[string]*Job) {
+	q.mu.Lock()
+	h := q.queued
+	for i := 1; i < len(h); i++ {
+		if parent := (i - 1) / 2; h.Less(i, parent) {
+			q.mu.Unlock()
+			t.Fatalf("heap invariant violated: %s sorts before its parent %s", h[i].ID, h[parent].ID)
+		}
+	}
+	q.mu.Unlock()
+
+	if got := q.Len(); got != len(queued) {
+		t.Fatalf("Len() = %d, want %d", got, len(queued))
+	}
+	peek := q.Peek()
+	if len(queued) == 0 {
+		if peek != nil {
+			t.Fatalf("Peek() of an empty queue = %s", peek.ID)
+		}
+		return
+	}
+	if peek == nil {
+		t.Fatalf("Peek() = nil with %d jobs queued", len(queued))
+	}
+	for _, other := range queued {
+		if before(other, peek) {
+			t.Fatalf("Peek() = %s while %s sorts before it", peek.ID, other.ID)
+		}
+	}
+}
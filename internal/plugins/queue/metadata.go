@@ -0,0 +1,198 @@
+package queue
+
+// Well-known job metadata keys
+const (
+	// MetadataFFmpegLogPath is the path of the captured FFmpeg output for the job
+	MetadataFFmpegLogPath = "ffmpeg_log_path"
+
+	// MetadataProgress is the latest JSON-encoded ProgressSnapshot of the job
+	MetadataProgress = "progress"
+
+	// MetadataJobEvents is the JSON-encoded event log of a job, see
+	// AppendJobEvent
+	MetadataJobEvents = "job_events"
+
+	// MetadataGroupID groups related jobs, e.g. for event subscriptions
+	MetadataGroupID = "group_id"
+
+	// MetadataRequestID is the ID of the gRPC request that created the job
+	MetadataRequestID = "request_id"
+
+	// MetadataVideoCodec overrides the configured output video codec for the job
+	MetadataVideoCodec = "video_codec"
+
+	// MetadataPreset is the name of the transcode preset applied to the job
+	MetadataPreset = "preset"
+
+	// MetadataQualities overrides the configured output qualities with a
+	// comma-separated list
+	MetadataQualities = "qualities"
+
+	// MetadataOutputFormat selects hls, audio-only or fmp4 output for the job
+	MetadataOutputFormat = "output_format"
+
+	// MetadataHardwareAccel overrides the configured hardware encoder; none
+	// selects software encoding
+	MetadataHardwareAccel = "hardware_accel"
+
+	// MetadataLossless requests lossless video encoding when set to "true"
+	MetadataLossless = "lossless"
+
+	// MetadataAudioNormalization enables or disables loudness normalization
+	// for the job with "true" or "false"
+	MetadataAudioNormalization = "audio_normalization"
+
+	// MetadataTenantID identifies the tenant that submitted the job
+	MetadataTenantID = "tenant_id"
+
+	// MetadataGCSServiceAccount is the service account impersonated for the
+	// job's GCS storage operations, assigned from the tenant by the server
+	MetadataGCSServiceAccount = "gcs_service_account"
+
+	// MetadataOutputDirectory is the templated subdirectory the job output was placed in
+	MetadataOutputDirectory = "output_directory"
+
+	// MetadataManifestPath is the storage path of the job's output manifest
+	MetadataManifestPath = "manifest_path"
+
+	// MetadataUploadRetries is the number of times the job output upload was retried
+	MetadataUploadRetries = "upload_retries"
+
+	// MetadataFFmpegUserCPUMs is the user CPU time used by FFmpeg, in milliseconds
+	MetadataFFmpegUserCPUMs = "ffmpeg_user_cpu_ms"
+
+	// MetadataFFmpegSysCPUMs is the system CPU time used by FFmpeg, in milliseconds
+	MetadataFFmpegSysCPUMs = "ffmpeg_sys_cpu_ms"
+
+	// MetadataFFmpegMaxRSSKB is the peak resident set size of FFmpeg, in kilobytes
+	MetadataFFmpegMaxRSSKB = "ffmpeg_max_rss_kb"
+
+	// MetadataLoudnessInputI is the measured integrated loudness of the input, in LUFS
+	MetadataLoudnessInputI = "loudness_input_i"
+
+	// MetadataLoudnessInputTP is the measured true peak of the input, in dBTP
+	MetadataLoudnessInputTP = "loudness_input_tp"
+
+	// MetadataLoudnessInputLRA is the measured loudness range of the input, in LU
+	MetadataLoudnessInputLRA = "loudness_input_lra"
+
+	// MetadataLoudnessInputThresh is the measured gating threshold of the input, in LUFS
+	MetadataLoudnessInputThresh = "loudness_input_thresh"
+
+	// MetadataLoudnessTargetOffset is the gain offset computed by the measurement pass, in LU
+	MetadataLoudnessTargetOffset = "loudness_target_offset"
+
+	// MetadataLocalInputPath is the downloaded copy of a remote input, used by
+	// FFmpeg instead of the input URL while the job runs
+	MetadataLocalInputPath = "local_input_path"
+
+	// MetadataInputChecksum is the hex SHA-256 of a downloaded remote input
+	MetadataInputChecksum = "input_checksum"
+
+	// MetadataTraceContext is the JSON-encoded OpenTelemetry trace context of
+	// the request that enqueued a job, see InjectTraceContext
+	MetadataTraceContext = "trace_context"
+
+	// MetadataErrorCode classifies why a failed job failed, such as ErrWorkerTimeout
+	MetadataErrorCode = "error_code"
+
+	// MetadataChapters is the JSON list of chapters probed from the input
+	MetadataChapters = "chapters"
+
+	// MetadataSubtitleTracks is the JSON list of subtitle streams probed from the input
+	MetadataSubtitleTracks = "subtitle_tracks"
+
+	// MetadataAttachedImages is the JSON list of cover art streams probed from the input
+	MetadataAttachedImages = "attached_images"
+
+	// MetadataSourceFrameRate is the frame rate probed from the input, in frames per second
+	MetadataSourceFrameRate = "source_frame_rate"
+
+	// MetadataVariableFrameRate is "true" when the input was probed as
+	// variable frame rate
+	MetadataVariableFrameRate = "variable_frame_rate"
+
+	// MetadataUploadID is the ID of the direct upload a job's input was
+	// stored under by the client, see CreateUploadURL
+	MetadataUploadID = "upload_id"
+
+	// MetadataAudioTrackCount is the number of audio streams probed from the
+	// input
+	MetadataAudioTrackCount = "audio_track_count"
+
+	// MetadataAudioLanguages is the JSON-encoded list of language tags of the
+	// input audio streams, "" for untagged streams
+	MetadataAudioLanguages = "audio_languages"
+
+	// MetadataStatusHistory is the JSON-encoded list of status transitions
+	// of a job, see JobStateMachine
+	MetadataStatusHistory = "status_history"
+
+	// MetadataPriorityLane is the priority lane a job was routed to, see
+	// LaneQueue
+	MetadataPriorityLane = "priority_lane"
+)
+
+// reservedMetadataKeys are written by the server while it handles a job.
+// Clients cannot set them, see StripReservedMetadata.
+var reservedMetadataKeys = []string{
+	MetadataFFmpegLogPath,
+	MetadataProgress,
+	MetadataJobEvents,
+	MetadataRequestID,
+	MetadataPreset,
+	MetadataOutputDirectory,
+	MetadataManifestPath,
+	MetadataUploadRetries,
+	MetadataFFmpegUserCPUMs,
+	MetadataFFmpegSysCPUMs,
+	MetadataFFmpegMaxRSSKB,
+	MetadataLoudnessInputI,
+	MetadataLoudnessInputTP,
+	MetadataLoudnessInputLRA,
+	MetadataLoudnessInputThresh,
+	MetadataLoudnessTargetOffset,
+	MetadataLocalInputPath,
+	MetadataInputChecksum,
+	MetadataTraceContext,
+	MetadataErrorCode,
+	MetadataChapters,
+	MetadataSubtitleTracks,
+	MetadataAttachedImages,
+	MetadataSourceFrameRate,
+	MetadataVariableFrameRate,
+	MetadataUploadID,
+	MetadataAudioTrackCount,
+	MetadataAudioLanguages,
+	MetadataStatusHistory,
+	MetadataPriorityLane,
+	MetadataRetryCount,
+	MetadataResubmitCount,
+}
+
+// StripReservedMetadata removes the keys only the server writes from
+// client-supplied metadata and returns the keys it removed
+func StripReservedMetadata(metadata map[string]string) []string {
+	var stripped []string
+	for _, key := range reservedMetadataKeys {
+		if _, ok := metadata[key]; ok {
+			delete(metadata, key)
+			stripped = append(stripped, key)
+		}
+	}
+	return stripped
+}
+
+// VideoCodec returns the output video codec requested for the job, if any
+func (j *Job) VideoCodec() string {
+	return j.Metadata[MetadataVideoCodec]
+}
+
+// LocalInputPath returns the file FFmpeg reads the input from, which is the
+// downloaded copy of a remote input when there is one
+func (j *Job) LocalInputPath() string {
+	if path := j.Metadata[MetadataLocalInputPath]; path != "" {
+		return path
+	}
+	return j.InputPath
+}
@@ -0,0 +1,29 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripReservedMetadata(t *testing.T) {
+	metadata := map[string]string{
+		MetadataLocalInputPath: "/etc/passwd",
+		MetadataUploadID:       "../../secret",
+		MetadataStatusHistory:  "[]",
+		MetadataTenantID:       "acme",
+		MetadataQueueName:      "low",
+	}
+
+	stripped := StripReservedMetadata(metadata)
+	want := []string{MetadataLocalInputPath, MetadataUploadID, MetadataStatusHistory}
+	if !reflect.DeepEqual(stripped, want) {
+		t.Errorf("StripReservedMetadata() = %v, want %v", stripped, want)
+	}
+	if len(metadata) != 2 || metadata[MetadataTenantID] != "acme" || metadata[MetadataQueueName] != "low" {
+		t.Errorf("metadata = %v, want only the client keys left", metadata)
+	}
+
+	if stripped := StripReservedMetadata(nil); stripped != nil {
+		t.Errorf("StripReservedMetadata(nil) = %v, want nil", stripped)
+	}
+}
@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+)
+
+// MigrateResult counts the outcome of a queue migration
+type MigrateResult struct {
+	// Migrated jobs were enqueued in the destination and cancelled in the source
+	Migrated int
+	// Skipped jobs left the queued state before they were moved, such as
+	// jobs a worker picked up during the migration
+	Skipped int
+	// Failed jobs could not be enqueued in the destination, or were enqueued
+	// but could not be cancelled in the source and now exist in both
+	Failed int
+	// Errors describes every failure
+	Errors []error
+}
+
+// Migrate moves the queued jobs of src to dst, batchSize jobs at a time.
+// Each batch is enqueued in dst with BulkEnqueue, atomically if dst supports
+// it, and its jobs are then cancelled in src. Jobs in other states stay in
+// src. The returned error reports a failure to list the jobs of src; per
+// job failures are recorded in the result.
+func Migrate(ctx context.Context, src, dst Queue, batchSize int) (*MigrateResult, error) {
+	return migrate(ctx, src, dst, batchSize, false)
+}
+
+// MigrateDryRun reports what Migrate would do without changing either queue.
+// Every job that is still queued counts as migrated.
+func MigrateDryRun(ctx context.Context, src Queue, batchSize int) (*MigrateResult, error) {
+	return migrate(ctx, src, nil, batchSize, true)
+}
+
+func migrate(ctx context.Context, src, dst Queue, batchSize int, dryRun bool) (*MigrateResult, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive")
+	}
+
+	// Collect the jobs first, as cancelling them changes the queued listing
+	var queued []*Job
+	for offset := 0; ; offset += batchSize {
+		jobs, total, err := src.ListJobs(ctx, JobStatusQueued, batchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+		}
+		queued = append(queued, jobs...)
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+
+	result := &MigrateResult{}
+	for start := 0; start < len(queued); start += batchSize {
+		end := start + batchSize
+		if end > len(queued) {
+			end = len(queued)
+		}
+
+		// Leave out jobs that were dequeued or cancelled since the listing
+		var batch []*Job
+		for _, listed := range queued[start:end] {
+			job, err := src.GetJob(ctx, listed.ID)
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Errorf("failed to get job %s: %w", listed.ID, err))
+				continue
+			}
+			if job.Status != JobStatusQueued {
+				result.Skipped++
+				continue
+			}
+			batch = append(batch, job)
+		}
+
+		if len(batch) == 0 {
+			continue
+		}
+		if dryRun {
+			result.Migrated += len(batch)
+			continue
+		}
+
+		if err := BulkEnqueue(ctx, dst, batch); err != nil {
+			result.Failed += len(batch)
+			result.Errors = append(result.Errors, fmt.Errorf("failed to enqueue %d jobs in the destination: %w", len(batch), err))
+			continue
+		}
+
+		for _, job := range batch {
+			if err := src.CancelJob(ctx, job.ID); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Errorf("job %s was enqueued in the destination but not cancelled in the source: %w", job.ID, err))
+				continue
+			}
+			result.Migrated++
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// pickingQueue starts processing the next job, as a worker would, when the
+// first job is read back after the listing
+type pickingQueue struct {
+	*MemoryQueue
+	picked *Job
+}
+
+func (q *pickingQueue) GetJob(ctx context.Context, id string) (*Job, error) {
+	if q.picked == nil {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			return nil, err
+		}
+		job.Status = JobStatusProcessing
+		if err := q.UpdateJob(ctx, job); err != nil {
+			return nil, err
+		}
+		q.picked = job
+	}
+	return q.MemoryQueue.GetJob(ctx, id)
+}
+
+// enqueueMigrationJobs enqueues n jobs named job-0 to job-<n-1>
+func enqueueMigrationJobs(t *testing.T, q Queue, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := q.Enqueue(context.Background(), &Job{ID: fmt.Sprintf("job-%d", i), Priority: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	src := &pickingQueue{MemoryQueue: NewMemoryQueue()}
+	dst := NewMemoryQueue()
+	enqueueMigrationJobs(t, src, 5)
+
+	result, err := Migrate(ctx, src, dst, 2)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	// The job picked up by a worker during the migration stays in the source
+	if result.Migrated != 4 || result.Skipped != 1 || result.Failed != 0 {
+		t.Errorf("Migrate() = %+v, want 4 migrated and 1 skipped", result)
+	}
+
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		if id == src.picked.ID {
+			if job, err := dst.GetJob(ctx, id); err == nil && job != nil {
+				t.Errorf("the picked up job %s was migrated", id)
+			}
+			continue
+		}
+		if job, err := dst.GetJob(ctx, id); err != nil || job.Status != JobStatusQueued || job.Priority != i {
+			t.Errorf("destination job %s = %v, %v; want it queued with priority %d", id, job, err, i)
+		}
+		if job, _ := src.GetJob(ctx, id); job.Status != JobStatusCancelled {
+			t.Errorf("source job %s = %s, want it cancelled", id, job.Status)
+		}
+	}
+	if depth, _ := dst.GetQueueDepth(ctx); depth != 4 {
+		t.Errorf("destination depth = %d, want 4", depth)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	ctx := context.Background()
+	src := NewMemoryQueue()
+	enqueueMigrationJobs(t, src, 3)
+
+	result, err := MigrateDryRun(ctx, src, 2)
+	if err != nil || result.Migrated != 3 {
+		t.Fatalf("MigrateDryRun() = %+v, %v; want 3 migrated", result, err)
+	}
+	if depth, _ := src.GetQueueDepth(ctx); depth != 3 {
+		t.Errorf("source depth = %d after a dry run, want 3", depth)
+	}
+
+	if _, err := MigrateDryRun(ctx, src, 0); err == nil {
+		t.Errorf("MigrateDryRun() with batch size 0 succeeded")
+	}
+}
+
+func TestMigrateFailedBatch(t *testing.T) {
+	ctx := context.Background()
+	src := NewMemoryQueue()
+	enqueueMigrationJobs(t, src, 4)
+	dst := rejectingQueue{Queue: NewMemoryQueue(), reject: "job-1"}
+
+	// The queued listing is ordered by priority, so job-1 is in the second
+	// batch of two with job-0
+	result, err := Migrate(ctx, src, dst, 2)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result.Migrated != 2 || result.Failed != 2 || len(result.Errors) != 1 {
+		t.Errorf("Migrate() = %+v, want the batch with job-1 failed", result)
+	}
+	for _, id := range []string{"job-0", "job-1"} {
+		if job, _ := src.GetJob(ctx, id); job.Status != JobStatusQueued {
+			t.Errorf("source job %s of the failed batch = %s, want it still queued", id, job.Status)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MetadataQueueName selects the queue a job is routed to by MultiQueue
+const MetadataQueueName = "queue_name"
+
+// MultiQueue fans out over several queue backends. Jobs are dequeued from the
+// queues in priority order and enqueued to the queue named in their metadata.
+type MultiQueue struct {
+	names  []string
+	queues []Queue
+
+	mu     sync.RWMutex
+	owners map[string]int
+}
+
+// NewMultiQueue creates a multi-queue from named queues in priority order
+func NewMultiQueue(names []string, queues []Queue) (*MultiQueue, error) {
+	if len(queues) == 0 {
+		return nil, fmt.Errorf("multi queue requires at least one queue")
+	}
+	if len(names) != len(queues) {
+		return nil, fmt.Errorf("multi queue has %d names for %d queues", len(names), len(queues))
+	}
+
+	return &MultiQueue{
+		names:  names,
+		queues: queues,
+		owners: make(map[string]int),
+	}, nil
+}
+
+// Enqueue adds a job to the queue selected by its queue_name metadata
+func (mq *MultiQueue) Enqueue(ctx context.Context, job *Job) error {
+	index, err := mq.route(job)
+	if err != nil {
+		return err
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[MetadataQueueName] = mq.names[index]
+
+	if err := mq.queues[index].Enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	mq.setOwner(job.ID, index)
+	return nil
+}
+
+// Requeue puts a job back in the queue that holds it, so a requeued job
+// keeps its queue or lane
+func (mq *MultiQueue) Requeue(ctx context.Context, job *Job) error {
+	index, err := mq.ownerIndex(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		return fmt.Errorf("job not found: %s", job.ID)
+	}
+	if err := Requeue(ctx, mq.queues[index], job); err != nil {
+		return err
+	}
+
+	mq.setOwner(job.ID, index)
+	return nil
+}
+
+// Dequeue returns the first available job, polling queues in priority order
+func (mq *MultiQueue) Dequeue(ctx context.Context) (*Job, error) {
+	var errs []error
+	for i, q := range mq.queues {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("queue %s: %w", mq.names[i], err))
+			continue
+		}
+		if job != nil {
+			mq.setOwner(job.ID, i)
+			return job, nil
+		}
+	}
+
+	if len(errs) == len(mq.queues) {
+		return nil, errors.Join(errs...)
+	}
+	return nil, nil
+}
+
+// GetJob retrieves a job from whichever queue holds it
+func (mq *MultiQueue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	q, err := mq.owner(ctx, jobID)
+	if err != nil || q == nil {
+		return nil, err
+	}
+	return q.GetJob(ctx, jobID)
+}
+
+// UpdateJob updates a job in the queue that holds it. The owner of a
+// finished job is forgotten, as failed jobs are never acknowledged.
+func (mq *MultiQueue) UpdateJob(ctx context.Context, job *Job) error {
+	q, err := mq.owner(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if q == nil {
+		return fmt.Errorf("job not found: %s", job.ID)
+	}
+	if err := q.UpdateJob(ctx, job); err != nil {
+		return err
+	}
+
+	switch job.Status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		mq.forgetOwner(job.ID)
+	}
+	return nil
+}
+
+// Acknowledge acknowledges a job in the queue that holds it
+func (mq *MultiQueue) Acknowledge(ctx context.Context, jobID string) error {
+	q, err := mq.owner(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if q == nil {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if err := q.Acknowledge(ctx, jobID); err != nil {
+		return err
+	}
+
+	mq.forgetOwner(jobID)
+	return nil
+}
+
+// CancelJob cancels a job in the queue that holds it
+func (mq *MultiQueue) CancelJob(ctx context.Context, jobID string) error {
+	q, err := mq.owner(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if q == nil {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+	if err := q.CancelJob(ctx, jobID); err != nil {
+		return err
+	}
+
+	mq.forgetOwner(jobID)
+	return nil
+}
+
+// ListJobs lists jobs across all queues ordered by creation time
+func (mq *MultiQueue) ListJobs(ctx context.Context, status JobStatus, limit, offset int) ([]*Job, int, error) {
+	var all []*Job
+	total := 0
+
+	for i, q := range mq.queues {
+		jobs, count, err := q.ListJobs(ctx, status, limit+offset, 0)
+		if err != nil {
+			return nil, 0, fmt.Errorf("queue %s: %w", mq.names[i], err)
+		}
+		all = append(all, jobs...)
+		total += count
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+
+	if offset >= len(all) {
+		return nil, total, nil
+	}
+	all = all[offset:]
+	if limit > 0 && limit < len(all) {
+		all = all[:limit]
+	}
+
+	return all, total, nil
+}
+
+// GetQueueDepth returns the combined depth of all queues
+func (mq *MultiQueue) GetQueueDepth(ctx context.Context) (int, error) {
+	total := 0
+	for i, q := range mq.queues {
+		depth, err := q.GetQueueDepth(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("queue %s: %w", mq.names[i], err)
+		}
+		total += depth
+	}
+	return total, nil
+}
+
+// Close closes all queues
+func (mq *MultiQueue) Close() error {
+	var errs []error
+	for _, q := range mq.queues {
+		if err := q.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// route returns the index of the queue a job should be enqueued to
+func (mq *MultiQueue) route(job *Job) (int, error) {
+	name := job.Metadata[MetadataQueueName]
+	if name == "" {
+		return 0, nil
+	}
+
+	for i, n := range mq.names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown queue name: %s", name)
+}
+
+// setOwner records which queue holds a job
+func (mq *MultiQueue) setOwner(jobID string, index int) {
+	mq.mu.Lock()
+	mq.owners[jobID] = index
+	mq.mu.Unlock()
+}
+
+// forgetOwner drops the recorded queue of a job; later lookups search all
+// queues again
+func (mq *MultiQueue) forgetOwner(jobID string) {
+	mq.mu.Lock()
+	delete(mq.owners, jobID)
+	mq.mu.Unlock()
+}
+
+// owner returns the queue holding a job, searching all queues if unknown
+func (mq *MultiQueue) owner(ctx context.Context, jobID string) (Queue, error) {
+	index, err := mq.ownerIndex(ctx, jobID)
+	if err != nil || index < 0 {
+		return nil, err
+	}
+	return mq.queues[index], nil
+}
+
+// ownerIndex returns the index of the queue holding a job, or -1 if no queue
+// has it
+func (mq *MultiQueue) ownerIndex(ctx context.Context, jobID string) (int, error) {
+	mq.mu.RLock()
+	index, known := mq.owners[jobID]
+	mq.mu.RUnlock()
+
+	if known {
+		return index, nil
+	}
+
+	for i, q := range mq.queues {
+		job, err := q.GetJob(ctx, jobID)
+		if err != nil {
+			return -1, fmt.Errorf("queue %s: %w", mq.names[i], err)
+		}
+		if job != nil {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
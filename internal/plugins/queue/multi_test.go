@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMultiQueueForgetsFinishedJobs(t *testing.T) {
+	ctx := context.Background()
+	mq, err := NewMultiQueue([]string{"high", "low"}, []Queue{NewMemoryQueue(), NewMemoryQueue()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"fails", "completes", "cancelled"} {
+		job := &Job{ID: id, Metadata: map[string]string{MetadataQueueName: "low"}}
+		if err := mq.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+	jobs := make(map[string]*Job)
+	for range []int{0, 1, 2} {
+		job, err := mq.Dequeue(ctx)
+		if err != nil || job == nil {
+			t.Fatalf("Dequeue() = %v, %v", job, err)
+		}
+		jobs[job.ID] = job
+	}
+
+	failed := jobs["fails"]
+	failed.Status = JobStatusFailed
+	if err := mq.UpdateJob(ctx, failed); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+	completed := jobs["completes"]
+	completed.Status = JobStatusCompleted
+	if err := mq.UpdateJob(ctx, completed); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+	if err := mq.Acknowledge(ctx, "completes"); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if err := mq.CancelJob(ctx, "cancelled"); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+
+	if len(mq.owners) != 0 {
+		t.Errorf("owners = %v, want finished jobs forgotten", mq.owners)
+	}
+	// A forgotten job is still found by searching the queues
+	if job, err := mq.GetJob(ctx, "fails"); err != nil || job == nil || job.Status != JobStatusFailed {
+		t.Errorf("GetJob() = %v, %v; want the failed job", job, err)
+	}
+}
+
+func TestMultiQueueResubmitsToOwner(t *testing.T) {
+	ctx := context.Background()
+	high, low := NewMemoryQueue(), NewMemoryQueue()
+	mq, err := NewMultiQueue([]string{"high", "low"}, []Queue{high, low})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := &Job{ID: "a", Metadata: map[string]string{MetadataQueueName: "low"}}
+	if err := mq.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	job, _ = mq.Dequeue(ctx)
+	job.Status = JobStatusFailed
+	if err := mq.UpdateJob(ctx, job); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	// The failed job's owner is forgotten; resubmitting finds it again
+	resubmitted, err := ResubmitFailedJobs(ctx, mq, ResubmitFilter{})
+	if err != nil || resubmitted != 1 {
+		t.Fatalf("ResubmitFailedJobs() = %d, %v; want 1", resubmitted, err)
+	}
+	if low.Len() != 1 || high.Len() != 0 {
+		t.Errorf("queue lengths high=%d low=%d, want the job back in low", high.Len(), low.Len())
+	}
+}
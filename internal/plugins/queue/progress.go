@@ -0,0 +1,31 @@
+package queue
+
+import "encoding/json"
+
+// ProgressSnapshot contains the encoder statistics reported by FFmpeg
+type ProgressSnapshot struct {
+	Frame   int64   `json:"frame"`
+	FPS     float64 `json:"fps"`
+	Bitrate string  `json:"bitrate"`
+	SizeKB  int64   `json:"size_kb"`
+	TimeStr string  `json:"time_str"`
+	// OutTimeUS is the output timestamp reached in microseconds
+	OutTimeUS int64   `json:"out_time_us,omitempty"`
+	Speed     float64 `json:"speed"`
+	Percent   float64 `json:"percent"`
+}
+
+// LatestProgress decodes the most recent progress snapshot stored in the job metadata
+func (j *Job) LatestProgress() (*ProgressSnapshot, bool) {
+	data, ok := j.Metadata[MetadataProgress]
+	if !ok {
+		return nil, false
+	}
+
+	var snapshot ProgressSnapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, false
+	}
+
+	return &snapshot, true
+}
@@ -0,0 +1,41 @@
+package queue
+
+import "encoding/json"
+
+// MetadataQualityOutputFormats is the JSON-encoded map of quality to output
+// container (hls, mp4 or mkv) requested for the job
+const MetadataQualityOutputFormats = "quality_output_formats"
+
+// QualityOutputFormats returns the output container of each quality
+// requested for the job, or nil if the job uses the configured qualities
+func (j *Job) QualityOutputFormats() map[string]string {
+	data, ok := j.Metadata[MetadataQualityOutputFormats]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var formats map[string]string
+	if err := json.Unmarshal([]byte(data), &formats); err != nil {
+		return nil
+	}
+	return formats
+}
+
+// SetQualityOutputFormats records the qualities to produce for the job and
+// the output container of each
+func (j *Job) SetQualityOutputFormats(formats map[string]string) error {
+	if len(formats) == 0 {
+		delete(j.Metadata, MetadataQualityOutputFormats)
+		return nil
+	}
+
+	data, err := json.Marshal(formats)
+	if err != nil {
+		return err
+	}
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]string)
+	}
+	j.Metadata[MetadataQualityOutputFormats] = string(data)
+	return nil
+}
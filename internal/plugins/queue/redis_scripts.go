@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// dequeueScript pops the highest priority job ID from the pending sorted set
+// (KEYS[1]) and adds it to the processing sorted set (KEYS[2]), scored by the
+// dequeue time in ARGV[1]. Running both in one script means no other server
+// sharing the queue can see the job between the pop and the mark.
+var dequeueScript = redis.NewScript(`
+local popped = redis.call('ZPOPMAX', KEYS[1])
+if #popped == 0 then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[1], popped[1])
+return popped[1]
+`)
+
+// popForProcessing atomically takes the next job ID off the pending set and
+// marks it as processing. It returns "" when the queue is empty. The script
+// is sent with EVALSHA and only loaded when Redis does not have it cached.
+func popForProcessing(ctx context.Context, client redis.Scripter, pendingKey, processingKey string, now time.Time) (string, error) {
+	id, err := dequeueScript.Run(ctx, client, []string{pendingKey, processingKey}, now.Unix()).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
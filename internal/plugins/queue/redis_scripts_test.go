@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestPopForProcessing(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	server.ZAdd("pending", 1, "low")
+	server.ZAdd("pending", 5, "high")
+	now := time.Unix(1700000000, 0)
+
+	for _, want := range []string{"high", "low", ""} {
+		id, err := popForProcessing(ctx, client, "pending", "processing", now)
+		if err != nil || id != want {
+			t.Fatalf("popForProcessing() = %q, %v; want %q", id, err, want)
+		}
+	}
+
+	processing, err := server.ZMembers("processing")
+	if err != nil || len(processing) != 2 {
+		t.Fatalf("processing set = %v, %v; want both jobs", processing, err)
+	}
+	if score, _ := server.ZScore("processing", "high"); score != float64(now.Unix()) {
+		t.Errorf("processing score = %v, want the dequeue time %d", score, now.Unix())
+	}
+}
+
+func TestPopForProcessingConcurrent(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	const jobs = 50
+	for i := 0; i < jobs; i++ {
+		server.ZAdd("pending", float64(i), fmt.Sprintf("job-%d", i))
+	}
+
+	// Two servers sharing the queue pop until it is empty
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[string]int)
+	)
+	for i := 0; i < 2; i++ {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer client.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, err := popForProcessing(ctx, client, "pending", "processing", time.Now())
+				if err != nil {
+					t.Errorf("popForProcessing() error = %v", err)
+					return
+				}
+				if id == "" {
+					return
+				}
+				mu.Lock()
+				seen[id]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != jobs {
+		t.Errorf("popped %d distinct jobs, want %d", len(seen), jobs)
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("job %s popped %d times", id, count)
+		}
+	}
+	if processing, _ := server.ZMembers("processing"); len(processing) != jobs {
+		t.Errorf("processing set has %d jobs, want %d", len(processing), jobs)
+	}
+}
@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// timelineKey is the Redis sorted set of job IDs scored by creation time in
+// Unix seconds. It backs GetJobsByTimeRange for the Redis queue.
+const timelineKey = "flixsrota:jobs:timeline"
+
+// addToTimeline records a job in the timeline. It is called on Enqueue and
+// UpdateJob; ZADD leaves an existing entry in place with the same score, so
+// repeated updates are cheap.
+func addToTimeline(ctx context.Context, client redis.Cmdable, job *Job) error {
+	return client.ZAdd(ctx, timelineKey, &redis.Z{
+		Score:  float64(job.CreatedAt.Unix()),
+		Member: job.ID,
+	}).Err()
+}
+
+// timelineJobIDs returns the IDs of up to limit jobs created from (inclusive)
+// to (exclusive), oldest first. A limit of 0 returns every match. The caller
+// loads the jobs and filters them by status, so limit should allow for jobs
+// that will be filtered out.
+func timelineJobIDs(ctx context.Context, client redis.Cmdable, from, to time.Time, limit int) ([]string, error) {
+	opt := &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: "(" + strconv.FormatInt(to.Unix(), 10),
+	}
+	if limit > 0 {
+		opt.Count = int64(limit)
+	}
+	return client.ZRangeByScore(ctx, timelineKey, opt).Result()
+}
@@ -0,0 +1,19 @@
+package queue
+
+import "context"
+
+// Requeuer is implemented by queues whose Enqueue rejects the IDs they
+// already store. Requeue puts such a job back in the queue.
+type Requeuer interface {
+	Requeue(ctx context.Context, job *Job) error
+}
+
+// Requeue puts a job the queue already stores, such as one taken by Dequeue,
+// back in the queue. Queues that do not implement Requeuer get it through
+// Enqueue.
+func Requeue(ctx context.Context, q Queue, job *Job) error {
+	if r, ok := q.(Requeuer); ok {
+		return r.Requeue(ctx, job)
+	}
+	return q.Enqueue(ctx, job)
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata keys used when resubmitting failed jobs
+const (
+	// MetadataResubmitCount is the number of times a job has been resubmitted
+	MetadataResubmitCount = "resubmit_count"
+
+	// MetadataRetryCount is the number of automatic retries of a job
+	MetadataRetryCount = "retry_count"
+)
+
+// resubmitPageSize is the number of failed jobs listed per page while resubmitting
+const resubmitPageSize = 100
+
+// ResubmitFilter selects the failed jobs to resubmit
+type ResubmitFilter struct {
+	// OlderThan and NewerThan bound the job creation time; zero values are ignored
+	OlderThan time.Time
+	NewerThan time.Time
+
+	// TagFilter matches a metadata entry as "key=value", or any value as "key"
+	TagFilter string
+
+	// ResetRetryCount clears the automatic retry counter of resubmitted jobs
+	ResetRetryCount bool
+}
+
+// Matches reports whether a job satisfies the filter
+func (f ResubmitFilter) Matches(job *Job) bool {
+	if !f.OlderThan.IsZero() && !job.CreatedAt.Before(f.OlderThan) {
+		return false
+	}
+	if !f.NewerThan.IsZero() && !job.CreatedAt.After(f.NewerThan) {
+		return false
+	}
+
+	if f.TagFilter != "" {
+		key, value, hasValue := strings.Cut(f.TagFilter, "=")
+		actual, ok := job.Metadata[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Resubmitter is implemented by queues that can resubmit failed jobs natively
+type Resubmitter interface {
+	ResubmitFailedJobs(ctx context.Context, filter ResubmitFilter) (int, error)
+}
+
+// ResubmitCount returns the number of times the job has been resubmitted
+func (j *Job) ResubmitCount() int {
+	count, _ := strconv.Atoi(j.Metadata[MetadataResubmitCount])
+	return count
+}
+
+// RetryCount returns the number of automatic retries of the job
+func (j *Job) RetryCount() int {
+	count, _ := strconv.Atoi(j.Metadata[MetadataRetryCount])
+	return count
+}
+
+// ResubmitFailedJobs re-enqueues the failed jobs matching the filter and
+// returns the number of jobs resubmitted. Queues implementing Resubmitter
+// handle the operation themselves; otherwise failed jobs are listed and
+// re-enqueued one by one.
+func ResubmitFailedJobs(ctx context.Context, q Queue, filter ResubmitFilter) (int, error) {
+	if r, ok := q.(Resubmitter); ok {
+		return r.ResubmitFailedJobs(ctx, filter)
+	}
+	return ResubmitByListing(ctx, q, filter)
+}
+
+// ResubmitByListing resubmits failed jobs using only the Queue interface
+func ResubmitByListing(ctx context.Context, q Queue, filter ResubmitFilter) (int, error) {
+	// Collect matches first, as resubmitting changes the failed job listing
+	var matched []*Job
+	for offset := 0; ; offset += resubmitPageSize {
+		jobs, total, err := q.ListJobs(ctx, JobStatusFailed, resubmitPageSize, offset)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list failed jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			if filter.Matches(job) {
+				matched = append(matched, job)
+			}
+		}
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+
+	resubmitted := 0
+	for _, job := range matched {
+		if err := PrepareResubmit(job, filter.ResetRetryCount); err != nil {
+			return resubmitted, err
+		}
+		if err := Requeue(ctx, q, job); err != nil {
+			return resubmitted, fmt.Errorf("failed to resubmit job %s: %w", job.ID, err)
+		}
+		resubmitted++
+	}
+
+	return resubmitted, nil
+}
+
+// PrepareResubmit resets a failed job to the queued state and increments its
+// resubmit count. Jobs that are not failed are left unchanged.
+func PrepareResubmit(job *Job, resetRetryCount bool) error {
+	if err := DefaultStateMachine.Transition(job, JobStatusQueued, "resubmitted"); err != nil {
+		return err
+	}
+	count := job.ResubmitCount() + 1
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[MetadataResubmitCount] = strconv.Itoa(count)
+	delete(job.Metadata, MetadataProgress)
+	if resetRetryCount {
+		delete(job.Metadata, MetadataRetryCount)
+	}
+
+	job.Progress = 0.0
+	job.Error = ""
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	return nil
+}
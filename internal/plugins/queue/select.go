@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+)
+
+// Selector is implemented by queues that can hand out any queued job instead
+// of the next one in priority order
+type Selector interface {
+	// DequeueSelected calls pick with every queued job and removes and
+	// returns the one at the index it returns. It returns nil if the queue is
+	// empty or pick returns -1. pick must not modify or keep the jobs and may
+	// be called more than once.
+	DequeueSelected(ctx context.Context, pick func(jobs []*Job) int) (*Job, error)
+}
+
+// DequeueSelected removes and returns the queued job chosen by pick
+func (q *MemoryQueue) DequeueSelected(ctx context.Context, pick func(jobs []*Job) int) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued.Len() == 0 {
+		return nil, nil
+	}
+	i := pick(q.queued)
+	if i < 0 {
+		return nil, nil
+	}
+	return heap.Remove(&q.queued, i).(*Job).Clone(), nil
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"context"
+	"testing"
+)
+
+// pickID returns a pick function choosing the job with the given ID
+func pickID(id string) func(jobs []*Job) int {
+	return func(jobs []*Job) int {
+		for i, job := range jobs {
+			if job.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+}
+
+func TestMemoryQueueDequeueSelected(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	for i, id := range []string{"a", "b", "c"} {
+		if err := q.Enqueue(ctx, &Job{ID: id, Priority: i}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	job, err := q.DequeueSelected(ctx, pickID("b"))
+	if err != nil || job == nil || job.ID != "b" {
+		t.Fatalf("DequeueSelected(b) = %v, %v; want job b", job, err)
+	}
+	if job, err := q.DequeueSelected(ctx, pickID("missing")); job != nil || err != nil {
+		t.Errorf("DequeueSelected() choosing none = %v, %v; want nil, nil", job, err)
+	}
+
+	// The other jobs keep their priority order
+	for _, want := range []string{"c", "a"} {
+		job, err := q.Dequeue(ctx)
+		if err != nil || job == nil || job.ID != want {
+			t.Fatalf("Dequeue() = %v, %v; want job %s", job, err, want)
+		}
+	}
+	if job, err := q.DequeueSelected(ctx, pickID("a")); job != nil || err != nil {
+		t.Errorf("DequeueSelected() of an empty queue = %v, %v; want nil, nil", job, err)
+	}
+}
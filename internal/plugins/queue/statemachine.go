@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned when a job cannot move from its current
+// status to the requested one
+var ErrInvalidTransition = errors.New("invalid job status transition")
+
+// maxStatusHistory is the number of transitions kept on a job; older ones
+// are dropped first
+const maxStatusHistory = 50
+
+// StatusTransition is one recorded status change of a job
+type StatusTransition struct {
+	From   JobStatus `json:"from,omitempty"`
+	To     JobStatus `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// JobStateMachine validates job status changes and records each one in the
+// job's status history
+type JobStateMachine struct {
+	transitions map[JobStatus][]JobStatus
+}
+
+// DefaultStateMachine holds the transitions of the job lifecycle:
+//
+//	queued     -> processing, cancelled
+//	processing -> completed, failed, cancelled, queued (requeued stale jobs)
+//	failed     -> queued (resubmitted jobs)
+//
+// Completed and cancelled jobs are final.
+var DefaultStateMachine = NewJobStateMachine(map[JobStatus][]JobStatus{
+	JobStatusQueued:     {JobStatusProcessing, JobStatusCancelled},
+	JobStatusProcessing: {JobStatusCompleted, JobStatusFailed, JobStatusCancelled, JobStatusQueued},
+	JobStatusFailed:     {JobStatusQueued},
+})
+
+// NewJobStateMachine creates a state machine allowing the listed transitions
+// from each status
+func NewJobStateMachine(transitions map[JobStatus][]JobStatus) *JobStateMachine {
+	return &JobStateMachine{transitions: transitions}
+}
+
+// CanTransition reports whether a job may move from one status to another
+func (m *JobStateMachine) CanTransition(from, to JobStatus) bool {
+	for _, allowed := range m.transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the job to a new status and records the change with its
+// reason. The job is left unchanged when the transition is not allowed.
+func (m *JobStateMachine) Transition(job *Job, to JobStatus, reason string) error {
+	if !m.CanTransition(job.Status, to) {
+		return fmt.Errorf("%w: job %s from %q to %q", ErrInvalidTransition, job.ID, job.Status, to)
+	}
+	m.record(job, to, reason)
+	return nil
+}
+
+// Initialize puts a job that is being stored for the first time in the
+// queued state, whatever status the submitted copy had, as happens when a
+// failed or stale job is enqueued again
+func (m *JobStateMachine) Initialize(job *Job, reason string) {
+	if job.Status == JobStatusQueued {
+		return
+	}
+	m.record(job, JobStatusQueued, reason)
+}
+
+// record sets the status and appends the transition to the job's history
+func (m *JobStateMachine) record(job *Job, to JobStatus, reason string) {
+	history := append(job.StatusHistory(), StatusTransition{
+		From:   job.Status,
+		To:     to,
+		Reason: reason,
+		At:     time.Now(),
+	})
+	if len(history) > maxStatusHistory {
+		history = history[len(history)-maxStatusHistory:]
+	}
+
+	job.Status = to
+	if data, err := json.Marshal(history); err == nil {
+		if job.Metadata == nil {
+			job.Metadata = make(map[string]string)
+		}
+		job.Metadata[MetadataStatusHistory] = string(data)
+	}
+}
+
+// StatusHistory returns the recorded status changes of the job, oldest first
+func (j *Job) StatusHistory() []StatusTransition {
+	data, ok := j.Metadata[MetadataStatusHistory]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var history []StatusTransition
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil
+	}
+	return history
+}
@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultStateMachineTransitions(t *testing.T) {
+	statuses := []JobStatus{JobStatusQueued, JobStatusProcessing, JobStatusCompleted, JobStatusFailed, JobStatusCancelled}
+	allowed := map[[2]JobStatus]bool{
+		{JobStatusQueued, JobStatusProcessing}:    true,
+		{JobStatusQueued, JobStatusCancelled}:     true,
+		{JobStatusProcessing, JobStatusCompleted}: true,
+		{JobStatusProcessing, JobStatusFailed}:    true,
+		{JobStatusProcessing, JobStatusCancelled}: true,
+		{JobStatusProcessing, JobStatusQueued}:    true,
+		{JobStatusFailed, JobStatusQueued}:        true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]JobStatus{from, to}]
+			if got := DefaultStateMachine.CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestJobStateMachineTransition(t *testing.T) {
+	job := &Job{ID: "job-1", Status: JobStatusQueued}
+	if err := DefaultStateMachine.Transition(job, JobStatusProcessing, "picked up"); err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+	if err := DefaultStateMachine.Transition(job, JobStatusCompleted, "finished"); err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+
+	// Completed jobs are final, and a rejected transition changes nothing
+	err := DefaultStateMachine.Transition(job, JobStatusQueued, "requeued")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Transition() of a completed job error = %v, want ErrInvalidTransition", err)
+	}
+	if job.Status != JobStatusCompleted {
+		t.Errorf("Status = %s after a rejected transition, want completed", job.Status)
+	}
+
+	history := job.StatusHistory()
+	if len(history) != 2 || history[0].From != JobStatusQueued || history[0].To != JobStatusProcessing ||
+		history[1].Reason != "finished" || history[1].At.IsZero() {
+		t.Errorf("StatusHistory() = %+v, want the two accepted transitions", history)
+	}
+}
+
+func TestJobStateMachineHistoryLimit(t *testing.T) {
+	job := &Job{ID: "job-1", Status: JobStatusQueued}
+	for i := 0; i < maxStatusHistory; i++ {
+		DefaultStateMachine.Transition(job, JobStatusProcessing, "picked up")
+		DefaultStateMachine.Transition(job, JobStatusQueued, "stale")
+	}
+
+	history := job.StatusHistory()
+	if len(history) != maxStatusHistory {
+		t.Fatalf("StatusHistory() has %d entries, want %d", len(history), maxStatusHistory)
+	}
+	// The oldest entries are dropped
+	if last := history[len(history)-1]; last.To != JobStatusQueued || last.Reason != "stale" {
+		t.Errorf("last transition = %+v, want the latest requeue", last)
+	}
+}
+
+func TestJobStateMachineInitialize(t *testing.T) {
+	job := &Job{ID: "job-1", Status: JobStatusFailed}
+	DefaultStateMachine.Initialize(job, "enqueued")
+	if job.Status != JobStatusQueued || len(job.StatusHistory()) != 1 {
+		t.Errorf("Initialize() = %s with history %+v, want queued", job.Status, job.StatusHistory())
+	}
+
+	// A job that is already queued gets no history entry
+	job = &Job{ID: "job-2", Status: JobStatusQueued}
+	DefaultStateMachine.Initialize(job, "enqueued")
+	if job.StatusHistory() != nil {
+		t.Errorf("Initialize() of a queued job recorded %+v", job.StatusHistory())
+	}
+}
+
+func TestPrepareResubmit(t *testing.T) {
+	job := &Job{ID: "job-1", Status: JobStatusFailed, Progress: 40, Error: "exit status 1",
+		Metadata: map[string]string{MetadataRetryCount: "3", MetadataResubmitCount: "1"}}
+	if err := PrepareResubmit(job, true); err != nil {
+		t.Fatalf("PrepareResubmit() error = %v", err)
+	}
+	if job.Status != JobStatusQueued || job.Progress != 0 || job.Error != "" {
+		t.Errorf("job = %s at %v%% with error %q, want a fresh queued job", job.Status, job.Progress, job.Error)
+	}
+	if job.ResubmitCount() != 2 || job.RetryCount() != 0 {
+		t.Errorf("resubmit count = %d, retry count = %d; want 2 and 0", job.ResubmitCount(), job.RetryCount())
+	}
+
+	completed := &Job{ID: "job-2", Status: JobStatusCompleted}
+	if err := PrepareResubmit(completed, false); !errors.Is(err, ErrInvalidTransition) || completed.ResubmitCount() != 0 {
+		t.Errorf("PrepareResubmit() of a completed job error = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestMemoryQueueCancelFinishedJob(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	if err := q.Enqueue(ctx, &Job{ID: "job-1"}); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := q.Dequeue(ctx)
+	for _, status := range []JobStatus{JobStatusProcessing, JobStatusCompleted} {
+		if err := DefaultStateMachine.Transition(job, status, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.CancelJob(ctx, "job-1"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("CancelJob() of a completed job error = %v, want ErrInvalidTransition", err)
+	}
+	if stored, _ := q.GetJob(ctx, "job-1"); stored.Status != JobStatusCompleted {
+		t.Errorf("Status = %s, want the completed job left alone", stored.Status)
+	}
+}
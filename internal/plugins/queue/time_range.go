@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// timeRangePageSize is the number of jobs listed per page when a queue
+// cannot query by time natively
+const timeRangePageSize = 100
+
+// TimeRangeLister is implemented by queues that can look up jobs by creation
+// time natively
+type TimeRangeLister interface {
+	// GetJobsByTimeRange returns up to limit jobs created from (inclusive) to
+	// (exclusive) with the given status, or any status for an empty status,
+	// oldest first. A limit of 0 returns every match.
+	GetJobsByTimeRange(ctx context.Context, from, to time.Time, status JobStatus, limit int) ([]*Job, error)
+}
+
+// GetJobsByTimeRange returns the jobs created from (inclusive) to (exclusive)
+// with the given status, oldest first. Queues implementing TimeRangeLister
+// answer directly; otherwise every job with the status is listed and
+// filtered.
+func GetJobsByTimeRange(ctx context.Context, q Queue, from, to time.Time, status JobStatus, limit int) ([]*Job, error) {
+	if lister, ok := q.(TimeRangeLister); ok {
+		return lister.GetJobsByTimeRange(ctx, from, to, status, limit)
+	}
+	return GetJobsByTimeRangeByListing(ctx, q, from, to, status, limit)
+}
+
+// GetJobsByTimeRangeByListing finds jobs in a time range using only the Queue
+// interface
+func GetJobsByTimeRangeByListing(ctx context.Context, q Queue, from, to time.Time, status JobStatus, limit int) ([]*Job, error) {
+	var matched []*Job
+	for offset := 0; ; offset += timeRangePageSize {
+		jobs, total, err := q.ListJobs(ctx, status, timeRangePageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		matched = append(matched, filterTimeRange(jobs, from, to)...)
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+
+	return sortAndLimit(matched, limit), nil
+}
+
+// GetJobsByTimeRange returns the jobs created in the time range
+func (q *MemoryQueue) GetJobsByTimeRange(ctx context.Context, from, to time.Time, status JobStatus, limit int) ([]*Job, error) {
+	q.mu.Lock()
+	var matched []*Job
+	for _, job := range q.jobs {
+		if (status == "" || job.Status == status) && inTimeRange(job, from, to) {
+			matched = append(matched, job.Clone())
+		}
+	}
+	q.mu.Unlock()
+
+	return sortAndLimit(matched, limit), nil
+}
+
+// GetJobsByTimeRange returns the jobs created in the time range across all
+// queues
+func (mq *MultiQueue) GetJobsByTimeRange(ctx context.Context, from, to time.Time, status JobStatus, limit int) ([]*Job, error) {
+	var all []*Job
+	for i, q := range mq.queues {
+		jobs, err := GetJobsByTimeRange(ctx, q, from, to, status, limit)
+		if err != nil {
+			return nil, fmt.Errorf("queue %s: %w", mq.names[i], err)
+		}
+		all = append(all, jobs...)
+	}
+
+	return sortAndLimit(all, limit), nil
+}
+
+// inTimeRange reports whether a job was created from (inclusive) to (exclusive)
+func inTimeRange(job *Job, from, to time.Time) bool {
+	return !job.CreatedAt.Before(from) && job.CreatedAt.Before(to)
+}
+
+// filterTimeRange returns the jobs created in the time range
+func filterTimeRange(jobs []*Job, from, to time.Time) []*Job {
+	var matched []*Job
+	for _, job := range jobs {
+		if inTimeRange(job, from, to) {
+			matched = append(matched, job)
+		}
+	}
+	return matched
+}
+
+// sortAndLimit orders jobs by creation time and keeps the first limit, or
+// all of them for a limit of 0
+func sortAndLimit(jobs []*Job, limit int) []*Job {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	if limit > 0 && limit < len(jobs) {
+		jobs = jobs[:limit]
+	}
+	return jobs
+}
@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// listingQueue hides the TimeRangeLister implementation of a queue, so
+// GetJobsByTimeRange falls back to listing
+type listingQueue struct {
+	Queue
+}
+
+func TestGetJobsByTimeRange(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	multi, err := NewMultiQueue([]string{"only"}, []Queue{NewMemoryQueue()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queues := map[string]Queue{
+		"memory":  NewMemoryQueue(),
+		"multi":   multi,
+		"listing": listingQueue{NewMemoryQueue()},
+	}
+	for name, q := range queues {
+		t.Run(name, func(t *testing.T) {
+			// One job an hour, the second one completed
+			for i := 0; i < 4; i++ {
+				job := &Job{ID: fmt.Sprintf("job-%d", i), CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+				if err := q.Enqueue(ctx, job); err != nil {
+					t.Fatalf("Enqueue() error = %v", err)
+				}
+			}
+			completed, _ := q.GetJob(ctx, "job-2")
+			completed.Status = JobStatusCompleted
+			if err := q.UpdateJob(ctx, completed); err != nil {
+				t.Fatalf("UpdateJob() error = %v", err)
+			}
+
+			tests := []struct {
+				name     string
+				from, to time.Time
+				status   JobStatus
+				limit    int
+				want     []string
+			}{
+				{name: "range", from: base.Add(time.Hour), to: base.Add(3 * time.Hour), want: []string{"job-1", "job-2"}},
+				{name: "limit", from: base, to: base.Add(24 * time.Hour), limit: 3, want: []string{"job-0", "job-1", "job-2"}},
+				{name: "status", from: base, to: base.Add(24 * time.Hour), status: JobStatusCompleted, want: []string{"job-2"}},
+				{name: "empty", from: base.Add(-time.Hour), to: base, want: nil},
+			}
+			for _, tt := range tests {
+				jobs, err := GetJobsByTimeRange(ctx, q, tt.from, tt.to, tt.status, tt.limit)
+				if err != nil {
+					t.Fatalf("%s: GetJobsByTimeRange() error = %v", tt.name, err)
+				}
+				var got []string
+				for _, job := range jobs {
+					got = append(got, job.ID)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("%s: GetJobsByTimeRange() = %v, want %v", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineJobIDs(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 3; i >= 0; i-- {
+		job := &Job{ID: fmt.Sprintf("job-%d", i), CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		if err := addToTimeline(ctx, client, job); err != nil {
+			t.Fatalf("addToTimeline() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		limit    int
+		want     []string
+	}{
+		{name: "range", from: base.Add(time.Hour), to: base.Add(3 * time.Hour), want: []string{"job-1", "job-2"}},
+		{name: "limit", from: base, to: base.Add(24 * time.Hour), limit: 2, want: []string{"job-0", "job-1"}},
+		{name: "empty", from: base.Add(-time.Hour), to: base, want: []string{}},
+	}
+	for _, tt := range tests {
+		got, err := timelineJobIDs(ctx, client, tt.from, tt.to, tt.limit)
+		if err != nil {
+			t.Fatalf("%s: timelineJobIDs() error = %v", tt.name, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: timelineJobIDs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
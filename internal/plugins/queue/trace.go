@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of the queue spans
+const tracerName = "github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+
+// TracingQueue wraps a queue and carries the OpenTelemetry trace context of
+// each job across it, whatever the adapter
+type TracingQueue struct {
+	Queue
+}
+
+// NewTracingQueue wraps a queue so jobs keep the trace they were enqueued in
+func NewTracingQueue(q Queue) *TracingQueue {
+	return &TracingQueue{Queue: q}
+}
+
+// Enqueue stores the trace context of ctx with the job and adds it to the queue
+func (q *TracingQueue) Enqueue(ctx context.Context, job *Job) error {
+	InjectTraceContext(ctx, job)
+	return q.Queue.Enqueue(ctx, job)
+}
+
+// Requeue puts a stored job back in the wrapped queue, with the trace
+// context of ctx if it has one
+func (q *TracingQueue) Requeue(ctx context.Context, job *Job) error {
+	InjectTraceContext(ctx, job)
+	return Requeue(ctx, q.Queue, job)
+}
+
+// Dequeue takes the next job off the queue and records the time it waited as
+// a span of the trace it was enqueued in
+func (q *TracingQueue) Dequeue(ctx context.Context) (*Job, error) {
+	job, err := q.Queue.Dequeue(ctx)
+	if err != nil || job == nil {
+		return job, err
+	}
+
+	traceQueueWait(ctx, job)
+	return job, nil
+}
+
+// ResubmitFailedJobs resubmits failed jobs with the wrapped queue when it
+// implements Resubmitter
+func (q *TracingQueue) ResubmitFailedJobs(ctx context.Context, filter ResubmitFilter) (int, error) {
+	return ResubmitFailedJobs(ctx, q.Queue, filter)
+}
+
+// InjectTraceContext stores the trace context of ctx in the job metadata with
+// the global propagator, so the job's processing joins the enqueuing trace.
+// A job requeued outside any trace keeps the trace context it has.
+func InjectTraceContext(ctx context.Context, job *Job) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	job.Metadata[MetadataTraceContext] = string(data)
+}
+
+// ExtractTraceContext returns ctx with the trace context stored in the job
+// metadata, or ctx unchanged if the job has none
+func ExtractTraceContext(ctx context.Context, job *Job) context.Context {
+	value := job.Metadata[MetadataTraceContext]
+	if value == "" {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{}
+	if err := json.Unmarshal([]byte(value), &carrier); err != nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// traceQueueWait records the time a dequeued job spent in the queue since it
+// was created as a span of its trace, and makes it the parent of the job's
+// processing
+func traceQueueWait(ctx context.Context, job *Job) {
+	if job.Metadata[MetadataTraceContext] == "" {
+		return
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ExtractTraceContext(ctx, job), "queue.wait",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithTimestamp(job.CreatedAt))
+	span.End()
+	InjectTraceContext(ctx, job)
+}
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// withTestSpan returns a context carrying a sampled remote span, as the
+// gRPC handler of a traced request would have, and installs the W3C trace
+// context propagator for the test
+func withTestSpan(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc), sc
+}
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	ctx, sc := withTestSpan(t)
+
+	job := &Job{ID: "a"}
+	InjectTraceContext(ctx, job)
+	if job.Metadata[MetadataTraceContext] == "" {
+		t.Fatalf("InjectTraceContext() stored no trace context")
+	}
+
+	got := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), job))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Errorf("ExtractTraceContext() = %s/%s, want %s/%s", got.TraceID(), got.SpanID(), sc.TraceID(), sc.SpanID())
+	}
+
+	// Requeuing outside a trace keeps the original trace context
+	stored := job.Metadata[MetadataTraceContext]
+	InjectTraceContext(context.Background(), job)
+	if job.Metadata[MetadataTraceContext] != stored {
+		t.Errorf("InjectTraceContext() without a span replaced the trace context")
+	}
+
+	if ctx := ExtractTraceContext(context.Background(), &Job{}); trace.SpanContextFromContext(ctx).IsValid() {
+		t.Errorf("ExtractTraceContext() of a job without trace context returned a span")
+	}
+}
+
+// fifoQueue stores copies of its jobs in a slice, as a queue adapter stores
+// serialized jobs
+type fifoQueue struct {
+	Queue
+	jobs []*Job
+}
+
+func (q *fifoQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.jobs = append(q.jobs, job.Clone())
+	return nil
+}
+
+func (q *fifoQueue) Dequeue(ctx context.Context) (*Job, error) {
+	if len(q.jobs) == 0 {
+		return nil, nil
+	}
+	job := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	return job, nil
+}
+
+func TestTracingQueue(t *testing.T) {
+	ctx, sc := withTestSpan(t)
+	q := NewTracingQueue(&fifoQueue{})
+
+	if err := q.Enqueue(ctx, &Job{ID: "traced"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := q.Enqueue(context.Background(), &Job{ID: "untraced"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	// Another server dequeues the job without the request's context
+	for i := 0; i < 2; i++ {
+		job, err := q.Dequeue(context.Background())
+		if err != nil || job == nil {
+			t.Fatalf("Dequeue() = %v, %v; want a job", job, err)
+		}
+
+		got := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), job))
+		switch job.ID {
+		case "traced":
+			if got.TraceID() != sc.TraceID() {
+				t.Errorf("dequeued job has trace %s, want %s", got.TraceID(), sc.TraceID())
+			}
+		case "untraced":
+			if got.IsValid() {
+				t.Errorf("untraced job has trace context %s", job.Metadata[MetadataTraceContext])
+			}
+		}
+	}
+
+	if job, err := q.Dequeue(context.Background()); job != nil || err != nil {
+		t.Errorf("Dequeue() of an empty queue = %v, %v; want nil, nil", job, err)
+	}
+}
@@ -0,0 +1,40 @@
+package queue
+
+import "encoding/json"
+
+// MetadataRequiredWorkerLabels is the JSON-encoded map of labels a worker must
+// have to run the job
+const MetadataRequiredWorkerLabels = "required_worker_labels"
+
+// RequiredWorkerLabels returns the labels a worker must have to run the job,
+// or nil if any worker may run it
+func (j *Job) RequiredWorkerLabels() map[string]string {
+	data, ok := j.Metadata[MetadataRequiredWorkerLabels]
+	if !ok || data == "" {
+		return nil
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return nil
+	}
+	return labels
+}
+
+// SetRequiredWorkerLabels records the labels a worker must have to run the job
+func (j *Job) SetRequiredWorkerLabels(labels map[string]string) error {
+	if len(labels) == 0 {
+		delete(j.Metadata, MetadataRequiredWorkerLabels)
+		return nil
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	if j.Metadata == nil {
+		j.Metadata = make(map[string]string)
+	}
+	j.Metadata[MetadataRequiredWorkerLabels] = string(data)
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ConnectionTester is implemented by storage backends with a lightweight
+// liveness probe, such as an S3 HeadBucket
+type ConnectionTester interface {
+	// TestConnection returns an error if the storage backend is unreachable
+	TestConnection(ctx context.Context) error
+}
+
+// TestConnection probes a storage backend. Backends without a probe of their
+// own are tested by listing a single file.
+func TestConnection(ctx context.Context, s Storage) error {
+	if tester, ok := s.(ConnectionTester); ok {
+		return tester.TestConnection(ctx)
+	}
+	_, _, err := ListFilesPaginated(ctx, s, "", "", 1)
+	return err
+}
+
+// TestConnection checks that the base path exists and is a directory
+func (s *LocalStorage) TestConnection(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	info, err := os.Stat(s.basePath)
+	if err != nil {
+		return fmt.Errorf("base path unavailable: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("base path %s is not a directory", s.basePath)
+	}
+	return nil
+}
+
+// TestConnection probes the wrapped storage backend
+func (q *QuotaEnforcingStorage) TestConnection(ctx context.Context) error {
+	return TestConnection(ctx, q.Storage)
+}
+
+// TestConnection probes the wrapped storage backend
+func (c *ReadThroughCache) TestConnection(ctx context.Context) error {
+	return TestConnection(ctx, c.Storage)
+}
+
+// TestConnection probes every backend and fails only if none is reachable,
+// since reads fail over to any of them
+func (fs *FallbackStorage) TestConnection(ctx context.Context) error {
+	var errs []error
+	for i, backend := range fs.backends {
+		err := TestConnection(ctx, backend)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("backend %d: %w", i, err))
+	}
+	return errors.Join(errs...)
+}
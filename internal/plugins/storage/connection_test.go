@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// listingStorage is a backend without a probe of its own, whose ListFiles
+// fails with err
+type listingStorage struct {
+	Storage
+	err error
+}
+
+func (s listingStorage) ListFiles(ctx context.Context, prefix string) ([]string, error) {
+	return nil, s.err
+}
+
+func TestTestConnection(t *testing.T) {
+	ctx := context.Background()
+	unreachable := errors.New("connection refused")
+
+	if err := TestConnection(ctx, listingStorage{}); err != nil {
+		t.Errorf("TestConnection() of a listable backend error = %v", err)
+	}
+	if err := TestConnection(ctx, listingStorage{err: unreachable}); !errors.Is(err, unreachable) {
+		t.Errorf("TestConnection() error = %v, want the listing error", err)
+	}
+
+	dir := t.TempDir()
+	local, err := NewLocalStorage(dir, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TestConnection(ctx, local); err != nil {
+		t.Errorf("TestConnection() of local storage error = %v", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := TestConnection(ctx, local); err == nil {
+		t.Errorf("TestConnection() of a removed base path succeeded")
+	}
+}
+
+func TestFallbackStorageTestConnection(t *testing.T) {
+	ctx := context.Background()
+	up := listingStorage{}
+	down := listingStorage{err: errors.New("connection refused")}
+
+	if err := NewFallbackStorage(down, []Storage{up}, false, zap.NewNop()).TestConnection(ctx); err != nil {
+		t.Errorf("TestConnection() with a reachable fallback error = %v", err)
+	}
+
+	err := NewFallbackStorage(down, []Storage{down}, false, zap.NewNop()).TestConnection(ctx)
+	if err == nil || !strings.Contains(err.Error(), "backend 0") || !strings.Contains(err.Error(), "backend 1") {
+		t.Errorf("TestConnection() with every backend down error = %v, want both backends reported", err)
+	}
+}
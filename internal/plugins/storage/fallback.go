@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxFallbackLocations bounds how many files on fallback backends are
+// remembered. Beyond it the least recently recorded are forgotten, and looked
+// up on every backend again.
+const maxFallbackLocations = 10000
+
+// fallbackLocation is a file found on or written to a fallback backend
+type fallbackLocation struct {
+	remotePath string
+	backend    int
+}
+
+// FallbackStorage tries an ordered list of storage backends until one succeeds.
+// The first backend is the primary; the rest are fallbacks which only receive
+// writes when writableFallbacks is set.
+type FallbackStorage struct {
+	backends          []Storage
+	writableFallbacks bool
+	logger            *zap.Logger
+
+	mu           sync.RWMutex
+	maxLocations int
+	order        *list.List // front is most recently recorded
+	locations    map[string]*list.Element
+}
+
+// NewFallbackStorage creates a storage that fails over from primary to fallbacks
+func NewFallbackStorage(primary Storage, fallbacks []Storage, writableFallbacks bool, logger *zap.Logger) *FallbackStorage {
+	return &FallbackStorage{
+		backends:          append([]Storage{primary}, fallbacks...),
+		writableFallbacks: writableFallbacks,
+		logger:            logger,
+		maxLocations:      maxFallbackLocations,
+		order:             list.New(),
+		locations:         make(map[string]*list.Element),
+	}
+}
+
+// Upload uploads to the first writable backend that succeeds
+func (fs *FallbackStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	var errs []error
+	for i, backend := range fs.writableBackends() {
+		err := backend.Upload(ctx, localPath, remotePath)
+		if err == nil {
+			fs.setLocation(remotePath, i)
+			return nil
+		}
+		fs.logger.Warn("Storage backend upload failed, trying next backend",
+			zap.Int("backend", i),
+			zap.String("remote_path", remotePath),
+			zap.Error(err))
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("upload failed on all storage backends: %w", errors.Join(errs...))
+}
+
+// Download downloads from the first backend that has the file
+func (fs *FallbackStorage) Download(ctx context.Context, remotePath, localPath string) error {
+	var errs []error
+	for i, backend := range fs.backends {
+		err := backend.Download(ctx, remotePath, localPath)
+		if err == nil {
+			fs.setLocation(remotePath, i)
+			return nil
+		}
+		fs.logger.Warn("Storage backend download failed, trying next backend",
+			zap.Int("backend", i),
+			zap.String("remote_path", remotePath),
+			zap.Error(err))
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("download failed on all storage backends: %w", errors.Join(errs...))
+}
+
+// Delete deletes the file from every writable backend
+func (fs *FallbackStorage) Delete(ctx context.Context, remotePath string) error {
+	var errs []error
+	deleted := false
+	for i, backend := range fs.writableBackends() {
+		if err := backend.Delete(ctx, remotePath); err != nil {
+			fs.logger.Debug("Storage backend delete failed",
+				zap.Int("backend", i),
+				zap.String("remote_path", remotePath),
+				zap.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		deleted = true
+	}
+
+	fs.setLocation(remotePath, 0)
+
+	if !deleted {
+		return fmt.Errorf("delete failed on all storage backends: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// GetURL returns the URL from the backend where the file was found
+func (fs *FallbackStorage) GetURL(ctx context.Context, remotePath string) (string, error) {
+	if index, known := fs.location(remotePath); known {
+		return fs.backends[index].GetURL(ctx, remotePath)
+	}
+
+	var errs []error
+	for _, backend := range fs.backends {
+		url, err := backend.GetURL(ctx, remotePath)
+		if err == nil {
+			return url, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", fmt.Errorf("file not found on any storage backend: %w", errors.Join(errs...))
+}
+
+// ListFiles lists files from the first backend that responds
+func (fs *FallbackStorage) ListFiles(ctx context.Context, prefix string) ([]string, error) {
+	var errs []error
+	for i, backend := range fs.backends {
+		files, err := backend.ListFiles(ctx, prefix)
+		if err == nil {
+			return files, nil
+		}
+		fs.logger.Warn("Storage backend list failed, trying next backend",
+			zap.Int("backend", i),
+			zap.String("prefix", prefix),
+			zap.Error(err))
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("list failed on all storage backends: %w", errors.Join(errs...))
+}
+
+// writableBackends returns the backends that may receive writes
+func (fs *FallbackStorage) writableBackends() []Storage {
+	if fs.writableFallbacks {
+		return fs.backends
+	}
+	return fs.backends[:1]
+}
+
+// location returns the fallback backend known to hold a file
+func (fs *FallbackStorage) location(remotePath string) (int, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	element, ok := fs.locations[remotePath]
+	if !ok {
+		return 0, false
+	}
+	return element.Value.(*fallbackLocation).backend, true
+}
+
+// setLocation records which fallback backend holds a file, forgetting the
+// oldest records beyond the limit; files on the primary are not tracked
+// since it is always tried first
+func (fs *FallbackStorage) setLocation(remotePath string, index int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if element, ok := fs.locations[remotePath]; ok {
+		fs.order.Remove(element)
+		delete(fs.locations, remotePath)
+	}
+	if index == 0 {
+		return
+	}
+
+	fs.locations[remotePath] = fs.order.PushFront(&fallbackLocation{remotePath: remotePath, backend: index})
+	for fs.order.Len() > fs.maxLocations {
+		oldest := fs.order.Back()
+		fs.order.Remove(oldest)
+		delete(fs.locations, oldest.Value.(*fallbackLocation).remotePath)
+	}
+}
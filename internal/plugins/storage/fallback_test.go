@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// urlStorage is a backend serving URLs under base, whose uploads fail with err
+type urlStorage struct {
+	Storage
+	base string
+	err  error
+}
+
+func (s urlStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	return s.err
+}
+
+func (s urlStorage) Delete(ctx context.Context, remotePath string) error {
+	return s.err
+}
+
+func (s urlStorage) GetURL(ctx context.Context, remotePath string) (string, error) {
+	return s.base + remotePath, nil
+}
+
+func TestFallbackStorageLocations(t *testing.T) {
+	ctx := context.Background()
+	primary := urlStorage{base: "primary/", err: errors.New("primary down")}
+	fs := NewFallbackStorage(primary, []Storage{urlStorage{base: "fallback/"}}, true, zap.NewNop())
+	fs.maxLocations = 2
+
+	for i := 0; i < 3; i++ {
+		if err := fs.Upload(ctx, "in.mp4", fmt.Sprintf("out-%d.mp4", i)); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+	}
+
+	// Only the most recent fallback uploads are remembered
+	if len(fs.locations) != 2 || fs.order.Len() != 2 {
+		t.Fatalf("%d locations tracked, want 2", len(fs.locations))
+	}
+	want := map[string]string{
+		"out-0.mp4": "primary/out-0.mp4",
+		"out-1.mp4": "fallback/out-1.mp4",
+		"out-2.mp4": "fallback/out-2.mp4",
+	}
+	for remotePath, wantURL := range want {
+		if url, err := fs.GetURL(ctx, remotePath); err != nil || url != wantURL {
+			t.Errorf("GetURL(%s) = %s, %v; want %s", remotePath, url, err, wantURL)
+		}
+	}
+
+	// A deleted file is forgotten
+	if err := fs.Delete(ctx, "out-2.mp4"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, known := fs.location("out-2.mp4"); known || fs.order.Len() != 1 {
+		t.Errorf("location of a deleted file is still tracked")
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ListFilesPaginated returns a page of files whose path starts with prefix.
+// Local storage has no native cursor, so the sorted walk is paged with
+// pageToken as an index into it.
+func (s *LocalStorage) ListFilesPaginated(ctx context.Context, prefix string, pageToken string, pageSize int) ([]string, string, error) {
+	var files []string
+
+	err := filepath.WalkDir(s.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || isTagsSidecar(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, prefix) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+
+	sort.Strings(files)
+	return paginate(files, pageToken, pageSize)
+}
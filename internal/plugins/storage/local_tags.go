@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tagsSidecarSuffix is appended to a file name for the sidecar holding its tags
+const tagsSidecarSuffix = ".tags.json"
+
+// isTagsSidecar reports whether a file name is a tags sidecar
+func isTagsSidecar(name string) bool {
+	return strings.HasSuffix(name, tagsSidecarSuffix)
+}
+
+// tagsSidecarPath returns the sidecar file for an object and the object file
+func (s *LocalStorage) tagsSidecarPath(remotePath string) (string, string) {
+	file := filepath.Join(s.basePath, cleanRelPath(remotePath))
+	return file + tagsSidecarSuffix, file
+}
+
+// TagObject writes the tags of a file to its .tags.json sidecar
+func (s *LocalStorage) TagObject(ctx context.Context, remotePath string, tags map[string]string) error {
+	sidecar, file := s.tagsSidecarPath(remotePath)
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to encode object tags: %w", err)
+	}
+
+	// Replace the sidecar atomically so readers never see a partial file
+	tmp := sidecar + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write object tags: %w", err)
+	}
+	if err := os.Rename(tmp, sidecar); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write object tags: %w", err)
+	}
+
+	return nil
+}
+
+// GetObjectTags reads the tags of a file from its .tags.json sidecar. A file
+// without a sidecar has no tags.
+func (s *LocalStorage) GetObjectTags(ctx context.Context, remotePath string) (map[string]string, error) {
+	sidecar, file := s.tagsSidecarPath(remotePath)
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read object tags: %w", err)
+	}
+
+	tags := make(map[string]string)
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("invalid object tags: %w", err)
+	}
+	return tags, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+)
+
+// ffmpegLogDir is the subdirectory of the temp path holding FFmpeg job logs,
+// which have their own retention period
+const ffmpegLogDir = "logs"
+
+// TempDir returns the temp path
+func (s *LocalStorage) TempDir() string {
+	return s.tempPath
+}
+
+// PurgeTempFiles deletes files under the temp path that were last modified
+// more than maxAge ago. Files that cannot be deleted are skipped.
+func (s *LocalStorage) PurgeTempFiles(ctx context.Context, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	logDir := filepath.Join(s.tempPath, ffmpegLogDir)
+
+	var deleted int
+	err := filepath.WalkDir(s.tempPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path == logDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return nil
+		}
+
+		deleted++
+		metrics.AddTempBytesFreed(info.Size())
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// cleanRelPath cleans a storage path as if rooted at the base path, so ".."
+// cannot leave it
+func cleanRelPath(path string) string {
+	return filepath.Clean("/" + filepath.FromSlash(path))[1:]
+}
+
+// ListDirectory returns the files and directories directly below path
+func (s *LocalStorage) ListDirectory(ctx context.Context, path string) ([]StorageEntry, error) {
+	rel := cleanRelPath(path)
+
+	dirEntries, err := os.ReadDir(filepath.Join(s.basePath, rel))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]StorageEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if isTagsSidecar(d.Name()) {
+			continue
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+
+		entry := StorageEntry{
+			Name:    d.Name(),
+			Path:    filepath.ToSlash(filepath.Join(rel, d.Name())),
+			IsDir:   d.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if !d.IsDir() {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+	}
+
+	sortEntries(entries)
+	return entries, nil
+}
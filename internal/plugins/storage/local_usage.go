@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// GetUsage returns the total size and number of files whose path starts with prefix
+func (s *LocalStorage) GetUsage(ctx context.Context, prefix string) (Usage, error) {
+	var usage Usage
+
+	err := filepath.WalkDir(s.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || isTagsSidecar(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.Bytes += info.Size()
+		usage.Files++
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Usage{}, err
+	}
+
+	return usage, nil
+}
+
+// CollectMetrics returns the size and number of stored files and the
+// capacity of the filesystem holding the base path
+func (s *LocalStorage) CollectMetrics(ctx context.Context) (*Metrics, error) {
+	usage, err := s.GetUsage(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &Metrics{
+		UsedBytes: usage.Bytes,
+		FileCount: usage.Files,
+	}
+	if fsUsage, err := disk.UsageWithContext(ctx, s.basePath); err == nil {
+		metrics.TotalBytes = int64(fsUsage.Total)
+	}
+
+	return metrics, nil
+}
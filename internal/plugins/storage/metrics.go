@@ -0,0 +1,50 @@
+package storage
+
+import "context"
+
+// Metrics is a point-in-time measurement of a storage backend
+type Metrics struct {
+	// TotalBytes is the capacity of the backend, or 0 if it is unbounded or unknown
+	TotalBytes int64
+	UsedBytes  int64
+	FileCount  int64
+}
+
+// MetricsCollector is implemented by storage backends that can measure
+// their capacity and usage
+type MetricsCollector interface {
+	CollectMetrics(ctx context.Context) (*Metrics, error)
+}
+
+// CollectMetrics measures a storage backend. Backends implementing
+// MetricsCollector measure themselves; otherwise usage is taken from
+// UsageReporter, or by counting ListFiles, and the capacity is unknown.
+func CollectMetrics(ctx context.Context, s Storage) (*Metrics, error) {
+	if collector, ok := s.(MetricsCollector); ok {
+		return collector.CollectMetrics(ctx)
+	}
+
+	if reporter, ok := s.(UsageReporter); ok {
+		usage, err := reporter.GetUsage(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		return &Metrics{UsedBytes: usage.Bytes, FileCount: usage.Files}, nil
+	}
+
+	files, err := s.ListFiles(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{FileCount: int64(len(files))}, nil
+}
+
+// CollectMetrics measures the wrapped storage backend
+func (q *QuotaEnforcingStorage) CollectMetrics(ctx context.Context) (*Metrics, error) {
+	return CollectMetrics(ctx, q.Storage)
+}
+
+// CollectMetrics measures the wrapped storage backend
+func (c *ReadThroughCache) CollectMetrics(ctx context.Context) (*Metrics, error) {
+	return CollectMetrics(ctx, c.Storage)
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Opener is implemented by storage backends that can read files directly
+type Opener interface {
+	// Open returns a reader for the file and its size in bytes
+	Open(ctx context.Context, remotePath string) (io.ReadCloser, int64, error)
+}
+
+// Open returns a reader for a stored file. Backends implementing Opener are
+// read directly; otherwise the file is downloaded to a temporary file in
+// tempDir, which is removed when the reader is closed.
+func Open(ctx context.Context, s Storage, remotePath, tempDir string) (io.ReadCloser, int64, error) {
+	if opener, ok := s.(Opener); ok {
+		return opener.Open(ctx, remotePath)
+	}
+
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, 0, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(tempDir, "download-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmp.Close()
+
+	if err := s.Download(ctx, remotePath, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return nil, 0, err
+	}
+
+	file, err := os.Open(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, 0, fmt.Errorf("failed to open downloaded file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, fmt.Errorf("failed to stat downloaded file: %w", err)
+	}
+
+	return &tempFile{File: file}, info.Size(), nil
+}
+
+// tempFile is a downloaded file removed on Close
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the temporary file
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); removeErr != nil && err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// orphanPageSize is the number of jobs listed per page when collecting the
+// output paths of a queue
+const orphanPageSize = 100
+
+// CleanupResult lists the outcome of CleanupOrphanedFiles
+type CleanupResult struct {
+	// Deleted orphans were removed, or would be in a dry run
+	Deleted []string
+	// Skipped orphans are younger than the minimum age, or their age is
+	// unknown because the backend does not report modification times
+	Skipped []string
+	// Errors describes every orphan that could not be deleted
+	Errors []error
+}
+
+// FindOrphanedFiles returns the files below prefix in s that belong to no
+// job of q. A file belongs to a job when it is the job's output path or lies
+// below it. Files left behind by a crash, such as partial outputs of jobs
+// that were never recorded, are orphaned.
+func FindOrphanedFiles(ctx context.Context, s Storage, q queue.Queue, prefix string) ([]string, error) {
+	outputs, err := jobOutputPaths(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := s.ListFiles(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var orphans []string
+	for _, file := range files {
+		if !ownedByJob(file, outputs) {
+			orphans = append(orphans, file)
+		}
+	}
+	return orphans, nil
+}
+
+// CleanupOrphanedFiles deletes the orphaned files below prefix that were last
+// modified more than minAge before now. With dryRun nothing is deleted and
+// the result lists what would be.
+func CleanupOrphanedFiles(ctx context.Context, s Storage, q queue.Queue, prefix string, minAge time.Duration, dryRun bool) (*CleanupResult, error) {
+	orphans, err := FindOrphanedFiles(ctx, s, q, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	modTimes, err := fileModTimes(ctx, s, orphans)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CleanupResult{}
+	now := time.Now()
+	for _, file := range orphans {
+		modTime := modTimes[file]
+		if modTime.IsZero() || now.Sub(modTime) < minAge {
+			result.Skipped = append(result.Skipped, file)
+			continue
+		}
+		if !dryRun {
+			if err := s.Delete(ctx, file); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", file, err))
+				continue
+			}
+		}
+		result.Deleted = append(result.Deleted, file)
+	}
+	return result, nil
+}
+
+// jobOutputPaths returns the output path of every job in q, without leading
+// or trailing slashes
+func jobOutputPaths(ctx context.Context, q queue.Queue) (map[string]bool, error) {
+	outputs := make(map[string]bool)
+	for offset := 0; ; offset += orphanPageSize {
+		jobs, total, err := q.ListJobs(ctx, "", orphanPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			if output := strings.Trim(job.OutputPath, "/"); output != "" {
+				outputs[output] = true
+			}
+		}
+
+		if len(jobs) == 0 || offset+len(jobs) >= total {
+			break
+		}
+	}
+	return outputs, nil
+}
+
+// ownedByJob reports whether file is one of outputs or lies below one
+func ownedByJob(file string, outputs map[string]bool) bool {
+	for dir := strings.Trim(file, "/"); dir != "." && dir != ""; dir = path.Dir(dir) {
+		if outputs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// fileModTimes returns the modification time of each file. Files of
+// backends that do not report modification times are left out.
+func fileModTimes(ctx context.Context, s Storage, files []string) (map[string]time.Time, error) {
+	entries, err := fileEntries(ctx, s, files)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]time.Time, len(entries))
+	for file, entry := range entries {
+		if !entry.ModTime.IsZero() {
+			result[file] = entry.ModTime
+		}
+	}
+	return result, nil
+}
+
+// fileEntries returns the directory entry of each file, keyed by the file
+// name as given, listing every parent directory once
+func fileEntries(ctx context.Context, s Storage, files []string) (map[string]StorageEntry, error) {
+	byPath := make(map[string]StorageEntry)
+	listed := make(map[string]bool)
+
+	for _, file := range files {
+		dir := path.Dir(strings.Trim(file, "/"))
+		if dir == "." {
+			dir = ""
+		}
+		if listed[dir] {
+			continue
+		}
+		listed[dir] = true
+
+		entries, err := ListDirectory(ctx, s, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir {
+				byPath[entry.Path] = entry
+			}
+		}
+	}
+
+	// Entry paths are relative; map them back to the listed file names
+	result := make(map[string]StorageEntry, len(files))
+	for _, file := range files {
+		if entry, ok := byPath[strings.Trim(file, "/")]; ok {
+			result[file] = entry
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+)
+
+// memoryStorage holds files with their modification times
+type memoryStorage struct {
+	Storage
+	files map[string]time.Time
+}
+
+func (s *memoryStorage) ListFiles(ctx context.Context, prefix string) ([]string, error) {
+	var files []string
+	for file := range s.files {
+		if strings.HasPrefix(file, prefix) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, remotePath string) error {
+	delete(s.files, remotePath)
+	return nil
+}
+
+// timedStorage also reports modification times through ListDirectory
+type timedStorage struct {
+	*memoryStorage
+}
+
+func (s timedStorage) ListDirectory(ctx context.Context, dir string) ([]StorageEntry, error) {
+	var entries []StorageEntry
+	for file, modTime := range s.files {
+		if parent := path.Dir(file); parent == dir || (parent == "." && dir == "") {
+			entries = append(entries, StorageEntry{Name: path.Base(file), Path: file, ModTime: modTime})
+		}
+	}
+	return entries, nil
+}
+
+func newOrphanFixture(t *testing.T) (*memoryStorage, queue.Queue) {
+	t.Helper()
+
+	old := time.Now().Add(-2 * time.Hour)
+	s := &memoryStorage{files: map[string]time.Time{
+		"outputs/job-1/srota.m3u8":        old,
+		"outputs/job-1/stream_0/data0.ts": old,
+		"outputs/job-2.mp4":               old,
+		"outputs/crashed/srota.m3u8":      old,
+		"outputs/partial.mp4":             time.Now(),
+		"inputs/source.mp4":               old,
+	}}
+
+	q := queue.NewMemoryQueue()
+	for i, output := range []string{"/outputs/job-1/", "outputs/job-2.mp4"} {
+		job := &queue.Job{ID: fmt.Sprintf("job-%d", i+1), OutputPath: output}
+		if err := q.Enqueue(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, q
+}
+
+func TestFindOrphanedFiles(t *testing.T) {
+	s, q := newOrphanFixture(t)
+
+	orphans, err := FindOrphanedFiles(context.Background(), s, q, "outputs/")
+	if err != nil {
+		t.Fatalf("FindOrphanedFiles() error = %v", err)
+	}
+	want := []string{"outputs/crashed/srota.m3u8", "outputs/partial.mp4"}
+	if fmt.Sprint(orphans) != fmt.Sprint(want) {
+		t.Errorf("FindOrphanedFiles() = %v, want %v", orphans, want)
+	}
+}
+
+func TestCleanupOrphanedFiles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("dry run", func(t *testing.T) {
+		s, q := newOrphanFixture(t)
+		result, err := CleanupOrphanedFiles(ctx, timedStorage{s}, q, "outputs/", time.Hour, true)
+		if err != nil {
+			t.Fatalf("CleanupOrphanedFiles() error = %v", err)
+		}
+		if fmt.Sprint(result.Deleted) != "[outputs/crashed/srota.m3u8]" || fmt.Sprint(result.Skipped) != "[outputs/partial.mp4]" {
+			t.Errorf("CleanupOrphanedFiles() = %+v, want the old orphan deleted and the recent one skipped", result)
+		}
+		if _, ok := s.files["outputs/crashed/srota.m3u8"]; !ok {
+			t.Errorf("dry run deleted outputs/crashed/srota.m3u8")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s, q := newOrphanFixture(t)
+		result, err := CleanupOrphanedFiles(ctx, timedStorage{s}, q, "outputs/", time.Hour, false)
+		if err != nil || len(result.Deleted) != 1 || len(result.Errors) != 0 {
+			t.Fatalf("CleanupOrphanedFiles() = %+v, %v", result, err)
+		}
+		if _, ok := s.files["outputs/crashed/srota.m3u8"]; ok {
+			t.Errorf("outputs/crashed/srota.m3u8 was not deleted")
+		}
+		for _, kept := range []string{"outputs/partial.mp4", "outputs/job-1/srota.m3u8", "outputs/job-2.mp4", "inputs/source.mp4"} {
+			if _, ok := s.files[kept]; !ok {
+				t.Errorf("%s was deleted", kept)
+			}
+		}
+	})
+
+	t.Run("unknown age", func(t *testing.T) {
+		s, q := newOrphanFixture(t)
+		result, err := CleanupOrphanedFiles(ctx, s, q, "outputs/", time.Hour, false)
+		if err != nil {
+			t.Fatalf("CleanupOrphanedFiles() error = %v", err)
+		}
+		if len(result.Deleted) != 0 || len(result.Skipped) != 2 {
+			t.Errorf("CleanupOrphanedFiles() = %+v, want orphans without a modification time kept", result)
+		}
+	})
+}
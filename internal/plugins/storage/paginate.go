@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// PaginatedLister is implemented by storage backends that can list files a
+// page at a time. Backends with native pagination, such as S3 continuation
+// tokens or GCS page tokens, pass their token through as pageToken.
+type PaginatedLister interface {
+	// ListFilesPaginated returns up to pageSize files with the given prefix
+	// starting at pageToken, and the token of the next page. An empty
+	// nextPageToken means there are no more files.
+	ListFilesPaginated(ctx context.Context, prefix string, pageToken string, pageSize int) (files []string, nextPageToken string, err error)
+}
+
+// ListFilesPaginated lists a page of files from s. Backends implementing
+// PaginatedLister are paged natively; otherwise ListFiles is called and the
+// sorted result is paged with pageToken as an index.
+func ListFilesPaginated(ctx context.Context, s Storage, prefix string, pageToken string, pageSize int) ([]string, string, error) {
+	if lister, ok := s.(PaginatedLister); ok {
+		return lister.ListFilesPaginated(ctx, prefix, pageToken, pageSize)
+	}
+
+	files, err := s.ListFiles(ctx, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Strings(files)
+	return paginate(files, pageToken, pageSize)
+}
+
+// paginate returns the page of sorted files starting at the index encoded in
+// pageToken
+func paginate(files []string, pageToken string, pageSize int) ([]string, string, error) {
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("page size must be positive")
+	}
+
+	start := 0
+	if pageToken != "" {
+		var err error
+		start, err = strconv.Atoi(pageToken)
+		if err != nil || start < 0 {
+			return nil, "", fmt.Errorf("invalid page token: %s", pageToken)
+		}
+	}
+	if start >= len(files) {
+		return []string{}, "", nil
+	}
+
+	end := start + pageSize
+	if end >= len(files) {
+		return files[start:], "", nil
+	}
+
+	return files[start:end], strconv.Itoa(end), nil
+}
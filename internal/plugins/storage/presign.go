@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPresignNotSupported is returned for storage backends that cannot issue
+// pre-signed URLs, such as local storage
+var ErrPresignNotSupported = errors.New("storage backend does not support pre-signed URLs")
+
+// PresignedUploader is implemented by storage backends that can issue URLs a
+// client uploads a file to directly, such as S3 or GCS V4 signed URLs
+type PresignedUploader interface {
+	// PresignPutURL returns a URL that accepts an HTTP PUT of the object at
+	// remotePath until ttl has passed
+	PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error)
+}
+
+// PresignedDownloader is implemented by storage backends that can issue URLs
+// a file is read from directly, such as FFmpeg reading an S3 input
+type PresignedDownloader interface {
+	// PresignGetURL returns a URL that serves an HTTP GET of the object at
+	// remotePath until ttl has passed
+	PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error)
+}
+
+// PresignPutURL returns a pre-signed upload URL for remotePath in s
+func PresignPutURL(ctx context.Context, s Storage, remotePath string, ttl time.Duration) (string, error) {
+	if uploader, ok := s.(PresignedUploader); ok {
+		return uploader.PresignPutURL(ctx, remotePath, ttl)
+	}
+	return "", ErrPresignNotSupported
+}
+
+// PresignPutURL returns an upload URL from the wrapped storage backend. The
+// upload bypasses the quota, since it does not pass through this server.
+func (q *QuotaEnforcingStorage) PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignPutURL(ctx, q.Storage, remotePath, ttl)
+}
+
+// PresignPutURL returns an upload URL from the wrapped storage backend
+func (c *ReadThroughCache) PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignPutURL(ctx, c.Storage, remotePath, ttl)
+}
+
+// PresignPutURL returns an upload URL from the primary backend, where files
+// are looked up unless a fallback stored them
+func (fs *FallbackStorage) PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignPutURL(ctx, fs.backends[0], remotePath, ttl)
+}
+
+// PresignGetURL returns a pre-signed download URL for remotePath in s
+func PresignGetURL(ctx context.Context, s Storage, remotePath string, ttl time.Duration) (string, error) {
+	if downloader, ok := s.(PresignedDownloader); ok {
+		return downloader.PresignGetURL(ctx, remotePath, ttl)
+	}
+	return "", ErrPresignNotSupported
+}
+
+// PresignGetURL returns a download URL from the wrapped storage backend
+func (q *QuotaEnforcingStorage) PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignGetURL(ctx, q.Storage, remotePath, ttl)
+}
+
+// PresignGetURL returns a download URL from the wrapped storage backend,
+// bypassing the cache
+func (c *ReadThroughCache) PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignGetURL(ctx, c.Storage, remotePath, ttl)
+}
+
+// PresignGetURL returns a download URL from the primary backend
+func (fs *FallbackStorage) PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return PresignGetURL(ctx, fs.backends[0], remotePath, ttl)
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// presigningStorage issues URLs of the form <method> https://bucket/<path>
+type presigningStorage struct {
+	Storage
+}
+
+func (presigningStorage) PresignPutURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return "PUT https://bucket/" + remotePath, nil
+}
+
+func (presigningStorage) PresignGetURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	return "GET https://bucket/" + remotePath, nil
+}
+
+func TestPresignPutURL(t *testing.T) {
+	ctx := context.Background()
+	plain := listingStorage{}
+
+	for name, s := range map[string]Storage{
+		"backend":  presigningStorage{},
+		"quota":    NewQuotaEnforcingStorage(presigningStorage{}, 0, 0, nil, zap.NewNop()),
+		"fallback": NewFallbackStorage(presigningStorage{}, []Storage{plain}, false, zap.NewNop()),
+	} {
+		if url, err := PresignPutURL(ctx, s, "uploads/a", time.Minute); err != nil || url != "PUT https://bucket/uploads/a" {
+			t.Errorf("%s: PresignPutURL() = %s, %v", name, url, err)
+		}
+	}
+
+	// The URL comes from the primary backend, where the file is looked up
+	for name, s := range map[string]Storage{
+		"backend":  plain,
+		"fallback": NewFallbackStorage(plain, []Storage{presigningStorage{}}, false, zap.NewNop()),
+	} {
+		if _, err := PresignPutURL(ctx, s, "uploads/a", time.Minute); !errors.Is(err, ErrPresignNotSupported) {
+			t.Errorf("%s: PresignPutURL() error = %v, want ErrPresignNotSupported", name, err)
+		}
+	}
+}
+
+func TestPresignGetURL(t *testing.T) {
+	ctx := context.Background()
+	plain := listingStorage{}
+	cache, err := NewReadThroughCache(presigningStorage{}, t.TempDir(), 1<<20, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache is bypassed, so FFmpeg reads the object from the backend
+	for name, s := range map[string]Storage{
+		"backend":  presigningStorage{},
+		"quota":    NewQuotaEnforcingStorage(presigningStorage{}, 0, 0, nil, zap.NewNop()),
+		"cache":    cache,
+		"fallback": NewFallbackStorage(presigningStorage{}, []Storage{plain}, false, zap.NewNop()),
+	} {
+		if url, err := PresignGetURL(ctx, s, "uploads/a", time.Hour); err != nil || url != "GET https://bucket/uploads/a" {
+			t.Errorf("%s: PresignGetURL() = %s, %v", name, url, err)
+		}
+	}
+
+	for name, s := range map[string]Storage{
+		"backend":  plain,
+		"quota":    NewQuotaEnforcingStorage(plain, 0, 0, nil, zap.NewNop()),
+		"fallback": NewFallbackStorage(plain, []Storage{presigningStorage{}}, false, zap.NewNop()),
+	} {
+		if _, err := PresignGetURL(ctx, s, "uploads/a", time.Hour); !errors.Is(err, ErrPresignNotSupported) {
+			t.Errorf("%s: PresignGetURL() error = %v, want ErrPresignNotSupported", name, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrStorageQuotaExceeded is returned when an upload would exceed the tenant's quota
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// Usage is the storage consumed under a path prefix
+type Usage struct {
+	Bytes int64
+	Files int64
+}
+
+// UsageReporter is implemented by storage backends that can measure their usage
+type UsageReporter interface {
+	// GetUsage returns the total size and number of files under the prefix
+	GetUsage(ctx context.Context, prefix string) (Usage, error)
+}
+
+// QuotaCounter keeps a running usage total per tenant
+type QuotaCounter interface {
+	// Get returns the tenant usage and whether a counter exists for it
+	Get(ctx context.Context, tenantID string) (Usage, bool, error)
+
+	// Set initialises the tenant counter
+	Set(ctx context.Context, tenantID string, usage Usage) error
+
+	// Add adjusts the tenant counter by delta
+	Add(ctx context.Context, tenantID string, delta Usage) error
+
+	// Reset drops the tenant counter so it is recalculated on next use
+	Reset(ctx context.Context, tenantID string) error
+}
+
+// TenantFromPath returns the tenant owning a remote path, which is its first path segment
+func TenantFromPath(remotePath string) string {
+	tenantID, _, _ := strings.Cut(strings.TrimPrefix(path.Clean("/"+remotePath), "/"), "/")
+	return tenantID
+}
+
+// QuotaEnforcingStorage rejects uploads that would take a tenant over its
+// byte or file limits
+type QuotaEnforcingStorage struct {
+	Storage
+
+	maxBytes int64
+	maxFiles int64
+	counter  QuotaCounter
+	logger   *zap.Logger
+}
+
+// NewQuotaEnforcingStorage wraps a storage backend with per-tenant quotas.
+// A limit of 0 is unlimited.
+func NewQuotaEnforcingStorage(s Storage, maxBytes, maxFiles int64, counter QuotaCounter, logger *zap.Logger) *QuotaEnforcingStorage {
+	return &QuotaEnforcingStorage{
+		Storage:  s,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		counter:  counter,
+		logger:   logger,
+	}
+}
+
+// Upload stores a file if the tenant has enough quota left
+func (q *QuotaEnforcingStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat upload: %w", err)
+	}
+
+	tenantID := TenantFromPath(remotePath)
+	usage, err := q.usage(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if q.maxBytes > 0 && usage.Bytes+info.Size() > q.maxBytes {
+		return fmt.Errorf("%w: tenant %s would use %d of %d bytes", ErrStorageQuotaExceeded, tenantID, usage.Bytes+info.Size(), q.maxBytes)
+	}
+	if q.maxFiles > 0 && usage.Files+1 > q.maxFiles {
+		return fmt.Errorf("%w: tenant %s would have %d of %d files", ErrStorageQuotaExceeded, tenantID, usage.Files+1, q.maxFiles)
+	}
+
+	if err := q.Storage.Upload(ctx, localPath, remotePath); err != nil {
+		return err
+	}
+
+	if err := q.counter.Add(ctx, tenantID, Usage{Bytes: info.Size(), Files: 1}); err != nil {
+		q.logger.Warn("Failed to update storage quota counter", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// Delete removes a file and releases its quota
+func (q *QuotaEnforcingStorage) Delete(ctx context.Context, remotePath string) error {
+	tenantID := TenantFromPath(remotePath)
+
+	// Measure the file first so the counter can be decremented exactly
+	var freed *Usage
+	if reporter, ok := q.Storage.(UsageReporter); ok {
+		if usage, err := reporter.GetUsage(ctx, remotePath); err == nil {
+			freed = &usage
+		}
+	}
+
+	if err := q.Storage.Delete(ctx, remotePath); err != nil {
+		return err
+	}
+
+	// Without an exact size or an existing counter, recalculate on next upload
+	_, counted, err := q.counter.Get(ctx, tenantID)
+	if err == nil {
+		if freed != nil && counted {
+			err = q.counter.Add(ctx, tenantID, Usage{Bytes: -freed.Bytes, Files: -freed.Files})
+		} else {
+			err = q.counter.Reset(ctx, tenantID)
+		}
+	}
+	if err != nil {
+		q.logger.Warn("Failed to update storage quota counter", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// usage returns the tenant usage from the counter, initialising it from the
+// backend when no counter exists yet
+func (q *QuotaEnforcingStorage) usage(ctx context.Context, tenantID string) (Usage, error) {
+	usage, ok, err := q.counter.Get(ctx, tenantID)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to read storage quota counter: %w", err)
+	}
+	if ok {
+		return usage, nil
+	}
+
+	if reporter, ok := q.Storage.(UsageReporter); ok {
+		usage, err = reporter.GetUsage(ctx, tenantID+"/")
+		if err != nil {
+			return Usage{}, fmt.Errorf("failed to get storage usage: %w", err)
+		}
+	}
+
+	if err := q.counter.Set(ctx, tenantID, usage); err != nil {
+		q.logger.Warn("Failed to initialise storage quota counter", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// quotaKeyPrefix prefixes the Redis hash holding a tenant's usage
+const quotaKeyPrefix = "flixsrota:storage-quota:"
+
+// RedisQuotaCounter keeps tenant usage in Redis so it is shared between servers
+type RedisQuotaCounter struct {
+	client *redis.Client
+}
+
+// NewRedisQuotaCounter creates a Redis backed quota counter
+func NewRedisQuotaCounter(address, password string, db int) *RedisQuotaCounter {
+	return &RedisQuotaCounter{
+		client: redis.NewClient(&redis.Options{
+			Addr:     address,
+			Password: password,
+			DB:       db,
+		}),
+	}
+}
+
+// Get returns the tenant usage
+func (c *RedisQuotaCounter) Get(ctx context.Context, tenantID string) (Usage, bool, error) {
+	values, err := c.client.HMGet(ctx, quotaKeyPrefix+tenantID, "bytes", "files").Result()
+	if err != nil {
+		return Usage{}, false, err
+	}
+
+	bytes, bytesOK := values[0].(string)
+	files, filesOK := values[1].(string)
+	if !bytesOK || !filesOK {
+		return Usage{}, false, nil
+	}
+
+	var usage Usage
+	if usage.Bytes, err = strconv.ParseInt(bytes, 10, 64); err != nil {
+		return Usage{}, false, err
+	}
+	if usage.Files, err = strconv.ParseInt(files, 10, 64); err != nil {
+		return Usage{}, false, err
+	}
+
+	return usage, true, nil
+}
+
+// Set initialises the tenant usage
+func (c *RedisQuotaCounter) Set(ctx context.Context, tenantID string, usage Usage) error {
+	return c.client.HSet(ctx, quotaKeyPrefix+tenantID, "bytes", usage.Bytes, "files", usage.Files).Err()
+}
+
+// Add adjusts the tenant usage
+func (c *RedisQuotaCounter) Add(ctx context.Context, tenantID string, delta Usage) error {
+	key := quotaKeyPrefix + tenantID
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, "bytes", delta.Bytes)
+		pipe.HIncrBy(ctx, key, "files", delta.Files)
+		return nil
+	})
+	return err
+}
+
+// Reset drops the tenant usage
+func (c *RedisQuotaCounter) Reset(ctx context.Context, tenantID string) error {
+	return c.client.Del(ctx, quotaKeyPrefix+tenantID).Err()
+}
+
+// Close closes the Redis connection
+func (c *RedisQuotaCounter) Close() error {
+	return c.client.Close()
+}
+
+// MemoryQuotaCounter keeps tenant usage in process memory
+type MemoryQuotaCounter struct {
+	mu    sync.Mutex
+	usage map[string]Usage
+}
+
+// NewMemoryQuotaCounter creates an in-process quota counter
+func NewMemoryQuotaCounter() *MemoryQuotaCounter {
+	return &MemoryQuotaCounter{
+		usage: make(map[string]Usage),
+	}
+}
+
+// Get returns the tenant usage
+func (c *MemoryQuotaCounter) Get(ctx context.Context, tenantID string) (Usage, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage, ok := c.usage[tenantID]
+	return usage, ok, nil
+}
+
+// Set initialises the tenant usage
+func (c *MemoryQuotaCounter) Set(ctx context.Context, tenantID string, usage Usage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.usage[tenantID] = usage
+	return nil
+}
+
+// Add adjusts the tenant usage
+func (c *MemoryQuotaCounter) Add(ctx context.Context, tenantID string, delta Usage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage := c.usage[tenantID]
+	usage.Bytes += delta.Bytes
+	usage.Files += delta.Files
+	c.usage[tenantID] = usage
+	return nil
+}
+
+// Reset drops the tenant usage
+func (c *MemoryQuotaCounter) Reset(ctx context.Context, tenantID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.usage, tenantID)
+	return nil
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nikhil0verma/flixsrota/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// cacheTempPrefix prefixes partially written cache files, which are removed
+// when the cache is reopened
+const cacheTempPrefix = ".fill-"
+
+// cacheEntry is a file held in the read-through cache
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// ReadThroughCache keeps downloaded files in a bounded local directory,
+// evicting the least recently used files when it is full. Uploads and
+// deletes go straight to the wrapped backend and invalidate the cached copy.
+type ReadThroughCache struct {
+	Storage
+
+	cachePath string
+	maxBytes  int64
+	logger    *zap.Logger
+
+	mu      sync.Mutex
+	lru     *list.List // front is most recently used
+	entries map[string]*list.Element
+	size    int64
+}
+
+// NewReadThroughCache wraps a storage backend with a disk cache of up to
+// maxBytes in cachePath. Files left in cachePath by a previous run are kept.
+func NewReadThroughCache(s Storage, cachePath string, maxBytes int64, logger *zap.Logger) (*ReadThroughCache, error) {
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &ReadThroughCache{
+		Storage:   s,
+		cachePath: cachePath,
+		maxBytes:  maxBytes,
+		logger:    logger,
+		lru:       list.New(),
+		entries:   make(map[string]*list.Element),
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Download copies a file to localPath, from the cache when it holds the file
+// and otherwise from the wrapped backend, caching it for the next read
+func (c *ReadThroughCache) Download(ctx context.Context, remotePath, localPath string) error {
+	cached, err := c.fetch(ctx, remotePath)
+	if err != nil {
+		return err
+	}
+	if cached == "" {
+		// Not cacheable, such as files larger than the whole cache
+		return c.Storage.Download(ctx, remotePath, localPath)
+	}
+
+	if err := copyFile(cached, localPath); err != nil {
+		// Evicted between the fetch and the copy
+		if os.IsNotExist(err) {
+			return c.Storage.Download(ctx, remotePath, localPath)
+		}
+		return err
+	}
+	return nil
+}
+
+// Open returns a reader for a file, caching it first on a miss
+func (c *ReadThroughCache) Open(ctx context.Context, remotePath string) (io.ReadCloser, int64, error) {
+	cached, err := c.fetch(ctx, remotePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cached != "" {
+		if file, err := os.Open(cached); err == nil {
+			if info, err := file.Stat(); err == nil {
+				return file, info.Size(), nil
+			}
+			file.Close()
+		}
+	}
+
+	return Open(ctx, c.Storage, remotePath, TempDir(c.Storage))
+}
+
+// Upload stores a file and drops any cached copy of the previous version
+func (c *ReadThroughCache) Upload(ctx context.Context, localPath, remotePath string) error {
+	c.evict(cacheKey(remotePath))
+	return c.Storage.Upload(ctx, localPath, remotePath)
+}
+
+// Delete removes a file, or every file below a directory, from the wrapped
+// backend and the cache
+func (c *ReadThroughCache) Delete(ctx context.Context, remotePath string) error {
+	c.evict(cacheKey(remotePath))
+	return c.Storage.Delete(ctx, remotePath)
+}
+
+// Warm caches the given files and returns how many were added. Files already
+// cached are skipped, and files that fail to download are logged and skipped.
+func (c *ReadThroughCache) Warm(ctx context.Context, remotePaths []string) (int, error) {
+	warmed := 0
+	for _, remotePath := range remotePaths {
+		if err := ctx.Err(); err != nil {
+			return warmed, err
+		}
+		if c.cached(cacheKey(remotePath)) != "" {
+			continue
+		}
+
+		cached, err := c.fill(ctx, remotePath)
+		if err != nil {
+			c.logger.Debug("Failed to warm storage cache", zap.String("path", remotePath), zap.Error(err))
+			continue
+		}
+		if cached != "" {
+			warmed++
+		}
+	}
+	return warmed, nil
+}
+
+// fetch returns the cached path of a file, downloading it into the cache on a
+// miss. It returns "" for files too large to cache.
+func (c *ReadThroughCache) fetch(ctx context.Context, remotePath string) (string, error) {
+	if cached := c.cached(cacheKey(remotePath)); cached != "" {
+		metrics.RecordStorageCacheLookup(true)
+		return cached, nil
+	}
+
+	metrics.RecordStorageCacheLookup(false)
+	return c.fill(ctx, remotePath)
+}
+
+// cached returns the cached path of key and marks it as recently used, or ""
+// when it is not cached
+func (c *ReadThroughCache) cached(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return ""
+	}
+	c.lru.MoveToFront(element)
+	return c.filePath(key)
+}
+
+// fill downloads a file from the wrapped backend into the cache. Concurrent
+// misses for the same file each download it; the last one to finish wins.
+// Files the cache cannot hold are reported as too large to cache, so readers
+// fall back to the backend.
+func (c *ReadThroughCache) fill(ctx context.Context, remotePath string) (string, error) {
+	key := cacheKey(remotePath)
+	target := c.filePath(key)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		c.logger.Warn("Failed to create cache directory", zap.String("path", key), zap.Error(err))
+		return "", nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), cacheTempPrefix+"*")
+	if err != nil {
+		c.logger.Warn("Failed to create cache file", zap.String("path", key), zap.Error(err))
+		return "", nil
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := c.Storage.Download(ctx, remotePath, tmp.Name()); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to stat cache file: %w", err)
+	}
+	if info.Size() > c.maxBytes {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		c.logger.Warn("Failed to move file into cache", zap.String("path", key), zap.Error(err))
+		return "", nil
+	}
+	c.add(key, info.Size())
+	c.trim()
+
+	return target, nil
+}
+
+// evict drops key, and every key below it when it is a directory, from the
+// cache
+func (c *ReadThroughCache) evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for entryKey, element := range c.entries {
+		if entryKey == key || strings.HasPrefix(entryKey, key+"/") || key == "" {
+			c.remove(element)
+		}
+	}
+}
+
+// add records a cached file as most recently used, replacing any previous
+// entry for the key. c.mu must be held.
+func (c *ReadThroughCache) add(key string, size int64) {
+	if element, ok := c.entries[key]; ok {
+		c.size -= element.Value.(*cacheEntry).size
+		c.lru.Remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: size})
+	c.size += size
+}
+
+// trim evicts least recently used files until the cache fits. c.mu must be held.
+func (c *ReadThroughCache) trim() {
+	for c.size > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			return
+		}
+		c.remove(oldest)
+	}
+}
+
+// remove deletes a cached file and its entry. c.mu must be held.
+func (c *ReadThroughCache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	if err := os.Remove(c.filePath(entry.key)); err != nil && !os.IsNotExist(err) {
+		c.logger.Warn("Failed to remove cached file", zap.String("path", entry.key), zap.Error(err))
+	}
+	c.lru.Remove(element)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// load indexes the files already in the cache directory, treating the most
+// recently modified as the most recently used
+func (c *ReadThroughCache) load() error {
+	type cachedFile struct {
+		key  string
+		info fs.FileInfo
+	}
+	var files []cachedFile
+
+	err := filepath.WalkDir(c.cachePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), cacheTempPrefix) {
+			os.Remove(p)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.cachePath, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, cachedFile{key: filepath.ToSlash(rel), info: info})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().Before(files[j].info.ModTime())
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, file := range files {
+		c.add(file.key, file.info.Size())
+	}
+	c.trim()
+
+	return nil
+}
+
+// filePath returns where key is stored in the cache directory
+func (c *ReadThroughCache) filePath(key string) string {
+	return filepath.Join(c.cachePath, filepath.FromSlash(key))
+}
+
+// cacheKey normalises a remote path so it cannot point outside the cache
+// directory
+func cacheKey(remotePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+remotePath), "/")
+}
+
+// copyFile copies src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return out.Close()
+}
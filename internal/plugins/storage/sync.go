@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+)
+
+// SyncMode selects the direction in which Sync copies files
+type SyncMode string
+
+const (
+	// SyncPush copies files from the source to the destination
+	SyncPush SyncMode = "push"
+	// SyncPull copies files from the destination to the source
+	SyncPull SyncMode = "pull"
+	// SyncMirror copies files both ways, the newer copy winning
+	SyncMirror SyncMode = "mirror"
+)
+
+// ParseSyncMode returns the sync mode named by mode
+func ParseSyncMode(mode string) (SyncMode, error) {
+	switch SyncMode(mode) {
+	case SyncPush, SyncPull, SyncMirror:
+		return SyncMode(mode), nil
+	}
+	return "", fmt.Errorf("unknown sync mode %q (supported: push, pull, mirror)", mode)
+}
+
+// SyncConflict is a file whose two copies have the same modification time
+// but different sizes, so neither can be taken as the newer one
+type SyncConflict struct {
+	Path            string
+	SourceSize      int64
+	DestinationSize int64
+}
+
+// SyncResult lists the outcome of Sync
+type SyncResult struct {
+	// Pushed files were copied from the source to the destination
+	Pushed []string
+	// Pulled files were copied from the destination to the source
+	Pulled []string
+	// Unchanged files have copies of the same size on both sides
+	Unchanged int
+	// Conflicts were left untouched on both sides
+	Conflicts []SyncConflict
+	// Errors describes every file that could not be copied
+	Errors []error
+}
+
+// syncCopy is a file to copy and its direction
+type syncCopy struct {
+	path string
+	pull bool
+}
+
+// Sync copies the files below prefix between src and dst in the direction
+// of mode, copying up to concurrency files at a time. Files missing on the
+// receiving side are copied; copies of the same size are left alone. In
+// mirror mode the copy with the later modification time wins, and copies of
+// different sizes modified at the same time are reported as conflicts.
+func Sync(ctx context.Context, src, dst Storage, prefix string, mode SyncMode, concurrency int) (*SyncResult, error) {
+	if _, err := ParseSyncMode(string(mode)); err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	srcEntries, err := listEntries(ctx, src, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source: %w", err)
+	}
+	dstEntries, err := listEntries(ctx, dst, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination: %w", err)
+	}
+
+	result := &SyncResult{}
+	copies := planSync(srcEntries, dstEntries, mode, result)
+	if len(copies) == 0 {
+		return result, nil
+	}
+
+	workDir, err := os.MkdirTemp(TempDir(src), "sync-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	work := make(chan syncCopy)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				from, to := src, dst
+				if c.pull {
+					from, to = dst, src
+				}
+				err := copyBetween(ctx, from, to, c.path, workDir)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Errors = append(result.Errors, fmt.Errorf("failed to copy %s: %w", c.path, err))
+				case c.pull:
+					result.Pulled = append(result.Pulled, c.path)
+				default:
+					result.Pushed = append(result.Pushed, c.path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, c := range copies {
+		work <- c
+	}
+	close(work)
+	wg.Wait()
+
+	sort.Strings(result.Pushed)
+	sort.Strings(result.Pulled)
+	return result, nil
+}
+
+// planSync compares the files of both sides and returns the copies to make,
+// recording unchanged files and conflicts in result
+func planSync(srcEntries, dstEntries map[string]StorageEntry, mode SyncMode, result *SyncResult) []syncCopy {
+	paths := make([]string, 0, len(srcEntries)+len(dstEntries))
+	for file := range srcEntries {
+		paths = append(paths, file)
+	}
+	for file := range dstEntries {
+		if _, ok := srcEntries[file]; !ok {
+			paths = append(paths, file)
+		}
+	}
+	sort.Strings(paths)
+
+	var copies []syncCopy
+	for _, file := range paths {
+		srcEntry, inSrc := srcEntries[file]
+		dstEntry, inDst := dstEntries[file]
+
+		switch {
+		case !inDst:
+			if mode != SyncPull {
+				copies = append(copies, syncCopy{path: file})
+			}
+		case !inSrc:
+			if mode != SyncPush {
+				copies = append(copies, syncCopy{path: file, pull: true})
+			}
+		case srcEntry.Size == dstEntry.Size:
+			result.Unchanged++
+		case mode == SyncPush:
+			copies = append(copies, syncCopy{path: file})
+		case mode == SyncPull:
+			copies = append(copies, syncCopy{path: file, pull: true})
+		case srcEntry.ModTime.Equal(dstEntry.ModTime):
+			result.Conflicts = append(result.Conflicts, SyncConflict{
+				Path:            file,
+				SourceSize:      srcEntry.Size,
+				DestinationSize: dstEntry.Size,
+			})
+		default:
+			copies = append(copies, syncCopy{path: file, pull: dstEntry.ModTime.After(srcEntry.ModTime)})
+		}
+	}
+	return copies
+}
+
+// listEntries returns the entry of every file below prefix in s, keyed by
+// its path. Files the backend lists without an entry get an empty one.
+func listEntries(ctx context.Context, s Storage, prefix string) (map[string]StorageEntry, error) {
+	files, err := s.ListFiles(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fileEntries(ctx, s, files)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if _, ok := entries[file]; !ok {
+			entries[file] = StorageEntry{Name: path.Base(file), Path: file}
+		}
+	}
+	return entries, nil
+}
+
+// copyBetween downloads remotePath from one backend to a temp file and
+// uploads it to the same path of the other
+func copyBetween(ctx context.Context, from, to Storage, remotePath, workDir string) error {
+	tmp, err := os.CreateTemp(workDir, "file-*"+path.Ext(remotePath))
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := from.Download(ctx, remotePath, tmp.Name()); err != nil {
+		return err
+	}
+	return to.Upload(ctx, tmp.Name(), remotePath)
+}
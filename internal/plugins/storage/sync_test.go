@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncFile is a file of a treeStorage
+type syncFile struct {
+	data    string
+	modTime time.Time
+}
+
+// treeStorage keeps files in memory and lists them with their sizes and
+// modification times
+type treeStorage struct {
+	Storage
+	tempDir string
+	failOn  string
+
+	mu    sync.Mutex
+	files map[string]syncFile
+}
+
+// newTreeStorage returns a storage holding files, modified at modTime
+func newTreeStorage(t *testing.T, modTime time.Time, files map[string]string) *treeStorage {
+	s := &treeStorage{tempDir: t.TempDir(), files: make(map[string]syncFile)}
+	for name, data := range files {
+		s.files[name] = syncFile{data: data, modTime: modTime}
+	}
+	return s
+}
+
+func (s *treeStorage) TempDir() string { return s.tempDir }
+
+func (s *treeStorage) ListFiles(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var files []string
+	for name := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *treeStorage) ListDirectory(ctx context.Context, dir string) ([]StorageEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []StorageEntry
+	for name, file := range s.files {
+		if path.Dir(name) == dir || (dir == "" && !strings.Contains(name, "/")) {
+			entries = append(entries, StorageEntry{Name: path.Base(name), Path: name, Size: int64(len(file.data)), ModTime: file.modTime})
+		}
+	}
+	return entries, nil
+}
+
+func (s *treeStorage) Download(ctx context.Context, remotePath, localPath string) error {
+	s.mu.Lock()
+	file, ok := s.files[remotePath]
+	s.mu.Unlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+	return os.WriteFile(localPath, []byte(file.data), 0644)
+}
+
+func (s *treeStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	if remotePath == s.failOn {
+		return errors.New("access denied")
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[remotePath] = syncFile{data: string(data), modTime: time.Now()}
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	// newSides returns a source and destination that each have a file the
+	// other lacks, a file changed on each side, an unchanged file and a
+	// conflicting file, next to a file outside the synced prefix
+	newSides := func(t *testing.T) (*treeStorage, *treeStorage) {
+		src := newTreeStorage(t, older, map[string]string{
+			"tenantA/only-src.mp4": "src",
+			"tenantA/same.mp4":     "same",
+			"tenantA/conflict.mp4": "short",
+			"tenantB/other.mp4":    "other",
+		})
+		src.files["tenantA/src-newer.mp4"] = syncFile{data: "new source", modTime: newer}
+		src.files["tenantA/dst-newer.mp4"] = syncFile{data: "old", modTime: older}
+
+		dst := newTreeStorage(t, older, map[string]string{
+			"tenantA/only-dst.mp4": "dst",
+			"tenantA/same.mp4":     "same",
+			"tenantA/conflict.mp4": "much longer",
+		})
+		dst.files["tenantA/src-newer.mp4"] = syncFile{data: "old", modTime: older}
+		dst.files["tenantA/dst-newer.mp4"] = syncFile{data: "new destination", modTime: newer}
+		return src, dst
+	}
+
+	tests := []struct {
+		mode          SyncMode
+		wantPushed    string
+		wantPulled    string
+		wantConflicts int
+	}{
+		{mode: SyncPush, wantPushed: "tenantA/conflict.mp4 tenantA/dst-newer.mp4 tenantA/only-src.mp4 tenantA/src-newer.mp4"},
+		{mode: SyncPull, wantPulled: "tenantA/conflict.mp4 tenantA/dst-newer.mp4 tenantA/only-dst.mp4 tenantA/src-newer.mp4"},
+		{mode: SyncMirror, wantPushed: "tenantA/only-src.mp4 tenantA/src-newer.mp4", wantPulled: "tenantA/dst-newer.mp4 tenantA/only-dst.mp4", wantConflicts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			src, dst := newSides(t)
+			result, err := Sync(context.Background(), src, dst, "tenantA/", tt.mode, 2)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+
+			if got := strings.Join(result.Pushed, " "); got != tt.wantPushed {
+				t.Errorf("Pushed = %s, want %s", got, tt.wantPushed)
+			}
+			if got := strings.Join(result.Pulled, " "); got != tt.wantPulled {
+				t.Errorf("Pulled = %s, want %s", got, tt.wantPulled)
+			}
+			if result.Unchanged != 1 || len(result.Conflicts) != tt.wantConflicts || len(result.Errors) != 0 {
+				t.Errorf("result = %+v, want 1 unchanged file and %d conflicts", result, tt.wantConflicts)
+			}
+			for _, file := range result.Pushed {
+				if dst.files[file].data != src.files[file].data {
+					t.Errorf("pushed %s = %q, want %q", file, dst.files[file].data, src.files[file].data)
+				}
+			}
+			for _, file := range result.Pulled {
+				if src.files[file].data != dst.files[file].data {
+					t.Errorf("pulled %s = %q, want %q", file, src.files[file].data, dst.files[file].data)
+				}
+			}
+			if _, ok := dst.files["tenantB/other.mp4"]; ok {
+				t.Errorf("a file outside the prefix was copied")
+			}
+		})
+	}
+
+	// Conflicts are left untouched on both sides
+	src, dst := newSides(t)
+	result, _ := Sync(context.Background(), src, dst, "tenantA/", SyncMirror, 1)
+	if conflict := result.Conflicts[0]; conflict != (SyncConflict{Path: "tenantA/conflict.mp4", SourceSize: 5, DestinationSize: 11}) {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if src.files["tenantA/conflict.mp4"].data != "short" || dst.files["tenantA/conflict.mp4"].data != "much longer" {
+		t.Errorf("a conflicting file was overwritten")
+	}
+}
+
+func TestSyncCopyErrors(t *testing.T) {
+	now := time.Now()
+	src := newTreeStorage(t, now, map[string]string{"a.mp4": "a", "b.mp4": "b"})
+	dst := newTreeStorage(t, now, nil)
+	dst.failOn = "b.mp4"
+
+	result, err := Sync(context.Background(), src, dst, "", SyncPush, 4)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(result.Pushed) != 1 || result.Pushed[0] != "a.mp4" {
+		t.Errorf("Pushed = %v, want the file that could be copied", result.Pushed)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "failed to copy b.mp4: access denied") {
+		t.Errorf("Errors = %v, want the failed copy", result.Errors)
+	}
+
+	if _, err := Sync(context.Background(), src, dst, "", "sideways", 1); err == nil {
+		t.Errorf("Sync() with an unknown mode succeeded")
+	}
+}
+
+func TestParseSyncMode(t *testing.T) {
+	for _, mode := range []string{"push", "pull", "mirror"} {
+		if got, err := ParseSyncMode(mode); err != nil || string(got) != mode {
+			t.Errorf("ParseSyncMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseSyncMode("Push"); err == nil {
+		t.Errorf("ParseSyncMode(\"Push\") succeeded")
+	}
+}
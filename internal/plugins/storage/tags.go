@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// Object tags set on job output for lifecycle rules
+const (
+	TagTenantID  = "tenant_id"
+	TagJobID     = "job_id"
+	TagCreatedAt = "created_at"
+)
+
+// ErrTaggingNotSupported is returned for storage backends without object tags
+var ErrTaggingNotSupported = errors.New("storage backend does not support object tags")
+
+// ObjectTagger is implemented by storage backends that can attach key/value
+// tags to stored objects, such as S3 object tags that drive lifecycle rules
+type ObjectTagger interface {
+	// TagObject replaces the tags of an object
+	TagObject(ctx context.Context, remotePath string, tags map[string]string) error
+	// GetObjectTags returns the tags of an object
+	GetObjectTags(ctx context.Context, remotePath string) (map[string]string, error)
+}
+
+// TagObject replaces the tags of an object in s
+func TagObject(ctx context.Context, s Storage, remotePath string, tags map[string]string) error {
+	if tagger, ok := s.(ObjectTagger); ok {
+		return tagger.TagObject(ctx, remotePath, tags)
+	}
+	return ErrTaggingNotSupported
+}
+
+// GetObjectTags returns the tags of an object in s
+func GetObjectTags(ctx context.Context, s Storage, remotePath string) (map[string]string, error) {
+	if tagger, ok := s.(ObjectTagger); ok {
+		return tagger.GetObjectTags(ctx, remotePath)
+	}
+	return nil, ErrTaggingNotSupported
+}
+
+// UploadTagged uploads a file and tags it. Backends without object tags only
+// upload the file.
+func UploadTagged(ctx context.Context, s Storage, localPath, remotePath string, tags map[string]string) error {
+	if err := s.Upload(ctx, localPath, remotePath); err != nil {
+		return err
+	}
+
+	if err := TagObject(ctx, s, remotePath, tags); err != nil && !errors.Is(err, ErrTaggingNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// TagObject tags the object in the wrapped storage backend
+func (q *QuotaEnforcingStorage) TagObject(ctx context.Context, remotePath string, tags map[string]string) error {
+	return TagObject(ctx, q.Storage, remotePath, tags)
+}
+
+// GetObjectTags returns the tags from the wrapped storage backend
+func (q *QuotaEnforcingStorage) GetObjectTags(ctx context.Context, remotePath string) (map[string]string, error) {
+	return GetObjectTags(ctx, q.Storage, remotePath)
+}
+
+// TagObject tags the object in the wrapped storage backend
+func (c *ReadThroughCache) TagObject(ctx context.Context, remotePath string, tags map[string]string) error {
+	return TagObject(ctx, c.Storage, remotePath, tags)
+}
+
+// GetObjectTags returns the tags from the wrapped storage backend
+func (c *ReadThroughCache) GetObjectTags(ctx context.Context, remotePath string) (map[string]string, error) {
+	return GetObjectTags(ctx, c.Storage, remotePath)
+}
+
+// TagObject tags the object on the backend it was last stored on or read
+// from, which is the primary unless a fallback took over
+func (fs *FallbackStorage) TagObject(ctx context.Context, remotePath string, tags map[string]string) error {
+	return TagObject(ctx, fs.backendFor(remotePath), remotePath, tags)
+}
+
+// GetObjectTags returns the tags from the backend holding the object
+func (fs *FallbackStorage) GetObjectTags(ctx context.Context, remotePath string) (map[string]string, error) {
+	return GetObjectTags(ctx, fs.backendFor(remotePath), remotePath)
+}
+
+// backendFor returns the backend known to hold a file, or the primary
+func (fs *FallbackStorage) backendFor(remotePath string) Storage {
+	index, _ := fs.location(remotePath)
+	return fs.backends[index]
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// TempPurger is implemented by storage backends that keep temporary files
+// which can be left behind when a job is killed
+type TempPurger interface {
+	// PurgeTempFiles deletes temporary files older than maxAge and returns
+	// the number of files deleted
+	PurgeTempFiles(ctx context.Context, maxAge time.Duration) (int, error)
+}
+
+// PurgeTempFiles deletes temporary files older than maxAge from a storage
+// backend. Backends without temporary files delete nothing.
+func PurgeTempFiles(ctx context.Context, s Storage, maxAge time.Duration) (int, error) {
+	if purger, ok := s.(TempPurger); ok {
+		return purger.PurgeTempFiles(ctx, maxAge)
+	}
+	return 0, nil
+}
+
+// PurgeTempFiles purges the wrapped storage backend
+func (q *QuotaEnforcingStorage) PurgeTempFiles(ctx context.Context, maxAge time.Duration) (int, error) {
+	return PurgeTempFiles(ctx, q.Storage, maxAge)
+}
+
+// PurgeTempFiles purges the wrapped storage backend
+func (c *ReadThroughCache) PurgeTempFiles(ctx context.Context, maxAge time.Duration) (int, error) {
+	return PurgeTempFiles(ctx, c.Storage, maxAge)
+}
+
+// PurgeTempFiles purges every backend, continuing past failures
+func (fs *FallbackStorage) PurgeTempFiles(ctx context.Context, maxAge time.Duration) (int, error) {
+	var (
+		deleted  int
+		firstErr error
+	)
+	for _, backend := range fs.backends {
+		n, err := PurgeTempFiles(ctx, backend, maxAge)
+		deleted += n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return deleted, firstErr
+}
+
+// TempDirProvider is implemented by storage backends with a local directory
+// for temporary files
+type TempDirProvider interface {
+	// TempDir returns the directory for temporary files
+	TempDir() string
+}
+
+// TempDir returns the temporary directory of a storage backend, or the
+// system temporary directory for backends without one
+func TempDir(s Storage) string {
+	if provider, ok := s.(TempDirProvider); ok {
+		if dir := provider.TempDir(); dir != "" {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// TempDir returns the temporary directory of the wrapped storage backend
+func (q *QuotaEnforcingStorage) TempDir() string {
+	return TempDir(q.Storage)
+}
+
+// TempDir returns the temporary directory of the wrapped storage backend
+func (c *ReadThroughCache) TempDir() string {
+	return TempDir(c.Storage)
+}
+
+// TempDir returns the temporary directory of the primary backend
+func (fs *FallbackStorage) TempDir() string {
+	return TempDir(fs.backends[0])
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StorageEntry is a file or directory directly below a storage path
+type StorageEntry struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	IsDir   bool      `json:"is_dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// DirectoryLister is implemented by storage backends that can list one level
+// of their hierarchy. Object stores list virtual directories, e.g. S3
+// ListObjectsV2 with a "/" delimiter returns them as common prefixes.
+type DirectoryLister interface {
+	// ListDirectory returns the entries directly below path, directories first
+	ListDirectory(ctx context.Context, path string) ([]StorageEntry, error)
+}
+
+// ListDirectory lists the entries directly below path in s. Backends without
+// DirectoryLister are listed with ListFiles and grouped on "/"; their entries
+// have no size or modification time.
+func ListDirectory(ctx context.Context, s Storage, path string) ([]StorageEntry, error) {
+	if lister, ok := s.(DirectoryLister); ok {
+		return lister.ListDirectory(ctx, path)
+	}
+
+	prefix := strings.Trim(path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	files, err := s.ListFiles(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var entries []StorageEntry
+	for _, file := range files {
+		rest := strings.TrimPrefix(file, prefix)
+		name, _, isDir := strings.Cut(rest, "/")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, StorageEntry{Name: name, Path: prefix + name, IsDir: isDir})
+	}
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+// ListDirectory lists the wrapped storage backend
+func (q *QuotaEnforcingStorage) ListDirectory(ctx context.Context, path string) ([]StorageEntry, error) {
+	return ListDirectory(ctx, q.Storage, path)
+}
+
+// ListDirectory lists the wrapped storage backend
+func (c *ReadThroughCache) ListDirectory(ctx context.Context, path string) ([]StorageEntry, error) {
+	return ListDirectory(ctx, c.Storage, path)
+}
+
+// sortEntries orders directories before files, each by name
+func sortEntries(entries []StorageEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// TreeHandler serves GET ?path=<prefix> with the JSON entries directly below
+// the path
+func TreeHandler(s Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		entries, err := ListDirectory(r.Context(), s, r.URL.Query().Get("path"))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			http.Error(w, "path not found", http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []StorageEntry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
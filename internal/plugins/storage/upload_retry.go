@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrPermissionDenied is returned by storage backends whose credentials may
+// not write an object. Uploads failing with it are not retried.
+var ErrPermissionDenied = errors.New("storage permission denied")
+
+// ErrBucketNotFound is returned by storage backends whose bucket does not
+// exist. Uploads failing with it are not retried.
+var ErrBucketNotFound = errors.New("storage bucket not found")
+
+// UploadRetryPolicy controls the exponential backoff between upload attempts
+type UploadRetryPolicy struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+}
+
+// IsTransientError reports whether a failed upload may succeed when retried:
+// network timeouts, dropped connections and 5xx or 429 responses. Permission,
+// missing bucket and unrecognised errors are permanent.
+func IsTransientError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrPermissionDenied),
+		errors.Is(err, ErrBucketNotFound),
+		errors.Is(err, os.ErrPermission),
+		errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Cloud SDK response errors, such as the AWS SDK's, expose the status code
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		code := statusErr.HTTPStatusCode()
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// UploadWithRetry uploads a file, retrying transient failures with
+// exponential backoff and jitter. onRetry, if set, is called before each
+// retry. It returns the number of retries made.
+func UploadWithRetry(ctx context.Context, s Storage, localPath, remotePath string, policy UploadRetryPolicy, onRetry func(retry int, delay time.Duration, err error)) (int, error) {
+	for retry := 0; ; retry++ {
+		err := s.Upload(ctx, localPath, remotePath)
+		if err == nil || retry >= policy.MaxRetries || !IsTransientError(err) || ctx.Err() != nil {
+			return retry, err
+		}
+
+		delay := policy.backoff(retry)
+		if onRetry != nil {
+			onRetry(retry+1, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retry, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before retry n+1: the initial delay grown by the
+// multiplier for each earlier retry, capped at the maximum, of which a random
+// half is waited
+func (p UploadRetryPolicy) backoff(n int) time.Duration {
+	delay := float64(p.InitialDelay)
+	for i := 0; i < n && delay < float64(p.MaxDelay); i++ {
+		delay *= p.Multiplier
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	half := time.Duration(delay / 2)
+	if half <= 0 {
+		return time.Duration(delay)
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
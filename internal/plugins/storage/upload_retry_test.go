@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// statusError is a cloud SDK response error carrying an HTTP status code
+type statusError int
+
+func (e statusError) Error() string       { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) HTTPStatusCode() int { return int(e) }
+
+// flakyStorage fails its first uploads with the errors in errs
+type flakyStorage struct {
+	Storage
+	errs    []error
+	uploads int
+}
+
+func (s *flakyStorage) Upload(ctx context.Context, localPath, remotePath string) error {
+	s.uploads++
+	if s.uploads <= len(s.errs) {
+		return s.errs[s.uploads-1]
+	}
+	return nil
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrPermissionDenied, false},
+		{fmt.Errorf("upload: %w", ErrBucketNotFound), false},
+		{os.ErrPermission, false},
+		{context.Canceled, false},
+		{errors.New("invalid object name"), false},
+		{statusError(http.StatusForbidden), false},
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{syscall.ECONNREFUSED, true},
+		{statusError(http.StatusServiceUnavailable), true},
+		{statusError(http.StatusTooManyRequests), true},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientError(tt.err); got != tt.want {
+			t.Errorf("IsTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestUploadWithRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := UploadRetryPolicy{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond, Multiplier: 2}
+	transient := statusError(http.StatusServiceUnavailable)
+
+	s := &flakyStorage{errs: []error{transient, transient}}
+	var retries []int
+	n, err := UploadWithRetry(ctx, s, "local", "remote", policy, func(retry int, delay time.Duration, err error) {
+		retries = append(retries, retry)
+	})
+	if err != nil || n != 2 || s.uploads != 3 {
+		t.Errorf("UploadWithRetry() = %d, %v after %d uploads; want 2 retries and success after 3", n, err, s.uploads)
+	}
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("onRetry calls = %v, want [1 2]", retries)
+	}
+
+	s = &flakyStorage{errs: []error{transient, transient, transient, transient, transient}}
+	if n, err := UploadWithRetry(ctx, s, "local", "remote", policy, nil); !errors.Is(err, transient) || n != 3 || s.uploads != 4 {
+		t.Errorf("UploadWithRetry() = %d, %v after %d uploads; want the error after 3 retries", n, err, s.uploads)
+	}
+
+	s = &flakyStorage{errs: []error{ErrPermissionDenied}}
+	if n, err := UploadWithRetry(ctx, s, "local", "remote", policy, nil); !errors.Is(err, ErrPermissionDenied) || n != 0 || s.uploads != 1 {
+		t.Errorf("UploadWithRetry() = %d, %v after %d uploads; want a permanent error without retries", n, err, s.uploads)
+	}
+}
+
+func TestUploadRetryPolicyBackoff(t *testing.T) {
+	policy := UploadRetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+
+	for n, full := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		full *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if delay := policy.backoff(n); delay < full/2 || delay > full {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", n, delay, full/2, full)
+			}
+		}
+	}
+}
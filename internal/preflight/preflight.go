@@ -0,0 +1,157 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/plugins/queue"
+	"github.com/nikhil0verma/flixsrota/internal/plugins/storage"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// checkTimeout bounds the duration of a single check
+const checkTimeout = 10 * time.Second
+
+// MinFreeDiskBytes is the free space required on the working disk
+const MinFreeDiskBytes = 5 * 1024 * 1024 * 1024
+
+// Check is a single named dependency check
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result contains the outcome of a check
+type Result struct {
+	Name     string
+	Passed   bool
+	Message  string
+	Duration time.Duration
+}
+
+// Run executes all checks in parallel and returns their results in order
+func Run(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			message, err := check.Run(checkCtx)
+			results[i] = Result{
+				Name:     check.Name,
+				Passed:   err == nil,
+				Message:  message,
+				Duration: time.Since(start),
+			}
+			if err != nil {
+				results[i].Message = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Passed reports whether all results passed
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// QueueCheck verifies the queue backend is reachable
+func QueueCheck(q queue.Queue) Check {
+	return Check{
+		Name: "queue",
+		Run: func(ctx context.Context) (string, error) {
+			if err := queue.TestConnection(ctx, q); err != nil {
+				return "", fmt.Errorf("queue unreachable: %w", err)
+			}
+			return "queue reachable", nil
+		},
+	}
+}
+
+// StorageCheck verifies the storage backend is reachable
+func StorageCheck(st storage.Storage) Check {
+	return Check{
+		Name: "storage",
+		Run: func(ctx context.Context) (string, error) {
+			if err := storage.TestConnection(ctx, st); err != nil {
+				return "", fmt.Errorf("storage unreachable: %w", err)
+			}
+			return "storage reachable", nil
+		},
+	}
+}
+
+// FFmpegCheck verifies the FFmpeg binary is present and executable
+func FFmpegCheck(executablePath string) Check {
+	return Check{
+		Name: "ffmpeg",
+		Run: func(ctx context.Context) (string, error) {
+			if err := exec.CommandContext(ctx, executablePath, "-version").Run(); err != nil {
+				return "", fmt.Errorf("FFmpeg not found or not executable: %w", err)
+			}
+			return fmt.Sprintf("%s is executable", executablePath), nil
+		},
+	}
+}
+
+// DiskSpaceCheck verifies the disk holding path has enough free space
+func DiskSpaceCheck(path string, minFreeBytes uint64) Check {
+	return Check{
+		Name: "disk_space",
+		Run: func(ctx context.Context) (string, error) {
+			usage, err := disk.UsageWithContext(ctx, existingParent(path))
+			if err != nil {
+				return "", fmt.Errorf("failed to get disk usage for %s: %w", path, err)
+			}
+			if usage.Free < minFreeBytes {
+				return "", fmt.Errorf("only %d MB free on %s, need at least %d MB",
+					usage.Free/1024/1024, path, minFreeBytes/1024/1024)
+			}
+			return fmt.Sprintf("%d MB free on %s", usage.Free/1024/1024, path), nil
+		},
+	}
+}
+
+// existingParent returns path or its closest ancestor that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// FailedCheck reports a dependency that could not be initialized
+func FailedCheck(name string, err error) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) (string, error) {
+			return "", err
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reportTimeout bounds the delivery of a single crash report
+const reportTimeout = 10 * time.Second
+
+// Crash describes a panic recovered in a goroutine
+type Crash struct {
+	// Panic is the value passed to panic, formatted with %v
+	Panic string
+	// Stack is the stack trace of the panicking goroutine
+	Stack string
+	Time  time.Time
+}
+
+// CrashReporter sends recovered panics somewhere they are noticed
+type CrashReporter interface {
+	Report(ctx context.Context, crash Crash) error
+}
+
+var (
+	mu       sync.RWMutex
+	reporter CrashReporter = LogOnlyCrashReporter{}
+	rethrow  bool
+)
+
+// SetCrashReporter sets the reporter of recovered panics, and whether
+// SafeGo panics again once a crash was reported, which ends the process
+func SetCrashReporter(r CrashReporter, rethrowPanic bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	reporter = r
+	rethrow = rethrowPanic
+}
+
+// SafeGo runs fn in a new goroutine. A panic in fn is logged with its stack
+// trace and sent to the crash reporter instead of crashing the process,
+// unless the reporter is configured to rethrow it.
+func SafeGo(logger *zap.Logger, fn func()) {
+	go func() {
+		defer Recover(logger)
+		fn()
+	}()
+}
+
+// Recover handles a panic like SafeGo does. It must be deferred directly by
+// the goroutine it protects.
+func Recover(logger *zap.Logger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	crash := Crash{
+		Panic: fmt.Sprint(r),
+		Stack: string(debug.Stack()),
+		Time:  time.Now(),
+	}
+	logger.Error("Recovered panic in goroutine",
+		zap.String("panic", crash.Panic),
+		zap.String("stack", crash.Stack))
+
+	mu.RLock()
+	current, rethrowPanic := reporter, rethrow
+	mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	if err := current.Report(ctx, crash); err != nil {
+		logger.Warn("Failed to report panic", zap.Error(err))
+	}
+
+	if rethrowPanic {
+		panic(r)
+	}
+}
+
+// LogOnlyCrashReporter reports nothing beyond the log entry SafeGo writes
+type LogOnlyCrashReporter struct{}
+
+// Report does nothing
+func (LogOnlyCrashReporter) Report(ctx context.Context, crash Crash) error {
+	return nil
+}
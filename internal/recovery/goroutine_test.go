@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// channelReporter sends every reported crash on a channel
+type channelReporter struct {
+	crashes chan Crash
+	err     error
+}
+
+func (r *channelReporter) Report(ctx context.Context, crash Crash) error {
+	r.crashes <- crash
+	return r.err
+}
+
+// setTestReporter installs r for the test and restores the default after it
+func setTestReporter(t *testing.T, r CrashReporter, rethrowPanic bool) {
+	t.Helper()
+	SetCrashReporter(r, rethrowPanic)
+	t.Cleanup(func() { SetCrashReporter(LogOnlyCrashReporter{}, false) })
+}
+
+func TestSafeGo(t *testing.T) {
+	reporter := &channelReporter{crashes: make(chan Crash, 1), err: errors.New("webhook down")}
+	setTestReporter(t, reporter, false)
+	core, logs := observer.New(zap.DebugLevel)
+
+	SafeGo(zap.New(core), func() { panic("boom") })
+
+	select {
+	case crash := <-reporter.crashes:
+		if crash.Panic != "boom" || !strings.Contains(crash.Stack, "goroutine") || crash.Time.IsZero() {
+			t.Errorf("crash = %+v, want the panic with its stack trace", crash)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("panic was not reported")
+	}
+
+	// The failed delivery is logged after the panic itself
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("Failed to report panic").Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if logs.FilterMessage("Recovered panic in goroutine").Len() != 1 || logs.FilterMessage("Failed to report panic").Len() != 1 {
+		t.Errorf("logs = %v, want the panic and the failed report", logs.All())
+	}
+}
+
+func TestRecoverRethrow(t *testing.T) {
+	reporter := &channelReporter{crashes: make(chan Crash, 1)}
+	setTestReporter(t, reporter, true)
+
+	rethrown := func() (r interface{}) {
+		defer func() { r = recover() }()
+		defer Recover(zap.NewNop())
+		panic("boom")
+	}()
+
+	if rethrown != "boom" {
+		t.Errorf("rethrown panic = %v, want boom", rethrown)
+	}
+	if len(reporter.crashes) != 1 {
+		t.Errorf("panic was not reported before it was rethrown")
+	}
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	reporter := &channelReporter{crashes: make(chan Crash, 1)}
+	setTestReporter(t, reporter, true)
+
+	func() {
+		defer Recover(zap.NewNop())
+	}()
+	if len(reporter.crashes) != 0 {
+		t.Errorf("a goroutine that did not panic was reported")
+	}
+}
@@ -0,0 +1,137 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// maxSlackStack is the number of stack trace bytes included in a Slack
+// message, which Slack truncates at about 40000 characters
+const maxSlackStack = 3000
+
+// NewCrashReporter creates the crash reporter selected by cfg
+func NewCrashReporter(cfg config.CrashReporterConfig) (CrashReporter, error) {
+	switch cfg.Type {
+	case "", "log":
+		return LogOnlyCrashReporter{}, nil
+	case "slack":
+		return NewSlackCrashReporter(cfg.SlackWebhookURL), nil
+	case "sentry":
+		return NewSentryCrashReporter(cfg.SentryDSN)
+	default:
+		return nil, fmt.Errorf("unknown crash reporter: %s", cfg.Type)
+	}
+}
+
+// SlackCrashReporter posts crashes to a Slack incoming webhook
+type SlackCrashReporter struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackCrashReporter creates a reporter posting to webhookURL
+func NewSlackCrashReporter(webhookURL string) *SlackCrashReporter {
+	return &SlackCrashReporter{webhookURL: webhookURL, client: &http.Client{}}
+}
+
+// Report posts the panic and the start of its stack trace
+func (s *SlackCrashReporter) Report(ctx context.Context, crash Crash) error {
+	stack := crash.Stack
+	if len(stack) > maxSlackStack {
+		stack = stack[:maxSlackStack] + "\n..."
+	}
+	hostname, _ := os.Hostname()
+
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf(":boom: Flixsrota goroutine panicked on %s: %s\n```%s```", hostname, crash.Panic, stack),
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.client, s.webhookURL, body, nil)
+}
+
+// SentryCrashReporter sends crashes as error events to the Sentry store API
+type SentryCrashReporter struct {
+	storeURL string
+	auth     string
+	client   *http.Client
+}
+
+// NewSentryCrashReporter creates a reporter for a project DSN of the form
+// https://<public key>@<host>/<project id>
+func NewSentryCrashReporter(dsn string) (*SentryCrashReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %w", err)
+	}
+	projectID := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || projectID == "" {
+		return nil, fmt.Errorf("invalid sentry DSN: expected https://<key>@<host>/<project>")
+	}
+
+	return &SentryCrashReporter{
+		storeURL: fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, projectID),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=flixsrota/1.0, sentry_key=%s", u.User.Username()),
+		client:   &http.Client{},
+	}, nil
+}
+
+// Report sends the panic as a fatal event with the stack trace attached
+func (s *SentryCrashReporter) Report(ctx context.Context, crash Crash) error {
+	eventID := make([]byte, 16)
+	if _, err := rand.Read(eventID); err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event_id":    hex.EncodeToString(eventID),
+		"timestamp":   crash.Time.UTC().Format("2006-01-02T15:04:05"),
+		"level":       "fatal",
+		"logger":      "flixsrota",
+		"platform":    "go",
+		"server_name": hostname,
+		"message":     "goroutine panicked: " + crash.Panic,
+		"extra":       map[string]string{"stack": crash.Stack},
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.client, s.storeURL, body, map[string]string{"X-Sentry-Auth": s.auth})
+}
+
+// postJSON posts body to target and fails on non-2xx responses
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("crash report rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikhil0verma/flixsrota/internal/config"
+)
+
+// recordingServer records the last request body and headers it received,
+// answering with status
+func recordingServer(t *testing.T, status int) (*httptest.Server, *map[string]interface{}, *http.Header) {
+	t.Helper()
+	body := map[string]interface{}{}
+	header := http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body = map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("report is not JSON: %v", err)
+		}
+		body["path"] = r.URL.Path
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &body, &header
+}
+
+func TestSlackCrashReporter(t *testing.T) {
+	server, body, _ := recordingServer(t, http.StatusOK)
+	crash := Crash{Panic: "nil map", Stack: strings.Repeat("x", maxSlackStack+100), Time: time.Now()}
+
+	if err := NewSlackCrashReporter(server.URL).Report(context.Background(), crash); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	text, _ := (*body)["text"].(string)
+	if !strings.Contains(text, "nil map") || strings.Contains(text, strings.Repeat("x", maxSlackStack+1)) {
+		t.Errorf("text = %.100s..., want the panic and a truncated stack", text)
+	}
+
+	server, _, _ = recordingServer(t, http.StatusForbidden)
+	if err := NewSlackCrashReporter(server.URL).Report(context.Background(), crash); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Report() error = %v, want the rejected status", err)
+	}
+}
+
+func TestSentryCrashReporter(t *testing.T) {
+	server, body, header := recordingServer(t, http.StatusOK)
+	dsn := strings.Replace(server.URL, "://", "://public-key@", 1) + "/42"
+
+	reporter, err := NewSentryCrashReporter(dsn)
+	if err != nil {
+		t.Fatalf("NewSentryCrashReporter() error = %v", err)
+	}
+	crash := Crash{Panic: "nil map", Stack: "goroutine 1", Time: time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC)}
+	if err := reporter.Report(context.Background(), crash); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if (*body)["path"] != "/api/42/store/" || (*body)["level"] != "fatal" || (*body)["timestamp"] != "2024-03-07T12:00:00" {
+		t.Errorf("event = %v, want a fatal event posted to the project store", *body)
+	}
+	if auth := header.Get("X-Sentry-Auth"); !strings.Contains(auth, "sentry_key=public-key") {
+		t.Errorf("X-Sentry-Auth = %s, want the DSN key", auth)
+	}
+
+	for _, dsn := range []string{"https://sentry.example.com/42", "https://key@sentry.example.com/", "://bad"} {
+		if _, err := NewSentryCrashReporter(dsn); err == nil {
+			t.Errorf("NewSentryCrashReporter(%q) succeeded", dsn)
+		}
+	}
+}
+
+func TestNewCrashReporter(t *testing.T) {
+	tests := []struct {
+		cfg     config.CrashReporterConfig
+		want    string
+		wantErr bool
+	}{
+		{cfg: config.CrashReporterConfig{}, want: "recovery.LogOnlyCrashReporter"},
+		{cfg: config.CrashReporterConfig{Type: "log"}, want: "recovery.LogOnlyCrashReporter"},
+		{cfg: config.CrashReporterConfig{Type: "slack", SlackWebhookURL: "https://hooks.example.com/x"}, want: "*recovery.SlackCrashReporter"},
+		{cfg: config.CrashReporterConfig{Type: "sentry", SentryDSN: "https://key@sentry.example.com/1"}, want: "*recovery.SentryCrashReporter"},
+		{cfg: config.CrashReporterConfig{Type: "sentry", SentryDSN: "https://sentry.example.com/1"}, wantErr: true},
+		{cfg: config.CrashReporterConfig{Type: "pager"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		reporter, err := NewCrashReporter(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewCrashReporter(%+v) error = %v, want error %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if got := fmt.Sprintf("%T", reporter); err == nil && got != tt.want {
+			t.Errorf("NewCrashReporter(%+v) = %s, want %s", tt.cfg, got, tt.want)
+		}
+	}
+}